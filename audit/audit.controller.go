@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/requests"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+const (
+	HeaderAuditExportID  = "X-Audit-Export-ID"
+	HeaderAuditDigest    = "X-Audit-Digest"
+	HeaderAuditSignature = "X-Audit-Signature"
+)
+
+type AuditController struct{}
+
+func (n *AuditController) Pagination(c core.IHTTPContext) error {
+	input := &requests.AuditQuery{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	auditSvc := services.NewAuditService(c)
+	events, pageResponse, ierr := auditSvc.Pagination(auditFilterPayload(input), c.GetPageOptions())
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, core.NewPagination(events, pageResponse))
+}
+
+// Export streams the events as they are read, the digest and its signature are only known at the end
+// so they are sent as trailers and can also be fetched afterwards with the export id
+func (n *AuditController) Export(c core.IHTTPContext) error {
+	input := &requests.AuditQuery{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	format := consts.AuditExportFormatJSONLines
+	if utils.GetString(input.Format) == string(consts.AuditExportFormatCSV) {
+		format = consts.AuditExportFormatCSV
+	}
+
+	id := utils.GetUUID()
+	response := c.Response()
+	if format == consts.AuditExportFormatCSV {
+		response.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	} else {
+		response.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	}
+	response.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"audit-%s.%s\"", id, format))
+	response.Header().Set(HeaderAuditExportID, id)
+	response.Header().Set("Trailer", HeaderAuditDigest+", "+HeaderAuditSignature)
+
+	exportSvc := services.NewAuditExportService(c, services.NewHSMService(c))
+	export, ierr := exportSvc.Export(&services.AuditExportPayload{
+		ID:     id,
+		Format: format,
+		Filter: auditFilterPayload(input),
+	}, response)
+	if ierr != nil {
+		if response.Committed {
+			// the body is already partially sent, the missing trailers tell the client the export is not signed
+			return nil
+		}
+		response.Header().Del("Content-Disposition")
+		response.Header().Del("Trailer")
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	if !response.Committed {
+		response.WriteHeader(http.StatusOK)
+	}
+	response.Header().Set(HeaderAuditDigest, export.Digest)
+	response.Header().Set(HeaderAuditSignature, export.Signature)
+
+	return nil
+}
+
+func (n *AuditController) FindExport(c core.IHTTPContext) error {
+	exportSvc := services.NewAuditExportService(c, services.NewHSMService(c))
+	export, ierr := exportSvc.Find(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, export)
+}
+
+func auditFilterPayload(input *requests.AuditQuery) *services.AuditFilterPayload {
+	return &services.AuditFilterPayload{
+		KeyID:     utils.GetString(input.KeyID),
+		ActorID:   utils.GetString(input.ActorID),
+		Operation: utils.GetString(input.Operation),
+		Outcome:   utils.GetString(input.Outcome),
+		From:      input.Time(input.From),
+		To:        input.Time(input.To),
+	}
+}
@@ -0,0 +1,18 @@
+package audit
+
+import (
+	"github.com/labstack/echo/v4"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/middlewares"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+func NewAuditHTTPHandler(r *echo.Echo) {
+	audit := &AuditController{}
+
+	auth := middlewares.Authenticate(middlewares.DefaultAuthenticators...)
+	read := middlewares.RequireScope(consts.ScopeAuditRead)
+	r.GET("/audit", core.WithHTTPContext(audit.Pagination), auth, read)
+	r.GET("/audit/export", core.WithHTTPContext(audit.Export), auth, read)
+	r.GET("/audit/exports/:id", core.WithHTTPContext(audit.FindExport), auth, read)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"net/http"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/requests"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type ClientController struct{}
+
+func (n *ClientController) Pagination(c core.IHTTPContext) error {
+	clientSvc := services.NewAPIClientService(c)
+	clients, pageResponse, ierr := clientSvc.Pagination(c.GetPageOptions())
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, core.NewPagination(clients, pageResponse))
+}
+
+func (n *ClientController) Find(c core.IHTTPContext) error {
+	clientSvc := services.NewAPIClientService(c)
+	client, ierr := clientSvc.Find(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, client)
+}
+
+func (n *ClientController) Create(c core.IHTTPContext) error {
+	input := &requests.APIClientCreate{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	clientSvc := services.NewAPIClientService(c)
+	client, ierr := clientSvc.Create(&services.APIClientCreatePayload{
+		Name:                   utils.GetString(input.Name),
+		TenantID:               utils.GetString(input.TenantID),
+		CertificateFingerprint: utils.GetString(input.CertificateFingerprint),
+		IsAdmin:                input.IsAdmin != nil && *input.IsAdmin,
+		SignRateLimit:          input.SignRateLimit,
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusCreated, client)
+}
+
+func (n *ClientController) Revoke(c core.IHTTPContext) error {
+	clientSvc := services.NewAPIClientService(c)
+	ierr := clientSvc.Revoke(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
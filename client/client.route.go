@@ -0,0 +1,19 @@
+package client
+
+import (
+	"github.com/labstack/echo/v4"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/middlewares"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+func NewClientHTTPHandler(r *echo.Echo) {
+	client := &ClientController{}
+
+	auth := middlewares.Authenticate(middlewares.DefaultAuthenticators...)
+	admin := middlewares.RequireScope(consts.ScopeKeysAdmin)
+	r.GET("/admin/clients", core.WithHTTPContext(client.Pagination), auth, admin)
+	r.GET("/admin/clients/:id", core.WithHTTPContext(client.Find), auth, admin)
+	r.POST("/admin/clients", core.WithHTTPContext(client.Create), auth, admin)
+	r.DELETE("/admin/clients/:id", core.WithHTTPContext(client.Revoke), auth, admin)
+}
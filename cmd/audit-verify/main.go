@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+// audit-verify recomputes the hash chain of key_audit_events and exits with 1 when it is broken.
+// Truncating the end of the chain cannot be seen from the table alone, so pass a head recorded
+// earlier outside of the database with -sequence and -hash to make sure it is still there.
+func main() {
+	sequence := flag.Int64("sequence", 0, "sequence of a previously recorded chain head")
+	hash := flag.String("hash", "", "hash of the previously recorded chain head")
+	flag.Parse()
+
+	env := core.NewEnv()
+	mysql, err := core.NewDatabase(env.Config()).Connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "MySQL: %v", err)
+		os.Exit(1)
+	}
+
+	ctx := core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+	auditSvc := services.NewAuditService(ctx)
+
+	verification, ierr := auditSvc.Verify()
+	if ierr != nil {
+		fmt.Fprintf(os.Stderr, "Audit: %v", ierr)
+		os.Exit(1)
+	}
+
+	if verification.Valid && *sequence > 0 {
+		event, ierr := auditSvc.Find(*sequence)
+		if ierr != nil {
+			verification.Valid = false
+			verification.BrokenSequence = *sequence
+			verification.Reason = "the recorded head is missing"
+		} else if event.Hash != *hash {
+			verification.Valid = false
+			verification.BrokenSequence = *sequence
+			verification.Reason = "the recorded head hash does not match"
+		}
+	}
+
+	output, _ := json.MarshalIndent(verification, "", "  ")
+	fmt.Println(string(output))
+
+	if !verification.Valid {
+		os.Exit(1)
+	}
+}
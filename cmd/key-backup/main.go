@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+// key-backup writes every key, version, tag, delegation and escrow and the audit log to a tar.gz archive whose
+// manifest is protected by an HMAC under BACKUP_HMAC_KEY. Private keys stay encrypted by the HSM, the manifest
+// lists the KEKs that are needed to restore them and is printed once the archive is complete.
+// API clients, import jobs, operation requests with their approvals, audit exports and idempotency records
+// are left out, a restored deployment needs new clients and new import jobs.
+func main() {
+	out := flag.String("out", fmt.Sprintf("key-backup-%s.tar.gz", time.Now().UTC().Format("20060102T150405Z")), "path of the archive to write")
+	flag.Parse()
+
+	env := core.NewEnv()
+	mysql, err := core.NewDatabase(env.Config()).Connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "MySQL: %v", err)
+		os.Exit(1)
+	}
+
+	hsm, err := helpers.NewHSMSession(env.Int(consts.ENVHSMSlot), env.String(consts.ENVHSMPin))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "HSM: %v", err)
+		os.Exit(1)
+	}
+
+	ctx := core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+		DATA: map[string]interface{}{
+			consts.ContextKeyHSMSession: hsm,
+		},
+	})
+
+	file, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Backup: %v", err)
+		os.Exit(1)
+	}
+
+	manifest, ierr := services.NewKeyBackupService(ctx, services.NewHSMService(ctx)).Backup(file)
+	if closeErr := file.Close(); ierr == nil && closeErr != nil {
+		fmt.Fprintf(os.Stderr, "Backup: %v", closeErr)
+		os.Remove(*out)
+		os.Exit(1)
+	}
+	if ierr != nil {
+		fmt.Fprintf(os.Stderr, "Backup: %v", ierr)
+		os.Remove(*out)
+		os.Exit(1)
+	}
+
+	output, _ := json.MarshalIndent(manifest, "", "  ")
+	fmt.Println(string(output))
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+// key-restore verifies an archive of key-backup against BACKUP_HMAC_KEY, checks that the HSM holds the KEKs of
+// the manifest and decrypts a sample of the private keys of each KEK. Without -dry-run it then restores every
+// row in one transaction into a database without keys. It prints a report and exits with 1 on any failure.
+func main() {
+	in := flag.String("in", "", "path of the archive to restore")
+	sample := flag.Int("sample", 10, "number of private keys of each KEK to decrypt before restoring")
+	dryRun := flag.Bool("dry-run", false, "verify and decrypt the sample without writing to the database")
+	allowMissingKEK := flag.Bool("allow-missing-kek", false, "restore keys encrypted with KEKs the HSM does not hold")
+	flag.Parse()
+
+	if *in == "" || *sample < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	env := core.NewEnv()
+	mysql, err := core.NewDatabase(env.Config()).Connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "MySQL: %v", err)
+		os.Exit(1)
+	}
+
+	hsm, err := helpers.NewHSMSession(env.Int(consts.ENVHSMSlot), env.String(consts.ENVHSMPin))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "HSM: %v", err)
+		os.Exit(1)
+	}
+
+	ctx := core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+		DATA: map[string]interface{}{
+			consts.ContextKeyHSMSession: hsm,
+		},
+	})
+
+	file, err := os.Open(*in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Restore: %v", err)
+		os.Exit(1)
+	}
+	defer file.Close()
+
+	report, ierr := services.NewKeyBackupService(ctx, services.NewHSMService(ctx)).Restore(file, &services.KeyRestorePayload{
+		SampleSize:      *sample,
+		DryRun:          *dryRun,
+		AllowMissingKEK: *allowMissingKEK,
+	})
+	if ierr != nil {
+		fmt.Fprintf(os.Stderr, "Restore: %v", ierr)
+		os.Exit(1)
+	}
+
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+}
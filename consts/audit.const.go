@@ -0,0 +1,38 @@
+package consts
+
+type AuditOperation string
+
+const (
+	AuditOperationGenerate   AuditOperation = "generate"
+	AuditOperationStore      AuditOperation = "store"
+	AuditOperationImport     AuditOperation = "import"
+	AuditOperationExport     AuditOperation = "export"
+	AuditOperationSign       AuditOperation = "sign"
+	AuditOperationRotate     AuditOperation = "rotate"
+	AuditOperationUpdate     AuditOperation = "update"
+	AuditOperationDelete     AuditOperation = "delete"
+	AuditOperationDelegate   AuditOperation = "delegate"
+	AuditOperationUndelegate AuditOperation = "undelegate"
+	AuditOperationRecover    AuditOperation = "recover"
+	// the approval workflow of operations on keys that need a quorum
+	AuditOperationRequestApproval AuditOperation = "request_approval"
+	AuditOperationApprove         AuditOperation = "approve"
+	AuditOperationReject          AuditOperation = "reject"
+)
+
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeFailure AuditOutcome = "failure"
+)
+
+// AuditGenesisHash is the previous hash of the first event of the chain
+const AuditGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+type AuditExportFormat string
+
+const (
+	AuditExportFormatJSONLines AuditExportFormat = "jsonl"
+	AuditExportFormatCSV       AuditExportFormat = "csv"
+)
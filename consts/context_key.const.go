@@ -1,3 +1,7 @@
 package consts
 
 const ContextKeyHSMSession = "HSM_SESSION"
+const ContextKeyPrincipal = "PRINCIPAL"
+const ContextKeyJWKS = "JWKS"
+const ContextKeyRateLimiter = "RATE_LIMITER"
+const ContextKeyKeyCache = "KEY_CACHE"
@@ -0,0 +1,58 @@
+package consts
+
+const (
+	DIDMethodKey = "did:key:"
+	DIDMethodJWK = "did:jwk:"
+	// MultibaseBase58BTC is the multibase prefix of base58btc
+	MultibaseBase58BTC = "z"
+	// MultibaseBase64URL is the multibase prefix of unpadded base64url
+	MultibaseBase64URL = "u"
+)
+
+// Multicodec is the code of a multicodec table entry, it is prefixed to the key as an unsigned varint
+type Multicodec uint64
+
+const (
+	// MulticodecP256Pub prefixes a compressed P-256 point
+	MulticodecP256Pub Multicodec = 0x1200
+	// MulticodecRSAPub prefixes the PKCS #1 DER of an RSA public key
+	MulticodecRSAPub Multicodec = 0x1205
+	// MulticodecBLS12381G2Pub prefixes a compressed BLS12-381 G2 point
+	MulticodecBLS12381G2Pub Multicodec = 0xeb
+)
+
+const (
+	DIDContextV1         = "https://www.w3.org/ns/did/v1"
+	DIDJWKVerificationID = "#0"
+)
+
+type DIDVerificationMethodType string
+
+const (
+	DIDVerificationMethodJsonWebKey2020                    DIDVerificationMethodType = "JsonWebKey2020"
+	DIDVerificationMethodEcdsaSecp256r1VerificationKey2019 DIDVerificationMethodType = "EcdsaSecp256r1VerificationKey2019"
+	DIDVerificationMethodMultikey                          DIDVerificationMethodType = "Multikey"
+	// the legacy 2018 types the DID registry still registers keys with
+	DIDVerificationMethodSecp256r1VerificationKey2018 DIDVerificationMethodType = "Secp256r1VerificationKey2018"
+	DIDVerificationMethodRsaVerificationKey2018       DIDVerificationMethodType = "RsaVerificationKey2018"
+)
+
+// DIDVerificationMethodContexts are the JSON-LD contexts that define each verification method type
+var DIDVerificationMethodContexts = map[DIDVerificationMethodType]string{
+	DIDVerificationMethodJsonWebKey2020:                    "https://w3id.org/security/suites/jws-2020/v1",
+	DIDVerificationMethodEcdsaSecp256r1VerificationKey2019: "https://w3id.org/security/suites/ecdsa-2019/v1",
+	DIDVerificationMethodMultikey:                          "https://w3id.org/security/multikey/v1",
+	DIDVerificationMethodSecp256r1VerificationKey2018:      "https://w3id.org/security/v2",
+	DIDVerificationMethodRsaVerificationKey2018:            "https://w3id.org/security/v2",
+}
+
+// DIDOperation is an operation of the DID registry, signed by a key of the DID
+type DIDOperation string
+
+const (
+	DIDOperationRegister      DIDOperation = "DID_REGISTER"
+	DIDOperationAddKey        DIDOperation = "DID_ADD_KEY"
+	DIDOperationRevokeKey     DIDOperation = "DID_REVOKE_KEY"
+	DIDOperationAddController DIDOperation = "DID_ADD_CONTROLLER"
+	DIDOperationDeactivate    DIDOperation = "DID_DEACTIVATE"
+)
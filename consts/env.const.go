@@ -1,4 +1,34 @@
 package consts
 
+const ENVHost = "HOST"
+
 const ENVHSMPin = "HSM_PIN"
 const ENVHSMSlot = "HSM_SLOT"
+
+const ENVAuthAdminAPIKey = "AUTH_ADMIN_API_KEY"
+
+const ENVTLSCertFile = "TLS_CERT_FILE"
+const ENVTLSKeyFile = "TLS_KEY_FILE"
+const ENVTLSClientCAFile = "TLS_CLIENT_CA_FILE"
+
+const ENVAuthJWKSFile = "AUTH_JWKS_FILE"
+const ENVAuthJWKSURL = "AUTH_JWKS_URL"
+const ENVAuthJWTIssuer = "AUTH_JWT_ISSUER"
+const ENVAuthJWTAudience = "AUTH_JWT_AUDIENCE"
+const ENVAuthJWTTenantClaim = "AUTH_JWT_TENANT_CLAIM"
+
+const ENVAuditSigningKeyID = "AUDIT_SIGNING_KEY_ID"
+
+const ENVRateLimitRedisAddr = "RATE_LIMIT_REDIS_ADDR"
+const ENVRateLimitRedisPassword = "RATE_LIMIT_REDIS_PASSWORD"
+const ENVRateLimitClientRate = "RATE_LIMIT_CLIENT_RATE"
+const ENVRateLimitClientBurst = "RATE_LIMIT_CLIENT_BURST"
+const ENVRateLimitKeyRate = "RATE_LIMIT_KEY_RATE"
+const ENVRateLimitKeyBurst = "RATE_LIMIT_KEY_BURST"
+
+const ENVIdempotencyRetentionHours = "IDEMPOTENCY_RETENTION_HOURS"
+
+const ENVKeyCacheMaxEntries = "KEY_CACHE_MAX_ENTRIES"
+const ENVKeyCacheMaxTTLSeconds = "KEY_CACHE_MAX_TTL_SECONDS"
+
+const ENVBackupHMACKey = "BACKUP_HMAC_KEY"
@@ -0,0 +1,11 @@
+package consts
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusInProgress IdempotencyStatus = "in_progress"
+	IdempotencyStatusCompleted  IdempotencyStatus = "completed"
+)
+
+// IdempotencyDefaultRetentionHours is used when IDEMPOTENCY_RETENTION_HOURS is not set
+const IdempotencyDefaultRetentionHours = 24
@@ -0,0 +1,31 @@
+package consts
+
+// KeyApprovalOperation is a key operation that needs the approval of a quorum when the policy of the key asks for one
+type KeyApprovalOperation string
+
+const (
+	KeyApprovalOperationExport       KeyApprovalOperation = "export"
+	KeyApprovalOperationDelete       KeyApprovalOperation = "delete"
+	KeyApprovalOperationUpdatePolicy KeyApprovalOperation = "update_policy"
+)
+
+type KeyApprovalStatus string
+
+const (
+	KeyApprovalStatusPending  KeyApprovalStatus = "pending"
+	KeyApprovalStatusApproved KeyApprovalStatus = "approved"
+	KeyApprovalStatusRejected KeyApprovalStatus = "rejected"
+	KeyApprovalStatusExecuted KeyApprovalStatus = "executed"
+)
+
+type KeyApprovalDecision string
+
+const (
+	KeyApprovalDecisionApprove KeyApprovalDecision = "approve"
+	KeyApprovalDecisionReject  KeyApprovalDecision = "reject"
+)
+
+const (
+	KeyApprovalDefaultTTLSeconds = 86400
+	KeyApprovalMaxTTLSeconds     = 604800
+)
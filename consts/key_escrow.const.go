@@ -0,0 +1,9 @@
+package consts
+
+const (
+	// KeyEscrowRecoveryKeySize is the size of the AES-256 key the escrowed private key is wrapped under
+	KeyEscrowRecoveryKeySize = 32
+	KeyEscrowMinThreshold    = 2
+	// KeyEscrowMaxCustodians is the number of x coordinates of GF(256) Shamir shares
+	KeyEscrowMaxCustodians = 255
+)
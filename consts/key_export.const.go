@@ -0,0 +1,13 @@
+package consts
+
+type KeyExportFormat string
+
+const (
+	// KeyExportFormatAESKWP wraps the key the way import jobs of the same wrapping key type expect it
+	KeyExportFormatAESKWP KeyExportFormat = "AES_KWP"
+	// KeyExportFormatJWE encrypts the PKCS #8 key into a compact JWE with RSA-OAEP-256 and A256GCM
+	KeyExportFormatJWE KeyExportFormat = "JWE"
+)
+
+// KeyExportJWEHeaderDigest is the protected header parameter of a JWE bundle holding the digest of its metadata
+const KeyExportJWEHeaderDigest = "bundle_digest"
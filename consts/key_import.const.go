@@ -0,0 +1,21 @@
+package consts
+
+type KeyImportMethod string
+
+const (
+	// KeyImportMethodRSAOAEPAESKWP wraps a random AES-256 key with RSA-OAEP SHA-256 under a 3072 bits transport key
+	// and the PKCS #8 private key with AES-KWP under that AES key
+	KeyImportMethodRSAOAEPAESKWP KeyImportMethod = "RSA_OAEP_3072_SHA256_AES_256"
+	// KeyImportMethodECDHAESKWP derives the AES-256 key with HKDF-SHA256 from an ECDH agreement with a P-256
+	// transport key and wraps the PKCS #8 private key with AES-KWP under it
+	KeyImportMethodECDHAESKWP KeyImportMethod = "ECDH_P256_HKDF_SHA256_AES_256"
+)
+
+// KeyImportTransportRSAKeySize is the size in bits of RSA transport keys
+const KeyImportTransportRSAKeySize = 3072
+
+// KeyImportJobDefaultTTLSeconds and KeyImportJobMaxTTLSeconds bound the lifetime of ephemeral import jobs
+const (
+	KeyImportJobDefaultTTLSeconds = 24 * 60 * 60
+	KeyImportJobMaxTTLSeconds     = 7 * 24 * 60 * 60
+)
@@ -0,0 +1,10 @@
+package consts
+
+type KeyOperation string
+
+const (
+	KeyOperationRead   KeyOperation = "read"
+	KeyOperationSign   KeyOperation = "sign"
+	KeyOperationDelete KeyOperation = "delete"
+	KeyOperationManage KeyOperation = "manage"
+)
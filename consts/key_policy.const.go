@@ -0,0 +1,30 @@
+package consts
+
+type KeyUsage string
+
+const (
+	KeyUsageSign    KeyUsage = "sign"
+	KeyUsageVerify  KeyUsage = "verify"
+	KeyUsageEncrypt KeyUsage = "encrypt"
+	KeyUsageDerive  KeyUsage = "derive"
+)
+
+type SigningAlgorithm string
+
+const (
+	SigningAlgorithmES256 SigningAlgorithm = "ES256"
+	SigningAlgorithmRS256 SigningAlgorithm = "RS256"
+	// SigningAlgorithmBBS signs many messages at once with a BLS12381G2 key
+	SigningAlgorithmBBS SigningAlgorithm = "BBS"
+)
+
+type MessageFormat string
+
+const (
+	MessageFormatText   MessageFormat = "text"
+	MessageFormatHex    MessageFormat = "hex"
+	MessageFormatBase64 MessageFormat = "base64"
+	MessageFormatJSON   MessageFormat = "json"
+	// MessageFormatJWS is the "header.payload" signing input of a compact JWS
+	MessageFormatJWS MessageFormat = "jws"
+)
@@ -0,0 +1,4 @@
+package consts
+
+// KeySignBatchMaxItems bounds the messages of one POST /key/sign/batch request
+const KeySignBatchMaxItems = 1000
@@ -5,4 +5,15 @@ type KeyType string
 const (
 	KeyTypeECDSA KeyType = "ECDSA"
 	KeyTypeRSA   KeyType = "RSA"
+	// KeyTypeBLS12381G2 keys make BBS signatures, their public keys are points of the G2 group of BLS12-381
+	KeyTypeBLS12381G2 KeyType = "BLS12381G2"
+)
+
+// RSAKeySize is the size in bits of generated RSA keys
+const RSAKeySize = 2048
+
+// the PEM blocks of the compressed G2 point and of the big-endian secret scalar of a BLS12381G2 key
+const (
+	BBSPublicKeyPEMType  = "BLS12381G2 PUBLIC KEY"
+	BBSPrivateKeyPEMType = "BLS12381G2 PRIVATE KEY"
 )
@@ -0,0 +1,28 @@
+package consts
+
+// PresentationFormat is how a verifiable presentation is secured
+type PresentationFormat string
+
+const (
+	// PresentationFormatDataIntegrity embeds a Data Integrity proof of the ecdsa-jcs-2019 cryptosuite
+	PresentationFormatDataIntegrity PresentationFormat = "data_integrity"
+	// PresentationFormatJWT wraps the presentation in the "vp" claim of a JWT
+	PresentationFormatJWT PresentationFormat = "jwt"
+)
+
+const (
+	PresentationTypeVerifiablePresentation = "VerifiablePresentation"
+	DataIntegrityProofType                 = "DataIntegrityProof"
+	DataIntegrityCryptosuiteECDSAJCS2019   = "ecdsa-jcs-2019"
+	ProofPurposeAuthentication             = "authentication"
+)
+
+const (
+	// DataIntegrityCryptosuiteBBS2023 is the selective disclosure cryptosuite of BBS signatures
+	DataIntegrityCryptosuiteBBS2023 = "bbs-2023"
+	// BBS2023BaseProofHeader and BBS2023DerivedProofHeader prefix the CBOR of the proof values of the baseline feature
+	BBS2023BaseProofHeader    = "\xd9\x5d\x02"
+	BBS2023DerivedProofHeader = "\xd9\x5d\x03"
+	// BBS2023HMACKeySize is the size of the key that shuffles the blank node labels of a document
+	BBS2023HMACKeySize = 32
+)
@@ -0,0 +1,9 @@
+package consts
+
+type PrincipalType string
+
+const (
+	PrincipalTypeClient  PrincipalType = "client"
+	PrincipalTypeAdmin   PrincipalType = "admin"
+	PrincipalTypeSubject PrincipalType = "subject"
+)
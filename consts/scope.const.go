@@ -0,0 +1,11 @@
+package consts
+
+type Scope string
+
+const (
+	ScopeKeysGenerate Scope = "keys:generate"
+	ScopeKeysSign     Scope = "keys:sign"
+	ScopeKeysRead     Scope = "keys:read"
+	ScopeKeysAdmin    Scope = "keys:admin"
+	ScopeAuditRead    Scope = "audit:read"
+)
@@ -0,0 +1,7 @@
+package consts
+
+// SDJWTTypeVC is the "typ" of the issuer-signed JWT of an SD-JWT VC
+const SDJWTTypeVC = "dc+sd-jwt"
+
+// SDJWTNonDisclosableClaims are the claims of an SD-JWT VC a verifier needs to process it, they are always disclosed
+var SDJWTNonDisclosableClaims = []string{"iss", "nbf", "exp", "cnf", "vct", "status"}
@@ -0,0 +1,33 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	AuditRecordError = core.Error{
+		Status:  http.StatusInternalServerError,
+		Code:    "AUDIT_RECORD_ERROR",
+		Message: "the operation could not be recorded in the audit log",
+	}
+
+	AuditEventNotFoundError = core.Error{
+		Status:  http.StatusNotFound,
+		Code:    "AUDIT_EVENT_NOT_FOUND",
+		Message: "audit event is not found",
+	}
+
+	AuditExportNotFoundError = core.Error{
+		Status:  http.StatusNotFound,
+		Code:    "AUDIT_EXPORT_NOT_FOUND",
+		Message: "audit export is not found",
+	}
+
+	AuditSigningKeyNotConfiguredError = core.Error{
+		Status:  http.StatusServiceUnavailable,
+		Code:    "AUDIT_SIGNING_KEY_NOT_CONFIGURED",
+		Message: "no audit signing key is configured, set AUDIT_SIGNING_KEY_ID",
+	}
+)
@@ -0,0 +1,38 @@
+package emsgs
+
+import (
+	"fmt"
+	"net/http"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	UnauthorizedError = core.Error{
+		Status:  http.StatusUnauthorized,
+		Code:    "UNAUTHORIZED",
+		Message: "valid credentials are required",
+	}
+
+	ForbiddenError = core.Error{
+		Status:  http.StatusForbidden,
+		Code:    "FORBIDDEN",
+		Message: "you are not allowed to perform this operation",
+	}
+
+	APIClientNotFoundError = core.Error{
+		Status:  http.StatusNotFound,
+		Code:    "API_CLIENT_NOT_FOUND",
+		Message: "api client is not found",
+	}
+)
+
+func InsufficientScopeError(scope consts.Scope) core.IError {
+	return &core.Error{
+		Status:  http.StatusForbidden,
+		Code:    "INSUFFICIENT_SCOPE",
+		Message: fmt.Sprintf("the %s scope is required", scope),
+	}
+}
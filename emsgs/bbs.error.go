@@ -0,0 +1,51 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	BBSKeyRequiredError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "BBS_KEY_REQUIRED",
+		Message: "BBS signatures and bbs-2023 proofs must be made with a BLS12381G2 key",
+	}
+
+	BBSKeyMessageSigningError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "BBS_KEY_MESSAGE_SIGNING",
+		Message: "a BLS12381G2 key only signs through the BBS and bbs-2023 endpoints",
+	}
+
+	BBSKeyNotPortableError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "BBS_KEY_NOT_PORTABLE",
+		Message: "BLS12381G2 keys can not be exported or escrowed",
+	}
+
+	InvalidBBS2023StatementsError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_BBS_2023_STATEMENTS",
+		Message: "the statements must be canonical N-Quads and the indexes ascending indexes of the statements",
+	}
+
+	InvalidBBS2023ProofValueError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_BBS_2023_PROOF_VALUE",
+		Message: "the proof value is not a bbs-2023 proof of the expected kind",
+	}
+
+	BBS2023BaseProofMismatchError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "BBS_2023_BASE_PROOF_MISMATCH",
+		Message: "the base proof was not issued for the statements and mandatory indexes",
+	}
+
+	BBS2023ProofVerificationError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "BBS_2023_PROOF_VERIFICATION_FAILED",
+		Message: "the bbs-2023 proof does not verify for the statements and proof configuration",
+	}
+)
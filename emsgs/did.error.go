@@ -0,0 +1,33 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	InvalidDIDError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_DID",
+		Message: "the DID must be a did:key or did:jwk of a P-256 key or an RSA key",
+	}
+
+	InvalidVerificationMethodTypeError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_VERIFICATION_METHOD_TYPE",
+		Message: "the verification method type does not support the type of the key",
+	}
+
+	DIDOperationKeyMismatchError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "DID_OPERATION_KEY_MISMATCH",
+		Message: "the public key of a DID_REGISTER operation must be the public key of the signing key",
+	}
+
+	UnsupportedDIDOperationError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "UNSUPPORTED_DID_OPERATION",
+		Message: "the operation must be DID_REGISTER, DID_ADD_KEY, DID_REVOKE_KEY, DID_ADD_CONTROLLER or DID_DEACTIVATE",
+	}
+)
@@ -0,0 +1,27 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	InvalidIdempotencyKeyError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_IDEMPOTENCY_KEY",
+		Message: "the Idempotency-Key header must be at most 255 characters",
+	}
+
+	IdempotencyKeyReusedError = core.Error{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    "IDEMPOTENCY_KEY_REUSED",
+		Message: "the Idempotency-Key was already used for a different request",
+	}
+
+	IdempotencyRequestInProgressError = core.Error{
+		Status:  http.StatusConflict,
+		Code:    "IDEMPOTENCY_REQUEST_IN_PROGRESS",
+		Message: "a request with this Idempotency-Key is still in progress",
+	}
+)
@@ -19,6 +19,36 @@ var (
 		Message: "generate key error",
 	}
 
+	KeyAccessDeniedError = core.Error{
+		Status:  http.StatusForbidden,
+		Code:    "KEY_ACCESS_DENIED",
+		Message: "key does not exist or access is denied",
+	}
+
+	KeyDelegationNotFoundError = core.Error{
+		Status:  http.StatusNotFound,
+		Code:    "KEY_DELEGATION_NOT_FOUND",
+		Message: "key delegation is not found",
+	}
+
+	KeyVersionNotFoundError = core.Error{
+		Status:  http.StatusNotFound,
+		Code:    "KEY_VERSION_NOT_FOUND",
+		Message: "key version is not found",
+	}
+
+	KeyVersionNotSignableError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "KEY_VERSION_NOT_SIGNABLE",
+		Message: "only the latest version of a key signs, older versions are kept for verification",
+	}
+
+	KeyAliasAlreadyExistsError = core.Error{
+		Status:  http.StatusConflict,
+		Code:    "KEY_ALIAS_ALREADY_EXISTS",
+		Message: "key alias is already in use",
+	}
+
 	UnsupportedSigningAlgorithm = core.Error{
 		Status:  http.StatusBadRequest,
 		Code:    "UNSUPPORTED_APGORITHM",
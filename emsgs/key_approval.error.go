@@ -0,0 +1,57 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	KeyApprovalRequiredError = core.Error{
+		Status:  http.StatusForbidden,
+		Code:    "KEY_APPROVAL_REQUIRED",
+		Message: "the key policy requires this operation to be requested and approved by a quorum first",
+	}
+
+	KeyApprovalNotRequiredError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "KEY_APPROVAL_NOT_REQUIRED",
+		Message: "the key policy does not require approvals, perform the operation directly",
+	}
+
+	KeyOperationRequestNotFoundError = core.Error{
+		Status:  http.StatusNotFound,
+		Code:    "KEY_OPERATION_REQUEST_NOT_FOUND",
+		Message: "key operation request is not found",
+	}
+
+	KeyOperationRequestExpiredError = core.Error{
+		Status:  http.StatusGone,
+		Code:    "KEY_OPERATION_REQUEST_EXPIRED",
+		Message: "the key operation request has expired, create a new one",
+	}
+
+	KeyOperationRequestNotPendingError = core.Error{
+		Status:  http.StatusConflict,
+		Code:    "KEY_OPERATION_REQUEST_NOT_PENDING",
+		Message: "the key operation request is no longer waiting for approvals",
+	}
+
+	KeyOperationRequestNotApprovedError = core.Error{
+		Status:  http.StatusConflict,
+		Code:    "KEY_OPERATION_REQUEST_NOT_APPROVED",
+		Message: "the key operation request is not approved or was already executed",
+	}
+
+	KeyApproverDeniedError = core.Error{
+		Status:  http.StatusForbidden,
+		Code:    "KEY_APPROVER_DENIED",
+		Message: "the caller is not an approver of the key or is the requester",
+	}
+
+	KeyApprovalDuplicateError = core.Error{
+		Status:  http.StatusConflict,
+		Code:    "KEY_APPROVAL_DUPLICATE",
+		Message: "the caller already decided on this key operation request",
+	}
+)
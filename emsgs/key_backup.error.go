@@ -0,0 +1,50 @@
+package emsgs
+
+import (
+	"fmt"
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	BackupHMACKeyError = core.Error{
+		Status:  http.StatusInternalServerError,
+		Code:    "BACKUP_HMAC_KEY",
+		Message: "BACKUP_HMAC_KEY must be set to a secret of at least 32 bytes",
+	}
+
+	BackupFormatError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "BACKUP_FORMAT",
+		Message: "the archive is not a key backup of a supported format version",
+	}
+
+	BackupIntegrityError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "BACKUP_INTEGRITY",
+		Message: "the backup archive was altered or BACKUP_HMAC_KEY is not the key it was made with",
+	}
+
+	BackupRestoreTargetNotEmptyError = core.Error{
+		Status:  http.StatusConflict,
+		Code:    "BACKUP_RESTORE_TARGET_NOT_EMPTY",
+		Message: "a backup can only be restored into a database without keys and audit events",
+	}
+)
+
+func BackupKEKMissingError(kekIDs []string) core.IError {
+	return &core.Error{
+		Status:  http.StatusConflict,
+		Code:    "BACKUP_KEK_MISSING",
+		Message: fmt.Sprintf("the HSM does not hold the KEKs %v that keys of the backup are encrypted with", kekIDs),
+	}
+}
+
+func BackupDecryptError(keyID string, version int) core.IError {
+	return &core.Error{
+		Status:  http.StatusConflict,
+		Code:    "BACKUP_DECRYPT",
+		Message: fmt.Sprintf("version %d of key %s cannot be decrypted by the HSM or does not match its public key", version, keyID),
+	}
+}
@@ -0,0 +1,39 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	InvalidEscrowCustodianError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_ESCROW_CUSTODIAN",
+		Message: "every escrow custodian must be a PKIX PEM of a P-256 key or an RSA key of 2048 bits or more",
+	}
+
+	InvalidEscrowPolicyError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_ESCROW_POLICY",
+		Message: "the escrow threshold must be between 2 and the number of custodians, who must be distinct and at most 255",
+	}
+
+	KeyEscrowNotFoundError = core.Error{
+		Status:  http.StatusNotFound,
+		Code:    "KEY_ESCROW_NOT_FOUND",
+		Message: "the version of the key is not escrowed",
+	}
+
+	KeyEscrowSharesError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "KEY_ESCROW_SHARES_INVALID",
+		Message: "at least the threshold of distinct shares of the escrow are needed",
+	}
+
+	KeyEscrowRecoveryError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "KEY_ESCROW_RECOVERY_FAILED",
+		Message: "the shares do not rebuild the recovery key of the escrow",
+	}
+)
@@ -0,0 +1,27 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	KeyNotExportableError = core.Error{
+		Status:  http.StatusForbidden,
+		Code:    "KEY_NOT_EXPORTABLE",
+		Message: "the key policy does not allow exporting the key under this wrapping key",
+	}
+
+	InvalidWrappingKeyError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_WRAPPING_KEY",
+		Message: "the wrapping key must be a PKIX PEM of a P-256 key or an RSA key of 2048 bits or more, JWE needs an RSA key",
+	}
+
+	KeyBundleMismatchError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "KEY_BUNDLE_MISMATCH",
+		Message: "the bundle was not wrapped for the transport key and method of this import job",
+	}
+)
@@ -0,0 +1,45 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	InvalidPrivateKeyError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_PRIVATE_KEY",
+		Message: "the private key must be a JWK, a PKCS #8, PKCS #1 or SEC 1 PEM, or an encrypted PKCS #8 PEM with its password",
+	}
+
+	PrivateKeyPasswordRequiredError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "PRIVATE_KEY_PASSWORD_REQUIRED",
+		Message: "the private key is encrypted, private_key_password is required",
+	}
+
+	UnsupportedPrivateKeyError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "UNSUPPORTED_PRIVATE_KEY",
+		Message: "only P-256 ECDSA keys and RSA keys of 2048 bits or more are supported",
+	}
+
+	InvalidPublicKeyError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_PUBLIC_KEY",
+		Message: "the public key must be a PKIX PEM",
+	}
+
+	KeyPairMismatchError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "KEY_PAIR_MISMATCH",
+		Message: "the public key does not belong to the private key",
+	}
+
+	KeyTypeMismatchError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "KEY_TYPE_MISMATCH",
+		Message: "the key type does not match the private key",
+	}
+)
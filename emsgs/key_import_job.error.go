@@ -0,0 +1,33 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	KeyImportJobNotFoundError = core.Error{
+		Status:  http.StatusNotFound,
+		Code:    "KEY_IMPORT_JOB_NOT_FOUND",
+		Message: "key import job is not found",
+	}
+
+	KeyImportJobExpiredError = core.Error{
+		Status:  http.StatusGone,
+		Code:    "KEY_IMPORT_JOB_EXPIRED",
+		Message: "the key import job has expired, create a new one",
+	}
+
+	KeyImportJobUsedError = core.Error{
+		Status:  http.StatusConflict,
+		Code:    "KEY_IMPORT_JOB_USED",
+		Message: "the key import job was already used, create a new one",
+	}
+
+	InvalidWrappedKeyError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_WRAPPED_KEY",
+		Message: "the wrapped key cannot be unwrapped with the transport key of the import job",
+	}
+)
@@ -0,0 +1,39 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	KeyPolicyUsageDeniedError = core.Error{
+		Status:  http.StatusForbidden,
+		Code:    "KEY_POLICY_USAGE_DENIED",
+		Message: "the key policy does not allow this operation",
+	}
+
+	KeyPolicyAlgorithmDeniedError = core.Error{
+		Status:  http.StatusForbidden,
+		Code:    "KEY_POLICY_ALGORITHM_DENIED",
+		Message: "the key policy does not allow this algorithm",
+	}
+
+	KeyPolicyCallerDeniedError = core.Error{
+		Status:  http.StatusForbidden,
+		Code:    "KEY_POLICY_CALLER_DENIED",
+		Message: "the key policy does not allow this caller",
+	}
+
+	KeyPolicyMessageFormatDeniedError = core.Error{
+		Status:  http.StatusForbidden,
+		Code:    "KEY_POLICY_MESSAGE_FORMAT_DENIED",
+		Message: "the key policy does not allow this message format",
+	}
+
+	KeyPolicySignatureLimitError = core.Error{
+		Status:  http.StatusTooManyRequests,
+		Code:    "KEY_POLICY_SIGNATURE_LIMIT",
+		Message: "the key policy signature limit for the current window is reached",
+	}
+)
@@ -0,0 +1,27 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	PresentationProofUnsupportedError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "PRESENTATION_PROOF_UNSUPPORTED",
+		Message: "a data_integrity presentation must be signed with a P-256 key, use the jwt format for RSA keys",
+	}
+
+	PresentationVerificationMethodRequiredError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "PRESENTATION_VERIFICATION_METHOD_REQUIRED",
+		Message: "the verification_method is required when the holder is not the did:key of the key",
+	}
+
+	PresentationHolderMismatchError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "PRESENTATION_HOLDER_MISMATCH",
+		Message: "the verification_method must be a verification method of the holder of the presentation",
+	}
+)
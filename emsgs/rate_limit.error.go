@@ -0,0 +1,21 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	ClientRateLimitExceededError = core.Error{
+		Status:  http.StatusTooManyRequests,
+		Code:    "CLIENT_RATE_LIMIT_EXCEEDED",
+		Message: "too many signing requests from this client, retry after the Retry-After header",
+	}
+
+	KeyRateLimitExceededError = core.Error{
+		Status:  http.StatusTooManyRequests,
+		Code:    "KEY_RATE_LIMIT_EXCEEDED",
+		Message: "too many signing requests for this key, retry after the Retry-After header",
+	}
+)
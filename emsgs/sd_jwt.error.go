@@ -0,0 +1,15 @@
+package emsgs
+
+import (
+	"net/http"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	InvalidSDJWTDisclosablePathError = core.Error{
+		Status:  http.StatusBadRequest,
+		Code:    "INVALID_SD_JWT_DISCLOSABLE_PATH",
+		Message: "every disclosable path must point to a member of an object of the claims",
+	}
+)
@@ -0,0 +1,102 @@
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	kwpIVPrefix = []byte{0xA6, 0x59, 0x59, 0xA6}
+
+	ErrKeyUnwrap = errors.New("kwp: the wrapped key is not valid for this key encryption key")
+)
+
+// WrapKeyAESKWP wraps plaintext with the AES key wrap with padding of RFC 5649
+func WrapKeyAESKWP(kek []byte, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(plaintext) == 0 || uint64(len(plaintext)) > 1<<32-1 {
+		return nil, errors.New("kwp: the plaintext must be 1 to 2^32-1 bytes")
+	}
+
+	n := (len(plaintext) + 7) / 8
+	out := make([]byte, 8+n*8)
+	copy(out, kwpIVPrefix)
+	binary.BigEndian.PutUint32(out[4:8], uint32(len(plaintext)))
+	copy(out[8:], plaintext)
+	if n == 1 {
+		block.Encrypt(out, out)
+		return out, nil
+	}
+
+	b := make([]byte, 16)
+	defer Zeroize(b)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b, out[:8])
+			copy(b[8:], out[i*8:i*8+8])
+			block.Encrypt(b, b)
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(b[:8])^t)
+			copy(out[i*8:], b[8:])
+		}
+	}
+
+	return out, nil
+}
+
+// UnwrapKeyAESKWP reverses WrapKeyAESKWP and checks the integrity of the result,
+// the returned plaintext must be zeroed by the caller
+func UnwrapKeyAESKWP(kek []byte, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < 16 || len(ciphertext)%8 != 0 {
+		return nil, ErrKeyUnwrap
+	}
+
+	n := len(ciphertext)/8 - 1
+	out := make([]byte, len(ciphertext))
+	copy(out, ciphertext)
+	if n == 1 {
+		block.Decrypt(out, out)
+	} else {
+		b := make([]byte, 16)
+		defer Zeroize(b)
+		for j := 5; j >= 0; j-- {
+			for i := n; i >= 1; i-- {
+				t := uint64(n*j + i)
+				binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(out[:8])^t)
+				copy(b[8:], out[i*8:i*8+8])
+				block.Decrypt(b, b)
+				copy(out[:8], b[:8])
+				copy(out[i*8:], b[8:])
+			}
+		}
+	}
+
+	length := int(binary.BigEndian.Uint32(out[4:8]))
+	valid := subtle.ConstantTimeCompare(out[:4], kwpIVPrefix) == 1 && length > 8*(n-1) && length <= 8*n
+	if valid {
+		padding := byte(0)
+		for _, b := range out[8+length:] {
+			padding |= b
+		}
+		valid = padding == 0
+	}
+	if !valid {
+		Zeroize(out)
+		return nil, ErrKeyUnwrap
+	}
+
+	plaintext := make([]byte, length)
+	copy(plaintext, out[8:8+length])
+	Zeroize(out)
+
+	return plaintext, nil
+}
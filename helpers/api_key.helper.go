@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/hex"
+	"strings"
+)
+
+const apiKeySeparator = "."
+
+// GenerateAPIKey returns a key of the form "prefix.secret", the prefix is stored in clear to look the client up
+func GenerateAPIKey() (string, string, error) {
+	prefix := make([]byte, 6)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefixHex := hex.EncodeToString(prefix)
+	return prefixHex, prefixHex + apiKeySeparator + hex.EncodeToString(secret), nil
+}
+
+func APIKeyPrefix(apiKey string) string {
+	return strings.SplitN(apiKey, apiKeySeparator, 2)[0]
+}
+
+// HashAPIKey is a plain SHA-256, API keys carry 256 bits of entropy so a slow hash is not needed
+func HashAPIKey(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
+
+func CompareAPIKeyHash(apiKey string, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(apiKey)), []byte(hash)) == 1
+}
+
+// CertificateFingerprint is the hex encoded SHA-256 of the DER certificate
+func CertificateFingerprint(certificate *x509.Certificate) string {
+	hash := sha256.Sum256(certificate.Raw)
+	return hex.EncodeToString(hash[:])
+}
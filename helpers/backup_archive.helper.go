@@ -0,0 +1,144 @@
+package helpers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+)
+
+const (
+	BackupManifestName     = "manifest.json"
+	BackupManifestHMACName = "manifest.json.hmac"
+	// backupMaxFileSize bounds what a restore reads into memory for a single file of the archive
+	backupMaxFileSize = 1 << 30
+)
+
+var (
+	ErrBackupFormat    = errors.New("backup: the archive is not a key backup")
+	ErrBackupIntegrity = errors.New("backup: the archive was altered or the HMAC key is wrong")
+)
+
+// BackupFile is a file of a backup archive, listed in the manifest with its digest
+type BackupFile struct {
+	Name string
+	Rows int
+	Data []byte
+}
+
+// WriteBackupArchive writes a tar.gz with the manifest, its HMAC-SHA256 under hmacKey and the files,
+// the files are added to manifest.Files with their SHA-256
+func WriteBackupArchive(w io.Writer, hmacKey []byte, manifest *models.KeyBackupManifest, files []BackupFile) error {
+	manifest.Files = make([]models.KeyBackupFile, 0, len(files))
+	for _, file := range files {
+		digest := sha256.Sum256(file.Data)
+		manifest.Files = append(manifest.Files, models.KeyBackupFile{
+			Name:   file.Name,
+			Rows:   file.Rows,
+			SHA256: hex.EncodeToString(digest[:]),
+		})
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	entries := append([]BackupFile{
+		{Name: BackupManifestName, Data: manifestJSON},
+		{Name: BackupManifestHMACName, Data: []byte(hex.EncodeToString(backupHMAC(hmacKey, manifestJSON)))},
+	}, files...)
+	for _, entry := range entries {
+		err = tarWriter.WriteHeader(&tar.Header{
+			Name:    entry.Name,
+			Mode:    0600,
+			Size:    int64(len(entry.Data)),
+			ModTime: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err = tarWriter.Write(entry.Data); err != nil {
+			return err
+		}
+	}
+	if err = tarWriter.Close(); err != nil {
+		return err
+	}
+
+	return gzipWriter.Close()
+}
+
+// ReadBackupArchive reads an archive made by WriteBackupArchive and returns the files only once the HMAC of the
+// manifest and the digest of every file match, files that are not in the manifest are rejected
+func ReadBackupArchive(r io.Reader, hmacKey []byte) (*models.KeyBackupManifest, map[string][]byte, error) {
+	gzipReader, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, ErrBackupFormat
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	entries := make(map[string][]byte)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil || header.Typeflag != tar.TypeReg || header.Size > backupMaxFileSize {
+			return nil, nil, ErrBackupFormat
+		}
+		if _, duplicate := entries[header.Name]; duplicate {
+			return nil, nil, ErrBackupFormat
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(tarReader, backupMaxFileSize))
+		if err != nil {
+			return nil, nil, ErrBackupFormat
+		}
+		entries[header.Name] = data
+	}
+
+	manifestJSON, ok := entries[BackupManifestName]
+	if !ok {
+		return nil, nil, ErrBackupFormat
+	}
+	signature, err := hex.DecodeString(string(bytes.TrimSpace(entries[BackupManifestHMACName])))
+	if err != nil || !hmac.Equal(signature, backupHMAC(hmacKey, manifestJSON)) {
+		return nil, nil, ErrBackupIntegrity
+	}
+
+	manifest := &models.KeyBackupManifest{}
+	if err := json.Unmarshal(manifestJSON, manifest); err != nil {
+		return nil, nil, ErrBackupFormat
+	}
+
+	files := make(map[string][]byte)
+	for _, file := range manifest.Files {
+		data, ok := entries[file.Name]
+		digest := sha256.Sum256(data)
+		if !ok || hex.EncodeToString(digest[:]) != file.SHA256 {
+			return nil, nil, ErrBackupIntegrity
+		}
+		files[file.Name] = data
+	}
+	if len(files)+2 != len(entries) {
+		return nil, nil, ErrBackupIntegrity
+	}
+
+	return manifest, files, nil
+}
+
+func backupHMAC(key []byte, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+)
+
+type BackupArchiveHelperTestSuite struct {
+	suite.Suite
+	hmacKey []byte
+}
+
+func TestBackupArchiveHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(BackupArchiveHelperTestSuite))
+}
+
+func (s *BackupArchiveHelperTestSuite) SetupTest() {
+	s.hmacKey = []byte("0123456789abcdef0123456789abcdef")
+}
+
+func (s *BackupArchiveHelperTestSuite) archive() []byte {
+	buffer := &bytes.Buffer{}
+	err := WriteBackupArchive(buffer, s.hmacKey, &models.KeyBackupManifest{FormatVersion: 1}, []BackupFile{
+		{Name: "keys.jsonl", Rows: 2, Data: []byte("{\"id\":\"1\"}\n{\"id\":\"2\"}\n")},
+		{Name: "key_tags.jsonl", Data: []byte{}},
+	})
+	s.Require().NoError(err)
+	return buffer.Bytes()
+}
+
+// rewrite changes the entries of an archive to simulate tampering
+func (s *BackupArchiveHelperTestSuite) rewrite(archive []byte, change func(name string, data []byte) []byte) []byte {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	s.Require().NoError(err)
+	tarReader := tar.NewReader(gzipReader)
+
+	buffer := &bytes.Buffer{}
+	gzipWriter := gzip.NewWriter(buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	for {
+		header, err := tarReader.Next()
+		if err != nil {
+			break
+		}
+		data, err := ioutil.ReadAll(tarReader)
+		s.Require().NoError(err)
+		data = change(header.Name, data)
+		header.Size = int64(len(data))
+		s.Require().NoError(tarWriter.WriteHeader(header))
+		_, err = tarWriter.Write(data)
+		s.Require().NoError(err)
+	}
+	s.Require().NoError(tarWriter.Close())
+	s.Require().NoError(gzipWriter.Close())
+
+	return buffer.Bytes()
+}
+
+func (s *BackupArchiveHelperTestSuite) TestReadBackupArchive() {
+	manifest, files, err := ReadBackupArchive(bytes.NewReader(s.archive()), s.hmacKey)
+	s.NoError(err)
+	s.Equal(1, manifest.FormatVersion)
+	s.Len(manifest.Files, 2)
+	s.Equal(2, manifest.Files[0].Rows)
+	s.Equal("{\"id\":\"1\"}\n{\"id\":\"2\"}\n", string(files["keys.jsonl"]))
+	s.Empty(files["key_tags.jsonl"])
+}
+
+func (s *BackupArchiveHelperTestSuite) TestReadBackupArchive_WrongHMACKey() {
+	_, _, err := ReadBackupArchive(bytes.NewReader(s.archive()), []byte("another key of 32 bytes at least"))
+	s.Equal(ErrBackupIntegrity, err)
+}
+
+func (s *BackupArchiveHelperTestSuite) TestReadBackupArchive_AlteredFile() {
+	archive := s.rewrite(s.archive(), func(name string, data []byte) []byte {
+		if name == "keys.jsonl" {
+			return []byte("{\"id\":\"1\"}\n")
+		}
+		return data
+	})
+
+	_, _, err := ReadBackupArchive(bytes.NewReader(archive), s.hmacKey)
+	s.Equal(ErrBackupIntegrity, err)
+}
+
+func (s *BackupArchiveHelperTestSuite) TestReadBackupArchive_AlteredManifest() {
+	archive := s.rewrite(s.archive(), func(name string, data []byte) []byte {
+		if name == BackupManifestName {
+			return bytes.Replace(data, []byte(`"rows": 2`), []byte(`"rows": 1`), 1)
+		}
+		return data
+	})
+
+	_, _, err := ReadBackupArchive(bytes.NewReader(archive), s.hmacKey)
+	s.Equal(ErrBackupIntegrity, err)
+}
+
+func (s *BackupArchiveHelperTestSuite) TestReadBackupArchive_NotAnArchive() {
+	_, _, err := ReadBackupArchive(bytes.NewReader([]byte("not a backup")), s.hmacKey)
+	s.Equal(ErrBackupFormat, err)
+}
@@ -0,0 +1,89 @@
+package helpers
+
+import (
+	"errors"
+)
+
+const base58BTCAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+var ErrBase58 = errors.New("base58: invalid character")
+
+var base58BTCIndexes = newBase58Indexes()
+
+func newBase58Indexes() [256]int {
+	var indexes [256]int
+	for i := range indexes {
+		indexes[i] = -1
+	}
+	for i := 0; i < len(base58BTCAlphabet); i++ {
+		indexes[base58BTCAlphabet[i]] = i
+	}
+
+	return indexes
+}
+
+// EncodeBase58BTC encodes data with the Bitcoin alphabet, every leading zero byte becomes a leading "1"
+func EncodeBase58BTC(data []byte) string {
+	zeros := 0
+	for zeros < len(data) && data[zeros] == 0 {
+		zeros++
+	}
+
+	// digits holds the base 58 digits of data, least significant first
+	digits := make([]byte, 0, len(data)*138/100+1)
+	for _, b := range data[zeros:] {
+		carry := int(b)
+		for i := range digits {
+			carry += int(digits[i]) << 8
+			digits[i] = byte(carry % 58)
+			carry /= 58
+		}
+		for carry > 0 {
+			digits = append(digits, byte(carry%58))
+			carry /= 58
+		}
+	}
+
+	out := make([]byte, zeros+len(digits))
+	for i := 0; i < zeros; i++ {
+		out[i] = base58BTCAlphabet[0]
+	}
+	for i, digit := range digits {
+		out[len(out)-1-i] = base58BTCAlphabet[digit]
+	}
+
+	return string(out)
+}
+
+// DecodeBase58BTC reverses EncodeBase58BTC
+func DecodeBase58BTC(encoded string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(encoded) && encoded[zeros] == base58BTCAlphabet[0] {
+		zeros++
+	}
+
+	// bytes holds the decoded value, least significant first
+	bytes := make([]byte, 0, len(encoded)*733/1000+1)
+	for i := zeros; i < len(encoded); i++ {
+		carry := base58BTCIndexes[encoded[i]]
+		if carry < 0 {
+			return nil, ErrBase58
+		}
+		for j := range bytes {
+			carry += int(bytes[j]) * 58
+			bytes[j] = byte(carry)
+			carry >>= 8
+		}
+		for carry > 0 {
+			bytes = append(bytes, byte(carry))
+			carry >>= 8
+		}
+	}
+
+	out := make([]byte, zeros+len(bytes))
+	for i, b := range bytes {
+		out[len(out)-1-i] = b
+	}
+
+	return out, nil
+}
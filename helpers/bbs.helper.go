@@ -0,0 +1,579 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+)
+
+// the BLS12-381-SHA-256 ciphersuite of the BBS signature scheme of the IETF CFRG draft
+const (
+	BBSSecretKeySize = 32
+	BBSPublicKeySize = 96
+	BBSSignatureSize = bbsPointSize + bbsScalarSize
+	bbsAPIID         = "BBS_BLS12381G1_XMD:SHA-256_SSWU_RO_H2G_HM2S_"
+	bbsPointSize     = 48
+	bbsScalarSize    = 32
+	bbsExpandLen     = 48
+	// the Abar, Bbar and D points, the e^, r1^ and r3^ responses and the challenge of a proof
+	bbsProofMinSize = 3*bbsPointSize + 4*bbsScalarSize
+)
+
+var (
+	ErrBBSSecretKey = errors.New("bbs: invalid secret key")
+	ErrBBSPublicKey = errors.New("bbs: invalid public key")
+	ErrBBSSignature = errors.New("bbs: invalid signature")
+	ErrBBSProof     = errors.New("bbs: invalid proof")
+	ErrBBSIndexes   = errors.New("bbs: the disclosed indexes must be ascending indexes of the messages")
+)
+
+// BBSPublicKey is the compressed G2 point of a BBS public key
+type BBSPublicKey []byte
+
+// Equal tells whether other is the same BBS public key
+func (k BBSPublicKey) Equal(other crypto.PublicKey) bool {
+	otherKey, ok := other.(BBSPublicKey)
+	return ok && bytes.Equal(k, otherKey)
+}
+
+var bbsOrder = bls12381.NewG1().Q()
+
+// bbsP1 is the fixed G1 base point of the signatures of the ciphersuite
+var bbsP1 = func() *bls12381.PointG1 {
+	generators, err := bbsCreateGenerators(bbsAPIID+"BP_MESSAGE_GENERATOR_SEED", 1)
+	if err != nil {
+		panic(err)
+	}
+	return generators[0]
+}()
+
+// BBSPrivateKey is the secret scalar of a BLS12381G2 key with its public key
+type BBSPrivateKey struct {
+	D         *big.Int
+	PublicKey BBSPublicKey
+}
+
+// Public returns the public key of the private key
+func (k *BBSPrivateKey) Public() crypto.PublicKey {
+	return k.PublicKey
+}
+
+// NewBBSPrivateKey parses the big-endian secret scalar of a key
+func NewBBSPrivateKey(secretKey []byte) (*BBSPrivateKey, error) {
+	if len(secretKey) != BBSSecretKeySize {
+		return nil, ErrBBSSecretKey
+	}
+	d := new(big.Int).SetBytes(secretKey)
+	if d.Sign() == 0 || d.Cmp(bbsOrder) >= 0 {
+		d.SetInt64(0)
+		return nil, ErrBBSSecretKey
+	}
+
+	// W = SK * BP2
+	g2 := bls12381.NewG2()
+	w := g2.New()
+	g2.MulScalarBig(w, g2.One(), d)
+
+	return &BBSPrivateKey{D: d, PublicKey: g2.ToCompressed(w)}, nil
+}
+
+// ParseBBSPrivateKeyPEM parses a private key PEM of a BLS12381G2 key, the decoded scalar is zeroed
+func ParseBBSPrivateKeyPEM(data []byte) (*BBSPrivateKey, error) {
+	blockType, secretKey, err := DecodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(secretKey)
+	if blockType != consts.BBSPrivateKeyPEMType {
+		return nil, errors.New("pem: not a BLS12381G2 private key")
+	}
+
+	return NewBBSPrivateKey(secretKey)
+}
+
+// GenerateBBSKeyPair returns a BLS12381G2 key pair as a public key PEM of the compressed G2 point and a private key
+// PEM of the big-endian secret scalar, the private key PEM must be zeroed by the caller
+func GenerateBBSKeyPair() (string, []byte, error) {
+	d, err := bbsRandomScalar()
+	if err != nil {
+		return "", nil, err
+	}
+	secretKey := make([]byte, BBSSecretKeySize)
+	defer Zeroize(secretKey)
+	d.FillBytes(secretKey)
+	d.SetInt64(0)
+
+	privateKey, err := NewBBSPrivateKey(secretKey)
+	if err != nil {
+		return "", nil, err
+	}
+	defer ZeroizePrivateKey(privateKey)
+
+	return string(EncodePEM(consts.BBSPublicKeyPEMType, privateKey.PublicKey)), EncodePEM(consts.BBSPrivateKeyPEMType, secretKey), nil
+}
+
+// ParseBBSPublicKey checks that the public key is a compressed G2 point of the prime order subgroup other than
+// the identity
+func ParseBBSPublicKey(publicKey []byte) (BBSPublicKey, error) {
+	if _, err := bbsPublicKeyPoint(publicKey); err != nil {
+		return nil, err
+	}
+
+	return BBSPublicKey(append([]byte{}, publicKey...)), nil
+}
+
+// BBSSign signs the messages and the header, the signature is the compressed A point followed by the e scalar
+func BBSSign(privateKey *BBSPrivateKey, header []byte, messages [][]byte) ([]byte, error) {
+	if privateKey.D.Sign() == 0 {
+		return nil, ErrBBSSecretKey
+	}
+	publicKey := privateKey.PublicKey
+
+	scalars := bbsMessagesToScalars(messages)
+	generators, err := bbsCreateGenerators(bbsAPIID+"MESSAGE_GENERATOR_SEED", len(messages)+1)
+	if err != nil {
+		return nil, err
+	}
+	domain := bbsDomain(publicKey, generators, header)
+
+	input := bbsSerializeScalars(append(append([]*big.Int{privateKey.D}, scalars...), domain)...)
+	defer Zeroize(input)
+	e := bbsHashToScalar(input, bbsAPIID+"H2S_")
+
+	b, err := bbsCommitment(generators, domain, scalars, nil)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).Add(privateKey.D, e)
+	defer exponent.SetInt64(0)
+	exponent.Mod(exponent, bbsOrder)
+	if exponent.Sign() == 0 {
+		return nil, ErrBBSSecretKey
+	}
+	exponent.ModInverse(exponent, bbsOrder)
+
+	// A = B * (1 / (SK + e))
+	g1 := bls12381.NewG1()
+	a := g1.New()
+	g1.MulScalarBig(a, b, exponent)
+
+	return append(g1.ToCompressed(a), bbsSerializeScalars(e)...), nil
+}
+
+// BBSVerify checks a signature of the messages and the header
+func BBSVerify(publicKey BBSPublicKey, signature []byte, header []byte, messages [][]byte) error {
+	w, err := bbsPublicKeyPoint(publicKey)
+	if err != nil {
+		return err
+	}
+	a, e, err := bbsParseSignature(signature)
+	if err != nil {
+		return err
+	}
+
+	generators, err := bbsCreateGenerators(bbsAPIID+"MESSAGE_GENERATOR_SEED", len(messages)+1)
+	if err != nil {
+		return err
+	}
+	domain := bbsDomain(publicKey, generators, header)
+	b, err := bbsCommitment(generators, domain, bbsMessagesToScalars(messages), nil)
+	if err != nil {
+		return err
+	}
+
+	// e(A, W + BP2 * e) * e(B, -BP2) is the identity
+	g2 := bls12381.NewG2()
+	we := g2.New()
+	g2.MulScalarBig(we, g2.One(), e)
+	g2.Add(we, we, w)
+	engine := bls12381.NewEngine()
+	engine.AddPair(a, we)
+	engine.AddPairInv(b, g2.One())
+	if !engine.Check() {
+		return ErrBBSSignature
+	}
+
+	return nil
+}
+
+// BBSProofGen derives a zero-knowledge proof of knowledge of a signature of the messages that only discloses the
+// messages at the ascending disclosedIndexes, bound to the presentation header of the verifier
+func BBSProofGen(publicKey BBSPublicKey, signature []byte, header []byte, presentationHeader []byte, messages [][]byte, disclosedIndexes []int) ([]byte, error) {
+	if err := BBSVerify(publicKey, signature, header, messages); err != nil {
+		return nil, err
+	}
+	a, e, err := bbsParseSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	disclosed, err := bbsDisclosed(disclosedIndexes, len(messages))
+	if err != nil {
+		return nil, err
+	}
+
+	scalars := bbsMessagesToScalars(messages)
+	generators, err := bbsCreateGenerators(bbsAPIID+"MESSAGE_GENERATOR_SEED", len(messages)+1)
+	if err != nil {
+		return nil, err
+	}
+	domain := bbsDomain(publicKey, generators, header)
+	b, err := bbsCommitment(generators, domain, scalars, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	undisclosed := make([]int, 0, len(messages)-len(disclosedIndexes))
+	for i := range messages {
+		if !disclosed[i] {
+			undisclosed = append(undisclosed, i)
+		}
+	}
+	random := make([]*big.Int, 5+len(undisclosed))
+	for i := range random {
+		if random[i], err = bbsRandomScalar(); err != nil {
+			return nil, err
+		}
+	}
+	r1, r2, eTilde, r1Tilde, r3Tilde, mTilde := random[0], random[1], random[2], random[3], random[4], random[5:]
+
+	// D = B * r2, Abar = A * (r1 * r2), Bbar = D * r1 - Abar * e
+	g1 := bls12381.NewG1()
+	d := g1.New()
+	g1.MulScalarBig(d, b, r2)
+	aBar := g1.New()
+	g1.MulScalarBig(aBar, a, bbsMul(r1, r2))
+	bBar, err := bbsMultiExp([]*bls12381.PointG1{d, aBar}, []*big.Int{r1, bbsNeg(e)})
+	if err != nil {
+		return nil, err
+	}
+	// T1 = Abar * e~ + D * r1~, T2 = D * r3~ + H_j1 * m~_j1 + ... + H_jU * m~_jU
+	t1, err := bbsMultiExp([]*bls12381.PointG1{aBar, d}, []*big.Int{eTilde, r1Tilde})
+	if err != nil {
+		return nil, err
+	}
+	t2Points := []*bls12381.PointG1{d}
+	t2Scalars := []*big.Int{r3Tilde}
+	for i, j := range undisclosed {
+		t2Points = append(t2Points, generators[j+1])
+		t2Scalars = append(t2Scalars, mTilde[i])
+	}
+	t2, err := bbsMultiExp(t2Points, t2Scalars)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := bbsChallenge(aBar, bBar, d, t1, t2, domain, disclosedIndexes, scalars, presentationHeader)
+
+	r3 := new(big.Int).ModInverse(r2, bbsOrder)
+	responses := []*big.Int{
+		bbsAdd(eTilde, bbsMul(e, challenge)),
+		bbsAdd(r1Tilde, bbsNeg(bbsMul(r1, challenge))),
+		bbsAdd(r3Tilde, bbsNeg(bbsMul(r3, challenge))),
+	}
+	for i, j := range undisclosed {
+		responses = append(responses, bbsAdd(mTilde[i], bbsMul(scalars[j], challenge)))
+	}
+	responses = append(responses, challenge)
+
+	proof := append(append(append(g1.ToCompressed(aBar), g1.ToCompressed(bBar)...), g1.ToCompressed(d)...), bbsSerializeScalars(responses...)...)
+	return proof, nil
+}
+
+// BBSProofVerify checks a proof of the disclosed messages at the ascending disclosedIndexes, the number of
+// undisclosed messages is given by the size of the proof
+func BBSProofVerify(publicKey BBSPublicKey, proof []byte, header []byte, presentationHeader []byte, disclosedMessages [][]byte, disclosedIndexes []int) error {
+	w, err := bbsPublicKeyPoint(publicKey)
+	if err != nil {
+		return err
+	}
+	if len(proof) < bbsProofMinSize || (len(proof)-bbsProofMinSize)%bbsScalarSize != 0 {
+		return ErrBBSProof
+	}
+	if len(disclosedMessages) != len(disclosedIndexes) {
+		return ErrBBSIndexes
+	}
+
+	g1 := bls12381.NewG1()
+	points := make([]*bls12381.PointG1, 3)
+	for i := range points {
+		if points[i], err = g1.FromCompressed(proof[i*bbsPointSize : (i+1)*bbsPointSize]); err != nil {
+			return ErrBBSProof
+		}
+	}
+	aBar, bBar, d := points[0], points[1], points[2]
+	scalars := make([]*big.Int, (len(proof)-3*bbsPointSize)/bbsScalarSize)
+	for i := range scalars {
+		offset := 3*bbsPointSize + i*bbsScalarSize
+		scalars[i] = new(big.Int).SetBytes(proof[offset : offset+bbsScalarSize])
+		if scalars[i].Cmp(bbsOrder) >= 0 {
+			return ErrBBSProof
+		}
+	}
+	eHat, r1Hat, r3Hat, mHat, challenge := scalars[0], scalars[1], scalars[2], scalars[3:len(scalars)-1], scalars[len(scalars)-1]
+
+	count := len(disclosedIndexes) + len(mHat)
+	disclosed, err := bbsDisclosed(disclosedIndexes, count)
+	if err != nil {
+		return err
+	}
+	generators, err := bbsCreateGenerators(bbsAPIID+"MESSAGE_GENERATOR_SEED", count+1)
+	if err != nil {
+		return err
+	}
+	domain := bbsDomain(publicKey, generators, header)
+
+	// T1 = Bbar * c + Abar * e^ + D * r1^
+	t1, err := bbsMultiExp([]*bls12381.PointG1{bBar, aBar, d}, []*big.Int{challenge, eHat, r1Hat})
+	if err != nil {
+		return err
+	}
+	// T2 = Bv * c + D * r3^ + H_j1 * m^_j1 + ... + H_jU * m^_jU, with Bv = P1 + Q_1 * domain + the disclosed messages
+	disclosedScalars := bbsMessagesToScalars(disclosedMessages)
+	allScalars := make([]*big.Int, count)
+	for i, index := range disclosedIndexes {
+		allScalars[index] = disclosedScalars[i]
+	}
+	bv, err := bbsCommitment(generators, domain, allScalars, disclosed)
+	if err != nil {
+		return err
+	}
+	t2Points := []*bls12381.PointG1{bv, d}
+	t2Scalars := []*big.Int{challenge, r3Hat}
+	u := 0
+	for i := 0; i < count; i++ {
+		if !disclosed[i] {
+			t2Points = append(t2Points, generators[i+1])
+			t2Scalars = append(t2Scalars, mHat[u])
+			u++
+		}
+	}
+	t2, err := bbsMultiExp(t2Points, t2Scalars)
+	if err != nil {
+		return err
+	}
+
+	if bbsChallenge(aBar, bBar, d, t1, t2, domain, disclosedIndexes, allScalars, presentationHeader).Cmp(challenge) != 0 {
+		return ErrBBSProof
+	}
+
+	// e(Abar, W) * e(Bbar, -BP2) is the identity
+	engine := bls12381.NewEngine()
+	engine.AddPair(aBar, w)
+	engine.AddPairInv(bBar, bls12381.NewG2().One())
+	if !engine.Check() {
+		return ErrBBSProof
+	}
+
+	return nil
+}
+
+func bbsPublicKeyPoint(publicKey []byte) (*bls12381.PointG2, error) {
+	if len(publicKey) != BBSPublicKeySize {
+		return nil, ErrBBSPublicKey
+	}
+	g2 := bls12381.NewG2()
+	w, err := g2.FromCompressed(publicKey)
+	if err != nil || g2.IsZero(w) {
+		return nil, ErrBBSPublicKey
+	}
+
+	return w, nil
+}
+
+func bbsParseSignature(signature []byte) (*bls12381.PointG1, *big.Int, error) {
+	if len(signature) != BBSSignatureSize {
+		return nil, nil, ErrBBSSignature
+	}
+	g1 := bls12381.NewG1()
+	a, err := g1.FromCompressed(signature[:bbsPointSize])
+	if err != nil || g1.IsZero(a) {
+		return nil, nil, ErrBBSSignature
+	}
+	e := new(big.Int).SetBytes(signature[bbsPointSize:])
+	if e.Sign() == 0 || e.Cmp(bbsOrder) >= 0 {
+		return nil, nil, ErrBBSSignature
+	}
+
+	return a, e, nil
+}
+
+// bbsDisclosed checks that the indexes are ascending and lower than count
+func bbsDisclosed(indexes []int, count int) ([]bool, error) {
+	disclosed := make([]bool, count)
+	for i, index := range indexes {
+		if index < 0 || index >= count || (i > 0 && index <= indexes[i-1]) {
+			return nil, ErrBBSIndexes
+		}
+		disclosed[index] = true
+	}
+
+	return disclosed, nil
+}
+
+// bbsCreateGenerators hashes count points to G1 from the seed
+func bbsCreateGenerators(seed string, count int) ([]*bls12381.PointG1, error) {
+	seedDST := []byte(bbsAPIID + "SIG_GENERATOR_SEED_")
+	generatorDST := []byte(bbsAPIID + "SIG_GENERATOR_DST_")
+	g1 := bls12381.NewG1()
+
+	v := expandMessageXMD([]byte(seed), seedDST, bbsExpandLen)
+	generators := make([]*bls12381.PointG1, count)
+	for i := range generators {
+		v = expandMessageXMD(append(v, bbsI2OSP(uint64(i+1))...), seedDST, bbsExpandLen)
+		generator, err := g1.HashToCurve(v, generatorDST)
+		if err != nil {
+			return nil, err
+		}
+		generators[i] = generator
+	}
+
+	return generators, nil
+}
+
+// bbsDomain binds the signature to the public key, the generators and the header
+func bbsDomain(publicKey []byte, generators []*bls12381.PointG1, header []byte) *big.Int {
+	g1 := bls12381.NewG1()
+	input := append([]byte{}, publicKey...)
+	input = append(input, bbsI2OSP(uint64(len(generators)-1))...)
+	for _, generator := range generators {
+		input = append(input, g1.ToCompressed(generator)...)
+	}
+	input = append(input, bbsAPIID...)
+	input = append(input, bbsI2OSP(uint64(len(header)))...)
+	input = append(input, header...)
+
+	return bbsHashToScalar(input, bbsAPIID+"H2S_")
+}
+
+// bbsCommitment is P1 + Q_1 * domain + H_1 * msg_1 + ... + H_L * msg_L, over the messages of include when not nil
+func bbsCommitment(generators []*bls12381.PointG1, domain *big.Int, scalars []*big.Int, include []bool) (*bls12381.PointG1, error) {
+	points := []*bls12381.PointG1{bbsP1, generators[0]}
+	exponents := []*big.Int{big.NewInt(1), domain}
+	for i, scalar := range scalars {
+		if include == nil || include[i] {
+			points = append(points, generators[i+1])
+			exponents = append(exponents, scalar)
+		}
+	}
+
+	return bbsMultiExp(points, exponents)
+}
+
+func bbsChallenge(aBar, bBar, d, t1, t2 *bls12381.PointG1, domain *big.Int, disclosedIndexes []int, scalars []*big.Int, presentationHeader []byte) *big.Int {
+	g1 := bls12381.NewG1()
+	input := bbsI2OSP(uint64(len(disclosedIndexes)))
+	for _, index := range disclosedIndexes {
+		input = append(input, bbsI2OSP(uint64(index))...)
+		input = append(input, bbsSerializeScalars(scalars[index])...)
+	}
+	for _, point := range []*bls12381.PointG1{aBar, bBar, d, t1, t2} {
+		input = append(input, g1.ToCompressed(point)...)
+	}
+	input = append(input, bbsSerializeScalars(domain)...)
+	input = append(input, bbsI2OSP(uint64(len(presentationHeader)))...)
+	input = append(input, presentationHeader...)
+
+	return bbsHashToScalar(input, bbsAPIID+"H2S_")
+}
+
+func bbsMessagesToScalars(messages [][]byte) []*big.Int {
+	scalars := make([]*big.Int, len(messages))
+	for i, message := range messages {
+		scalars[i] = bbsHashToScalar(message, bbsAPIID+"MAP_MSG_TO_SCALAR_AS_HASH_")
+	}
+
+	return scalars
+}
+
+func bbsHashToScalar(message []byte, dst string) *big.Int {
+	scalar := new(big.Int).SetBytes(expandMessageXMD(message, []byte(dst), bbsExpandLen))
+	return scalar.Mod(scalar, bbsOrder)
+}
+
+// bbsRandomScalar reduces 48 random bytes so the scalar is uniform enough
+func bbsRandomScalar() (*big.Int, error) {
+	random := make([]byte, bbsExpandLen)
+	defer Zeroize(random)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	scalar := new(big.Int).SetBytes(random)
+	scalar.Mod(scalar, bbsOrder)
+	if scalar.Sign() == 0 {
+		return bbsRandomScalar()
+	}
+
+	return scalar, nil
+}
+
+func bbsMultiExp(points []*bls12381.PointG1, scalars []*big.Int) (*bls12381.PointG1, error) {
+	g1 := bls12381.NewG1()
+	result := g1.New()
+	if _, err := g1.MultiExpBig(result, points, scalars); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func bbsSerializeScalars(scalars ...*big.Int) []byte {
+	out := make([]byte, len(scalars)*bbsScalarSize)
+	for i, scalar := range scalars {
+		scalar.FillBytes(out[i*bbsScalarSize : (i+1)*bbsScalarSize])
+	}
+
+	return out
+}
+
+func bbsI2OSP(value uint64) []byte {
+	out := make([]byte, 8)
+	binary.BigEndian.PutUint64(out, value)
+	return out
+}
+
+func bbsAdd(a, b *big.Int) *big.Int {
+	return new(big.Int).Mod(new(big.Int).Add(a, b), bbsOrder)
+}
+
+func bbsMul(a, b *big.Int) *big.Int {
+	return new(big.Int).Mod(new(big.Int).Mul(a, b), bbsOrder)
+}
+
+func bbsNeg(a *big.Int) *big.Int {
+	return new(big.Int).Mod(new(big.Int).Neg(a), bbsOrder)
+}
+
+// expandMessageXMD is expand_message_xmd of RFC 9380 with SHA-256
+func expandMessageXMD(message []byte, dst []byte, length int) []byte {
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+	hash := sha256.New()
+	hash.Write(make([]byte, hash.BlockSize()))
+	hash.Write(message)
+	hash.Write([]byte{byte(length >> 8), byte(length), 0})
+	hash.Write(dstPrime)
+	b0 := hash.Sum(nil)
+
+	out := make([]byte, 0, length+sha256.Size)
+	bi := make([]byte, sha256.Size)
+	for i := 1; len(out) < length; i++ {
+		xored := make([]byte, sha256.Size)
+		for j := range xored {
+			xored[j] = b0[j] ^ bi[j]
+		}
+		hash.Reset()
+		hash.Write(xored)
+		hash.Write([]byte{byte(i)})
+		hash.Write(dstPrime)
+		bi = hash.Sum(nil)
+		out = append(out, bi...)
+	}
+
+	return out[:length]
+}
@@ -0,0 +1,131 @@
+package helpers
+
+import (
+	"encoding/hex"
+	"testing"
+
+	bls12381 "github.com/kilic/bls12-381"
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+)
+
+type BBSHelperTestSuite struct {
+	suite.Suite
+	privateKey *BBSPrivateKey
+	publicKey  BBSPublicKey
+	header     []byte
+	messages   [][]byte
+}
+
+func TestBBSHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(BBSHelperTestSuite))
+}
+
+func (s *BBSHelperTestSuite) SetupTest() {
+	publicKeyPEM, privateKeyPEM, err := GenerateBBSKeyPair()
+	s.Require().NoError(err)
+	privateKey, err := ParseBBSPrivateKeyPEM(privateKeyPEM)
+	s.Require().NoError(err)
+	blockType, publicKey, err := DecodePEM([]byte(publicKeyPEM))
+	s.Require().NoError(err)
+	s.Equal(consts.BBSPublicKeyPEMType, blockType)
+	s.Equal(BBSPublicKey(publicKey), privateKey.PublicKey)
+
+	s.privateKey = privateKey
+	s.publicKey = publicKey
+	s.header = []byte("header")
+	s.messages = [][]byte{[]byte("given_name"), []byte("family_name"), []byte("birthdate"), {}}
+}
+
+func (s *BBSHelperTestSuite) TestExpandMessageXMD() {
+	// the expand_message_xmd SHA-256 vectors of RFC 9380 appendix K.1
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	s.Equal("68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235",
+		hex.EncodeToString(expandMessageXMD([]byte(""), dst, 0x20)))
+	s.Equal("d8ccab23b5985ccea865c6c97b6e5b8350e794e603b4b97902f53a8a0d60561"+
+		"5", hex.EncodeToString(expandMessageXMD([]byte("abc"), dst, 0x20)))
+}
+
+func (s *BBSHelperTestSuite) TestP1() {
+	// the base point of the BLS12-381-SHA-256 ciphersuite
+	s.Equal("a8ce256102840821a3e94ea9025e4662b205762f9776b3a766c872b948f1fd225e7c59698588e70d11406d161b4e28c9",
+		hex.EncodeToString(bls12381.NewG1().ToCompressed(bbsP1)))
+}
+
+func (s *BBSHelperTestSuite) TestSignVector() {
+	// the key pair and valid single message signature vectors of the BLS12-381-SHA-256 ciphersuite of the draft
+	secretKey, _ := hex.DecodeString("60e55110f76883a13d030b2f6bd11883422d5abde717569fc0731f51237169fc")
+	privateKey, err := NewBBSPrivateKey(secretKey)
+	s.Require().NoError(err)
+	s.Equal("a820f230f6ae38503b86c70dc50b61c58a77e45c39ab25c0652bbaa8fa136f2851bd4781c9dcde39fc9d1d52c9e60268061e7d7632171d91aa8d460acee0e96f1e7c4cfb12d3ff9ab5d5dc91c277db75c845d649ef3c4f63aebc364cd55ded0c",
+		hex.EncodeToString(privateKey.PublicKey))
+
+	header, _ := hex.DecodeString("11223344556677889900aabbccddeeff")
+	message, _ := hex.DecodeString("9872ad089e452c7b6e283dfac2a80d58e8d0ff71cc4d5e310a1debdda4a45f02")
+	signature, err := BBSSign(privateKey, header, [][]byte{message})
+	s.Require().NoError(err)
+	s.Equal("84773160b824e194073a57493dac1a20b667af70cd2352d8af241c77658da5253aa8458317cca0eae615690d55b1f27164657dcafee1d5c1973947aa70e2cfbb4c892340be5969920d0916067b4565a0",
+		hex.EncodeToString(signature))
+}
+
+func (s *BBSHelperTestSuite) TestSignVerify() {
+	signature, err := BBSSign(s.privateKey, s.header, s.messages)
+	s.Require().NoError(err)
+	s.Len(signature, BBSSignatureSize)
+	s.NoError(BBSVerify(s.publicKey, signature, s.header, s.messages))
+
+	// Expect error on a changed message, header or signature
+	s.Equal(ErrBBSSignature, BBSVerify(s.publicKey, signature, s.header, [][]byte{[]byte("given_name")}))
+	s.Equal(ErrBBSSignature, BBSVerify(s.publicKey, signature, []byte("other"), s.messages))
+	signature[len(signature)-1] ^= 1
+	s.Equal(ErrBBSSignature, BBSVerify(s.publicKey, signature, s.header, s.messages))
+}
+
+func (s *BBSHelperTestSuite) TestProof() {
+	signature, err := BBSSign(s.privateKey, s.header, s.messages)
+	s.Require().NoError(err)
+	presentationHeader := []byte("nonce")
+
+	proof, err := BBSProofGen(s.publicKey, signature, s.header, presentationHeader, s.messages, []int{0, 2})
+	s.Require().NoError(err)
+	s.Len(proof, bbsProofMinSize+2*bbsScalarSize)
+	disclosed := [][]byte{s.messages[0], s.messages[2]}
+	s.NoError(BBSProofVerify(s.publicKey, proof, s.header, presentationHeader, disclosed, []int{0, 2}))
+
+	// proofs of the same signature are unlinkable
+	other, err := BBSProofGen(s.publicKey, signature, s.header, presentationHeader, s.messages, []int{0, 2})
+	s.Require().NoError(err)
+	s.NotEqual(proof, other)
+
+	// a proof that discloses every message or none
+	proof, err = BBSProofGen(s.publicKey, signature, s.header, nil, s.messages, []int{0, 1, 2, 3})
+	s.Require().NoError(err)
+	s.NoError(BBSProofVerify(s.publicKey, proof, s.header, nil, s.messages, []int{0, 1, 2, 3}))
+	proof, err = BBSProofGen(s.publicKey, signature, s.header, nil, s.messages, nil)
+	s.Require().NoError(err)
+	s.NoError(BBSProofVerify(s.publicKey, proof, s.header, nil, nil, nil))
+
+	// Expect error on a changed disclosed message, index or presentation header
+	proof, err = BBSProofGen(s.publicKey, signature, s.header, presentationHeader, s.messages, []int{0, 2})
+	s.Require().NoError(err)
+	s.Equal(ErrBBSProof, BBSProofVerify(s.publicKey, proof, s.header, presentationHeader, [][]byte{s.messages[0], s.messages[1]}, []int{0, 2}))
+	s.Equal(ErrBBSProof, BBSProofVerify(s.publicKey, proof, s.header, presentationHeader, disclosed, []int{0, 1}))
+	s.Equal(ErrBBSProof, BBSProofVerify(s.publicKey, proof, s.header, []byte("other"), disclosed, []int{0, 2}))
+
+	// Expect error on indexes that are not ascending
+	_, err = BBSProofGen(s.publicKey, signature, s.header, nil, s.messages, []int{2, 0})
+	s.Equal(ErrBBSIndexes, err)
+}
+
+func (s *BBSHelperTestSuite) TestParseBBSPublicKey() {
+	publicKey, err := ParseBBSPublicKey(s.publicKey)
+	s.NoError(err)
+	s.Equal(s.publicKey, publicKey)
+
+	_, err = ParseBBSPublicKey(s.publicKey[1:])
+	s.Equal(ErrBBSPublicKey, err)
+	identity := make([]byte, BBSPublicKeySize)
+	identity[0] = 0xc0
+	_, err = ParseBBSPublicKey(identity)
+	s.Equal(ErrBBSPublicKey, err)
+}
@@ -0,0 +1,498 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+)
+
+var (
+	ErrBBS2023ProofValue = errors.New("bbs-2023: malformed proof value")
+	ErrBBS2023Statement  = errors.New("bbs-2023: a statement is not an N-Quad or has an unknown blank node label")
+	ErrBBS2023Indexes    = errors.New("bbs-2023: the indexes must be ascending indexes of the statements")
+	ErrBBS2023BaseProof  = errors.New("bbs-2023: the base proof was not issued for the statements and mandatory indexes")
+)
+
+// BBS2023BaseProof is the proof value the issuer adds to a document, it lets the holder derive proofs
+// that disclose any subset of the non-mandatory statements
+type BBS2023BaseProof struct {
+	Signature         []byte
+	Header            []byte
+	PublicKey         BBSPublicKey
+	HMACKey           []byte
+	MandatoryPointers []string
+}
+
+// ProofValue serializes the proof as a multibase base64url of the proof header and its CBOR
+func (p *BBS2023BaseProof) ProofValue() string {
+	data := []byte(consts.BBS2023BaseProofHeader)
+	data = cborAppendHead(data, cborMajorArray, 5)
+	data = cborAppendBytes(data, p.Signature)
+	data = cborAppendBytes(data, p.Header)
+	data = cborAppendBytes(data, p.PublicKey)
+	data = cborAppendBytes(data, p.HMACKey)
+	data = cborAppendHead(data, cborMajorArray, uint64(len(p.MandatoryPointers)))
+	for _, pointer := range p.MandatoryPointers {
+		data = cborAppendText(data, pointer)
+	}
+
+	return consts.MultibaseBase64URL + base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseBBS2023BaseProof reverses BBS2023BaseProof.ProofValue
+func ParseBBS2023BaseProof(proofValue string) (*BBS2023BaseProof, error) {
+	r, err := bbs2023ProofReader(proofValue, consts.BBS2023BaseProofHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &BBS2023BaseProof{}
+	if proof.Signature, err = r.bytes(); err != nil {
+		return nil, ErrBBS2023ProofValue
+	}
+	if proof.Header, err = r.bytes(); err != nil {
+		return nil, ErrBBS2023ProofValue
+	}
+	publicKey, err := r.bytes()
+	if err != nil {
+		return nil, ErrBBS2023ProofValue
+	}
+	if proof.PublicKey, err = ParseBBSPublicKey(publicKey); err != nil {
+		return nil, ErrBBS2023ProofValue
+	}
+	if proof.HMACKey, err = r.bytes(); err != nil {
+		return nil, ErrBBS2023ProofValue
+	}
+	if proof.MandatoryPointers, err = r.texts(); err != nil || len(r.data) > 0 {
+		return nil, ErrBBS2023ProofValue
+	}
+
+	return proof, nil
+}
+
+// BBS2023DerivedProof is the proof value of a document that discloses a subset of the statements of the issued document,
+// LabelMap maps the canonical blank node labels of the disclosed document to the shuffled labels of the issued one
+type BBS2023DerivedProof struct {
+	Proof              []byte
+	LabelMap           map[int]int
+	MandatoryIndexes   []int
+	SelectiveIndexes   []int
+	PresentationHeader []byte
+}
+
+// ProofValue serializes the proof as a multibase base64url of the proof header and its CBOR
+func (p *BBS2023DerivedProof) ProofValue() string {
+	data := []byte(consts.BBS2023DerivedProofHeader)
+	data = cborAppendHead(data, cborMajorArray, 5)
+	data = cborAppendBytes(data, p.Proof)
+	data = cborAppendUintMap(data, p.LabelMap)
+	data = cborAppendUints(data, p.MandatoryIndexes)
+	data = cborAppendUints(data, p.SelectiveIndexes)
+	data = cborAppendBytes(data, p.PresentationHeader)
+
+	return consts.MultibaseBase64URL + base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseBBS2023DerivedProof reverses BBS2023DerivedProof.ProofValue
+func ParseBBS2023DerivedProof(proofValue string) (*BBS2023DerivedProof, error) {
+	r, err := bbs2023ProofReader(proofValue, consts.BBS2023DerivedProofHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &BBS2023DerivedProof{}
+	if proof.Proof, err = r.bytes(); err != nil {
+		return nil, ErrBBS2023ProofValue
+	}
+	if proof.LabelMap, err = r.uintMap(); err != nil {
+		return nil, ErrBBS2023ProofValue
+	}
+	if proof.MandatoryIndexes, err = r.uints(); err != nil {
+		return nil, ErrBBS2023ProofValue
+	}
+	if proof.SelectiveIndexes, err = r.uints(); err != nil {
+		return nil, ErrBBS2023ProofValue
+	}
+	if proof.PresentationHeader, err = r.bytes(); err != nil || len(r.data) > 0 {
+		return nil, ErrBBS2023ProofValue
+	}
+
+	return proof, nil
+}
+
+func bbs2023ProofReader(proofValue string, header string) (*cborReader, error) {
+	if !strings.HasPrefix(proofValue, consts.MultibaseBase64URL) {
+		return nil, ErrBBS2023ProofValue
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(proofValue, consts.MultibaseBase64URL))
+	if err != nil || !strings.HasPrefix(string(data), header) {
+		return nil, ErrBBS2023ProofValue
+	}
+
+	r := &cborReader{data: data[len(header):]}
+	if n, err := r.head(cborMajorArray); err != nil || n != 5 {
+		return nil, ErrBBS2023ProofValue
+	}
+
+	return r, nil
+}
+
+// BBS2023SignatureInput shuffles the blank node labels of the canonical N-Quads of a document with the HMAC key
+// and returns the BBS header and messages of its base proof, the statements at mandatoryIndexes are bound by the header
+func BBS2023SignatureInput(hmacKey []byte, proofConfig string, statements []string, mandatoryIndexes []int) ([]byte, [][]byte, error) {
+	labelMap, err := BBS2023LabelMap(hmacKey, statements)
+	if err != nil {
+		return nil, nil, err
+	}
+	groups, err := GroupBBS2023Statements(statements, labelMap, mandatoryIndexes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return groups.Header(proofConfig), groups.Messages(groups.NonMandatory), nil
+}
+
+// DeriveBBS2023Proof derives a proof of the base proof that discloses the mandatory statements and the non-mandatory
+// statements at selectiveIndexes, statements are the canonical N-Quads of the issued document and the indexes refer to them.
+// It returns the derived proof value with the disclosed statements in the order they are hashed
+func DeriveBBS2023Proof(baseProofValue string, statements []string, mandatoryIndexes []int, selectiveIndexes []int, presentationHeader []byte) (string, []string, error) {
+	baseProof, err := ParseBBS2023BaseProof(baseProofValue)
+	if err != nil {
+		return "", nil, err
+	}
+	labelMap, err := BBS2023LabelMap(baseProof.HMACKey, statements)
+	if err != nil {
+		return "", nil, err
+	}
+	groups, err := GroupBBS2023Statements(statements, labelMap, mandatoryIndexes)
+	if err != nil {
+		return "", nil, err
+	}
+	if !ascendingIndexes(selectiveIndexes, len(statements)) {
+		return "", nil, ErrBBS2023Indexes
+	}
+
+	// the selected statements as indexes of the messages and the disclosed statements in their sorted order
+	disclosed := make(map[int]bool, len(groups.Mandatory)+len(selectiveIndexes))
+	for _, position := range groups.Mandatory {
+		disclosed[position] = true
+	}
+	selected := make(map[int]bool, len(selectiveIndexes))
+	for _, i := range selectiveIndexes {
+		position := groups.Positions[i]
+		if disclosed[position] {
+			return "", nil, ErrBBS2023Indexes
+		}
+		disclosed[position] = true
+		selected[position] = true
+	}
+	messageIndexes := make([]int, 0, len(selectiveIndexes))
+	for i, position := range groups.NonMandatory {
+		if selected[position] {
+			messageIndexes = append(messageIndexes, i)
+		}
+	}
+	revealed := make([]string, 0, len(disclosed))
+	revealedMandatory := make([]int, 0, len(groups.Mandatory))
+	for position, statement := range groups.Statements {
+		if !disclosed[position] {
+			continue
+		}
+		if !selected[position] {
+			revealedMandatory = append(revealedMandatory, len(revealed))
+		}
+		revealed = append(revealed, statement)
+	}
+
+	// the signature only verifies when the statements and the mandatory ones are those the base proof was issued for
+	messages := groups.Messages(groups.NonMandatory)
+	if err := BBSVerify(baseProof.PublicKey, baseProof.Signature, baseProof.Header, messages); err != nil {
+		return "", nil, ErrBBS2023BaseProof
+	}
+	proof, err := BBSProofGen(baseProof.PublicKey, baseProof.Signature, baseProof.Header, presentationHeader, messages, messageIndexes)
+	if err != nil {
+		return "", nil, err
+	}
+	revealed, compressed, err := RevealBBS2023Statements(revealed)
+	if err != nil {
+		return "", nil, err
+	}
+
+	derivedProof := &BBS2023DerivedProof{
+		Proof:              proof,
+		LabelMap:           compressed,
+		MandatoryIndexes:   revealedMandatory,
+		SelectiveIndexes:   messageIndexes,
+		PresentationHeader: presentationHeader,
+	}
+
+	return derivedProof.ProofValue(), revealed, nil
+}
+
+// VerifyBBS2023Proof verifies a derived proof of the disclosed statements with the proof configuration it was issued with
+func VerifyBBS2023Proof(publicKey BBSPublicKey, proofValue string, proofConfig string, statements []string) error {
+	derivedProof, err := ParseBBS2023DerivedProof(proofValue)
+	if err != nil {
+		return err
+	}
+	groups, err := GroupBBS2023Statements(statements, ExpandBBS2023LabelMap(derivedProof.LabelMap), derivedProof.MandatoryIndexes)
+	if err != nil {
+		return err
+	}
+	if len(groups.NonMandatory) != len(derivedProof.SelectiveIndexes) {
+		return ErrBBS2023Indexes
+	}
+
+	return BBSProofVerify(publicKey, derivedProof.Proof, groups.Header(proofConfig), derivedProof.PresentationHeader,
+		groups.Messages(groups.NonMandatory), derivedProof.SelectiveIndexes)
+}
+
+// BBS2023Statements are the statements of a document with their blank nodes relabelled, sorted and split into
+// the mandatory statements, which are always disclosed, and the non-mandatory statements, which are signed as messages
+type BBS2023Statements struct {
+	Statements []string
+	// Positions maps the index of a statement as given to its index in Statements
+	Positions    []int
+	Mandatory    []int
+	NonMandatory []int
+}
+
+// GroupBBS2023Statements relabels the blank nodes of the N-Quad statements with labelMap, sorts them and splits them
+// into the statements at mandatoryIndexes and the others
+func GroupBBS2023Statements(statements []string, labelMap map[string]string, mandatoryIndexes []int) (*BBS2023Statements, error) {
+	if !ascendingIndexes(mandatoryIndexes, len(statements)) {
+		return nil, ErrBBS2023Indexes
+	}
+
+	relabelled := make([]string, len(statements))
+	order := make([]int, len(statements))
+	for i, statement := range statements {
+		label, err := relabelNQuad(statement, labelMap)
+		if err != nil {
+			return nil, err
+		}
+		relabelled[i] = label
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return relabelled[order[i]] < relabelled[order[j]]
+	})
+
+	mandatory := make(map[int]bool, len(mandatoryIndexes))
+	for _, i := range mandatoryIndexes {
+		mandatory[i] = true
+	}
+	groups := &BBS2023Statements{
+		Statements: make([]string, len(statements)),
+		Positions:  make([]int, len(statements)),
+	}
+	for position, i := range order {
+		groups.Statements[position] = relabelled[i]
+		groups.Positions[i] = position
+		if mandatory[i] {
+			groups.Mandatory = append(groups.Mandatory, position)
+		} else {
+			groups.NonMandatory = append(groups.NonMandatory, position)
+		}
+	}
+
+	return groups, nil
+}
+
+// Messages returns the statements at indexes as BBS messages
+func (s *BBS2023Statements) Messages(indexes []int) [][]byte {
+	messages := make([][]byte, len(indexes))
+	for i, index := range indexes {
+		messages[i] = []byte(s.Statements[index])
+	}
+
+	return messages
+}
+
+// Header is the BBS header that binds a signature to the proof configuration and the mandatory statements
+func (s *BBS2023Statements) Header(proofConfig string) []byte {
+	proofHash := sha256.Sum256([]byte(NormalizeNQuad(proofConfig)))
+	mandatoryHash := sha256.New()
+	for _, i := range s.Mandatory {
+		mandatoryHash.Write([]byte(s.Statements[i]))
+	}
+
+	return mandatoryHash.Sum(proofHash[:])
+}
+
+// BBS2023LabelMap maps the canonical blank node labels of the statements to labels shuffled by the HMAC key:
+// the HMAC digests of the labels are sorted and every label becomes "b" followed by the rank of its digest
+func BBS2023LabelMap(hmacKey []byte, statements []string) (map[string]string, error) {
+	labels, err := nquadBlankNodeLabels(statements)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := make([]string, len(labels))
+	digestLabels := make(map[string]string, len(labels))
+	for i, label := range labels {
+		mac := hmac.New(sha256.New, hmacKey)
+		mac.Write([]byte(label))
+		digests[i] = base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+		digestLabels[digests[i]] = label
+	}
+	sort.Strings(digests)
+
+	labelMap := make(map[string]string, len(labels))
+	for rank, digest := range digests {
+		labelMap[digestLabels[digest]] = "b" + strconv.Itoa(rank)
+	}
+
+	return labelMap, nil
+}
+
+// RevealBBS2023Statements gives the blank nodes of the disclosed statements canonical labels in the order they first
+// appear and returns the statements with those labels with the compressed map from the canonical labels to the shuffled ones
+func RevealBBS2023Statements(statements []string) ([]string, map[int]int, error) {
+	labels, err := nquadBlankNodeLabels(statements)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	canonicalLabels := make(map[string]string, len(labels))
+	compressed := make(map[int]int, len(labels))
+	for i, label := range labels {
+		shuffled, err := strconv.Atoi(strings.TrimPrefix(label, "b"))
+		if err != nil || !strings.HasPrefix(label, "b") {
+			return nil, nil, ErrBBS2023Statement
+		}
+		canonicalLabels[label] = "c14n" + strconv.Itoa(i)
+		compressed[i] = shuffled
+	}
+
+	revealed := make([]string, len(statements))
+	for i, statement := range statements {
+		if revealed[i], err = relabelNQuad(statement, canonicalLabels); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return revealed, compressed, nil
+}
+
+// ExpandBBS2023LabelMap reverses the compression of the label map of a derived proof
+func ExpandBBS2023LabelMap(compressed map[int]int) map[string]string {
+	labelMap := make(map[string]string, len(compressed))
+	for canonical, shuffled := range compressed {
+		labelMap["c14n"+strconv.Itoa(canonical)] = "b" + strconv.Itoa(shuffled)
+	}
+
+	return labelMap
+}
+
+// NormalizeNQuad terminates a statement with the line feed canonical N-Quads end with
+func NormalizeNQuad(statement string) string {
+	if strings.HasSuffix(statement, "\n") {
+		return statement
+	}
+
+	return statement + "\n"
+}
+
+// nquadBlankNodeLabels returns the distinct blank node labels of the statements in the order they first appear
+func nquadBlankNodeLabels(statements []string) ([]string, error) {
+	labels := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, statement := range statements {
+		_, err := mapNQuadBlankNodes(statement, func(label string) (string, bool) {
+			if !seen[label] {
+				seen[label] = true
+				labels = append(labels, label)
+			}
+			return label, true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return labels, nil
+}
+
+// relabelNQuad replaces the blank node labels of a statement, every label must be in labelMap
+func relabelNQuad(statement string, labelMap map[string]string) (string, error) {
+	return mapNQuadBlankNodes(statement, func(label string) (string, bool) {
+		replacement, ok := labelMap[label]
+		return replacement, ok
+	})
+}
+
+// mapNQuadBlankNodes rewrites the "_:label" terms of a statement outside of its IRIs and literals
+func mapNQuadBlankNodes(statement string, mapLabel func(label string) (string, bool)) (string, error) {
+	statement = NormalizeNQuad(statement)
+	if strings.Count(statement, "\n") != 1 {
+		return "", ErrBBS2023Statement
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(statement); {
+		switch {
+		case statement[i] == '<':
+			end := strings.IndexByte(statement[i:], '>')
+			if end < 0 {
+				return "", ErrBBS2023Statement
+			}
+			b.WriteString(statement[i : i+end+1])
+			i += end + 1
+		case statement[i] == '"':
+			end := i + 1
+			for end < len(statement) && statement[end] != '"' {
+				if statement[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(statement) {
+				return "", ErrBBS2023Statement
+			}
+			b.WriteString(statement[i : end+1])
+			i = end + 1
+		case strings.HasPrefix(statement[i:], "_:"):
+			end := i + 2
+			for end < len(statement) && isBlankNodeLabelChar(statement[end]) {
+				end++
+			}
+			// a label never ends with a dot, it is the end of the statement
+			for end > i+2 && statement[end-1] == '.' {
+				end--
+			}
+			label, ok := mapLabel(statement[i+2 : end])
+			if end == i+2 || !ok {
+				return "", ErrBBS2023Statement
+			}
+			b.WriteString("_:" + label)
+			i = end
+		default:
+			b.WriteByte(statement[i])
+			i++
+		}
+	}
+
+	return b.String(), nil
+}
+
+func isBlankNodeLabelChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.'
+}
+
+// ascendingIndexes tells whether indexes are strictly ascending indexes of a list of n items
+func ascendingIndexes(indexes []int, n int) bool {
+	for i, index := range indexes {
+		if index < 0 || index >= n || i > 0 && index <= indexes[i-1] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,147 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BBS2023HelperTestSuite struct {
+	suite.Suite
+	privateKey  *BBSPrivateKey
+	hmacKey     []byte
+	proofConfig string
+	statements  []string
+}
+
+func TestBBS2023HelperTestSuite(t *testing.T) {
+	suite.Run(t, new(BBS2023HelperTestSuite))
+}
+
+func (s *BBS2023HelperTestSuite) SetupTest() {
+	_, privateKeyPEM, err := GenerateBBSKeyPair()
+	s.Require().NoError(err)
+	privateKey, err := ParseBBSPrivateKeyPEM(privateKeyPEM)
+	s.Require().NoError(err)
+
+	s.privateKey = privateKey
+	s.hmacKey = []byte("00112233445566778899aabbccddeeff")
+	s.proofConfig = `_:c14n0 <http://purl.org/dc/terms/created> "2024-01-01T00:00:00Z" .`
+	s.statements = []string{
+		`<did:example:issuer> <https://schema.org/name> "Issuer" .`,
+		`_:c14n0 <https://schema.org/alumniOf> "_:c14n9 is not a blank node" .`,
+		`_:c14n0 <https://schema.org/givenName> "Alice" .`,
+		`_:c14n1 <https://schema.org/credentialSubject> _:c14n0 .`,
+		`_:c14n1 <https://www.w3.org/2018/credentials#issuer> <did:example:issuer> .`,
+	}
+}
+
+func (s *BBS2023HelperTestSuite) baseProof(mandatoryIndexes []int) string {
+	header, messages, err := BBS2023SignatureInput(s.hmacKey, s.proofConfig, s.statements, mandatoryIndexes)
+	s.Require().NoError(err)
+	signature, err := BBSSign(s.privateKey, header, messages)
+	s.Require().NoError(err)
+
+	baseProof := &BBS2023BaseProof{
+		Signature:         signature,
+		Header:            header,
+		PublicKey:         s.privateKey.PublicKey,
+		HMACKey:           s.hmacKey,
+		MandatoryPointers: []string{"/issuer"},
+	}
+	return baseProof.ProofValue()
+}
+
+func (s *BBS2023HelperTestSuite) TestBaseProofValue() {
+	proofValue := s.baseProof([]int{0, 4})
+	s.True(strings.HasPrefix(proofValue, "u2V0C"))
+
+	baseProof, err := ParseBBS2023BaseProof(proofValue)
+	s.Require().NoError(err)
+	s.Equal(s.privateKey.PublicKey, baseProof.PublicKey)
+	s.Equal(s.hmacKey, baseProof.HMACKey)
+	s.Equal([]string{"/issuer"}, baseProof.MandatoryPointers)
+	s.Equal(proofValue, baseProof.ProofValue())
+
+	_, err = ParseBBS2023DerivedProof(proofValue)
+	s.ErrorIs(err, ErrBBS2023ProofValue)
+	_, err = ParseBBS2023BaseProof(proofValue[:len(proofValue)-4])
+	s.ErrorIs(err, ErrBBS2023ProofValue)
+}
+
+func (s *BBS2023HelperTestSuite) TestLabelMap() {
+	labelMap, err := BBS2023LabelMap(s.hmacKey, s.statements)
+	s.Require().NoError(err)
+	s.Len(labelMap, 2)
+	s.ElementsMatch([]string{"b0", "b1"}, []string{labelMap["c14n0"], labelMap["c14n1"]})
+
+	relabelled, err := relabelNQuad(s.statements[1], labelMap)
+	s.Require().NoError(err)
+	s.Equal(`_:`+labelMap["c14n0"]+` <https://schema.org/alumniOf> "_:c14n9 is not a blank node" .`+"\n", relabelled)
+
+	_, err = relabelNQuad(`_:c14n2 <https://schema.org/name> "Bob" .`, labelMap)
+	s.ErrorIs(err, ErrBBS2023Statement)
+}
+
+func (s *BBS2023HelperTestSuite) TestDeriveAndVerify() {
+	mandatoryIndexes := []int{0, 4}
+	proofValue := s.baseProof(mandatoryIndexes)
+
+	derived, revealed, err := DeriveBBS2023Proof(proofValue, s.statements, mandatoryIndexes, []int{2, 3}, []byte("challenge"))
+	s.Require().NoError(err)
+	s.True(strings.HasPrefix(derived, "u2V0D"))
+	s.Len(revealed, 4)
+	for _, statement := range revealed {
+		s.NotContains(statement, "alumniOf")
+	}
+	s.NoError(VerifyBBS2023Proof(s.privateKey.PublicKey, derived, s.proofConfig, revealed))
+
+	derivedProof, err := ParseBBS2023DerivedProof(derived)
+	s.Require().NoError(err)
+	s.Equal([]byte("challenge"), derivedProof.PresentationHeader)
+	s.Equal(derived, derivedProof.ProofValue())
+
+	// another proof configuration, a changed statement or a dropped statement do not verify
+	s.ErrorIs(VerifyBBS2023Proof(s.privateKey.PublicKey, derived, s.proofConfig+" ", revealed), ErrBBSProof)
+	tampered := make([]string, len(revealed))
+	for i, statement := range revealed {
+		tampered[i] = strings.Replace(statement, "Alice", "Mallory", 1)
+	}
+	s.NotEqual(revealed, tampered)
+	s.Error(VerifyBBS2023Proof(s.privateKey.PublicKey, derived, s.proofConfig, tampered))
+	s.Error(VerifyBBS2023Proof(s.privateKey.PublicKey, derived, s.proofConfig, revealed[:3]))
+}
+
+func (s *BBS2023HelperTestSuite) TestDeriveMandatoryOnly() {
+	proofValue := s.baseProof([]int{0, 4})
+
+	derived, revealed, err := DeriveBBS2023Proof(proofValue, s.statements, []int{0, 4}, nil, nil)
+	s.Require().NoError(err)
+	s.Len(revealed, 2)
+	s.NoError(VerifyBBS2023Proof(s.privateKey.PublicKey, derived, s.proofConfig, revealed))
+}
+
+func (s *BBS2023HelperTestSuite) TestDeriveInvalid() {
+	proofValue := s.baseProof([]int{0, 4})
+
+	// other mandatory statements than the issuer's, a selected mandatory statement and unordered indexes
+	_, _, err := DeriveBBS2023Proof(proofValue, s.statements, []int{0}, []int{2}, nil)
+	s.ErrorIs(err, ErrBBS2023BaseProof)
+	_, _, err = DeriveBBS2023Proof(proofValue, s.statements, []int{0, 4}, []int{4}, nil)
+	s.ErrorIs(err, ErrBBS2023Indexes)
+	_, _, err = DeriveBBS2023Proof(proofValue, s.statements, []int{4, 0}, nil, nil)
+	s.ErrorIs(err, ErrBBS2023Indexes)
+}
+
+func (s *BBS2023HelperTestSuite) TestCBORHeads() {
+	for _, n := range []uint64{0, 23, 24, 255, 256, 65535, 65536, 1 << 32} {
+		r := &cborReader{data: cborAppendHead(nil, cborMajorUint, n)}
+		value, err := r.head(cborMajorUint)
+		s.Require().NoError(err)
+		s.Equal(n, value)
+		s.Empty(r.data)
+	}
+	s.Equal([]byte{0x18, 0x18}, cborAppendHead(nil, cborMajorUint, 24))
+	s.Equal([]byte{0xa2, 0x00, 0x03, 0x01, 0x02}, cborAppendUintMap(nil, map[int]int{1: 2, 0: 3}))
+}
@@ -0,0 +1,205 @@
+package helpers
+
+import (
+	"errors"
+	"sort"
+)
+
+// the major types of the CBOR data items the proof values are made of
+const (
+	cborMajorUint  = 0
+	cborMajorBytes = 2
+	cborMajorText  = 3
+	cborMajorArray = 4
+	cborMajorMap   = 5
+	maxInt         = int(^uint(0) >> 1)
+)
+
+var ErrCBOR = errors.New("cbor: malformed or unsupported data item")
+
+// cborAppendHead appends the head of a data item in its shortest form
+func cborAppendHead(data []byte, major byte, n uint64) []byte {
+	major <<= 5
+	size := 0
+	switch {
+	case n < 24:
+		return append(data, major|byte(n))
+	case n <= 0xff:
+		data, size = append(data, major|24), 1
+	case n <= 0xffff:
+		data, size = append(data, major|25), 2
+	case n <= 0xffffffff:
+		data, size = append(data, major|26), 4
+	default:
+		data, size = append(data, major|27), 8
+	}
+	for i := size - 1; i >= 0; i-- {
+		data = append(data, byte(n>>(8*i)))
+	}
+
+	return data
+}
+
+func cborAppendBytes(data []byte, value []byte) []byte {
+	return append(cborAppendHead(data, cborMajorBytes, uint64(len(value))), value...)
+}
+
+func cborAppendText(data []byte, value string) []byte {
+	return append(cborAppendHead(data, cborMajorText, uint64(len(value))), value...)
+}
+
+func cborAppendUints(data []byte, values []int) []byte {
+	data = cborAppendHead(data, cborMajorArray, uint64(len(values)))
+	for _, value := range values {
+		data = cborAppendHead(data, cborMajorUint, uint64(value))
+	}
+
+	return data
+}
+
+// cborAppendUintMap appends a map of unsigned integers with its keys in the sorted order of deterministic encoding
+func cborAppendUintMap(data []byte, values map[int]int) []byte {
+	keys := make([]int, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	data = cborAppendHead(data, cborMajorMap, uint64(len(keys)))
+	for _, key := range keys {
+		data = cborAppendHead(data, cborMajorUint, uint64(key))
+		data = cborAppendHead(data, cborMajorUint, uint64(values[key]))
+	}
+
+	return data
+}
+
+// cborReader reads the definite length data items written by the cborAppend functions
+type cborReader struct {
+	data []byte
+}
+
+func (r *cborReader) head(major byte) (uint64, error) {
+	if len(r.data) == 0 || r.data[0]>>5 != major {
+		return 0, ErrCBOR
+	}
+	info := r.data[0] & 0x1f
+	r.data = r.data[1:]
+
+	size := 0
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, ErrCBOR
+	}
+	if len(r.data) < size {
+		return 0, ErrCBOR
+	}
+	n := uint64(0)
+	for _, b := range r.data[:size] {
+		n = n<<8 | uint64(b)
+	}
+	r.data = r.data[size:]
+
+	return n, nil
+}
+
+// length reads the head of an item whose length is bounded by the remaining data
+func (r *cborReader) length(major byte) (int, error) {
+	n, err := r.head(major)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(len(r.data)) {
+		return 0, ErrCBOR
+	}
+
+	return int(n), nil
+}
+
+func (r *cborReader) bytes() ([]byte, error) {
+	n, err := r.length(cborMajorBytes)
+	if err != nil {
+		return nil, err
+	}
+	value := append([]byte{}, r.data[:n]...)
+	r.data = r.data[n:]
+
+	return value, nil
+}
+
+func (r *cborReader) texts() ([]string, error) {
+	n, err := r.length(cborMajorArray)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, n)
+	for i := range values {
+		size, err := r.length(cborMajorText)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = string(r.data[:size])
+		r.data = r.data[size:]
+	}
+
+	return values, nil
+}
+
+func (r *cborReader) uint() (int, error) {
+	n, err := r.head(cborMajorUint)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(maxInt) {
+		return 0, ErrCBOR
+	}
+
+	return int(n), nil
+}
+
+func (r *cborReader) uints() ([]int, error) {
+	n, err := r.length(cborMajorArray)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]int, n)
+	for i := range values {
+		if values[i], err = r.uint(); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+func (r *cborReader) uintMap() (map[int]int, error) {
+	n, err := r.length(cborMajorMap)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[int]int, n)
+	for i := 0; i < n; i++ {
+		key, err := r.uint()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := values[key]; ok {
+			return nil, ErrCBOR
+		}
+		if values[key], err = r.uint(); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
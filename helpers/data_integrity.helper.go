@@ -0,0 +1,35 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+)
+
+// DataIntegrityHashData is the hash data of the jcs cryptosuites of Data Integrity, the SHA-256 of the canonical
+// proof configuration followed by the SHA-256 of the canonical document without its proof
+func DataIntegrityHashData(proofConfig interface{}, document interface{}) ([]byte, error) {
+	proofConfigHash, err := canonicalHash(proofConfig)
+	if err != nil {
+		return nil, err
+	}
+	documentHash, err := canonicalHash(document)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(proofConfigHash, documentHash...), nil
+}
+
+func canonicalHash(value interface{}) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := CanonicalizeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(canonical)
+
+	return hash[:], nil
+}
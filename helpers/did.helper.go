@@ -0,0 +1,234 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+)
+
+var (
+	ErrDID                    = errors.New("did: not a did:key or did:jwk of a P-256, RSA or BLS12-381 G2 key")
+	ErrVerificationMethodType = errors.New("did: the verification method type does not support the key")
+)
+
+// ResolvedDID is the single verification method of a did:key or did:jwk, JWK is nil for BLS12-381 G2 keys
+// which have no JWK form
+type ResolvedDID struct {
+	DID                  string
+	VerificationMethodID string
+	PublicKey            crypto.PublicKey
+	JWK                  *JWK
+}
+
+// PublicKeyMultibase returns the base58btc multibase of the key prefixed with its multicodec,
+// a compressed point for P-256 and BLS12-381 G2 keys and the PKCS #1 DER for RSA keys
+func PublicKeyMultibase(publicKey crypto.PublicKey) (string, error) {
+	var codec consts.Multicodec
+	var key []byte
+	switch publicKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if publicKey.Curve != elliptic.P256() {
+			return "", ErrDID
+		}
+		codec = consts.MulticodecP256Pub
+		key = elliptic.MarshalCompressed(publicKey.Curve, publicKey.X, publicKey.Y)
+	case *rsa.PublicKey:
+		codec = consts.MulticodecRSAPub
+		key = x509.MarshalPKCS1PublicKey(publicKey)
+	case BBSPublicKey:
+		codec = consts.MulticodecBLS12381G2Pub
+		key = publicKey
+	default:
+		return "", ErrDID
+	}
+
+	prefix := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(prefix, uint64(codec))
+
+	return consts.MultibaseBase58BTC + EncodeBase58BTC(append(prefix[:n], key...)), nil
+}
+
+// ParsePublicKeyMultibase reverses PublicKeyMultibase
+func ParsePublicKeyMultibase(multibase string) (crypto.PublicKey, error) {
+	if !strings.HasPrefix(multibase, consts.MultibaseBase58BTC) {
+		return nil, ErrDID
+	}
+	data, err := DecodeBase58BTC(strings.TrimPrefix(multibase, consts.MultibaseBase58BTC))
+	if err != nil {
+		return nil, ErrDID
+	}
+	codec, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, ErrDID
+	}
+
+	switch consts.Multicodec(codec) {
+	case consts.MulticodecP256Pub:
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), data[n:])
+		if x == nil {
+			return nil, ErrDID
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case consts.MulticodecRSAPub:
+		publicKey, err := x509.ParsePKCS1PublicKey(data[n:])
+		if err != nil {
+			return nil, ErrDID
+		}
+		return publicKey, nil
+	case consts.MulticodecBLS12381G2Pub:
+		publicKey, err := ParseBBSPublicKey(data[n:])
+		if err != nil {
+			return nil, ErrDID
+		}
+		return publicKey, nil
+	}
+
+	return nil, ErrDID
+}
+
+// DIDKey derives the did:key of a public key PEM
+func DIDKey(publicKeyPEM string) (string, error) {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return "", err
+	}
+	multibase, err := PublicKeyMultibase(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	return consts.DIDMethodKey + multibase, nil
+}
+
+// DIDJWK derives the did:jwk of a PKIX public key PEM, the JWK holds only its required members sorted by name
+// so every client derives the same DID
+func DIDJWK(publicKeyPEM string) (string, error) {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return "", err
+	}
+	jwk, err := NewPublicJWK(publicKey)
+	if err != nil {
+		return "", ErrDID
+	}
+
+	members := map[string]string{"kty": jwk.Kty}
+	for name, value := range map[string]string{"crv": jwk.Crv, "x": jwk.X, "y": jwk.Y, "n": jwk.N, "e": jwk.E} {
+		if value != "" {
+			members[name] = value
+		}
+	}
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+
+	return consts.DIDMethodJWK + base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// ResolveDID decodes the public key of a did:key or did:jwk, nothing is looked up
+func ResolveDID(did string) (*ResolvedDID, error) {
+	var publicKey crypto.PublicKey
+	var verificationMethodID string
+	switch {
+	case strings.HasPrefix(did, consts.DIDMethodKey):
+		multibase := strings.TrimPrefix(did, consts.DIDMethodKey)
+		key, err := ParsePublicKeyMultibase(multibase)
+		if err != nil {
+			return nil, err
+		}
+		publicKey = key
+		verificationMethodID = did + "#" + multibase
+	case strings.HasPrefix(did, consts.DIDMethodJWK):
+		data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(did, consts.DIDMethodJWK))
+		if err != nil {
+			return nil, ErrDID
+		}
+		members := make(map[string]interface{})
+		if err := json.Unmarshal(data, &members); err != nil {
+			return nil, ErrDID
+		}
+		// a did:jwk must never carry a private key
+		if _, ok := members["d"]; ok {
+			return nil, ErrDID
+		}
+		jwk := &JWK{}
+		if err := json.Unmarshal(data, jwk); err != nil {
+			return nil, ErrDID
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, ErrDID
+		}
+		publicKey = key
+		verificationMethodID = did + consts.DIDJWKVerificationID
+	default:
+		return nil, ErrDID
+	}
+
+	var jwk *JWK
+	if _, ok := publicKey.(BBSPublicKey); !ok {
+		key, err := NewPublicJWK(publicKey)
+		if err != nil {
+			return nil, ErrDID
+		}
+		jwk = key
+	}
+
+	return &ResolvedDID{
+		DID:                  did,
+		VerificationMethodID: verificationMethodID,
+		PublicKey:            publicKey,
+		JWK:                  jwk,
+	}, nil
+}
+
+// NewVerificationMethod renders a public key as a verification method of methodType, with publicKeyJwk
+// for JsonWebKey2020, publicKeyMultibase for Multikey and EcdsaSecp256r1VerificationKey2019 and publicKeyPem for
+// the 2018 types, which only take the key type they are named after
+func NewVerificationMethod(publicKey crypto.PublicKey, methodType consts.DIDVerificationMethodType, controller string, id string) (*models.DIDVerificationMethod, error) {
+	_, isECDSA := publicKey.(*ecdsa.PublicKey)
+	_, isRSA := publicKey.(*rsa.PublicKey)
+
+	verificationMethod := &models.DIDVerificationMethod{
+		ID:         id,
+		Type:       string(methodType),
+		Controller: controller,
+	}
+	switch {
+	case methodType == consts.DIDVerificationMethodJsonWebKey2020:
+		jwk, err := NewPublicJWK(publicKey)
+		if err != nil {
+			return nil, ErrVerificationMethodType
+		}
+		verificationMethod.PublicKeyJWK = jwk
+	case methodType == consts.DIDVerificationMethodMultikey,
+		methodType == consts.DIDVerificationMethodEcdsaSecp256r1VerificationKey2019 && isECDSA:
+		multibase, err := PublicKeyMultibase(publicKey)
+		if err != nil {
+			return nil, ErrVerificationMethodType
+		}
+		verificationMethod.PublicKeyMultibase = multibase
+	case methodType == consts.DIDVerificationMethodSecp256r1VerificationKey2018 && isECDSA,
+		methodType == consts.DIDVerificationMethodRsaVerificationKey2018 && isRSA:
+		der, err := x509.MarshalPKIXPublicKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		verificationMethod.PublicKeyPEM = string(EncodePEM("PUBLIC KEY", der))
+	default:
+		return nil, ErrVerificationMethodType
+	}
+
+	return verificationMethod, nil
+}
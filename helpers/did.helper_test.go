@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+)
+
+type DIDHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestDIDHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(DIDHelperTestSuite))
+}
+
+func (s *DIDHelperTestSuite) publicKeyPEM(publicKey interface{}) string {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	s.Require().NoError(err)
+
+	return string(EncodePEM("PUBLIC KEY", der))
+}
+
+func (s *DIDHelperTestSuite) p256PublicKey(x string, y string) *ecdsa.PublicKey {
+	xBytes, err := base64.RawURLEncoding.DecodeString(x)
+	s.Require().NoError(err)
+	yBytes, err := base64.RawURLEncoding.DecodeString(y)
+	s.Require().NoError(err)
+
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(xBytes), Y: new(big.Int).SetBytes(yBytes)}
+}
+
+func (s *DIDHelperTestSuite) TestBase58BTC() {
+	for data, encoded := range map[string]string{
+		"Hello World!":             "2NEpo7TZRRrLZSi2U",
+		"\x00\x00\x28\x7f\xb4\xcd": "11233QC4",
+		"":                         "",
+		"\x00":                     "1",
+	} {
+		s.Equal(encoded, EncodeBase58BTC([]byte(data)))
+		decoded, err := DecodeBase58BTC(encoded)
+		s.NoError(err)
+		s.Equal([]byte(data), decoded)
+	}
+
+	_, err := DecodeBase58BTC("0OIl")
+	s.Equal(ErrBase58, err)
+}
+
+func (s *DIDHelperTestSuite) TestDIDKey_P256() {
+	publicKey := s.p256PublicKey("igrFmi0whuihKnj9R3Om1SoMph72wUGeFaBbzG2vzns", "efsX5b10x8yjyrj4ny3pGfLcY7Xby1KzgqOdqnsrJIM")
+
+	did, err := DIDKey(s.publicKeyPEM(publicKey))
+	s.NoError(err)
+	s.Equal("did:key:zDnaerx9CtbPJ1q36T5Ln5wYt3MQYeGRG5ehnPAmxcf5mDZpv", did)
+
+	resolved, err := ResolveDID(did)
+	s.Require().NoError(err)
+	s.True(publicKey.Equal(resolved.PublicKey))
+	s.Equal(did+"#zDnaerx9CtbPJ1q36T5Ln5wYt3MQYeGRG5ehnPAmxcf5mDZpv", resolved.VerificationMethodID)
+	s.Equal("igrFmi0whuihKnj9R3Om1SoMph72wUGeFaBbzG2vzns", resolved.JWK.X)
+}
+
+func (s *DIDHelperTestSuite) TestDIDKey_RSA() {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+
+	did, err := DIDKey(s.publicKeyPEM(&privateKey.PublicKey))
+	s.NoError(err)
+	s.True(strings.HasPrefix(did, "did:key:z4MX"))
+
+	resolved, err := ResolveDID(did)
+	s.Require().NoError(err)
+	s.True(privateKey.PublicKey.Equal(resolved.PublicKey))
+}
+
+func (s *DIDHelperTestSuite) TestDIDJWK() {
+	publicKey := s.p256PublicKey("acbIQiuMs3i8_uszEjJ2tpTtRM4EU3yz91PH6CdH2V0", "_KcyLj9vWMptnmKtm46GqDz8wf74I5LKgrl2GzH3nSE")
+
+	did, err := DIDJWK(s.publicKeyPEM(publicKey))
+	s.NoError(err)
+	s.Equal("did:jwk:eyJjcnYiOiJQLTI1NiIsImt0eSI6IkVDIiwieCI6ImFjYklRaXVNczNpOF91c3pFakoydHBUdFJNNEVVM3l6OTFQSDZDZEgyVjAiLCJ5IjoiX0tjeUxqOXZXTXB0bm1LdG00NkdxRHo4d2Y3NEk1TEtncmwyR3pIM25TRSJ9", did)
+
+	resolved, err := ResolveDID(did)
+	s.Require().NoError(err)
+	s.True(publicKey.Equal(resolved.PublicKey))
+	s.Equal(did+"#0", resolved.VerificationMethodID)
+}
+
+func (s *DIDHelperTestSuite) TestResolveDID_Invalid() {
+	for _, did := range []string{
+		"did:web:example.com",
+		"did:key:z6MkhaXgBZDvotDkL5257faiztiGiC2QtKLGpbnnEGta2doK",
+		"did:key:zDnaerx9CtbPJ1q36T5Ln5wYt3MQYeGRG5ehnPAmxcf5mDZp",
+		"did:jwk:" + base64.RawURLEncoding.EncodeToString([]byte(`{"kty":"EC","crv":"P-256","x":"acbIQiuMs3i8_uszEjJ2tpTtRM4EU3yz91PH6CdH2V0","y":"_KcyLj9vWMptnmKtm46GqDz8wf74I5LKgrl2GzH3nSE","d":"AA"}`)),
+	} {
+		_, err := ResolveDID(did)
+		s.Equal(ErrDID, err, did)
+	}
+}
+
+func (s *DIDHelperTestSuite) TestNewVerificationMethod() {
+	publicKey := s.p256PublicKey("igrFmi0whuihKnj9R3Om1SoMph72wUGeFaBbzG2vzns", "efsX5b10x8yjyrj4ny3pGfLcY7Xby1KzgqOdqnsrJIM")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+
+	verificationMethod, err := NewVerificationMethod(publicKey, consts.DIDVerificationMethodJsonWebKey2020, "did:example:123", "did:example:123#key-1")
+	s.Require().NoError(err)
+	s.Equal("did:example:123#key-1", verificationMethod.ID)
+	s.Equal("did:example:123", verificationMethod.Controller)
+	s.Equal(&JWK{Kty: "EC", Crv: "P-256", X: "igrFmi0whuihKnj9R3Om1SoMph72wUGeFaBbzG2vzns", Y: "efsX5b10x8yjyrj4ny3pGfLcY7Xby1KzgqOdqnsrJIM"}, verificationMethod.PublicKeyJWK)
+
+	for _, methodType := range []consts.DIDVerificationMethodType{consts.DIDVerificationMethodMultikey, consts.DIDVerificationMethodEcdsaSecp256r1VerificationKey2019} {
+		verificationMethod, err = NewVerificationMethod(publicKey, methodType, "did:example:123", "did:example:123#key-1")
+		s.Require().NoError(err)
+		s.Equal("zDnaerx9CtbPJ1q36T5Ln5wYt3MQYeGRG5ehnPAmxcf5mDZpv", verificationMethod.PublicKeyMultibase)
+		s.Nil(verificationMethod.PublicKeyJWK)
+	}
+
+	verificationMethod, err = NewVerificationMethod(publicKey, consts.DIDVerificationMethodSecp256r1VerificationKey2018, "did:example:123", "did:example:123#key-1")
+	s.Require().NoError(err)
+	s.Equal(s.publicKeyPEM(publicKey), verificationMethod.PublicKeyPEM)
+
+	verificationMethod, err = NewVerificationMethod(&rsaKey.PublicKey, consts.DIDVerificationMethodRsaVerificationKey2018, "did:example:123", "did:example:123#key-1")
+	s.Require().NoError(err)
+	s.Equal(s.publicKeyPEM(&rsaKey.PublicKey), verificationMethod.PublicKeyPEM)
+
+	for _, methodType := range []consts.DIDVerificationMethodType{consts.DIDVerificationMethodEcdsaSecp256r1VerificationKey2019, consts.DIDVerificationMethodSecp256r1VerificationKey2018} {
+		_, err = NewVerificationMethod(&rsaKey.PublicKey, methodType, "did:example:123", "did:example:123#key-1")
+		s.Equal(ErrVerificationMethodType, err)
+	}
+	_, err = NewVerificationMethod(publicKey, consts.DIDVerificationMethodRsaVerificationKey2018, "did:example:123", "did:example:123#key-1")
+	s.Equal(ErrVerificationMethodType, err)
+}
@@ -0,0 +1,175 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var ErrJCS = errors.New("jcs: the JSON has a number that is not an IEEE 754 double")
+
+// CanonicalizeJSON serializes JSON with the JSON Canonicalization Scheme of RFC 8785: members sorted by the UTF-16
+// code units of their names, no whitespace, minimal string escapes and numbers written as ECMAScript does
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("jcs: trailing data after the JSON value")
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := writeCanonicalJSON(buffer, value); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func writeCanonicalJSON(buffer *bytes.Buffer, value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		buffer.WriteString("null")
+	case bool:
+		buffer.WriteString(strconv.FormatBool(value))
+	case json.Number:
+		number, err := canonicalNumber(value)
+		if err != nil {
+			return err
+		}
+		buffer.WriteString(number)
+	case string:
+		writeCanonicalString(buffer, value)
+	case []interface{}:
+		buffer.WriteByte('[')
+		for i, item := range value {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := writeCanonicalJSON(buffer, item); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(']')
+	case map[string]interface{}:
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return lessUTF16(names[i], names[j])
+		})
+		buffer.WriteByte('{')
+		for i, name := range names {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeCanonicalString(buffer, name)
+			buffer.WriteByte(':')
+			if err := writeCanonicalJSON(buffer, value[name]); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte('}')
+	default:
+		return fmt.Errorf("jcs: unsupported value %T", value)
+	}
+
+	return nil
+}
+
+func lessUTF16(a string, b string) bool {
+	unitsA := utf16.Encode([]rune(a))
+	unitsB := utf16.Encode([]rune(b))
+	for i := 0; i < len(unitsA) && i < len(unitsB); i++ {
+		if unitsA[i] != unitsB[i] {
+			return unitsA[i] < unitsB[i]
+		}
+	}
+
+	return len(unitsA) < len(unitsB)
+}
+
+func writeCanonicalString(buffer *bytes.Buffer, value string) {
+	buffer.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			buffer.WriteString(`\"`)
+		case '\\':
+			buffer.WriteString(`\\`)
+		case '\b':
+			buffer.WriteString(`\b`)
+		case '\f':
+			buffer.WriteString(`\f`)
+		case '\n':
+			buffer.WriteString(`\n`)
+		case '\r':
+			buffer.WriteString(`\r`)
+		case '\t':
+			buffer.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(buffer, `\u%04x`, r)
+				continue
+			}
+			buffer.WriteRune(r)
+		}
+	}
+	buffer.WriteByte('"')
+}
+
+// canonicalNumber writes the shortest representation that round-trips, in the notation ECMAScript picks
+func canonicalNumber(number json.Number) (string, error) {
+	value, err := strconv.ParseFloat(string(number), 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return "", ErrJCS
+	}
+	if value == 0 {
+		return "0", nil
+	}
+
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+	// the shortest digits d1.d2...dk and the exponent, value = 0.d1...dk * 10^n
+	scientific := strconv.FormatFloat(value, 'e', -1, 64)
+	mantissa, exponent := scientific[:strings.IndexByte(scientific, 'e')], scientific[strings.IndexByte(scientific, 'e')+1:]
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, err := strconv.Atoi(exponent)
+	if err != nil {
+		return "", ErrJCS
+	}
+	k, n := len(digits), e+1
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k), nil
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:], nil
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits, nil
+	}
+
+	exponentSign := "+"
+	if n-1 < 0 {
+		exponentSign = "-"
+	}
+	fraction := ""
+	if k > 1 {
+		fraction = "." + digits[1:]
+	}
+
+	return sign + digits[:1] + fraction + "e" + exponentSign + strconv.Itoa(int(math.Abs(float64(n-1)))), nil
+}
@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type JCSHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestJCSHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(JCSHelperTestSuite))
+}
+
+func (s *JCSHelperTestSuite) TestCanonicalizeJSON() {
+	// the example of RFC 8785 section 3.2.2
+	canonical, err := CanonicalizeJSON([]byte(`{
+		"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+		"string": "\u20ac$\u000F\u000aA'B\u0022\u005c\u005c\u0022\u002f",
+		"literals": [null, true, false]
+	}`))
+	s.NoError(err)
+	s.Equal(`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`, string(canonical))
+
+	// names are sorted by their UTF-16 code units, not by their UTF-8 bytes
+	canonical, err = CanonicalizeJSON([]byte(`{"\ud83d\ude00":1,"\ufb33":2,"a":{"z":[],"b":{}}}`))
+	s.NoError(err)
+	s.Equal("{\"a\":{\"b\":{},\"z\":[]},\"\U0001F600\":1,\"\uFB33\":2}", string(canonical))
+}
+
+func (s *JCSHelperTestSuite) TestCanonicalNumber() {
+	for number, expected := range map[string]string{
+		"0":                     "0",
+		"-0":                    "0",
+		"1":                     "1",
+		"-1.5":                  "-1.5",
+		"100":                   "100",
+		"1e21":                  "1e+21",
+		"123456789012345678901": "123456789012345680000",
+		"0.000001":              "0.000001",
+		"0.0000001":             "1e-7",
+		"9007199254740993":      "9007199254740992",
+	} {
+		canonical, err := canonicalNumber(json.Number(number))
+		s.NoError(err)
+		s.Equal(expected, canonical, number)
+	}
+
+	_, err := canonicalNumber(json.Number("1e400"))
+	s.Equal(ErrJCS, err)
+}
+
+func (s *JCSHelperTestSuite) TestDataIntegrityHashData() {
+	proofConfig := map[string]interface{}{"type": "DataIntegrityProof", "cryptosuite": "ecdsa-jcs-2019"}
+	document := map[string]interface{}{"type": []string{"VerifiablePresentation"}, "holder": "did:example:holder"}
+
+	hashData, err := DataIntegrityHashData(proofConfig, document)
+	s.NoError(err)
+	proofConfigHash := sha256.Sum256([]byte(`{"cryptosuite":"ecdsa-jcs-2019","type":"DataIntegrityProof"}`))
+	documentHash := sha256.Sum256([]byte(`{"holder":"did:example:holder","type":["VerifiablePresentation"]}`))
+	s.Equal(append(proofConfigHash[:], documentHash[:]...), hashData)
+}
@@ -0,0 +1,122 @@
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+const (
+	jweAlgorithmRSAOAEP256 = "RSA-OAEP-256"
+	jweEncryptionA256GCM   = "A256GCM"
+)
+
+var ErrJWEDecrypt = errors.New("jwe: the token is not a RSA-OAEP-256 A256GCM JWE for this key")
+
+// EncryptJWERSAOAEP returns a compact JWE of plaintext with alg RSA-OAEP-256 and enc A256GCM,
+// header holds additional protected header parameters
+func EncryptJWERSAOAEP(publicKey *rsa.PublicKey, plaintext []byte, header map[string]string) (string, error) {
+	protected := map[string]string{}
+	for name, value := range header {
+		protected[name] = value
+	}
+	protected["alg"] = jweAlgorithmRSAOAEP256
+	protected["enc"] = jweEncryptionA256GCM
+	protectedJSON, err := json.Marshal(protected)
+	if err != nil {
+		return "", err
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(protectedJSON)
+
+	cek := make([]byte, 32)
+	defer Zeroize(cek)
+	if _, err := rand.Read(cek); err != nil {
+		return "", err
+	}
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, cek, nil)
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := newA256GCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nil, iv, plaintext, []byte(encodedHeader))
+	tagStart := len(sealed) - aead.Overhead()
+
+	return strings.Join([]string{
+		encodedHeader,
+		base64.RawURLEncoding.EncodeToString(encryptedKey),
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(sealed[:tagStart]),
+		base64.RawURLEncoding.EncodeToString(sealed[tagStart:]),
+	}, "."), nil
+}
+
+// DecryptJWERSAOAEP decrypts a compact JWE made by EncryptJWERSAOAEP and returns its protected header,
+// the returned plaintext must be zeroed by the caller
+func DecryptJWERSAOAEP(privateKey *rsa.PrivateKey, compact string) (map[string]string, []byte, error) {
+	parts := strings.Split(compact, ".")
+	if len(parts) != 5 {
+		return nil, nil, ErrJWEDecrypt
+	}
+
+	protectedJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, ErrJWEDecrypt
+	}
+	header := map[string]string{}
+	if err := json.Unmarshal(protectedJSON, &header); err != nil {
+		return nil, nil, ErrJWEDecrypt
+	}
+	if header["alg"] != jweAlgorithmRSAOAEP256 || header["enc"] != jweEncryptionA256GCM {
+		return nil, nil, ErrJWEDecrypt
+	}
+
+	decoded := make([][]byte, 4)
+	for i, part := range parts[1:] {
+		decoded[i], err = base64.RawURLEncoding.DecodeString(part)
+		if err != nil {
+			return nil, nil, ErrJWEDecrypt
+		}
+	}
+	encryptedKey, iv, ciphertext, tag := decoded[0], decoded[1], decoded[2], decoded[3]
+
+	cek, err := rsa.DecryptOAEP(sha256.New(), nil, privateKey, encryptedKey, nil)
+	if err != nil || len(cek) != 32 {
+		Zeroize(cek)
+		return nil, nil, ErrJWEDecrypt
+	}
+	defer Zeroize(cek)
+
+	aead, err := newA256GCM(cek)
+	if err != nil || len(iv) != aead.NonceSize() || len(tag) != aead.Overhead() {
+		return nil, nil, ErrJWEDecrypt
+	}
+	plaintext, err := aead.Open(nil, iv, append(ciphertext, tag...), []byte(parts[0]))
+	if err != nil {
+		return nil, nil, ErrJWEDecrypt
+	}
+
+	return header, plaintext, nil
+}
+
+func newA256GCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,46 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type JWEHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestJWEHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(JWEHelperTestSuite))
+}
+
+func (s *JWEHelperTestSuite) TestEncryptDecryptJWERSAOAEP() {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+
+	compact, err := EncryptJWERSAOAEP(&privateKey.PublicKey, []byte("private key"), map[string]string{"kid": "key-1"})
+	s.Require().NoError(err)
+	s.Len(strings.Split(compact, "."), 5)
+
+	header, plaintext, err := DecryptJWERSAOAEP(privateKey, compact)
+	s.NoError(err)
+	s.Equal("private key", string(plaintext))
+	s.Equal("key-1", header["kid"])
+	s.Equal("RSA-OAEP-256", header["alg"])
+	s.Equal("A256GCM", header["enc"])
+
+	// the protected header is authenticated
+	parts := strings.Split(compact, ".")
+	parts[0] = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RSA-OAEP-256","enc":"A256GCM","kid":"key-2"}`))
+	_, _, err = DecryptJWERSAOAEP(privateKey, strings.Join(parts, "."))
+	s.Equal(ErrJWEDecrypt, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+	_, _, err = DecryptJWERSAOAEP(otherKey, compact)
+	s.Equal(ErrJWEDecrypt, err)
+}
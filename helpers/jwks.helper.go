@@ -0,0 +1,236 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const jwksCacheTTL = 10 * time.Minute
+
+type JWK struct {
+	Kid string `json:"kid,omitempty"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKey converts an RSA or EC JWK
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		curve, err := jwkCurve(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		publicKey := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.New("jwk point is not on the curve")
+		}
+
+		return publicKey, nil
+	}
+
+	return nil, fmt.Errorf("unsupported jwk key type %q", k.Kty)
+}
+
+// NewPublicJWK converts a P-256 or RSA public key, the coordinates of P-256 keys are padded to 32 bytes
+func NewPublicJWK(publicKey crypto.PublicKey) (*JWK, error) {
+	switch publicKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if publicKey.Curve != elliptic.P256() {
+			break
+		}
+		coordinates := elliptic.Marshal(publicKey.Curve, publicKey.X, publicKey.Y)[1:]
+
+		return &JWK{
+			Kty: "EC",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(coordinates[:32]),
+			Y:   base64.RawURLEncoding.EncodeToString(coordinates[32:]),
+		}, nil
+	case *rsa.PublicKey:
+		return &JWK{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}, nil
+	}
+
+	return nil, errors.New("unsupported jwk public key")
+}
+
+func jwkCurve(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported jwk curve %q", crv)
+}
+
+// JWKSProvider serves verification keys from a local JWKS file or a JWKS URL,
+// the URL is fetched again after the cache TTL or when an unknown key ID shows up
+type JWKSProvider struct {
+	file      string
+	url       string
+	client    *http.Client
+	mutex     sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func NewJWKSProvider(file string, url string) *JWKSProvider {
+	return &JWKSProvider{
+		file:   file,
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *JWKSProvider) Key(kid string) (crypto.PublicKey, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.keys == nil || (p.url != "" && time.Since(p.fetchedAt) > jwksCacheTTL) {
+		if err := p.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	key, ok := p.lookup(kid)
+	if !ok && p.url != "" && time.Since(p.fetchedAt) > time.Minute {
+		if err := p.load(); err != nil {
+			return nil, err
+		}
+		key, ok = p.lookup(kid)
+	}
+	if !ok {
+		return nil, fmt.Errorf("jwks has no key %q", kid)
+	}
+
+	return key, nil
+}
+
+// lookup allows tokens without a key ID when the set holds a single key
+func (p *JWKSProvider) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *JWKSProvider) load() error {
+	var data []byte
+	var err error
+	if p.file != "" {
+		data, err = ioutil.ReadFile(p.file)
+	} else {
+		data, err = p.fetch()
+	}
+	if err != nil {
+		return err
+	}
+
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	p.keys = keys
+	p.fetchedAt = time.Now()
+	return nil
+}
+
+func (p *JWKSProvider) fetch() ([]byte, error) {
+	response, err := p.client.Get(p.url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching jwks returned %d", response.StatusCode)
+	}
+
+	return ioutil.ReadAll(response.Body)
+}
+
+// ParseJWKS skips keys that are not meant for signatures or cannot be used
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	set := &JWKSet{}
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		publicKey, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = publicKey
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no usable signing key")
+	}
+
+	return keys, nil
+}
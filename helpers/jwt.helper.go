@@ -0,0 +1,237 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+type JWTHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// JWTAudience accepts both the string and the array form of "aud"
+type JWTAudience []string
+
+func (a *JWTAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = JWTAudience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+type JWTClaims struct {
+	Issuer    string                 `json:"iss"`
+	Subject   string                 `json:"sub"`
+	Audience  JWTAudience            `json:"aud"`
+	ExpiresAt int64                  `json:"exp"`
+	NotBefore int64                  `json:"nbf"`
+	IssuedAt  int64                  `json:"iat"`
+	Scope     string                 `json:"scope"`
+	Scp       []string               `json:"scp"`
+	Extra     map[string]interface{} `json:"-"`
+}
+
+// Scopes merges the space separated "scope" claim and the "scp" array used by some providers
+func (c JWTClaims) Scopes() []string {
+	return append(strings.Fields(c.Scope), c.Scp...)
+}
+
+type JWTVerifyOptions struct {
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+type JWTKeyFunc func(kid string) (crypto.PublicKey, error)
+
+// VerifyJWT checks the signature of a compact JWS with the key returned for its "kid" and validates
+// the registered claims, an empty issuer or audience option skips that check
+func VerifyJWT(token string, keyFunc JWTKeyFunc, options *JWTVerifyOptions) (*JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("jwt must have three parts")
+	}
+
+	header := &JWTHeader{}
+	if err := decodeJWTPart(parts[0], header); err != nil {
+		return nil, err
+	}
+
+	publicKey, err := keyFunc(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyJWSSignature(header.Alg, publicKey, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	claims := &JWTClaims{}
+	if err := decodeJWTPart(parts[1], claims); err != nil {
+		return nil, err
+	}
+	if err := decodeJWTPart(parts[1], &claims.Extra); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(options.Leeway)) {
+		return nil, errors.New("jwt is expired")
+	}
+	if claims.NotBefore != 0 && now.Add(options.Leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, errors.New("jwt is not valid yet")
+	}
+	if options.Issuer != "" && claims.Issuer != options.Issuer {
+		return nil, errors.New("jwt issuer is not accepted")
+	}
+	if options.Audience != "" && !containsString(claims.Audience, options.Audience) {
+		return nil, errors.New("jwt audience is not accepted")
+	}
+
+	return claims, nil
+}
+
+// VerifyJWSSignature supports the RSA and ECDSA JWS algorithms, "none" and HMAC are rejected
+func VerifyJWSSignature(alg string, publicKey crypto.PublicKey, signingInput []byte, signature []byte) error {
+	hash, err := jwsHash(alg)
+	if err != nil {
+		return err
+	}
+	hasher := hash.New()
+	hasher.Write(signingInput)
+	digest := hasher.Sum(nil)
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(alg, "RS") {
+			return rsa.VerifyPKCS1v15(key, hash, digest, signature)
+		}
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(key, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			break
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid ecdsa signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("invalid ecdsa signature")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("algorithm %q does not match the key", alg)
+}
+
+// SignJWSSignature signs with the RSA and ECDSA JWS algorithms, ECDSA signatures are the fixed size r || s of JWS
+func SignJWSSignature(alg string, privateKey crypto.PrivateKey, signingInput []byte) ([]byte, error) {
+	hash, err := jwsHash(alg)
+	if err != nil {
+		return nil, err
+	}
+	hasher := hash.New()
+	hasher.Write(signingInput)
+	digest := hasher.Sum(nil)
+
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if strings.HasPrefix(alg, "RS") {
+			return rsa.SignPKCS1v15(rand.Reader, key, hash, digest)
+		}
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.SignPSS(rand.Reader, key, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+		}
+	case *ecdsa.PrivateKey:
+		if !strings.HasPrefix(alg, "ES") {
+			break
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			return nil, err
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature := make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+		return signature, nil
+	}
+
+	return nil, fmt.Errorf("algorithm %q does not match the key", alg)
+}
+
+// JWTSigningInput is the "header.payload" of a compact JWS of the claims, the JWT is the signing input followed
+// by "." and the base64url of the signature
+func JWTSigningInput(header interface{}, claims interface{}) (string, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON), nil
+}
+
+func jwsHash(alg string) (crypto.Hash, error) {
+	switch alg {
+	case "RS256", "PS256", "ES256":
+		return crypto.SHA256, nil
+	case "RS384", "PS384", "ES384":
+		return crypto.SHA384, nil
+	case "RS512", "PS512", "ES512":
+		return crypto.SHA512, nil
+	}
+
+	return 0, fmt.Errorf("unsupported jws algorithm %q", alg)
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
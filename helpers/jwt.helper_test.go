@@ -0,0 +1,125 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type JWTHelperTestSuite struct {
+	suite.Suite
+	privateKey *ecdsa.PrivateKey
+	keyFunc    JWTKeyFunc
+}
+
+func TestJWTHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(JWTHelperTestSuite))
+}
+
+func (j *JWTHelperTestSuite) SetupTest() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	j.NoError(err)
+	j.privateKey = privateKey
+	j.keyFunc = func(kid string) (crypto.PublicKey, error) {
+		return &privateKey.PublicKey, nil
+	}
+}
+
+func (j *JWTHelperTestSuite) sign(header map[string]interface{}, claims map[string]interface{}) string {
+	headerJSON, _ := json.Marshal(header)
+	claimsJSON, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, j.privateKey, digest[:])
+	j.NoError(err)
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (j *JWTHelperTestSuite) TestJWTHelper_VerifyJWT_ExpectSuccess() {
+	token := j.sign(map[string]interface{}{"alg": "ES256"}, map[string]interface{}{
+		"iss":       "https://issuer.example",
+		"sub":       "did:example:holder",
+		"aud":       []string{"key-repository"},
+		"exp":       time.Now().Add(time.Minute).Unix(),
+		"scope":     "keys:read keys:sign",
+		"tenant_id": "tenant-1",
+	})
+
+	claims, err := VerifyJWT(token, j.keyFunc, &JWTVerifyOptions{
+		Issuer:   "https://issuer.example",
+		Audience: "key-repository",
+	})
+	j.NoError(err)
+	j.Equal("did:example:holder", claims.Subject)
+	j.Equal([]string{"keys:read", "keys:sign"}, claims.Scopes())
+	j.Equal("tenant-1", claims.Extra["tenant_id"])
+}
+
+func (j *JWTHelperTestSuite) TestJWTHelper_VerifyJWT_ExpectError() {
+	options := &JWTVerifyOptions{Issuer: "https://issuer.example", Audience: "key-repository"}
+	claims := map[string]interface{}{
+		"iss": "https://issuer.example",
+		"sub": "did:example:holder",
+		"aud": "key-repository",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	}
+
+	// Expect error on "none" algorithm
+	_, err := VerifyJWT(j.sign(map[string]interface{}{"alg": "none"}, claims), j.keyFunc, options)
+	j.Error(err)
+
+	// Expect error on algorithm not matching the key
+	_, err = VerifyJWT(j.sign(map[string]interface{}{"alg": "RS256"}, claims), j.keyFunc, options)
+	j.Error(err)
+
+	// Expect error on wrong audience
+	_, err = VerifyJWT(j.sign(map[string]interface{}{"alg": "ES256"}, claims), j.keyFunc, &JWTVerifyOptions{Audience: "other"})
+	j.Error(err)
+
+	// Expect error on tampered payload
+	token := j.sign(map[string]interface{}{"alg": "ES256"}, claims)
+	claims["sub"] = "did:example:attacker"
+	tampered := j.sign(map[string]interface{}{"alg": "ES256"}, claims)
+	_, err = VerifyJWT(token[:len(token)-86]+tampered[len(tampered)-86:], j.keyFunc, options)
+	j.Error(err)
+
+	// Expect error on expired token
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = VerifyJWT(j.sign(map[string]interface{}{"alg": "ES256"}, claims), j.keyFunc, options)
+	j.Error(err)
+}
+
+func (j *JWTHelperTestSuite) TestJWTHelper_SignJWSSignature_ExpectVerified() {
+	signingInput, err := JWTSigningInput(&JWTHeader{Alg: "ES256", Typ: "JWT"}, map[string]interface{}{
+		"iss": "https://issuer.example",
+		"aud": "key-repository",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	j.NoError(err)
+	signature, err := SignJWSSignature("ES256", j.privateKey, []byte(signingInput))
+	j.NoError(err)
+
+	claims, err := VerifyJWT(signingInput+"."+base64.RawURLEncoding.EncodeToString(signature), j.keyFunc, &JWTVerifyOptions{
+		Issuer:   "https://issuer.example",
+		Audience: "key-repository",
+	})
+	j.NoError(err)
+	j.Equal("https://issuer.example", claims.Issuer)
+
+	// Expect error when the algorithm does not match the key
+	_, err = SignJWSSignature("RS256", j.privateKey, []byte(signingInput))
+	j.Error(err)
+}
@@ -0,0 +1,25 @@
+package helpers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const KeyVersionSeparator = "@"
+
+// ParseKeyReference splits a key reference of the form "id" or "id@version".
+// A version of 0 means the latest version of the key.
+func ParseKeyReference(reference string) (string, int, error) {
+	parts := strings.SplitN(reference, KeyVersionSeparator, 2)
+	if len(parts) == 1 {
+		return parts[0], 0, nil
+	}
+
+	version, err := strconv.Atoi(parts[1])
+	if err != nil || version < 1 {
+		return "", 0, errors.New("invalid key version")
+	}
+
+	return parts[0], version, nil
+}
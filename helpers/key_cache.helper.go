@@ -0,0 +1,185 @@
+package helpers
+
+import (
+	"crypto"
+	"strings"
+	"sync"
+	"time"
+)
+
+type KeyCacheStats struct {
+	Entries   int     `json:"entries"`
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	Evictions uint64  `json:"evictions"`
+	HitRate   float64 `json:"hit_rate"`
+}
+
+type keyCacheEntry struct {
+	privateKey  crypto.PrivateKey
+	fingerprint string
+	expiresAt   time.Time
+	usesLeft    int
+	// the key is zeroized once it is evicted and no caller is still signing with it
+	references int
+	evicted    bool
+}
+
+// KeyCache keeps parsed private keys in locked memory for a short time so signing does not go through the HSM
+// every time, entries are zeroized when they expire, run out of uses or are invalidated
+type KeyCache struct {
+	mutex      sync.Mutex
+	entries    map[string]*keyCacheEntry
+	maxEntries int
+	maxTTL     time.Duration
+	stats      KeyCacheStats
+}
+
+func NewKeyCache(maxEntries int, maxTTL time.Duration) *KeyCache {
+	return &KeyCache{
+		entries:    make(map[string]*keyCacheEntry),
+		maxEntries: maxEntries,
+		maxTTL:     maxTTL,
+	}
+}
+
+// Take returns the cached key for id when it was cached from the same encrypted key and spends uses of it,
+// release must be called once the key is not used anymore
+func (c *KeyCache) Take(id string, fingerprint string, uses int) (privateKey crypto.PrivateKey, release func(), ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[id]
+	if ok && (entry.fingerprint != fingerprint || time.Now().After(entry.expiresAt) || entry.usesLeft < uses) {
+		c.evict(id)
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return nil, nil, false
+	}
+
+	c.stats.Hits++
+	entry.usesLeft -= uses
+	entry.references++
+	if entry.usesLeft == 0 {
+		c.evict(id)
+	}
+
+	return entry.privateKey, c.releaser(entry), true
+}
+
+// Put caches a parsed private key that the caller is about to use uses times, release must be called once the caller
+// does not use the key anymore. It returns false when the key is not cached and stays owned by the caller
+func (c *KeyCache) Put(id string, fingerprint string, privateKey crypto.PrivateKey, ttl time.Duration, maxUses int, uses int) (release func(), ok bool) {
+	if ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+	if ttl <= 0 || (maxUses > 0 && maxUses <= uses) {
+		return nil, false
+	}
+	if maxUses <= 0 {
+		maxUses = int(^uint(0) >> 1)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.sweep()
+	c.evict(id)
+	if len(c.entries) >= c.maxEntries {
+		return nil, false
+	}
+	if err := LockPrivateKey(privateKey); err != nil {
+		return nil, false
+	}
+
+	entry := &keyCacheEntry{
+		privateKey:  privateKey,
+		fingerprint: fingerprint,
+		expiresAt:   time.Now().Add(ttl),
+		usesLeft:    maxUses - uses,
+		references:  1,
+	}
+	c.entries[id] = entry
+
+	return c.releaser(entry), true
+}
+
+// Invalidate drops every cached version of a key, cache ids are "id@version"
+func (c *KeyCache) Invalidate(keyID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for id := range c.entries {
+		if strings.HasPrefix(id, keyID+"@") {
+			c.evict(id)
+		}
+	}
+}
+
+// Sweep drops the expired entries
+func (c *KeyCache) Sweep() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.sweep()
+}
+
+func (c *KeyCache) Stats() KeyCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	if stats.Hits+stats.Misses > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(stats.Hits+stats.Misses)
+	}
+
+	return stats
+}
+
+func (c *KeyCache) sweep() {
+	now := time.Now()
+	for id, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			c.evict(id)
+		}
+	}
+}
+
+func (c *KeyCache) evict(id string) {
+	entry, ok := c.entries[id]
+	if !ok {
+		return
+	}
+
+	delete(c.entries, id)
+	c.stats.Evictions++
+	entry.evicted = true
+	if entry.references == 0 {
+		ZeroizePrivateKey(entry.privateKey)
+	}
+}
+
+func (c *KeyCache) releaser(entry *keyCacheEntry) func() {
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			c.mutex.Lock()
+			defer c.mutex.Unlock()
+
+			entry.references--
+			if entry.evicted && entry.references == 0 {
+				ZeroizePrivateKey(entry.privateKey)
+			}
+		})
+	}
+}
+
+// SweepKeyCache drops expired keys every interval so they do not stay in memory until the next lookup
+func SweepKeyCache(cache *KeyCache, interval time.Duration) {
+	for range time.Tick(interval) {
+		cache.Sweep()
+	}
+}
@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type KeyCacheHelperTestSuite struct {
+	suite.Suite
+	privateKey *ecdsa.PrivateKey
+}
+
+func TestKeyCacheHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyCacheHelperTestSuite))
+}
+
+func (s *KeyCacheHelperTestSuite) SetupTest() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	s.privateKey = privateKey
+}
+
+func (s *KeyCacheHelperTestSuite) TestKeyCache_MaxUses() {
+	cache := NewKeyCache(10, time.Minute)
+
+	release, ok := cache.Put("key@1", "fingerprint", s.privateKey, time.Minute, 3, 1)
+	s.True(ok)
+	release()
+
+	cached, release, ok := cache.Take("key@1", "fingerprint", 2)
+	s.True(ok)
+	s.Equal(s.privateKey, cached)
+
+	// The last use evicts the key but it is only zeroized once released
+	s.NotZero(s.privateKey.D.Sign())
+	release()
+	s.Zero(s.privateKey.D.Sign())
+
+	_, _, ok = cache.Take("key@1", "fingerprint", 1)
+	s.False(ok)
+
+	stats := cache.Stats()
+	s.Equal(uint64(1), stats.Hits)
+	s.Equal(uint64(1), stats.Misses)
+	s.Equal(0.5, stats.HitRate)
+	s.Zero(stats.Entries)
+}
+
+func (s *KeyCacheHelperTestSuite) TestKeyCache_Invalidate() {
+	cache := NewKeyCache(10, time.Minute)
+
+	release, ok := cache.Put("key@1", "fingerprint", s.privateKey, time.Hour, 0, 1)
+	s.True(ok)
+	release()
+
+	// Another encrypted key under the same version is never served
+	_, _, ok = cache.Take("key@1", "other-fingerprint", 1)
+	s.False(ok)
+	s.Zero(s.privateKey.D.Sign())
+
+	s.SetupTest()
+	release, ok = cache.Put("key@2", "fingerprint", s.privateKey, time.Hour, 0, 1)
+	s.True(ok)
+	release()
+	cache.Invalidate("key")
+	s.Zero(s.privateKey.D.Sign())
+	s.Zero(cache.Stats().Entries)
+}
+
+func (s *KeyCacheHelperTestSuite) TestKeyCache_Disabled() {
+	cache := NewKeyCache(10, time.Minute)
+
+	_, ok := cache.Put("key@1", "fingerprint", s.privateKey, 0, 0, 1)
+	s.False(ok)
+	_, ok = cache.Put("key@1", "fingerprint", s.privateKey, time.Minute, 1, 1)
+	s.False(ok)
+	s.NotZero(s.privateKey.D.Sign())
+}
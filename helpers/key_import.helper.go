@@ -0,0 +1,278 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+
+	"github.com/youmark/pkcs8"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+)
+
+const minRSAKeySize = 2048
+
+var (
+	ErrPrivateKeyFormat           = errors.New("import: the private key is not a JWK, PKCS #8, PKCS #1 or SEC 1 key")
+	ErrPrivateKeyPasswordRequired = errors.New("import: the private key is encrypted and needs a password")
+	ErrUnsupportedPrivateKey      = errors.New("import: only P-256 and RSA keys of 2048 bits or more are supported")
+	ErrPublicKeyFormat            = errors.New("import: the public key is not a PKIX PEM")
+)
+
+// privateJWK keeps the private members as SecretBytes so they are decoded into buffers that can be zeroed
+type privateJWK struct {
+	Kty string      `json:"kty"`
+	Crv string      `json:"crv"`
+	X   string      `json:"x"`
+	Y   string      `json:"y"`
+	N   string      `json:"n"`
+	E   string      `json:"e"`
+	D   SecretBytes `json:"d"`
+	P   SecretBytes `json:"p"`
+	Q   SecretBytes `json:"q"`
+	DP  SecretBytes `json:"dp"`
+	DQ  SecretBytes `json:"dq"`
+	QI  SecretBytes `json:"qi"`
+}
+
+func (k *privateJWK) zeroize() {
+	Zeroize(k.D)
+	Zeroize(k.P)
+	Zeroize(k.Q)
+	Zeroize(k.DP)
+	Zeroize(k.DQ)
+	Zeroize(k.QI)
+}
+
+// ImportedKey is a private key in the form keys are stored in, PrivateKey must be zeroed by the caller
+type ImportedKey struct {
+	KeyType    consts.KeyType
+	PublicKey  string
+	PrivateKey []byte
+}
+
+// ImportPrivateKey parses a JWK, a PKCS #8 PEM that may be encrypted with password, a PKCS #1 RSA PEM or a SEC 1 EC PEM,
+// checks that the key is supported and returns it with its public key in the normalized form:
+// a SEC 1 or PKCS #1 private key PEM and a PKIX public key PEM
+func ImportPrivateKey(data []byte, password []byte) (*ImportedKey, error) {
+	privateKey, err := parseImportedPrivateKey(data, password)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroizePrivateKey(privateKey)
+
+	switch privateKey := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		if privateKey.Curve != elliptic.P256() {
+			return nil, ErrUnsupportedPrivateKey
+		}
+		der, err := x509.MarshalECPrivateKey(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		defer Zeroize(der)
+
+		return newImportedKey(consts.KeyTypeECDSA, &privateKey.PublicKey, EncodePEM("EC PRIVATE KEY", der))
+	case *rsa.PrivateKey:
+		if privateKey.N.BitLen() < minRSAKeySize {
+			return nil, ErrUnsupportedPrivateKey
+		}
+		der := x509.MarshalPKCS1PrivateKey(privateKey)
+		defer Zeroize(der)
+
+		return newImportedKey(consts.KeyTypeRSA, &privateKey.PublicKey, EncodePEM("RSA PRIVATE KEY", der))
+	}
+
+	return nil, ErrUnsupportedPrivateKey
+}
+
+func newImportedKey(keyType consts.KeyType, publicKey crypto.PublicKey, privateKey []byte) (*ImportedKey, error) {
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		Zeroize(privateKey)
+		return nil, err
+	}
+
+	return &ImportedKey{
+		KeyType:    keyType,
+		PublicKey:  string(EncodePEM("PUBLIC KEY", publicDER)),
+		PrivateKey: privateKey,
+	}, nil
+}
+
+// SamePublicKey tells whether publicKeyPEM is a public key PEM of the same key as other
+func SamePublicKey(publicKeyPEM string, other string) (bool, error) {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return false, err
+	}
+	otherPublicKey, err := parsePublicKeyPEM(other)
+	if err != nil {
+		return false, err
+	}
+
+	switch publicKey := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		return publicKey.Equal(otherPublicKey), nil
+	case *rsa.PublicKey:
+		return publicKey.Equal(otherPublicKey), nil
+	case BBSPublicKey:
+		return publicKey.Equal(otherPublicKey), nil
+	}
+
+	return false, ErrPublicKeyFormat
+}
+
+// PublicKeyFingerprint parses a PKIX public key PEM or the public key PEM of a BLS12381G2 key and returns
+// the hex SHA-256 of its DER, or of its compressed point, with the key
+func PublicKeyFingerprint(publicKeyPEM string) (string, crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return "", nil, ErrPublicKeyFormat
+	}
+
+	var publicKey crypto.PublicKey
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		publicKey, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case consts.BBSPublicKeyPEMType:
+		publicKey, err = ParseBBSPublicKey(block.Bytes)
+	default:
+		return "", nil, ErrPublicKeyFormat
+	}
+	if err != nil {
+		return "", nil, ErrPublicKeyFormat
+	}
+	digest := sha256.Sum256(block.Bytes)
+
+	return hex.EncodeToString(digest[:]), publicKey, nil
+}
+
+func parsePublicKeyPEM(publicKeyPEM string) (crypto.PublicKey, error) {
+	_, publicKey, err := PublicKeyFingerprint(publicKeyPEM)
+	return publicKey, err
+}
+
+func parseImportedPrivateKey(data []byte, password []byte) (crypto.PrivateKey, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return parsePrivateJWK(trimmed)
+	}
+
+	blockType, der, err := DecodePEM(data)
+	if err != nil {
+		return nil, ErrPrivateKeyFormat
+	}
+	defer Zeroize(der)
+
+	var privateKey crypto.PrivateKey
+	switch blockType {
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(der)
+	case "ENCRYPTED PRIVATE KEY":
+		if len(password) == 0 {
+			return nil, ErrPrivateKeyPasswordRequired
+		}
+		privateKey, err = pkcs8.ParsePKCS8PrivateKey(der, password)
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(der)
+	default:
+		return nil, ErrPrivateKeyFormat
+	}
+	if err != nil {
+		return nil, ErrPrivateKeyFormat
+	}
+
+	return privateKey, nil
+}
+
+// parsePrivateJWK derives the public key from the private members and rejects a JWK whose public members disagree
+func parsePrivateJWK(data []byte) (crypto.PrivateKey, error) {
+	jwk := &privateJWK{}
+	defer jwk.zeroize()
+	if err := json.Unmarshal(data, jwk); err != nil || len(jwk.D) == 0 {
+		return nil, ErrPrivateKeyFormat
+	}
+
+	d, err := decodeJWKSecret(jwk.D)
+	if err != nil {
+		return nil, err
+	}
+
+	switch jwk.Kty {
+	case "EC":
+		if jwk.Crv != "P-256" {
+			ZeroizePrivateKey(&ecdsa.PrivateKey{D: d})
+			return nil, ErrUnsupportedPrivateKey
+		}
+		curve := elliptic.P256()
+		if d.Sign() <= 0 || d.Cmp(curve.Params().N) >= 0 {
+			ZeroizePrivateKey(&ecdsa.PrivateKey{D: d})
+			return nil, ErrPrivateKeyFormat
+		}
+		privateKey := &ecdsa.PrivateKey{PublicKey: ecdsa.PublicKey{Curve: curve}, D: d}
+		privateKey.X, privateKey.Y = curve.ScalarBaseMult(d.Bytes())
+
+		x, xErr := decodeJWKPublic(jwk.X)
+		y, yErr := decodeJWKPublic(jwk.Y)
+		if xErr != nil || yErr != nil || x.Cmp(privateKey.X) != 0 || y.Cmp(privateKey.Y) != 0 {
+			ZeroizePrivateKey(privateKey)
+			return nil, ErrPrivateKeyFormat
+		}
+
+		return privateKey, nil
+	case "RSA":
+		n, nErr := decodeJWKPublic(jwk.N)
+		e, eErr := decodeJWKPublic(jwk.E)
+		p, pErr := decodeJWKSecret(jwk.P)
+		q, qErr := decodeJWKSecret(jwk.Q)
+		privateKey := &rsa.PrivateKey{D: d, Primes: []*big.Int{p, q}}
+		if nErr != nil || eErr != nil || pErr != nil || qErr != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			ZeroizePrivateKey(privateKey)
+			return nil, ErrPrivateKeyFormat
+		}
+		privateKey.PublicKey = rsa.PublicKey{N: n, E: int(e.Int64())}
+		if err := privateKey.Validate(); err != nil {
+			ZeroizePrivateKey(privateKey)
+			return nil, ErrPrivateKeyFormat
+		}
+		privateKey.Precompute()
+
+		return privateKey, nil
+	}
+
+	ZeroizePrivateKey(&ecdsa.PrivateKey{D: d})
+	return nil, ErrUnsupportedPrivateKey
+}
+
+func decodeJWKSecret(value SecretBytes) (*big.Int, error) {
+	decoded := make([]byte, base64.RawURLEncoding.DecodedLen(len(value)))
+	defer Zeroize(decoded)
+	n, err := base64.RawURLEncoding.Decode(decoded, value)
+	if err != nil || n == 0 {
+		return nil, ErrPrivateKeyFormat
+	}
+
+	return new(big.Int).SetBytes(decoded[:n]), nil
+}
+
+func decodeJWKPublic(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(decoded) == 0 {
+		return nil, ErrPrivateKeyFormat
+	}
+
+	return new(big.Int).SetBytes(decoded), nil
+}
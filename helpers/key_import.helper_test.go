@@ -0,0 +1,152 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/youmark/pkcs8"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+)
+
+type KeyImportHelperTestSuite struct {
+	suite.Suite
+	ecdsaKey *ecdsa.PrivateKey
+	rsaKey   *rsa.PrivateKey
+}
+
+func TestKeyImportHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyImportHelperTestSuite))
+}
+
+func (s *KeyImportHelperTestSuite) SetupSuite() {
+	var err error
+	s.ecdsaKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	s.rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+}
+
+func (s *KeyImportHelperTestSuite) encode(blockType string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func (s *KeyImportHelperTestSuite) jwk(members map[string]interface{}) []byte {
+	data, err := json.Marshal(members)
+	s.Require().NoError(err)
+	return data
+}
+
+func (s *KeyImportHelperTestSuite) b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (s *KeyImportHelperTestSuite) TestImportPrivateKey_ECDSA() {
+	sec1, err := x509.MarshalECPrivateKey(s.ecdsaKey)
+	s.Require().NoError(err)
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(s.ecdsaKey)
+	s.Require().NoError(err)
+	encrypted, err := pkcs8.ConvertPrivateKeyToPKCS8(s.ecdsaKey, []byte("secret"))
+	s.Require().NoError(err)
+	jwk := s.jwk(map[string]interface{}{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   s.b64(s.ecdsaKey.X.FillBytes(make([]byte, 32))),
+		"y":   s.b64(s.ecdsaKey.Y.FillBytes(make([]byte, 32))),
+		"d":   s.b64(s.ecdsaKey.D.FillBytes(make([]byte, 32))),
+	})
+
+	expected := s.encode("EC PRIVATE KEY", sec1)
+	for _, data := range [][]byte{expected, s.encode("PRIVATE KEY", pkcs8DER), jwk} {
+		imported, err := ImportPrivateKey(data, nil)
+		s.NoError(err)
+		s.Equal(consts.KeyTypeECDSA, imported.KeyType)
+		s.Equal(string(expected), string(imported.PrivateKey))
+	}
+
+	imported, err := ImportPrivateKey(s.encode("ENCRYPTED PRIVATE KEY", encrypted), []byte("secret"))
+	s.NoError(err)
+	s.Equal(string(expected), string(imported.PrivateKey))
+
+	_, err = ImportPrivateKey(s.encode("ENCRYPTED PRIVATE KEY", encrypted), nil)
+	s.Equal(ErrPrivateKeyPasswordRequired, err)
+	_, err = ImportPrivateKey(s.encode("ENCRYPTED PRIVATE KEY", encrypted), []byte("wrong"))
+	s.Equal(ErrPrivateKeyFormat, err)
+}
+
+func (s *KeyImportHelperTestSuite) TestImportPrivateKey_RSA() {
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(s.rsaKey)
+	s.Require().NoError(err)
+	jwk := s.jwk(map[string]interface{}{
+		"kty": "RSA",
+		"n":   s.b64(s.rsaKey.N.Bytes()),
+		"e":   "AQAB",
+		"d":   s.b64(s.rsaKey.D.Bytes()),
+		"p":   s.b64(s.rsaKey.Primes[0].Bytes()),
+		"q":   s.b64(s.rsaKey.Primes[1].Bytes()),
+	})
+
+	expected := s.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(s.rsaKey))
+	for _, data := range [][]byte{expected, s.encode("PRIVATE KEY", pkcs8DER), jwk} {
+		imported, err := ImportPrivateKey(data, nil)
+		s.NoError(err)
+		s.Equal(consts.KeyTypeRSA, imported.KeyType)
+		s.Equal(string(expected), string(imported.PrivateKey))
+
+		same, err := SamePublicKey(imported.PublicKey, imported.PublicKey)
+		s.NoError(err)
+		s.True(same)
+	}
+}
+
+func (s *KeyImportHelperTestSuite) TestImportPrivateKey_ExpectError() {
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	s.Require().NoError(err)
+	_, err = ImportPrivateKey(s.encode("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(smallKey)), nil)
+	s.Equal(ErrUnsupportedPrivateKey, err)
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	s.Require().NoError(err)
+	sec1, err := x509.MarshalECPrivateKey(p384Key)
+	s.Require().NoError(err)
+	_, err = ImportPrivateKey(s.encode("EC PRIVATE KEY", sec1), nil)
+	s.Equal(ErrUnsupportedPrivateKey, err)
+
+	_, err = ImportPrivateKey([]byte("not a key"), nil)
+	s.Equal(ErrPrivateKeyFormat, err)
+
+	// the public members of the JWK belong to another key
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	_, err = ImportPrivateKey(s.jwk(map[string]interface{}{
+		"kty": "EC",
+		"crv": "P-256",
+		"x":   s.b64(other.X.FillBytes(make([]byte, 32))),
+		"y":   s.b64(other.Y.FillBytes(make([]byte, 32))),
+		"d":   s.b64(s.ecdsaKey.D.FillBytes(make([]byte, 32))),
+	}), nil)
+	s.Equal(ErrPrivateKeyFormat, err)
+
+	ecdsaImported, err := ImportPrivateKey(s.encode("EC PRIVATE KEY", mustMarshalEC(s.ecdsaKey)), nil)
+	s.Require().NoError(err)
+	otherImported, err := ImportPrivateKey(s.encode("EC PRIVATE KEY", mustMarshalEC(other)), nil)
+	s.Require().NoError(err)
+	same, err := SamePublicKey(ecdsaImported.PublicKey, otherImported.PublicKey)
+	s.NoError(err)
+	s.False(same)
+}
+
+func mustMarshalEC(privateKey *ecdsa.PrivateKey) []byte {
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		panic(err)
+	}
+	return der
+}
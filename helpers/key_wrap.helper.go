@@ -0,0 +1,116 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+)
+
+const keyWrapAESKeySize = 32
+
+// WrapKeyRSAOAEPAESKWP wraps key the way cloud KMS imports do: a random AES-256 key encrypted with RSA-OAEP SHA-256
+// followed by key wrapped with that AES key using AES-KWP, a label binds data to the wrapping, cloud KMS imports use none
+func WrapKeyRSAOAEPAESKWP(publicKey *rsa.PublicKey, key []byte, label []byte) ([]byte, error) {
+	kek := make([]byte, keyWrapAESKeySize)
+	defer Zeroize(kek)
+	if _, err := rand.Read(kek); err != nil {
+		return nil, err
+	}
+
+	wrappedKEK, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey, kek, label)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := WrapKeyAESKWP(kek, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(wrappedKEK, wrappedKey...), nil
+}
+
+// UnwrapKeyRSAOAEPAESKWP reverses WrapKeyRSAOAEPAESKWP with the same label, the returned key must be zeroed by the caller
+func UnwrapKeyRSAOAEPAESKWP(privateKey *rsa.PrivateKey, wrapped []byte, label []byte) ([]byte, error) {
+	size := privateKey.Size()
+	if len(wrapped) <= size {
+		return nil, ErrKeyUnwrap
+	}
+
+	kek, err := rsa.DecryptOAEP(sha256.New(), nil, privateKey, wrapped[:size], label)
+	if err != nil || len(kek) != keyWrapAESKeySize {
+		Zeroize(kek)
+		return nil, ErrKeyUnwrap
+	}
+	defer Zeroize(kek)
+
+	return UnwrapKeyAESKWP(kek, wrapped[size:])
+}
+
+// WrapKeyECDHAESKWP wraps key with an AES-256 key derived with HKDF-SHA256 from an ECDH agreement between a fresh
+// key pair and publicKey, the result is the uncompressed fresh public key followed by the AES-KWP wrapped key
+func WrapKeyECDHAESKWP(publicKey *ecdsa.PublicKey, key []byte, info []byte) ([]byte, error) {
+	ephemeral, err := ecdsa.GenerateKey(publicKey.Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	defer ZeroizePrivateKey(ephemeral)
+
+	kek, err := deriveECDHKEK(ephemeral, publicKey, info)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(kek)
+
+	wrappedKey, err := WrapKeyAESKWP(kek, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(elliptic.Marshal(ephemeral.Curve, ephemeral.X, ephemeral.Y), wrappedKey...), nil
+}
+
+// UnwrapKeyECDHAESKWP reverses WrapKeyECDHAESKWP, the returned key must be zeroed by the caller
+func UnwrapKeyECDHAESKWP(privateKey *ecdsa.PrivateKey, wrapped []byte, info []byte) ([]byte, error) {
+	size := 1 + 2*((privateKey.Curve.Params().BitSize+7)/8)
+	if len(wrapped) <= size {
+		return nil, ErrKeyUnwrap
+	}
+	x, y := elliptic.Unmarshal(privateKey.Curve, wrapped[:size])
+	if x == nil {
+		return nil, ErrKeyUnwrap
+	}
+
+	kek, err := deriveECDHKEK(privateKey, &ecdsa.PublicKey{Curve: privateKey.Curve, X: x, Y: y}, info)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(kek)
+
+	return UnwrapKeyAESKWP(kek, wrapped[size:])
+}
+
+// deriveECDHKEK runs HKDF-SHA256 without salt over the x coordinate of the shared point,
+// one HMAC block is enough for an AES-256 key
+func deriveECDHKEK(privateKey *ecdsa.PrivateKey, publicKey *ecdsa.PublicKey, info []byte) ([]byte, error) {
+	if !privateKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return nil, errors.New("ecdh: the public key is not on the curve")
+	}
+	x, _ := privateKey.Curve.ScalarMult(publicKey.X, publicKey.Y, privateKey.D.Bytes())
+	shared := x.FillBytes(make([]byte, (privateKey.Curve.Params().BitSize+7)/8))
+	defer Zeroize(shared)
+
+	extract := hmac.New(sha256.New, make([]byte, sha256.Size))
+	extract.Write(shared)
+	prk := extract.Sum(nil)
+	defer Zeroize(prk)
+
+	expand := hmac.New(sha256.New, prk)
+	expand.Write(info)
+	expand.Write([]byte{1})
+
+	return expand.Sum(nil)[:keyWrapAESKeySize], nil
+}
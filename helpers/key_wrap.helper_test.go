@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type KeyWrapHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestKeyWrapHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyWrapHelperTestSuite))
+}
+
+func (s *KeyWrapHelperTestSuite) decodeHex(value string) []byte {
+	b, err := hex.DecodeString(value)
+	s.Require().NoError(err)
+	return b
+}
+
+// vectors of RFC 5649 section 6
+func (s *KeyWrapHelperTestSuite) TestAESKWP_RFC5649() {
+	kek := s.decodeHex("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+	vectors := map[string]string{
+		"c37b7e6492584340bed12207808941155068f738": "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a",
+		"466f7250617369":                           "afbeb0f07dfbf5419200f2ccb50bb24f",
+	}
+	for plaintext, ciphertext := range vectors {
+		wrapped, err := WrapKeyAESKWP(kek, s.decodeHex(plaintext))
+		s.NoError(err)
+		s.Equal(ciphertext, hex.EncodeToString(wrapped))
+
+		unwrapped, err := UnwrapKeyAESKWP(kek, wrapped)
+		s.NoError(err)
+		s.Equal(plaintext, hex.EncodeToString(unwrapped))
+
+		wrapped[len(wrapped)-1] ^= 1
+		_, err = UnwrapKeyAESKWP(kek, wrapped)
+		s.Equal(ErrKeyUnwrap, err)
+	}
+}
+
+func (s *KeyWrapHelperTestSuite) TestRSAOAEPAESKWP() {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+	key := []byte("a private key that is not a multiple of eight bytes")
+
+	wrapped, err := WrapKeyRSAOAEPAESKWP(&privateKey.PublicKey, key, []byte("label"))
+	s.NoError(err)
+	unwrapped, err := UnwrapKeyRSAOAEPAESKWP(privateKey, wrapped, []byte("label"))
+	s.NoError(err)
+	s.Equal(key, unwrapped)
+
+	_, err = UnwrapKeyRSAOAEPAESKWP(privateKey, wrapped, []byte("other"))
+	s.Equal(ErrKeyUnwrap, err)
+
+	wrapped[0] ^= 1
+	_, err = UnwrapKeyRSAOAEPAESKWP(privateKey, wrapped, []byte("label"))
+	s.Equal(ErrKeyUnwrap, err)
+}
+
+func (s *KeyWrapHelperTestSuite) TestECDHAESKWP() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	key := []byte("a private key that is not a multiple of eight bytes")
+
+	wrapped, err := WrapKeyECDHAESKWP(&privateKey.PublicKey, key, []byte("info"))
+	s.NoError(err)
+	unwrapped, err := UnwrapKeyECDHAESKWP(privateKey, wrapped, []byte("info"))
+	s.NoError(err)
+	s.Equal(key, unwrapped)
+
+	_, err = UnwrapKeyECDHAESKWP(privateKey, wrapped, []byte("other"))
+	s.Equal(ErrKeyUnwrap, err)
+}
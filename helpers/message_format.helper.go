@@ -0,0 +1,49 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+)
+
+// IsMessageFormat reports whether message is well formed in the given format, any message is text
+func IsMessageFormat(message string, format consts.MessageFormat) bool {
+	switch format {
+	case consts.MessageFormatText:
+		return true
+	case consts.MessageFormatHex:
+		_, err := hex.DecodeString(message)
+		return err == nil && message != ""
+	case consts.MessageFormatBase64:
+		_, err := DecodeBase64(message)
+		return err == nil && message != ""
+	case consts.MessageFormatJSON:
+		return json.Valid([]byte(message))
+	case consts.MessageFormatJWS:
+		parts := strings.Split(message, ".")
+		if len(parts) != 2 {
+			return false
+		}
+		header, err := base64.RawURLEncoding.DecodeString(parts[0])
+		if err != nil || !json.Valid(header) {
+			return false
+		}
+		_, err = base64.RawURLEncoding.DecodeString(parts[1])
+		return err == nil
+	}
+
+	return false
+}
+
+// DecodeBase64 accepts standard and unpadded URL-safe base64
+func DecodeBase64(message string) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(message)
+	if err != nil {
+		return base64.RawURLEncoding.DecodeString(message)
+	}
+
+	return decoded, nil
+}
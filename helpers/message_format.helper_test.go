@@ -0,0 +1,30 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+)
+
+type MessageFormatHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestMessageFormatHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(MessageFormatHelperTestSuite))
+}
+
+func (s *MessageFormatHelperTestSuite) TestIsMessageFormat() {
+	s.True(IsMessageFormat("anything at all", consts.MessageFormatText))
+	s.True(IsMessageFormat("0a1B", consts.MessageFormatHex))
+	s.False(IsMessageFormat("0a1", consts.MessageFormatHex))
+	s.True(IsMessageFormat("eyJhIjoxfQ==", consts.MessageFormatBase64))
+	s.True(IsMessageFormat("eyJhIjoxfQ", consts.MessageFormatBase64))
+	s.False(IsMessageFormat("not base64!", consts.MessageFormatBase64))
+	s.True(IsMessageFormat(`{"operation":"DID_REGISTER"}`, consts.MessageFormatJSON))
+	s.False(IsMessageFormat(`{"operation":`, consts.MessageFormatJSON))
+	s.True(IsMessageFormat("eyJhbGciOiJFUzI1NiJ9.eyJzdWIiOiIxIn0", consts.MessageFormatJWS))
+	s.False(IsMessageFormat("eyJhbGciOiJFUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln", consts.MessageFormatJWS))
+	s.False(IsMessageFormat("anything", "unknown"))
+}
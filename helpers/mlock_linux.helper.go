@@ -0,0 +1,82 @@
+// +build linux
+
+package helpers
+
+import (
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// lockedPages counts the locks on every page, munlock is not counted by the kernel and
+// unlocking the words of one key would otherwise unlock the words of other keys on the same page.
+// lockedBuffers keeps the buffers locked by LockMemory, unlocking any other buffer changes nothing
+var (
+	lockedPagesMutex sync.Mutex
+	lockedPages      = make(map[uintptr]int)
+	lockedBuffers    = make(map[uintptr]int)
+)
+
+// LockMemory keeps the pages of b out of swap, it needs RLIMIT_MEMLOCK to be large enough
+func LockMemory(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+
+	lockedPagesMutex.Lock()
+	defer lockedPagesMutex.Unlock()
+
+	if err := syscall.Mlock(b); err != nil {
+		return err
+	}
+	lockedBuffers[uintptr(unsafe.Pointer(&b[0]))]++
+	first, last := pageRange(b)
+	for page := first; page <= last; page += uintptr(syscall.Getpagesize()) {
+		lockedPages[page]++
+	}
+
+	return nil
+}
+
+// UnlockMemory releases a lock taken by LockMemory, the pages that still hold other locked memory stay locked
+func UnlockMemory(b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+
+	lockedPagesMutex.Lock()
+	defer lockedPagesMutex.Unlock()
+
+	start := uintptr(unsafe.Pointer(&b[0]))
+	if lockedBuffers[start] == 0 {
+		return nil
+	}
+	lockedBuffers[start]--
+	if lockedBuffers[start] == 0 {
+		delete(lockedBuffers, start)
+	}
+
+	pageSize := uintptr(syscall.Getpagesize())
+	first, last := pageRange(b)
+	for page := first; page <= last; page += pageSize {
+		if lockedPages[page] > 1 {
+			lockedPages[page]--
+			continue
+		}
+
+		delete(lockedPages, page)
+		if _, _, errno := syscall.Syscall(syscall.SYS_MUNLOCK, page, pageSize, 0); errno != 0 {
+			return errno
+		}
+	}
+
+	return nil
+}
+
+// pageRange returns the addresses of the first and the last page of b
+func pageRange(b []byte) (uintptr, uintptr) {
+	mask := ^(uintptr(syscall.Getpagesize()) - 1)
+	start := uintptr(unsafe.Pointer(&b[0]))
+
+	return start & mask, (start + uintptr(len(b)) - 1) & mask
+}
@@ -0,0 +1,39 @@
+// +build linux
+
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MlockHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestMlockHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(MlockHelperTestSuite))
+}
+
+func (m *MlockHelperTestSuite) TestUnlockMemory_ExpectSharedPageLocked() {
+	// two keys on the same page
+	b := make([]byte, 64)
+	first, second := b[:32], b[32:]
+	if err := LockMemory(first); err != nil {
+		m.T().Skipf("mlock is not allowed: %v", err)
+	}
+	m.Require().NoError(LockMemory(second))
+	page, _ := pageRange(b)
+	m.Equal(2, lockedPages[page])
+
+	// a buffer that was never locked does not release the page
+	m.Require().NoError(UnlockMemory(b[16:48]))
+	m.Equal(2, lockedPages[page])
+
+	m.Require().NoError(UnlockMemory(first))
+	m.Equal(1, lockedPages[page])
+
+	m.Require().NoError(UnlockMemory(second))
+	m.NotContains(lockedPages, page)
+}
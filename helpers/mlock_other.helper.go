@@ -0,0 +1,12 @@
+// +build !linux
+
+package helpers
+
+// LockMemory is not supported on this platform, memory may be swapped
+func LockMemory(b []byte) error {
+	return nil
+}
+
+func UnlockMemory(b []byte) error {
+	return nil
+}
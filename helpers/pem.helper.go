@@ -0,0 +1,173 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+)
+
+const pemLineLength = 64
+
+// DecodePEM decodes the first PEM block of data into a buffer the caller can zero once the DER is parsed,
+// unlike encoding/pem it does not copy the base64 text. Blocks with headers are not supported
+func DecodePEM(data []byte) (string, []byte, error) {
+	begin := []byte("-----BEGIN ")
+	start := bytes.Index(data, begin)
+	if start < 0 {
+		return "", nil, errors.New("pem: no block found")
+	}
+	rest := data[start+len(begin):]
+	typeEnd := bytes.Index(rest, []byte("-----"))
+	if typeEnd < 0 {
+		return "", nil, errors.New("pem: malformed header")
+	}
+	blockType := string(rest[:typeEnd])
+	rest = rest[typeEnd+5:]
+
+	end := bytes.Index(rest, []byte("-----END "+blockType+"-----"))
+	if end < 0 {
+		return "", nil, errors.New("pem: missing footer")
+	}
+	body := rest[:end]
+
+	der := make([]byte, base64.StdEncoding.DecodedLen(len(body)))
+	n := 0
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if bytes.IndexByte(line, ':') >= 0 {
+			Zeroize(der)
+			return "", nil, errors.New("pem: headers are not supported")
+		}
+
+		written, err := base64.StdEncoding.Decode(der[n:], line)
+		if err != nil {
+			Zeroize(der)
+			return "", nil, err
+		}
+		n += written
+	}
+
+	return blockType, der[:n], nil
+}
+
+// EncodePEM encodes der into a buffer of the exact size so no partial copy is left behind by growing it
+func EncodePEM(blockType string, der []byte) []byte {
+	header := "-----BEGIN " + blockType + "-----\n"
+	footer := "-----END " + blockType + "-----\n"
+	encodedLength := base64.StdEncoding.EncodedLen(len(der))
+	lines := (encodedLength + pemLineLength - 1) / pemLineLength
+
+	out := make([]byte, 0, len(header)+encodedLength+lines+len(footer))
+	out = append(out, header...)
+	for i := 0; i < len(der); i += pemLineLength / 4 * 3 {
+		end := i + pemLineLength/4*3
+		if end > len(der) {
+			end = len(der)
+		}
+		start := len(out)
+		out = out[:start+base64.StdEncoding.EncodedLen(end-i)]
+		base64.StdEncoding.Encode(out[start:], der[i:end])
+		out = append(out, '\n')
+	}
+
+	return append(out, footer...)
+}
+
+// ParseECDSAPrivateKeyPEM accepts SEC 1 and PKCS #8 keys, the decoded DER is zeroed
+func ParseECDSAPrivateKeyPEM(data []byte) (*ecdsa.PrivateKey, error) {
+	_, der, err := DecodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(der)
+
+	if privateKey, err := x509.ParseECPrivateKey(der); err == nil {
+		return privateKey, nil
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaPrivateKey, ok := privateKey.(*ecdsa.PrivateKey)
+	if !ok {
+		ZeroizePrivateKey(privateKey)
+		return nil, errors.New("pem: not an ECDSA private key")
+	}
+
+	return ecdsaPrivateKey, nil
+}
+
+// ParseRSAPrivateKeyPEM accepts PKCS #1 and PKCS #8 keys, the decoded DER is zeroed
+func ParseRSAPrivateKeyPEM(data []byte) (*rsa.PrivateKey, error) {
+	_, der, err := DecodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+	defer Zeroize(der)
+
+	if privateKey, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return privateKey, nil
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaPrivateKey, ok := privateKey.(*rsa.PrivateKey)
+	if !ok {
+		ZeroizePrivateKey(privateKey)
+		return nil, errors.New("pem: not an RSA private key")
+	}
+
+	return rsaPrivateKey, nil
+}
+
+// GenerateECDSAKeyPair returns a P-256 key pair as a PKIX public key PEM and a SEC 1 private key PEM,
+// the private key PEM must be zeroed by the caller
+func GenerateECDSAKeyPair() (string, []byte, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", nil, err
+	}
+	defer ZeroizePrivateKey(privateKey)
+
+	return encodeKeyPair(privateKey, &privateKey.PublicKey, "EC PRIVATE KEY", func() ([]byte, error) {
+		return x509.MarshalECPrivateKey(privateKey)
+	})
+}
+
+// GenerateRSAKeyPair returns a key pair as a PKIX public key PEM and a PKCS #1 private key PEM,
+// the private key PEM must be zeroed by the caller
+func GenerateRSAKeyPair(bits int) (string, []byte, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return "", nil, err
+	}
+	defer ZeroizePrivateKey(privateKey)
+
+	return encodeKeyPair(privateKey, &privateKey.PublicKey, "RSA PRIVATE KEY", func() ([]byte, error) {
+		return x509.MarshalPKCS1PrivateKey(privateKey), nil
+	})
+}
+
+func encodeKeyPair(privateKey interface{}, publicKey interface{}, privateKeyType string, marshalPrivateKey func() ([]byte, error)) (string, []byte, error) {
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	privateDER, err := marshalPrivateKey()
+	if err != nil {
+		return "", nil, err
+	}
+	defer Zeroize(privateDER)
+
+	return string(EncodePEM("PUBLIC KEY", publicDER)), EncodePEM(privateKeyType, privateDER), nil
+}
@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type PEMHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestPEMHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(PEMHelperTestSuite))
+}
+
+func (s *PEMHelperTestSuite) TestEncodePEM_MatchesEncodingPEM() {
+	for _, length := range []int{0, 1, 47, 48, 49, 121, 1190} {
+		der := make([]byte, length)
+		for i := range der {
+			der[i] = byte(i)
+		}
+
+		s.Equal(string(pem.EncodeToMemory(&pem.Block{Type: "TEST", Bytes: der})), string(EncodePEM("TEST", der)))
+
+		blockType, decoded, err := DecodePEM(EncodePEM("TEST", der))
+		s.NoError(err)
+		s.Equal("TEST", blockType)
+		s.Equal(der, decoded)
+	}
+}
+
+func (s *PEMHelperTestSuite) TestDecodePEM_ExpectError() {
+	_, _, err := DecodePEM([]byte("no block"))
+	s.Error(err)
+	_, _, err = DecodePEM([]byte("-----BEGIN TEST-----\nAAAA\n"))
+	s.Error(err)
+	_, _, err = DecodePEM([]byte("-----BEGIN TEST-----\nProc-Type: 4,ENCRYPTED\n\nAAAA\n-----END TEST-----\n"))
+	s.Error(err)
+}
+
+func (s *PEMHelperTestSuite) TestParsePrivateKeyPEM() {
+	_, ecdsaPrivateKey, err := GenerateECDSAKeyPair()
+	s.NoError(err)
+	_, err = ParseECDSAPrivateKeyPEM(ecdsaPrivateKey)
+	s.NoError(err)
+	_, err = ParseRSAPrivateKeyPEM(ecdsaPrivateKey)
+	s.Error(err)
+
+	_, rsaPrivateKey, err := GenerateRSAKeyPair(2048)
+	s.NoError(err)
+	_, err = ParseRSAPrivateKeyPEM(rsaPrivateKey)
+	s.NoError(err)
+	_, err = ParseECDSAPrivateKeyPEM(rsaPrivateKey)
+	s.Error(err)
+}
+
+func (s *PEMHelperTestSuite) TestSecretBytes_UnmarshalJSON() {
+	input := struct {
+		Secret SecretBytes `json:"secret"`
+	}{}
+	s.NoError(json.Unmarshal([]byte(`{"secret":"line\nnext \"q\" \\ \/ é \u00e9"}`), &input))
+	s.Equal("line\nnext \"q\" \\ / é é", string(input.Secret))
+
+	s.Error(json.Unmarshal([]byte(`{"secret":1}`), &input))
+	s.Error(json.Unmarshal([]byte(`{"secret":"\x"}`), &input))
+}
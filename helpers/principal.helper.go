@@ -0,0 +1,18 @@
+package helpers
+
+import (
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+// GetPrincipal returns the caller attached to the request, or the anonymous principal outside of a request
+func GetPrincipal(ctx core.IContext) *models.Principal {
+	if httpCtx, ok := ctx.(core.IHTTPContext); ok {
+		if principal, ok := httpCtx.Get(consts.ContextKeyPrincipal).(*models.Principal); ok && principal != nil {
+			return principal
+		}
+	}
+
+	return models.NewAnonymousPrincipal()
+}
@@ -0,0 +1,156 @@
+package helpers
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+const rateLimitRedisPrefix = "rate_limit:"
+
+const rateLimitSweepInterval = time.Minute
+
+// RateLimiter keeps token buckets by name
+type RateLimiter interface {
+	// Take removes a token from the bucket, it returns 0 when a token was available
+	// or how long to wait until the next one otherwise
+	Take(bucket string, limit *models.RateLimit) (time.Duration, error)
+}
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// fullAt is when the bucket is refilled to its burst, from then on it is the same as a new bucket
+	fullAt time.Time
+}
+
+// MemoryRateLimiter keeps the buckets of a single replica, buckets that refilled are evicted
+// so the map only holds the buckets in use
+type MemoryRateLimiter struct {
+	mutex         sync.Mutex
+	buckets       map[string]*tokenBucket
+	sweepInterval time.Duration
+	sweptAt       time.Time
+}
+
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{
+		buckets:       make(map[string]*tokenBucket),
+		sweepInterval: rateLimitSweepInterval,
+		sweptAt:       time.Now(),
+	}
+}
+
+func (l *MemoryRateLimiter) Take(bucket string, limit *models.RateLimit) (time.Duration, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+	b, ok := l.buckets[bucket]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit.Burst), updatedAt: now}
+		l.buckets[bucket] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updatedAt).Seconds()*limit.Rate)
+	b.updatedAt = now
+	wait := time.Duration(0)
+	if b.tokens >= 1 {
+		b.tokens--
+	} else {
+		wait = time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	}
+	b.fullAt = now.Add(time.Duration((float64(limit.Burst) - b.tokens) / limit.Rate * float64(time.Second)))
+
+	return wait, nil
+}
+
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.sweptAt) < l.sweepInterval {
+		return
+	}
+
+	for name, b := range l.buckets {
+		if !now.Before(b.fullAt) {
+			delete(l.buckets, name)
+		}
+	}
+	l.sweptAt = now
+}
+
+// rateLimitScript refills and takes from a bucket atomically, the clock of Redis is used
+// so replicas with skewed clocks share the same buckets
+var rateLimitScript = redis.NewScript(`
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated_at')
+local tokens = tonumber(bucket[1]) or burst
+local updatedAt = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updatedAt) / 1000 * rate)
+
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+else
+	wait = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated_at', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return wait
+`)
+
+// RedisRateLimiter keeps the buckets in Redis so every replica takes from the same buckets,
+// while Redis cannot be reached the buckets of the replica are used instead
+type RedisRateLimiter struct {
+	client   *redis.Client
+	fallback *MemoryRateLimiter
+}
+
+func NewRedisRateLimiter(addr string, password string) *RedisRateLimiter {
+	return &RedisRateLimiter{
+		client: redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: password,
+		}),
+		fallback: NewMemoryRateLimiter(),
+	}
+}
+
+// Take returns the error of Redis along with the wait of the fallback buckets, so the caller can report
+// the outage and still limit the bucket
+func (l *RedisRateLimiter) Take(bucket string, limit *models.RateLimit) (time.Duration, error) {
+	wait, err := rateLimitScript.Run(context.Background(), l.client, []string{rateLimitRedisPrefix + bucket},
+		strconv.FormatFloat(limit.Rate, 'f', -1, 64), limit.Burst).Int64()
+	if err != nil {
+		fallbackWait, _ := l.fallback.Take(bucket, limit)
+		return fallbackWait, err
+	}
+
+	return time.Duration(wait) * time.Millisecond, nil
+}
+
+// SetRetryAfter tells the caller of the request when to retry, in whole seconds
+func SetRetryAfter(ctx core.IContext, wait time.Duration) {
+	httpCtx, ok := ctx.(core.IHTTPContext)
+	if !ok {
+		return
+	}
+
+	seconds := int64(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	httpCtx.Response().Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+)
+
+type RateLimitHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestRateLimitHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitHelperTestSuite))
+}
+
+func (s *RateLimitHelperTestSuite) TestMemoryRateLimiter_Take() {
+	limiter := NewMemoryRateLimiter()
+	limit := &models.RateLimit{Rate: 1, Burst: 2}
+
+	wait, err := limiter.Take("client", limit)
+	s.NoError(err)
+	s.Zero(wait)
+	wait, err = limiter.Take("client", limit)
+	s.NoError(err)
+	s.Zero(wait)
+
+	// The burst is spent, the next token comes after about a second
+	wait, err = limiter.Take("client", limit)
+	s.NoError(err)
+	s.True(wait > 900*time.Millisecond && wait <= time.Second, wait)
+
+	// Other buckets are not affected
+	wait, err = limiter.Take("key", limit)
+	s.NoError(err)
+	s.Zero(wait)
+}
+
+func (s *RateLimitHelperTestSuite) TestMemoryRateLimiter_EvictsRefilledBuckets() {
+	limiter := NewMemoryRateLimiter()
+	limiter.sweepInterval = 0
+
+	wait, err := limiter.Take("slow", &models.RateLimit{Rate: 0.001, Burst: 1})
+	s.NoError(err)
+	s.Zero(wait)
+	_, err = limiter.Take("fast", &models.RateLimit{Rate: 1000, Burst: 1})
+	s.NoError(err)
+	s.Len(limiter.buckets, 2)
+
+	// The fast bucket is full again after a millisecond and is evicted, the slow one is still spent
+	time.Sleep(5 * time.Millisecond)
+	wait, err = limiter.Take("slow", &models.RateLimit{Rate: 0.001, Burst: 1})
+	s.NoError(err)
+	s.True(wait > 0)
+	s.Len(limiter.buckets, 1)
+	s.Contains(limiter.buckets, "slow")
+}
+
+func (s *RateLimitHelperTestSuite) TestRedisRateLimiter_FallsBackToMemory() {
+	limiter := NewRedisRateLimiter("127.0.0.1:1", "")
+	limit := &models.RateLimit{Rate: 1, Burst: 1}
+
+	// Without Redis the bucket is still limited, by the buckets of the replica
+	wait, err := limiter.Take("client", limit)
+	s.Error(err)
+	s.Zero(wait)
+	wait, err = limiter.Take("client", limit)
+	s.Error(err)
+	s.True(wait > 0)
+}
@@ -0,0 +1,29 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/labstack/echo/v4"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+// GetRequestID returns the request ID given by the caller or set by the server, empty outside of a request
+func GetRequestID(ctx core.IContext) string {
+	httpCtx, ok := ctx.(core.IHTTPContext)
+	if !ok {
+		return ""
+	}
+
+	if requestID := httpCtx.Response().Header().Get(echo.HeaderXRequestID); requestID != "" {
+		return requestID
+	}
+
+	return httpCtx.Request().Header.Get(echo.HeaderXRequestID)
+}
+
+// MessageDigest is the hex encoded SHA-256 of a message, used where the message itself must not be kept
+func MessageDigest(message string) string {
+	hash := sha256.Sum256([]byte(message))
+	return hex.EncodeToString(hash[:])
+}
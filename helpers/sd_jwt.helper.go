@@ -0,0 +1,124 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+const (
+	// SDJWTAlg is the "_sd_alg" of the digests of the disclosures
+	SDJWTAlg = "sha-256"
+	// SDJWTSeparator separates the issuer-signed JWT from the disclosures in the combined format
+	SDJWTSeparator = "~"
+	sdJWTSaltSize  = 16
+)
+
+var ErrSDJWTPath = errors.New("sd-jwt: the path must name a member of an object of the claims, other than _sd and _sd_alg")
+
+// SDJWTDigest is the base64url of the SHA-256 of the ASCII of the disclosure
+func SDJWTDigest(disclosure string) string {
+	hash := sha256.Sum256([]byte(disclosure))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// NewSDJWTDisclosure salts an object member and returns its disclosure, the base64url of the JSON array
+// [salt, name, value]
+func NewSDJWTDisclosure(name string, value interface{}) (string, error) {
+	salt := make([]byte, sdJWTSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal([]interface{}{base64.RawURLEncoding.EncodeToString(salt), name, value})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// ParseSDJWTPath splits a JSON Pointer (RFC 6901) to a member of the claims into its member names
+func ParseSDJWTPath(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") || pointer == "/" {
+		return nil, ErrSDJWTPath
+	}
+
+	names := strings.Split(pointer[1:], "/")
+	for i, name := range names {
+		names[i] = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+	}
+
+	return names, nil
+}
+
+// SelectivelyDisclose replaces the members of the claims at the paths by the sorted digests of their disclosures
+// in the "_sd" array of their object, nested paths are disclosed first so the disclosure of an object holds the
+// digests of its own disclosable members. The claims are not modified
+func SelectivelyDisclose(claims map[string]interface{}, paths [][]string) (map[string]interface{}, []string, error) {
+	disclosures := make([]string, 0, len(paths))
+	disclosed, err := selectivelyDisclose(claims, paths, &disclosures)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return disclosed, disclosures, nil
+}
+
+func selectivelyDisclose(object map[string]interface{}, paths [][]string, disclosures *[]string) (map[string]interface{}, error) {
+	disclosable := make(map[string]bool)
+	nested := make(map[string][][]string)
+	for _, path := range paths {
+		name := path[0]
+		if _, ok := object[name]; !ok || name == "_sd" || name == "_sd_alg" {
+			return nil, ErrSDJWTPath
+		}
+		if len(path) == 1 {
+			disclosable[name] = true
+		} else {
+			nested[name] = append(nested[name], path[1:])
+		}
+	}
+
+	result := make(map[string]interface{}, len(object))
+	for name, value := range object {
+		result[name] = value
+	}
+	for name, paths := range nested {
+		member, ok := object[name].(map[string]interface{})
+		if !ok {
+			return nil, ErrSDJWTPath
+		}
+		disclosed, err := selectivelyDisclose(member, paths, disclosures)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = disclosed
+	}
+
+	names := make([]string, 0, len(disclosable))
+	for name := range disclosable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	digests := make([]string, 0, len(names))
+	for _, name := range names {
+		disclosure, err := NewSDJWTDisclosure(name, result[name])
+		if err != nil {
+			return nil, err
+		}
+		*disclosures = append(*disclosures, disclosure)
+		digests = append(digests, SDJWTDigest(disclosure))
+		delete(result, name)
+	}
+	if len(digests) > 0 {
+		// sorted digests do not reveal the original order of the members
+		sort.Strings(digests)
+		result["_sd"] = digests
+	}
+
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SDJWTHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestSDJWTHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(SDJWTHelperTestSuite))
+}
+
+func (s *SDJWTHelperTestSuite) TestSDJWTDigest() {
+	// the example disclosure of the SD-JWT specification
+	s.Equal("uutlBuYeMDyjLLTpf6Jxi7yNkEF35jdyWMn9U7b_RYY",
+		SDJWTDigest("WyI2cU1RdlJMNWhhaiIsICJmYW1pbHlfbmFtZSIsICJNw7ZiaXVzIl0"))
+}
+
+func (s *SDJWTHelperTestSuite) TestParseSDJWTPath() {
+	path, err := ParseSDJWTPath("/address/street~1name~0")
+	s.NoError(err)
+	s.Equal([]string{"address", "street/name~"}, path)
+
+	_, err = ParseSDJWTPath("address")
+	s.Equal(ErrSDJWTPath, err)
+}
+
+func (s *SDJWTHelperTestSuite) TestSelectivelyDisclose() {
+	claims := map[string]interface{}{
+		"iss":         "did:example:issuer",
+		"given_name":  "Erika",
+		"family_name": "Mustermann",
+		"address": map[string]interface{}{
+			"street_address": "Heidestrasse 17",
+			"locality":       "Koeln",
+		},
+	}
+	disclosed, disclosures, err := SelectivelyDisclose(claims, [][]string{
+		{"given_name"}, {"address", "street_address"}, {"address"},
+	})
+	s.Require().NoError(err)
+	s.Len(disclosures, 3)
+	s.Equal("did:example:issuer", disclosed["iss"])
+	s.Equal("Mustermann", disclosed["family_name"])
+	s.NotContains(disclosed, "given_name")
+	s.NotContains(disclosed, "address")
+	s.Len(disclosed["_sd"], 2)
+	// the claims are not modified
+	s.Equal("Erika", claims["given_name"])
+
+	// every disclosure is referenced by a digest, the street address from the disclosure of the address
+	byName := make(map[string][]interface{})
+	for _, disclosure := range disclosures {
+		data, err := base64.RawURLEncoding.DecodeString(disclosure)
+		s.Require().NoError(err)
+		var decoded []interface{}
+		s.Require().NoError(json.Unmarshal(data, &decoded))
+		s.Len(decoded, 3)
+		byName[decoded[1].(string)] = append(decoded, SDJWTDigest(disclosure))
+	}
+	s.Equal("Erika", byName["given_name"][2])
+	s.Contains(disclosed["_sd"], byName["given_name"][3])
+	s.Contains(disclosed["_sd"], byName["address"][3])
+	address := byName["address"][2].(map[string]interface{})
+	s.Equal("Koeln", address["locality"])
+	s.Equal([]interface{}{byName["street_address"][3]}, address["_sd"])
+
+	// Expect error on a missing member and on a member of a value that is not an object
+	_, _, err = SelectivelyDisclose(claims, [][]string{{"birthdate"}})
+	s.Equal(ErrSDJWTPath, err)
+	_, _, err = SelectivelyDisclose(claims, [][]string{{"given_name", "first"}})
+	s.Equal(ErrSDJWTPath, err)
+}
@@ -0,0 +1,69 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"unicode/utf8"
+)
+
+// SecretBytes is a JSON string decoded straight into bytes, unlike a string it can be zeroed after use
+type SecretBytes []byte
+
+func (b *SecretBytes) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return errors.New("secret: must be a JSON string")
+	}
+	data = data[1 : len(data)-1]
+
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\\' {
+			out = append(out, data[i])
+			continue
+		}
+
+		i++
+		if i >= len(data) {
+			Zeroize(out)
+			return errors.New("secret: unterminated escape")
+		}
+		switch data[i] {
+		case '"', '\\', '/':
+			out = append(out, data[i])
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'u':
+			if i+4 >= len(data) {
+				Zeroize(out)
+				return errors.New("secret: malformed unicode escape")
+			}
+			code, err := strconv.ParseUint(string(data[i+1:i+5]), 16, 32)
+			if err != nil {
+				Zeroize(out)
+				return errors.New("secret: malformed unicode escape")
+			}
+			var encoded [utf8.UTFMax]byte
+			n := utf8.EncodeRune(encoded[:], rune(code))
+			out = append(out, encoded[:n]...)
+			i += 4
+		default:
+			Zeroize(out)
+			return errors.New("secret: unknown escape")
+		}
+	}
+
+	*b = out
+	return nil
+}
@@ -0,0 +1,101 @@
+package helpers
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"io"
+	"math/big"
+	"unsafe"
+)
+
+// privateKeyWords returns the big integers holding the secret parts of a parsed private key
+func privateKeyWords(privateKey crypto.PrivateKey) []*big.Int {
+	switch key := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		return []*big.Int{key.D}
+	case *rsa.PrivateKey:
+		ints := []*big.Int{key.D, key.Precomputed.Dp, key.Precomputed.Dq, key.Precomputed.Qinv}
+		ints = append(ints, key.Primes...)
+		for _, crt := range key.Precomputed.CRTValues {
+			ints = append(ints, crt.Exp, crt.Coeff, crt.R)
+		}
+		return ints
+	case *BBSPrivateKey:
+		return []*big.Int{key.D}
+	}
+
+	return nil
+}
+
+// wordBytes views the words of a big integer as bytes without copying them
+func wordBytes(n *big.Int) []byte {
+	if n == nil {
+		return nil
+	}
+
+	words := n.Bits()
+	if len(words) == 0 {
+		return nil
+	}
+
+	size := len(words) * int(unsafe.Sizeof(words[0]))
+	return (*[1 << 30]byte)(unsafe.Pointer(&words[0]))[:size:size]
+}
+
+// LockPrivateKey keeps the secret parts of a parsed private key out of swap.
+// Only the big integers of the key are locked and zeroed: from Go 1.20 rsa Precompute also keeps
+// unexported copies of the primes, which stay swappable and are left to the garbage collector
+func LockPrivateKey(privateKey crypto.PrivateKey) error {
+	for _, n := range privateKeyWords(privateKey) {
+		if err := LockMemory(wordBytes(n)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ZeroizePrivateKey overwrites the secret parts of a parsed private key and unlocks their memory,
+// the key must not be used afterwards
+func ZeroizePrivateKey(privateKey crypto.PrivateKey) {
+	for _, n := range privateKeyWords(privateKey) {
+		b := wordBytes(n)
+		Zeroize(b)
+		_ = UnlockMemory(b)
+		if n != nil {
+			n.SetInt64(0)
+		}
+	}
+}
+
+// Zeroize overwrites b with zeros
+func Zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// ReadAllZeroizing reads r to the end like ioutil.ReadAll but zeroes every buffer it outgrows, so what was read
+// only remains in the returned slice, which the caller zeroes once done
+func ReadAllZeroizing(r io.Reader) ([]byte, error) {
+	b := make([]byte, 0, 512)
+	for {
+		if len(b) == cap(b) {
+			grown := make([]byte, len(b), 2*cap(b))
+			copy(grown, b)
+			Zeroize(b)
+			b = grown
+		}
+
+		n, err := r.Read(b[len(b):cap(b)])
+		b = b[:len(b)+n]
+		if err == io.EOF {
+			return b, nil
+		}
+		if err != nil {
+			Zeroize(b)
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type SecureMemoryHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestSecureMemoryHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(SecureMemoryHelperTestSuite))
+}
+
+func (s *SecureMemoryHelperTestSuite) TestReadAllZeroizing() {
+	for _, size := range []int{0, 1, 511, 512, 513, 5000} {
+		data := bytes.Repeat([]byte{0xa5}, size)
+		read, err := ReadAllZeroizing(bytes.NewReader(data))
+		s.Require().NoError(err)
+		s.Equal(data, append([]byte{}, read...))
+	}
+
+	_, err := ReadAllZeroizing(io.MultiReader(bytes.NewReader([]byte("secret")), &failingReader{}))
+	s.Error(err)
+}
+
+type failingReader struct{}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
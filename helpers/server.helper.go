@@ -0,0 +1,38 @@
+package helpers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+// StartHTTPSServer serves over TLS and asks for client certificates signed by the configured CA,
+// clients without a certificate can still authenticate another way
+func StartHTTPSServer(e *echo.Echo, env core.IENV) error {
+	clientCAs := x509.NewCertPool()
+	clientCA, err := ioutil.ReadFile(env.String(consts.ENVTLSClientCAFile))
+	if err != nil {
+		return err
+	}
+	if !clientCAs.AppendCertsFromPEM(clientCA) {
+		return errors.New("no client CA certificate found")
+	}
+
+	server := &http.Server{
+		Addr:    env.String(consts.ENVHost),
+		Handler: e,
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			ClientAuth: tls.VerifyClientCertIfGiven,
+			ClientCAs:  clientCAs,
+		},
+	}
+
+	return server.ListenAndServeTLS(env.String(consts.ENVTLSCertFile), env.String(consts.ENVTLSKeyFile))
+}
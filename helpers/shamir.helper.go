@@ -0,0 +1,115 @@
+package helpers
+
+import (
+	"crypto/rand"
+	"errors"
+)
+
+var (
+	ErrShamirParameters = errors.New("shamir: the threshold must be between 2 and the number of shares, at most 255")
+	ErrShamirShares     = errors.New("shamir: the shares are not distinct shares of the same length")
+)
+
+// gf256Exp and gf256Log are the tables of GF(2^8) with the AES polynomial x^8 + x^4 + x^3 + x + 1 and generator 3
+var gf256Exp, gf256Log = newGF256Tables()
+
+func newGF256Tables() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var log [256]byte
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		exp[i] = x
+		log[x] = byte(i)
+		// multiply by the generator 3, that is x * 2 + x
+		doubled := x << 1
+		if x&0x80 != 0 {
+			doubled ^= 0x1b
+		}
+		x ^= doubled
+	}
+	// the second half saves a modulo in gf256Mul
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+
+	return exp, log
+}
+
+func gf256Mul(a byte, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gf256Exp[int(gf256Log[a])+int(gf256Log[b])]
+}
+
+func gf256Div(a byte, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gf256Exp[int(gf256Log[a])+255-int(gf256Log[b])]
+}
+
+// SplitSecret splits secret into n shares so that any threshold of them rebuild it and fewer reveal nothing.
+// A share is its x coordinate followed by the value of the polynomial of every byte of the secret at x
+func SplitSecret(secret []byte, n int, threshold int) ([][]byte, error) {
+	if threshold < 2 || threshold > n || n > 255 || len(secret) == 0 {
+		return nil, ErrShamirParameters
+	}
+
+	// coefficients[i] holds the random coefficients of degree 1 to threshold-1 of the polynomial of secret[i]
+	coefficients := make([]byte, len(secret)*(threshold-1))
+	defer Zeroize(coefficients)
+	if _, err := rand.Read(coefficients); err != nil {
+		return nil, err
+	}
+
+	shares := make([][]byte, n)
+	for s := range shares {
+		x := byte(s + 1)
+		share := make([]byte, len(secret)+1)
+		share[0] = x
+		for i, b := range secret {
+			// Horner's rule from the highest degree down to the secret byte
+			y := byte(0)
+			for d := threshold - 2; d >= 0; d-- {
+				y = gf256Mul(y, x) ^ coefficients[i*(threshold-1)+d]
+			}
+			share[i+1] = gf256Mul(y, x) ^ b
+		}
+		shares[s] = share
+	}
+
+	return shares, nil
+}
+
+// CombineShares rebuilds the secret from shares made by SplitSecret by Lagrange interpolation at 0,
+// shares of a different secret or fewer than the threshold give a wrong secret rather than an error
+func CombineShares(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, ErrShamirShares
+	}
+	size := len(shares[0])
+	seen := make(map[byte]bool)
+	for _, share := range shares {
+		if len(share) != size || size < 2 || share[0] == 0 || seen[share[0]] {
+			return nil, ErrShamirShares
+		}
+		seen[share[0]] = true
+	}
+
+	secret := make([]byte, size-1)
+	for j, share := range shares {
+		// the Lagrange basis polynomial of share j evaluated at 0
+		basis := byte(1)
+		for k, other := range shares {
+			if k != j {
+				basis = gf256Mul(basis, gf256Div(other[0], other[0]^share[0]))
+			}
+		}
+		for i := range secret {
+			secret[i] ^= gf256Mul(basis, share[i+1])
+		}
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type ShamirHelperTestSuite struct {
+	suite.Suite
+}
+
+func TestShamirHelperTestSuite(t *testing.T) {
+	suite.Run(t, new(ShamirHelperTestSuite))
+}
+
+func (s *ShamirHelperTestSuite) TestGF256() {
+	// 0x53 and 0xca are inverses in the AES field
+	s.Equal(byte(0x01), gf256Mul(0x53, 0xca))
+	s.Equal(byte(0xc1), gf256Mul(0x57, 0x83))
+	for a := 1; a < 256; a++ {
+		s.Equal(byte(a), gf256Div(gf256Mul(byte(a), 0x1f), 0x1f))
+	}
+}
+
+func (s *ShamirHelperTestSuite) TestSplitCombine() {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	shares, err := SplitSecret(secret, 5, 3)
+	s.Require().NoError(err)
+	s.Len(shares, 5)
+
+	// every subset of the threshold rebuilds the secret
+	for a := 0; a < 5; a++ {
+		for b := a + 1; b < 5; b++ {
+			for c := b + 1; c < 5; c++ {
+				combined, err := CombineShares([][]byte{shares[c], shares[a], shares[b]})
+				s.NoError(err)
+				s.Equal(secret, combined)
+			}
+		}
+	}
+	combined, err := CombineShares(shares)
+	s.NoError(err)
+	s.Equal(secret, combined)
+
+	combined, err = CombineShares(shares[:2])
+	s.NoError(err)
+	s.NotEqual(secret, combined)
+}
+
+func (s *ShamirHelperTestSuite) TestSplitCombine_Invalid() {
+	_, err := SplitSecret([]byte("secret"), 3, 4)
+	s.Equal(ErrShamirParameters, err)
+	_, err = SplitSecret([]byte("secret"), 3, 1)
+	s.Equal(ErrShamirParameters, err)
+
+	shares, err := SplitSecret([]byte("secret"), 3, 2)
+	s.Require().NoError(err)
+	_, err = CombineShares([][]byte{shares[0], shares[0]})
+	s.Equal(ErrShamirShares, err)
+	_, err = CombineShares([][]byte{shares[0], shares[1][:3]})
+	s.Equal(ErrShamirShares, err)
+}
@@ -31,11 +31,18 @@ func Base64StringJoinedToByteArraySeries(base64Strings string, sep string) ([][]
 	return byteArraySeries, nil
 }
 
-func ByteArraySeriesToString(byteArraySeries [][]byte) string {
-	fulltext := ""
+// ByteArraySeriesConcat joins the series into a buffer of the exact size and zeroes the series,
+// so the only copy left is the returned buffer the caller can zero
+func ByteArraySeriesConcat(byteArraySeries [][]byte) []byte {
+	length := 0
+	for _, byteArray := range byteArraySeries {
+		length += len(byteArray)
+	}
 
+	fulltext := make([]byte, 0, length)
 	for _, byteArray := range byteArraySeries {
-		fulltext = fulltext + string(byteArray)
+		fulltext = append(fulltext, byteArray...)
+		Zeroize(byteArray)
 	}
 
 	return fulltext
@@ -0,0 +1,8 @@
+package helpers
+
+import "time"
+
+func TruncateToSecond(t *time.Time) *time.Time {
+	truncated := t.Truncate(time.Second)
+	return &truncated
+}
@@ -0,0 +1,103 @@
+package home
+
+import (
+	"net/http"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/requests"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+func (n *HomeController) SignBBS(c core.IHTTPContext) error {
+	input := &requests.KeySignBBS{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	header, _ := helpers.DecodeBase64(utils.GetString(input.Header))
+	messages := make([][]byte, len(input.Messages))
+	for i, message := range input.Messages {
+		messages[i] = []byte(message)
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	signature, ierr := keySvc.SignBBS(utils.GetString(input.ID), &services.KeyBBSPayload{
+		Header:   header,
+		Messages: messages,
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, signature)
+}
+
+func (n *HomeController) SignBBS2023(c core.IHTTPContext) error {
+	input := &requests.KeySignBBS2023{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	hmacKey, _ := helpers.DecodeBase64(utils.GetString(input.HMACKey))
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	proof, ierr := keySvc.SignBBS2023(utils.GetString(input.ID), &services.KeyBBS2023Payload{
+		ProofConfig:       utils.GetString(input.ProofConfig),
+		Statements:        input.Statements,
+		MandatoryIndexes:  input.MandatoryIndexes,
+		MandatoryPointers: input.MandatoryPointers,
+		HMACKey:           hmacKey,
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, proof)
+}
+
+func (n *HomeController) DeriveBBS2023(c core.IHTTPContext) error {
+	input := &requests.BBS2023Derive{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	presentationHeader, _ := helpers.DecodeBase64(utils.GetString(input.PresentationHeader))
+
+	bbsSvc := services.NewBBSService(c)
+	proof, ierr := bbsSvc.DeriveBBS2023(&services.BBS2023DerivePayload{
+		ProofValue:         utils.GetString(input.ProofValue),
+		Statements:         input.Statements,
+		MandatoryIndexes:   input.MandatoryIndexes,
+		SelectiveIndexes:   input.SelectiveIndexes,
+		PresentationHeader: presentationHeader,
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, proof)
+}
+
+func (n *HomeController) VerifyBBS2023(c core.IHTTPContext) error {
+	input := &requests.BBS2023Verify{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	bbsSvc := services.NewBBSService(c)
+	ierr := bbsSvc.VerifyBBS2023(&services.BBS2023VerifyPayload{
+		VerificationMethod: utils.GetString(input.VerificationMethod),
+		ProofValue:         utils.GetString(input.ProofValue),
+		ProofConfig:        utils.GetString(input.ProofConfig),
+		Statements:         input.Statements,
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, core.Map{
+		"verified": true,
+	})
+}
@@ -0,0 +1,84 @@
+package home
+
+import (
+	"net/http"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/requests"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+func (n *HomeController) ResolveDID(c core.IHTTPContext) error {
+	didSvc := services.NewDIDService(c)
+	document, ierr := didSvc.Resolve(c.Param("did"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, document)
+}
+
+func (n *HomeController) VerificationMethod(c core.IHTTPContext) error {
+	input := &requests.KeyVerificationMethod{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	verificationMethod, ierr := keySvc.VerificationMethod(c.Param("id"), &services.KeyVerificationMethodPayload{
+		Controller: utils.GetString(input.Controller),
+		Type:       consts.DIDVerificationMethodType(utils.GetString(input.Type)),
+		Fragment:   utils.GetString(input.Fragment),
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, verificationMethod)
+}
+
+func (n *HomeController) SignDIDOperation(c core.IHTTPContext) error {
+	input := &requests.KeySignDIDOperation{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	envelope, ierr := keySvc.SignDIDOperation(utils.GetString(input.ID), &services.KeyDIDOperationPayload{
+		Operation:  consts.DIDOperation(utils.GetString(input.Operation)),
+		DIDAddress: utils.GetString(input.DIDAddress),
+		PublicKey:  utils.GetString(input.PublicKey),
+		KeyType:    consts.DIDVerificationMethodType(utils.GetString(input.KeyType)),
+		KeyID:      utils.GetString(input.KeyID),
+		Controller: utils.GetString(input.Controller),
+		Nonce:      utils.GetString(input.Nonce),
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, envelope)
+}
+
+func (n *HomeController) SignPresentation(c core.IHTTPContext) error {
+	input := &requests.KeySignPresentation{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	presentation, ierr := keySvc.SignPresentation(utils.GetString(input.ID), &services.KeyPresentationPayload{
+		Presentation:       input.Presentation,
+		Challenge:          utils.GetString(input.Challenge),
+		Domain:             utils.GetString(input.Domain),
+		Format:             consts.PresentationFormat(utils.GetString(input.Format)),
+		VerificationMethod: utils.GetString(input.VerificationMethod),
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, presentation)
+}
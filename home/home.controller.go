@@ -2,7 +2,10 @@ package home
 
 import (
 	"net/http"
+	"strings"
 
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
 	"gitlab.finema.co/finema/etda/key-repository-api/requests"
 	"gitlab.finema.co/finema/etda/key-repository-api/services"
 	core "ssi-gitlab.teda.th/ssi/core"
@@ -23,15 +26,24 @@ func (n *HomeController) Get(c core.IHTTPContext) error {
 
 func (n *HomeController) Store(c core.IHTTPContext) error {
 	input := &requests.KeyStore{}
+	defer func() {
+		helpers.Zeroize(input.PrivateKey)
+		helpers.Zeroize(input.PrivateKeyPassword)
+	}()
 	if err := c.BindWithValidate(input); err != nil {
 		return c.JSON(err.GetStatus(), err.JSON())
 	}
 
-	keySvc := services.NewKeyService(c, services.NewHSMService(c))
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
 	key, ierr := keySvc.Store(&services.KeyStorePayload{
-		PublicKey:  utils.GetString(input.PublicKey),
-		PrivateKey: utils.GetString(input.PrivateKey),
-		KeyType:    utils.GetString(input.KeyType),
+		PublicKey:          utils.GetString(input.PublicKey),
+		PrivateKey:         input.PrivateKey,
+		PrivateKeyPassword: input.PrivateKeyPassword,
+		KeyType:            utils.GetString(input.KeyType),
+		Alias:              utils.GetString(input.Alias),
+		Tags:               input.Tags,
+		Policy:             input.Policy,
+		Escrow:             input.Escrow,
 	})
 	if ierr != nil {
 		return c.JSON(ierr.GetStatus(), ierr.JSON())
@@ -41,8 +53,18 @@ func (n *HomeController) Store(c core.IHTTPContext) error {
 }
 
 func (n *HomeController) Generate(c core.IHTTPContext) error {
-	keySvc := services.NewKeyService(c, services.NewHSMService(c))
-	key, ierr := keySvc.Generate()
+	input := &requests.KeyGenerate{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	key, ierr := keySvc.Generate(&services.KeyGeneratePayload{
+		Alias:  utils.GetString(input.Alias),
+		Tags:   input.Tags,
+		Policy: input.Policy,
+		Escrow: input.Escrow,
+	})
 	if ierr != nil {
 		return c.JSON(ierr.GetStatus(), ierr.JSON())
 	}
@@ -51,8 +73,38 @@ func (n *HomeController) Generate(c core.IHTTPContext) error {
 }
 
 func (n *HomeController) GenerateRSA(c core.IHTTPContext) error {
-	keySvc := services.NewKeyService(c, services.NewHSMService(c))
-	key, ierr := keySvc.GenerateRSA()
+	input := &requests.KeyGenerate{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	key, ierr := keySvc.GenerateRSA(&services.KeyGeneratePayload{
+		Alias:  utils.GetString(input.Alias),
+		Tags:   input.Tags,
+		Policy: input.Policy,
+		Escrow: input.Escrow,
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+func (n *HomeController) GenerateBBS(c core.IHTTPContext) error {
+	input := &requests.KeyGenerate{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	key, ierr := keySvc.GenerateBBS(&services.KeyGeneratePayload{
+		Alias:  utils.GetString(input.Alias),
+		Tags:   input.Tags,
+		Policy: input.Policy,
+		Escrow: input.Escrow,
+	})
 	if ierr != nil {
 		return c.JSON(ierr.GetStatus(), ierr.JSON())
 	}
@@ -66,14 +118,174 @@ func (n *HomeController) Sign(c core.IHTTPContext) error {
 		return c.JSON(err.GetStatus(), err.JSON())
 	}
 
-	keySvc := services.NewKeyService(c, services.NewHSMService(c))
-	key, ierr := keySvc.Sign(utils.GetString(input.ID), utils.GetString(input.Message))
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	signature, ierr := keySvc.Sign(utils.GetString(input.ID), utils.GetString(input.Message))
 	if ierr != nil {
 		return c.JSON(ierr.GetStatus(), ierr.JSON())
 	}
 
 	return c.JSON(http.StatusOK, core.Map{
-		"signature": key,
+		"signature": signature.Signature,
 		"message":   utils.GetString(input.Message),
+		"key_id":    signature.KeyID,
+		"version":   signature.Version,
+	})
+}
+
+func (n *HomeController) SignBatch(c core.IHTTPContext) error {
+	input := &requests.KeySignBatch{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	items := make([]services.KeySignBatchItem, len(input.Items))
+	for i, item := range input.Items {
+		items[i] = services.KeySignBatchItem{
+			ID:      utils.GetString(item.ID),
+			Message: utils.GetString(item.Message),
+		}
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	results, ierr := keySvc.SignBatch(items)
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, core.Map{
+		"items": results,
+	})
+}
+
+// KeyCacheStats reports the hit rate of the cache of parsed private keys of this replica
+func (n *HomeController) KeyCacheStats(c core.IHTTPContext) error {
+	cache, ok := c.GetData(consts.ContextKeyKeyCache).(*helpers.KeyCache)
+	if !ok {
+		return c.JSON(http.StatusOK, core.Map{
+			"enabled": false,
+		})
+	}
+
+	return c.JSON(http.StatusOK, core.Map{
+		"enabled": true,
+		"stats":   cache.Stats(),
+	})
+}
+
+func (n *HomeController) Find(c core.IHTTPContext) error {
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	key, ierr := keySvc.Find(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, key)
+}
+
+func (n *HomeController) Pagination(c core.IHTTPContext) error {
+	tags := make(map[string]string)
+	for _, tag := range c.QueryParams()["tag"] {
+		nameValue := strings.SplitN(tag, ":", 2)
+		if len(nameValue) == 2 {
+			tags[nameValue[0]] = nameValue[1]
+		}
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	keys, pageResponse, ierr := keySvc.Pagination(&services.KeyPaginationPayload{
+		Alias: c.QueryParam("alias"),
+		Type:  c.QueryParam("type"),
+		Tags:  tags,
+	}, c.GetPageOptions())
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, core.NewPagination(keys, pageResponse))
+}
+
+func (n *HomeController) Update(c core.IHTTPContext) error {
+	input := &requests.KeyUpdate{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	key, ierr := keySvc.Update(c.Param("id"), &services.KeyUpdatePayload{
+		Alias:         input.Alias,
+		Tags:          input.Tags,
+		Policy:        input.Policy,
+		SignRateLimit: input.SignRateLimit,
+		CachePolicy:   input.CachePolicy,
 	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, key)
+}
+
+func (n *HomeController) Versions(c core.IHTTPContext) error {
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	versions, ierr := keySvc.Versions(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, versions)
+}
+
+func (n *HomeController) Rotate(c core.IHTTPContext) error {
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	key, ierr := keySvc.Rotate(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+func (n *HomeController) Delete(c core.IHTTPContext) error {
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	ierr := keySvc.Delete(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (n *HomeController) Delegations(c core.IHTTPContext) error {
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	delegations, ierr := keySvc.Delegations(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, delegations)
+}
+
+func (n *HomeController) Delegate(c core.IHTTPContext) error {
+	input := &requests.KeyDelegate{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	delegation, ierr := keySvc.Delegate(c.Param("id"), utils.GetString(input.PrincipalID))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusCreated, delegation)
+}
+
+func (n *HomeController) Undelegate(c core.IHTTPContext) error {
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	ierr := keySvc.Undelegate(c.Param("id"), c.Param("principal_id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.NoContent(http.StatusNoContent)
 }
@@ -2,6 +2,8 @@ package home
 
 import (
 	"github.com/labstack/echo/v4"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/middlewares"
 	core "ssi-gitlab.teda.th/ssi/core"
 )
 
@@ -9,8 +11,49 @@ func NewHomeHTTPHandler(r *echo.Echo) {
 	home := &HomeController{}
 
 	r.GET("/", core.WithHTTPContext(home.Get))
-	r.POST("/key/store", core.WithHTTPContext(home.Store))
-	r.POST("/key/generate", core.WithHTTPContext(home.Generate))
-	r.POST("/key/generate/rsa", core.WithHTTPContext(home.GenerateRSA))
-	r.POST("/key/sign", core.WithHTTPContext(home.Sign))
+
+	auth := middlewares.Authenticate(middlewares.DefaultAuthenticators...)
+	generate := middlewares.RequireScope(consts.ScopeKeysGenerate)
+	sign := middlewares.RequireScope(consts.ScopeKeysSign)
+	read := middlewares.RequireScope(consts.ScopeKeysRead)
+	admin := middlewares.RequireScope(consts.ScopeKeysAdmin)
+	idempotent := middlewares.Idempotent()
+	r.POST("/key/store", core.WithHTTPContext(home.Store), auth, generate, idempotent)
+	r.POST("/key/generate", core.WithHTTPContext(home.Generate), auth, generate, idempotent)
+	r.POST("/key/generate/rsa", core.WithHTTPContext(home.GenerateRSA), auth, generate, idempotent)
+	r.POST("/key/generate/bbs", core.WithHTTPContext(home.GenerateBBS), auth, generate, idempotent)
+	r.POST("/key/import-jobs", core.WithHTTPContext(home.CreateImportJob), auth, generate)
+	r.GET("/key/import-jobs/:id", core.WithHTTPContext(home.FindImportJob), auth, generate)
+	r.POST("/key/import", core.WithHTTPContext(home.Import), auth, generate, idempotent)
+	r.POST("/key/import/bundle", core.WithHTTPContext(home.ImportBundle), auth, generate, idempotent)
+	r.POST("/key/sign", core.WithHTTPContext(home.Sign), auth, sign)
+	r.POST("/key/sign/batch", core.WithHTTPContext(home.SignBatch), auth, sign)
+	r.POST("/key/sign/did-operation", core.WithHTTPContext(home.SignDIDOperation), auth, sign)
+	r.POST("/key/sign/presentation", core.WithHTTPContext(home.SignPresentation), auth, sign)
+	r.POST("/key/sign/sd-jwt", core.WithHTTPContext(home.SignSDJWT), auth, sign)
+	r.POST("/key/sign/bbs", core.WithHTTPContext(home.SignBBS), auth, sign)
+	r.POST("/key/sign/bbs-2023", core.WithHTTPContext(home.SignBBS2023), auth, sign)
+	r.GET("/keys", core.WithHTTPContext(home.Pagination), auth, read)
+	r.GET("/keys/:id", core.WithHTTPContext(home.Find), auth, read)
+	r.PUT("/keys/:id", core.WithHTTPContext(home.Update), auth, generate)
+	r.DELETE("/keys/:id", core.WithHTTPContext(home.Delete), auth, generate)
+	r.GET("/keys/:id/versions", core.WithHTTPContext(home.Versions), auth, read)
+	r.GET("/keys/:id/verification-method", core.WithHTTPContext(home.VerificationMethod), auth, read)
+	r.POST("/keys/:id/rotate", core.WithHTTPContext(home.Rotate), auth, generate)
+	r.POST("/keys/:id/export", core.WithHTTPContext(home.Export), auth, generate)
+	r.GET("/keys/:id/escrows", core.WithHTTPContext(home.Escrows), auth, read)
+	r.POST("/keys/:id/recover", core.WithHTTPContext(home.Recover), auth, generate)
+	r.GET("/keys/:id/delegations", core.WithHTTPContext(home.Delegations), auth, read)
+	r.POST("/keys/:id/delegations", core.WithHTTPContext(home.Delegate), auth, generate)
+	r.DELETE("/keys/:id/delegations/:principal_id", core.WithHTTPContext(home.Undelegate), auth, generate)
+	r.POST("/keys/:id/operation-requests", core.WithHTTPContext(home.CreateOperationRequest), auth, generate)
+	r.GET("/operation-requests", core.WithHTTPContext(home.OperationRequests), auth, read)
+	r.GET("/operation-requests/:id", core.WithHTTPContext(home.FindOperationRequest), auth, read)
+	r.POST("/operation-requests/:id/approve", core.WithHTTPContext(home.ApproveOperationRequest), auth, generate)
+	r.POST("/operation-requests/:id/reject", core.WithHTTPContext(home.RejectOperationRequest), auth, generate)
+	r.POST("/operation-requests/:id/execute", core.WithHTTPContext(home.ExecuteOperationRequest), auth, generate)
+	r.GET("/dids/:did", core.WithHTTPContext(home.ResolveDID), auth, read)
+	r.POST("/bbs-2023/derive", core.WithHTTPContext(home.DeriveBBS2023), auth, read)
+	r.POST("/bbs-2023/verify", core.WithHTTPContext(home.VerifyBBS2023), auth, read)
+	r.GET("/admin/key-cache", core.WithHTTPContext(home.KeyCacheStats), auth, admin)
 }
@@ -0,0 +1,95 @@
+package home
+
+import (
+	"net/http"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/requests"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+func newKeyApprovalService(c core.IHTTPContext) services.IKeyApprovalService {
+	auditSvc := services.NewAuditService(c)
+	return services.NewKeyApprovalService(c, services.NewKeyService(c, services.NewHSMService(c), auditSvc), auditSvc)
+}
+
+func (n *HomeController) CreateOperationRequest(c core.IHTTPContext) error {
+	input := &requests.KeyOperationRequestCreate{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	request, ierr := newKeyApprovalService(c).Create(&services.KeyOperationRequestCreatePayload{
+		KeyID:             c.Param("id"),
+		Operation:         consts.KeyApprovalOperation(utils.GetString(input.Operation)),
+		Policy:            input.Policy,
+		WrappingPublicKey: utils.GetString(input.WrappingPublicKey),
+		Format:            consts.KeyExportFormat(utils.GetString(input.Format)),
+		TTLSeconds:        input.TTL(),
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusCreated, request)
+}
+
+func (n *HomeController) OperationRequests(c core.IHTTPContext) error {
+	operationRequests, pageResponse, ierr := newKeyApprovalService(c).Pagination(&services.KeyOperationRequestPaginationPayload{
+		KeyID:  c.QueryParam("key_id"),
+		Status: c.QueryParam("status"),
+	}, c.GetPageOptions())
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, core.NewPagination(operationRequests, pageResponse))
+}
+
+func (n *HomeController) FindOperationRequest(c core.IHTTPContext) error {
+	request, ierr := newKeyApprovalService(c).Find(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, request)
+}
+
+func (n *HomeController) ApproveOperationRequest(c core.IHTTPContext) error {
+	input := &requests.KeyOperationRequestDecide{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	request, ierr := newKeyApprovalService(c).Approve(c.Param("id"), utils.GetString(input.Comment))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, request)
+}
+
+func (n *HomeController) RejectOperationRequest(c core.IHTTPContext) error {
+	input := &requests.KeyOperationRequestDecide{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	request, ierr := newKeyApprovalService(c).Reject(c.Param("id"), utils.GetString(input.Comment))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, request)
+}
+
+func (n *HomeController) ExecuteOperationRequest(c core.IHTTPContext) error {
+	result, ierr := newKeyApprovalService(c).Execute(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
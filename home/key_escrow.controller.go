@@ -0,0 +1,47 @@
+package home
+
+import (
+	"net/http"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/requests"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+func (n *HomeController) Escrows(c core.IHTTPContext) error {
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	escrows, ierr := keySvc.Escrows(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, escrows)
+}
+
+func (n *HomeController) Recover(c core.IHTTPContext) error {
+	input := &requests.KeyRecover{}
+	defer func() {
+		for _, share := range input.Shares {
+			helpers.Zeroize(share)
+		}
+	}()
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	shares := make([][]byte, 0, len(input.Shares))
+	for _, share := range input.Shares {
+		shares = append(shares, share)
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	key, ierr := keySvc.Recover(c.Param("id"), &services.KeyRecoverPayload{
+		Shares: shares,
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, key)
+}
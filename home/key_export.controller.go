@@ -0,0 +1,29 @@
+package home
+
+import (
+	"net/http"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/requests"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+func (n *HomeController) Export(c core.IHTTPContext) error {
+	input := &requests.KeyExport{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	bundle, ierr := keySvc.Export(c.Param("id"), &services.KeyExportPayload{
+		WrappingPublicKey: utils.GetString(input.WrappingPublicKey),
+		Format:            consts.KeyExportFormat(utils.GetString(input.Format)),
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, bundle)
+}
@@ -0,0 +1,86 @@
+package home
+
+import (
+	"net/http"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/requests"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+func newKeyImportService(c core.IHTTPContext) services.IKeyImportService {
+	hsmSvc := services.NewHSMService(c)
+	return services.NewKeyImportService(c, hsmSvc, services.NewKeyService(c, hsmSvc, services.NewAuditService(c)))
+}
+
+func (n *HomeController) CreateImportJob(c core.IHTTPContext) error {
+	input := &requests.KeyImportJobCreate{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	job, ierr := newKeyImportService(c).CreateJob(&services.KeyImportJobCreatePayload{
+		Method:     consts.KeyImportMethod(utils.GetString(input.Method)),
+		TTLSeconds: input.TTL(),
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusCreated, job)
+}
+
+func (n *HomeController) FindImportJob(c core.IHTTPContext) error {
+	job, ierr := newKeyImportService(c).FindJob(c.Param("id"))
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+func (n *HomeController) Import(c core.IHTTPContext) error {
+	input := &requests.KeyImport{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	wrappedKey, _ := helpers.DecodeBase64(utils.GetString(input.WrappedKey))
+	key, ierr := newKeyImportService(c).Import(&services.KeyImportPayload{
+		ImportJobID: utils.GetString(input.ImportJobID),
+		WrappedKey:  wrappedKey,
+		PublicKey:   utils.GetString(input.PublicKey),
+		KeyType:     utils.GetString(input.KeyType),
+		Alias:       utils.GetString(input.Alias),
+		Tags:        input.Tags,
+		Policy:      input.Policy,
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+func (n *HomeController) ImportBundle(c core.IHTTPContext) error {
+	input := &requests.KeyImportBundle{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	key, ierr := newKeyImportService(c).ImportBundle(&services.KeyImportBundlePayload{
+		ImportJobID: utils.GetString(input.ImportJobID),
+		Bundle:      input.Bundle,
+		Alias:       utils.GetString(input.Alias),
+		Tags:        input.Tags,
+		Policy:      input.Policy,
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
@@ -0,0 +1,30 @@
+package home
+
+import (
+	"net/http"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/requests"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+func (n *HomeController) SignSDJWT(c core.IHTTPContext) error {
+	input := &requests.KeySignSDJWT{}
+	if err := c.BindWithValidate(input); err != nil {
+		return c.JSON(err.GetStatus(), err.JSON())
+	}
+
+	keySvc := services.NewKeyService(c, services.NewHSMService(c), services.NewAuditService(c))
+	sdJWT, ierr := keySvc.IssueSDJWT(utils.GetString(input.ID), &services.KeySDJWTPayload{
+		Claims:      input.Claims,
+		Disclosable: input.Disclosable,
+		HolderKeyID: utils.GetString(input.HolderKeyID),
+		Kid:         utils.GetString(input.Kid),
+	})
+	if ierr != nil {
+		return c.JSON(ierr.GetStatus(), ierr.JSON())
+	}
+
+	return c.JSON(http.StatusOK, sdJWT)
+}
@@ -5,9 +5,12 @@ import (
 	"os"
 	"time"
 
+	"gitlab.finema.co/finema/etda/key-repository-api/audit"
+	"gitlab.finema.co/finema/etda/key-repository-api/client"
 	"gitlab.finema.co/finema/etda/key-repository-api/consts"
 	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
 	"gitlab.finema.co/finema/etda/key-repository-api/home"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
 	core "ssi-gitlab.teda.th/ssi/core"
 )
 
@@ -33,16 +36,50 @@ func main() {
 			consts.ContextKeyHSMSession: hsm,
 		},
 	}
+	if env.String(consts.ENVRateLimitRedisAddr) != "" {
+		contextOptions.DATA[consts.ContextKeyRateLimiter] = helpers.NewRedisRateLimiter(env.String(consts.ENVRateLimitRedisAddr), env.String(consts.ENVRateLimitRedisPassword))
+	} else {
+		contextOptions.DATA[consts.ContextKeyRateLimiter] = helpers.NewMemoryRateLimiter()
+	}
+	if env.Int(consts.ENVKeyCacheMaxEntries) > 0 {
+		maxTTL := env.Int(consts.ENVKeyCacheMaxTTLSeconds)
+		if maxTTL <= 0 {
+			maxTTL = 300
+		}
+		keyCache := helpers.NewKeyCache(env.Int(consts.ENVKeyCacheMaxEntries), time.Duration(maxTTL)*time.Second)
+		contextOptions.DATA[consts.ContextKeyKeyCache] = keyCache
+		go helpers.SweepKeyCache(keyCache, time.Minute)
+	}
+	if env.String(consts.ENVAuthJWKSFile) != "" || env.String(consts.ENVAuthJWKSURL) != "" {
+		if env.String(consts.ENVAuthJWTIssuer) == "" || env.String(consts.ENVAuthJWTAudience) == "" {
+			fmt.Fprintf(os.Stderr, "JWT: %s and %s are required with a JWKS", consts.ENVAuthJWTIssuer, consts.ENVAuthJWTAudience)
+			os.Exit(1)
+		}
+		contextOptions.DATA[consts.ContextKeyJWKS] = helpers.NewJWKSProvider(env.String(consts.ENVAuthJWKSFile), env.String(consts.ENVAuthJWKSURL))
+	}
+
 	sqlDB, err := mysql.DB()
 	sqlDB.SetMaxIdleConns(20000)
 	sqlDB.SetConnMaxIdleTime(time.Hour)
 	go helpers.KeepHSMAlive(contextOptions, env.Int(consts.ENVHSMSlot), env.String(consts.ENVHSMPin))
+	go services.SweepIdempotencyRecords(core.NewContext(contextOptions), time.Hour)
 
 	e := core.NewHTTPServer(&core.HTTPContextOptions{
 		ContextOptions: contextOptions,
 	})
 
 	home.NewHomeHTTPHandler(e)
+	client.NewClientHTTPHandler(e)
+	audit.NewAuditHTTPHandler(e)
 
-	core.StartHTTPServer(e, env)
+	if env.String(consts.ENVTLSCertFile) == "" {
+		core.StartHTTPServer(e, env)
+		return
+	}
+
+	err = helpers.StartHTTPSServer(e, env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "HTTPS: %v", err)
+		os.Exit(1)
+	}
 }
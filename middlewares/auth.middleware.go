@@ -0,0 +1,165 @@
+package middlewares
+
+import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+const HeaderAPIKey = "X-API-Key"
+
+// Authenticator returns nil without an error when the request does not carry its kind of credential,
+// so the next authenticator can be tried
+type Authenticator func(c core.IHTTPContext) (*models.Principal, core.IError)
+
+var DefaultAuthenticators = []Authenticator{
+	ClientCertificateAuthenticator,
+	AdminAPIKeyAuthenticator,
+	APIKeyAuthenticator,
+	JWTAuthenticator,
+}
+
+// Authenticate rejects requests none of the authenticators can identify and
+// attaches the principal to the request for helpers.GetPrincipal
+func Authenticate(authenticators ...Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return core.WithHTTPContext(func(c core.IHTTPContext) error {
+			for _, authenticate := range authenticators {
+				principal, ierr := authenticate(c)
+				if ierr != nil {
+					return c.JSON(ierr.GetStatus(), ierr.JSON())
+				}
+				if principal != nil {
+					c.Set(consts.ContextKeyPrincipal, principal)
+					return next(c)
+				}
+			}
+
+			return c.JSON(emsgs.UnauthorizedError.GetStatus(), emsgs.UnauthorizedError.JSON())
+		})
+	}
+}
+
+// RequireScope must run after Authenticate
+func RequireScope(scope consts.Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return core.WithHTTPContext(func(c core.IHTTPContext) error {
+			if !helpers.GetPrincipal(c).HasScope(scope) {
+				ierr := emsgs.InsufficientScopeError(scope)
+				return c.JSON(ierr.GetStatus(), ierr.JSON())
+			}
+
+			return next(c)
+		})
+	}
+}
+
+func ClientCertificateAuthenticator(c core.IHTTPContext) (*models.Principal, core.IError) {
+	state := c.Request().TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.PeerCertificates) == 0 {
+		return nil, nil
+	}
+
+	client, ierr := services.NewAPIClientService(c).AuthenticateCertificate(state.PeerCertificates[0])
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return client.Principal(), nil
+}
+
+// AdminAPIKeyAuthenticator accepts the bootstrap key from the environment, used to register the first clients
+func AdminAPIKeyAuthenticator(c core.IHTTPContext) (*models.Principal, core.IError) {
+	adminAPIKey := c.ENV().String(consts.ENVAuthAdminAPIKey)
+	apiKey := getAPIKey(c)
+	if adminAPIKey == "" || apiKey == "" {
+		return nil, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(adminAPIKey), []byte(apiKey)) != 1 {
+		return nil, nil
+	}
+
+	return &models.Principal{
+		ID:     string(consts.PrincipalTypeAdmin),
+		Type:   string(consts.PrincipalTypeAdmin),
+		Scopes: []string{string(consts.ScopeKeysAdmin)},
+	}, nil
+}
+
+func APIKeyAuthenticator(c core.IHTTPContext) (*models.Principal, core.IError) {
+	apiKey := getAPIKey(c)
+	if apiKey == "" {
+		return nil, nil
+	}
+
+	client, ierr := services.NewAPIClientService(c).AuthenticateAPIKey(apiKey)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return client.Principal(), nil
+}
+
+// JWTAuthenticator accepts bearer tokens signed by a key of the configured JWKS for the configured issuer and audience.
+// The principal is "jwt:<iss>:<sub>" so subjects never collide with API clients or the admin, and the tenant is read
+// from the claim named by AUTH_JWT_TENANT_CLAIM, tokens without a tenant are rejected
+func JWTAuthenticator(c core.IHTTPContext) (*models.Principal, core.IError) {
+	authorization := strings.SplitN(c.Request().Header.Get(echo.HeaderAuthorization), " ", 2)
+	if len(authorization) != 2 || !strings.EqualFold(authorization[0], "Bearer") {
+		return nil, nil
+	}
+
+	jwks, ok := c.GetData(consts.ContextKeyJWKS).(*helpers.JWKSProvider)
+	issuer := c.ENV().String(consts.ENVAuthJWTIssuer)
+	audience := c.ENV().String(consts.ENVAuthJWTAudience)
+	if !ok || issuer == "" || audience == "" {
+		return nil, c.NewError(emsgs.UnauthorizedError, emsgs.UnauthorizedError)
+	}
+
+	claims, err := helpers.VerifyJWT(authorization[1], jwks.Key, &helpers.JWTVerifyOptions{
+		Issuer:   issuer,
+		Audience: audience,
+		Leeway:   time.Minute,
+	})
+	if err != nil {
+		return nil, c.NewError(err, emsgs.UnauthorizedError)
+	}
+
+	tenantClaim := c.ENV().String(consts.ENVAuthJWTTenantClaim)
+	if tenantClaim == "" {
+		tenantClaim = "tenant_id"
+	}
+	tenantID, _ := claims.Extra[tenantClaim].(string)
+	if claims.Subject == "" || tenantID == "" {
+		return nil, c.NewError(emsgs.UnauthorizedError, emsgs.UnauthorizedError)
+	}
+
+	return &models.Principal{
+		ID:       "jwt:" + claims.Issuer + ":" + claims.Subject,
+		TenantID: tenantID,
+		Type:     string(consts.PrincipalTypeSubject),
+		Scopes:   claims.Scopes(),
+	}, nil
+}
+
+// getAPIKey reads either the X-API-Key header or an "Authorization: ApiKey <key>" header
+func getAPIKey(c core.IHTTPContext) string {
+	if apiKey := c.Request().Header.Get(HeaderAPIKey); apiKey != "" {
+		return apiKey
+	}
+
+	authorization := strings.SplitN(c.Request().Header.Get(echo.HeaderAuthorization), " ", 2)
+	if len(authorization) == 2 && strings.EqualFold(authorization[0], "ApiKey") {
+		return authorization[1]
+	}
+
+	return ""
+}
@@ -0,0 +1,183 @@
+// +build e2e
+
+package middlewares
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+// authTestENV overrides the JWT settings of the environment
+type authTestENV struct {
+	core.IENV
+	values map[string]string
+}
+
+func (e authTestENV) String(key string) string {
+	if value, ok := e.values[key]; ok {
+		return value
+	}
+
+	return e.IENV.String(key)
+}
+
+type AuthMiddlewareTestSuite struct {
+	suite.Suite
+	rCtx       core.IContext
+	e          *echo.Echo
+	privateKey *ecdsa.PrivateKey
+	jwksDir    string
+}
+
+func TestAuthMiddlewareTestSuite(t *testing.T) {
+	suite.Run(t, new(AuthMiddlewareTestSuite))
+}
+
+func (a *AuthMiddlewareTestSuite) SetupSuite() {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	a.Require().NoError(err)
+	a.privateKey = privateKey
+
+	jwk, err := helpers.NewPublicJWK(&privateKey.PublicKey)
+	a.Require().NoError(err)
+	jwks, err := json.Marshal(&helpers.JWKSet{Keys: []helpers.JWK{*jwk}})
+	a.Require().NoError(err)
+	a.jwksDir, err = ioutil.TempDir("", "jwks")
+	a.Require().NoError(err)
+	jwksFile := filepath.Join(a.jwksDir, "jwks.json")
+	a.Require().NoError(ioutil.WriteFile(jwksFile, jwks, 0600))
+
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	contextOptions := &core.ContextOptions{
+		DB: mysql,
+		ENV: authTestENV{IENV: env, values: map[string]string{
+			consts.ENVAuthAdminAPIKey:    "",
+			consts.ENVAuthJWTIssuer:      "https://issuer.example",
+			consts.ENVAuthJWTAudience:    "key-repository",
+			consts.ENVAuthJWTTenantClaim: "tenant_id",
+		}},
+		DATA: map[string]interface{}{
+			consts.ContextKeyJWKS: helpers.NewJWKSProvider(jwksFile, ""),
+		},
+	}
+	a.rCtx = core.NewContext(contextOptions)
+
+	a.e = core.NewHTTPServer(&core.HTTPContextOptions{ContextOptions: contextOptions})
+	a.e.GET("/principal", core.WithHTTPContext(func(c core.IHTTPContext) error {
+		return c.JSON(http.StatusOK, helpers.GetPrincipal(c))
+	}), Authenticate(DefaultAuthenticators...))
+}
+
+func (a *AuthMiddlewareTestSuite) TearDownSuite() {
+	os.RemoveAll(a.jwksDir)
+}
+
+// principal calls the route with the header and returns the status and the principal it answered
+func (a *AuthMiddlewareTestSuite) principal(header string, value string) (int, *models.Principal) {
+	request := httptest.NewRequest(http.MethodGet, "/principal", nil)
+	request.Header.Set(header, value)
+	recorder := httptest.NewRecorder()
+	a.e.ServeHTTP(recorder, request)
+
+	principal := &models.Principal{}
+	if recorder.Code == http.StatusOK {
+		a.Require().NoError(json.Unmarshal(recorder.Body.Bytes(), principal))
+	}
+
+	return recorder.Code, principal
+}
+
+func (a *AuthMiddlewareTestSuite) token(claims map[string]interface{}) string {
+	signingInput, err := helpers.JWTSigningInput(map[string]interface{}{"alg": "ES256", "typ": "JWT"}, claims)
+	a.Require().NoError(err)
+	signature, err := helpers.SignJWSSignature("ES256", a.privateKey, []byte(signingInput))
+	a.Require().NoError(err)
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (a *AuthMiddlewareTestSuite) claims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":       "https://issuer.example",
+		"sub":       "client-1",
+		"aud":       "key-repository",
+		"exp":       time.Now().Add(time.Minute).Unix(),
+		"scope":     "keys:read keys:sign",
+		"tenant_id": "tenant-1",
+	}
+}
+
+func (a *AuthMiddlewareTestSuite) TestAuthMiddleware_APIKey_ExpectPrincipal() {
+	client, ierr := services.NewAPIClientService(a.rCtx).Create(&services.APIClientCreatePayload{Name: "issuer", TenantID: "tenant-1"})
+	a.Require().NoError(ierr)
+
+	status, principal := a.principal(HeaderAPIKey, client.APIKey)
+	a.Equal(http.StatusOK, status)
+	a.Equal(client.ID, principal.ID)
+	a.Equal("tenant-1", principal.TenantID)
+
+	status, principal = a.principal(echo.HeaderAuthorization, "ApiKey "+client.APIKey)
+	a.Equal(http.StatusOK, status)
+	a.Equal(client.ID, principal.ID)
+}
+
+func (a *AuthMiddlewareTestSuite) TestAuthMiddleware_APIKey_ExpectUnauthorized() {
+	client, ierr := services.NewAPIClientService(a.rCtx).Create(&services.APIClientCreatePayload{Name: "issuer", TenantID: "tenant-1"})
+	a.Require().NoError(ierr)
+
+	// Expect error on a wrong secret
+	status, _ := a.principal(HeaderAPIKey, client.APIKey[:len(client.APIKey)-1]+"x")
+	a.Equal(http.StatusUnauthorized, status)
+
+	// Expect error on the key of a revoked client
+	a.Require().NoError(services.NewAPIClientService(a.rCtx).Revoke(client.ID))
+	status, _ = a.principal(HeaderAPIKey, client.APIKey)
+	a.Equal(http.StatusUnauthorized, status)
+
+	// Expect error without any credential
+	status, _ = a.principal(HeaderAPIKey, "")
+	a.Equal(http.StatusUnauthorized, status)
+}
+
+func (a *AuthMiddlewareTestSuite) TestAuthMiddleware_JWT_ExpectPrincipal() {
+	status, principal := a.principal(echo.HeaderAuthorization, "Bearer "+a.token(a.claims()))
+	a.Equal(http.StatusOK, status)
+	a.Equal("jwt:https://issuer.example:client-1", principal.ID)
+	a.Equal("tenant-1", principal.TenantID)
+	a.Equal(string(consts.PrincipalTypeSubject), principal.Type)
+	a.Equal([]string{"keys:read", "keys:sign"}, principal.Scopes)
+}
+
+func (a *AuthMiddlewareTestSuite) TestAuthMiddleware_JWT_ExpectUnauthorized() {
+	for name, change := range map[string]func(claims map[string]interface{}){
+		"expired":        func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"other issuer":   func(claims map[string]interface{}) { claims["iss"] = "https://other.example" },
+		"other audience": func(claims map[string]interface{}) { claims["aud"] = "other" },
+		"no tenant":      func(claims map[string]interface{}) { delete(claims, "tenant_id") },
+		"no subject":     func(claims map[string]interface{}) { delete(claims, "sub") },
+	} {
+		claims := a.claims()
+		change(claims)
+		status, _ := a.principal(echo.HeaderAuthorization, "Bearer "+a.token(claims))
+		a.Equal(http.StatusUnauthorized, status, name)
+	}
+}
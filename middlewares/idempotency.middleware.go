@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotencyReplayed = "Idempotency-Replayed"
+)
+
+// Idempotent must run after Authenticate, idempotency keys belong to the principal that sent them.
+// Successful responses are kept and replayed to retries, failed requests create nothing and can be retried
+func Idempotent() echo.MiddlewareFunc {
+	return idempotent(services.NewIdempotencyService)
+}
+
+func idempotent(newIdempotencyService func(ctx core.IContext) services.IIdempotencyService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return core.WithHTTPContext(func(c core.IHTTPContext) error {
+			idempotencyKey := c.Request().Header.Get(HeaderIdempotencyKey)
+			if idempotencyKey == "" {
+				return next(c)
+			}
+			if len(idempotencyKey) > 255 {
+				return c.JSON(emsgs.InvalidIdempotencyKeyError.GetStatus(), emsgs.InvalidIdempotencyKeyError.JSON())
+			}
+
+			// the bodies of /key/store and /key/import carry private keys, the only copy is zeroed once the handler returns
+			digest := sha256.New()
+			body, err := helpers.ReadAllZeroizing(io.TeeReader(c.Request().Body, digest))
+			if err != nil {
+				ierr := c.NewError(err, errmsgs.BadRequest)
+				return c.JSON(ierr.GetStatus(), ierr.JSON())
+			}
+			defer helpers.Zeroize(body)
+			c.Request().Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			idempotencySvc := newIdempotencyService(c)
+			record, ierr := idempotencySvc.Begin(&services.IdempotencyBeginPayload{
+				IdempotencyKey: idempotencyKey,
+				Method:         c.Request().Method,
+				Path:           c.Path(),
+				RequestHash:    hex.EncodeToString(digest.Sum(nil)),
+			})
+			if ierr != nil {
+				return c.JSON(ierr.GetStatus(), ierr.JSON())
+			}
+			if record.Status == string(consts.IdempotencyStatusCompleted) {
+				c.Response().Header().Set(HeaderIdempotencyReplayed, "true")
+				return c.JSONBlob(record.ResponseStatus, []byte(record.ResponseBody))
+			}
+
+			// a panicking handler must not leave the key claimed until it expires
+			finished := false
+			defer func() {
+				if !finished {
+					idempotencySvc.Release(record)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = recorder
+			err = next(c)
+			finished = true
+
+			status := c.Response().Status
+			if err != nil || status < http.StatusOK || status >= http.StatusMultipleChoices {
+				ierr = idempotencySvc.Release(record)
+			} else {
+				ierr = idempotencySvc.Complete(record, status, recorder.body.Bytes())
+			}
+			if ierr != nil {
+				// the response is already sent, a retry will either replay it or report the request as in progress
+				c.Log().Error(fmt.Sprintf("idempotency: cannot save the response of key %s: %v", idempotencyKey, ierr))
+			}
+
+			return err
+		})
+	}
+}
+
+// responseRecorder keeps a copy of the body written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
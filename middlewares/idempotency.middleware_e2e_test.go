@@ -0,0 +1,131 @@
+// +build e2e
+
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gitlab.finema.co/finema/etda/key-repository-api/services"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+const idempotencyTestBody = `{"alias":"issuer"}`
+
+type IdempotencyMiddlewareTestSuite struct {
+	suite.Suite
+	e       *echo.Echo
+	mis     *services.MockIdempotencyService
+	handled int
+}
+
+func TestIdempotencyMiddlewareTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyMiddlewareTestSuite))
+}
+
+func (i *IdempotencyMiddlewareTestSuite) SetupTest() {
+	env := core.NewENVPath("./..")
+	i.mis = services.NewMockIdempotencyService()
+	i.handled = 0
+
+	i.e = core.NewHTTPServer(&core.HTTPContextOptions{ContextOptions: &core.ContextOptions{ENV: env}})
+	middleware := idempotent(func(ctx core.IContext) services.IIdempotencyService {
+		return i.mis
+	})
+	i.e.POST("/key/generate", core.WithHTTPContext(func(c core.IHTTPContext) error {
+		i.handled++
+		return c.JSON(http.StatusCreated, map[string]string{"id": "key-1"})
+	}), middleware)
+	i.e.POST("/key/fail", core.WithHTTPContext(func(c core.IHTTPContext) error {
+		i.handled++
+		return c.JSON(emsgs.KeyNotFoundError.GetStatus(), emsgs.KeyNotFoundError.JSON())
+	}), middleware)
+}
+
+func (i *IdempotencyMiddlewareTestSuite) serve(path string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(idempotencyTestBody))
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set(HeaderIdempotencyKey, "request-1")
+	recorder := httptest.NewRecorder()
+	i.e.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+// beginPayload matches the payload of the test body sent to path
+func (i *IdempotencyMiddlewareTestSuite) beginPayload(path string) interface{} {
+	digest := sha256.Sum256([]byte(idempotencyTestBody))
+	return mock.MatchedBy(func(payload *services.IdempotencyBeginPayload) bool {
+		return payload.IdempotencyKey == "request-1" &&
+			payload.Method == http.MethodPost &&
+			payload.Path == path &&
+			payload.RequestHash == hex.EncodeToString(digest[:])
+	})
+}
+
+func (i *IdempotencyMiddlewareTestSuite) TestIdempotencyMiddleware_ExpectCompleted() {
+	record := &models.IdempotencyRecord{ID: "record-1", Status: string(consts.IdempotencyStatusInProgress)}
+	i.mis.On("Begin", i.beginPayload("/key/generate")).Return(record, nil)
+	i.mis.On("Complete", record, http.StatusCreated, mock.MatchedBy(func(body []byte) bool {
+		return strings.TrimSpace(string(body)) == `{"id":"key-1"}`
+	})).Return(nil)
+
+	recorder := i.serve("/key/generate")
+	i.Equal(http.StatusCreated, recorder.Code)
+	i.Equal(1, i.handled)
+	i.Empty(recorder.Header().Get(HeaderIdempotencyReplayed))
+	i.mis.AssertExpectations(i.T())
+}
+
+func (i *IdempotencyMiddlewareTestSuite) TestIdempotencyMiddleware_ExpectReplayed() {
+	record := &models.IdempotencyRecord{
+		ID:             "record-1",
+		Status:         string(consts.IdempotencyStatusCompleted),
+		ResponseStatus: http.StatusCreated,
+		ResponseBody:   `{"id":"key-1"}`,
+	}
+	i.mis.On("Begin", i.beginPayload("/key/generate")).Return(record, nil)
+
+	recorder := i.serve("/key/generate")
+	i.Equal(http.StatusCreated, recorder.Code)
+	i.Equal(`{"id":"key-1"}`, recorder.Body.String())
+	i.Equal("true", recorder.Header().Get(HeaderIdempotencyReplayed))
+	i.Equal(0, i.handled)
+	i.mis.AssertNotCalled(i.T(), "Complete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (i *IdempotencyMiddlewareTestSuite) TestIdempotencyMiddleware_ExpectReleased() {
+	record := &models.IdempotencyRecord{ID: "record-1", Status: string(consts.IdempotencyStatusInProgress)}
+	i.mis.On("Begin", i.beginPayload("/key/fail")).Return(record, nil)
+	i.mis.On("Release", record).Return(nil)
+
+	recorder := i.serve("/key/fail")
+	i.Equal(emsgs.KeyNotFoundError.GetStatus(), recorder.Code)
+	i.mis.AssertExpectations(i.T())
+	i.mis.AssertNotCalled(i.T(), "Complete", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (i *IdempotencyMiddlewareTestSuite) TestIdempotencyMiddleware_ExpectConflict() {
+	i.mis.On("Begin", i.beginPayload("/key/generate")).Return((*models.IdempotencyRecord)(nil), emsgs.IdempotencyRequestInProgressError)
+
+	recorder := i.serve("/key/generate")
+	i.Equal(emsgs.IdempotencyRequestInProgressError.GetStatus(), recorder.Code)
+	i.Equal(0, i.handled)
+
+	// Expect the error of the service on a key reused with another body
+	i.mis = services.NewMockIdempotencyService()
+	i.mis.On("Begin", mock.Anything).Return((*models.IdempotencyRecord)(nil), emsgs.IdempotencyKeyReusedError)
+	recorder = i.serve("/key/generate")
+	i.Equal(emsgs.IdempotencyKeyReusedError.GetStatus(), recorder.Code)
+	i.Equal(0, i.handled)
+}
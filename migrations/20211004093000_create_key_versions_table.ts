@@ -0,0 +1,33 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    await knex.schema.alterTable("keys", function (table) {
+        table.integer('version').notNullable().defaultTo(1)
+    })
+
+    await knex.schema.createTable("key_versions", function (table) {
+        table.string('id', 255).primary()
+        table.string('key_id', 255).notNullable().references('id').inTable('keys')
+        table.integer('version').notNullable()
+        table.text('public_key').notNullable()
+        table.text('private_key_encrypted').notNullable()
+        table.dateTime('created_at').notNullable()
+        table.dateTime('updated_at').notNullable()
+        table.unique(['key_id', 'version'])
+    })
+
+    // every existing key becomes version 1 of itself
+    return knex.raw(`
+        INSERT INTO key_versions (id, key_id, version, public_key, private_key_encrypted, created_at, updated_at)
+        SELECT UUID(), id, 1, public_key, private_key_encrypted, created_at, updated_at FROM \`keys\`
+    `)
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    await knex.schema.dropTableIfExists('key_versions')
+    return knex.schema.alterTable("keys", function (table) {
+        table.dropColumn('version')
+    })
+}
@@ -0,0 +1,31 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    await knex.schema.alterTable("keys", function (table) {
+        table.string('tenant_id', 255).notNullable().defaultTo('')
+        table.string('alias', 255)
+        table.unique(['tenant_id', 'alias'])
+    })
+
+    return knex.schema.createTable("key_tags", function (table) {
+        table.string('id', 255).primary()
+        table.string('key_id', 255).notNullable().references('id').inTable('keys')
+        table.string('name', 255).notNullable()
+        table.string('value', 255).notNullable()
+        table.dateTime('created_at').notNullable()
+        table.dateTime('updated_at').notNullable()
+        table.unique(['key_id', 'name'])
+        table.index(['name', 'value'])
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    await knex.schema.dropTableIfExists('key_tags')
+    return knex.schema.alterTable("keys", function (table) {
+        table.dropUnique(['tenant_id', 'alias'])
+        table.dropColumn('alias')
+        table.dropColumn('tenant_id')
+    })
+}
@@ -0,0 +1,28 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    await knex.schema.alterTable("keys", function (table) {
+        table.string('owner_id', 255).notNullable().defaultTo('')
+        table.index(['tenant_id', 'owner_id'])
+    })
+
+    return knex.schema.createTable("key_delegations", function (table) {
+        table.string('id', 255).primary()
+        table.string('key_id', 255).notNullable().references('id').inTable('keys')
+        table.string('principal_id', 255).notNullable()
+        table.dateTime('created_at').notNullable()
+        table.dateTime('updated_at').notNullable()
+        table.unique(['key_id', 'principal_id'])
+        table.index(['principal_id'])
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    await knex.schema.dropTableIfExists('key_delegations')
+    return knex.schema.alterTable("keys", function (table) {
+        table.dropIndex(['tenant_id', 'owner_id'])
+        table.dropColumn('owner_id')
+    })
+}
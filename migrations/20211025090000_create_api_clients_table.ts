@@ -0,0 +1,22 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    return knex.schema.createTable("api_clients", function (table) {
+        table.string('id', 255).primary()
+        table.string('name', 255).notNullable()
+        table.string('tenant_id', 255).notNullable().defaultTo('')
+        table.string('api_key_prefix', 255).unique()
+        table.string('api_key_hash', 255)
+        table.string('certificate_fingerprint', 255).unique()
+        table.boolean('is_admin').notNullable().defaultTo(false)
+        table.dateTime('revoked_at')
+        table.dateTime('created_at').notNullable()
+        table.dateTime('updated_at').notNullable()
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    return knex.schema.dropTableIfExists('api_clients')
+}
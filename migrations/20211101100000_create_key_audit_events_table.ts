@@ -0,0 +1,42 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    await knex.schema.createTable("key_audit_events", function (table) {
+        table.string('id', 255).primary()
+        table.bigInteger('sequence').notNullable().unique()
+        table.string('actor_id', 255).notNullable()
+        table.string('actor_type', 255).notNullable()
+        table.string('tenant_id', 255).notNullable()
+        table.string('operation', 255).notNullable()
+        table.string('key_id', 255).notNullable()
+        table.integer('key_version').notNullable()
+        table.string('message_digest', 255).notNullable()
+        table.string('outcome', 255).notNullable()
+        table.string('error_code', 255).notNullable()
+        table.string('request_id', 255).notNullable()
+        table.string('previous_hash', 64).notNullable()
+        table.string('hash', 64).notNullable()
+        table.dateTime('created_at').notNullable()
+        table.index(['key_id'])
+        table.index(['actor_id'])
+        table.index(['created_at'])
+    })
+
+    // the table is append-only, tampering has to go around the application and is caught by the hash chain
+    await knex.raw(`
+        CREATE TRIGGER key_audit_events_no_update BEFORE UPDATE ON key_audit_events
+        FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'key_audit_events is append-only'
+    `)
+    return knex.raw(`
+        CREATE TRIGGER key_audit_events_no_delete BEFORE DELETE ON key_audit_events
+        FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'key_audit_events is append-only'
+    `)
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    await knex.raw('DROP TRIGGER IF EXISTS key_audit_events_no_update')
+    await knex.raw('DROP TRIGGER IF EXISTS key_audit_events_no_delete')
+    return knex.schema.dropTableIfExists('key_audit_events')
+}
@@ -0,0 +1,25 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    return knex.schema.createTable("audit_exports", function (table) {
+        table.string('id', 255).primary()
+        table.string('actor_id', 255).notNullable()
+        table.string('tenant_id', 255).notNullable()
+        table.string('format', 255).notNullable()
+        table.text('filters').notNullable()
+        table.bigInteger('event_count').notNullable()
+        table.bigInteger('first_sequence').notNullable()
+        table.bigInteger('last_sequence').notNullable()
+        table.string('digest', 64).notNullable()
+        table.text('signature').notNullable()
+        table.string('signing_key_id', 255).notNullable()
+        table.integer('signing_key_version').notNullable()
+        table.dateTime('created_at').notNullable()
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    return knex.schema.dropTableIfExists('audit_exports')
+}
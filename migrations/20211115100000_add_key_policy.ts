@@ -0,0 +1,24 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    await knex.schema.alterTable("keys", function (table) {
+        table.text('policy')
+    })
+
+    // the policy rate limit counts recent signatures of a key
+    return knex.schema.alterTable("key_audit_events", function (table) {
+        table.index(['key_id', 'operation', 'created_at'])
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    await knex.schema.alterTable("key_audit_events", function (table) {
+        table.dropIndex(['key_id', 'operation', 'created_at'])
+    })
+
+    return knex.schema.alterTable("keys", function (table) {
+        table.dropColumn('policy')
+    })
+}
@@ -0,0 +1,23 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    await knex.schema.alterTable("api_clients", function (table) {
+        table.text('sign_rate_limit')
+    })
+
+    return knex.schema.alterTable("keys", function (table) {
+        table.text('sign_rate_limit')
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    await knex.schema.alterTable("keys", function (table) {
+        table.dropColumn('sign_rate_limit')
+    })
+
+    return knex.schema.alterTable("api_clients", function (table) {
+        table.dropColumn('sign_rate_limit')
+    })
+}
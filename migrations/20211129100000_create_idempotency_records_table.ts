@@ -0,0 +1,26 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    return knex.schema.createTable("idempotency_records", function (table) {
+        table.string('id', 255).primary()
+        table.string('principal_id', 255).notNullable()
+        table.string('idempotency_key', 255).notNullable()
+        table.string('method', 16).notNullable()
+        table.string('path', 255).notNullable()
+        table.string('request_hash', 64).notNullable()
+        table.string('status', 32).notNullable()
+        table.integer('response_status').notNullable().defaultTo(0)
+        table.text('response_body', 'mediumtext')
+        table.dateTime('expires_at').notNullable()
+        table.dateTime('created_at').notNullable()
+        table.dateTime('updated_at').notNullable()
+        table.unique(['principal_id', 'idempotency_key'])
+        table.index(['expires_at'])
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    return knex.schema.dropTableIfExists('idempotency_records')
+}
@@ -0,0 +1,15 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    return knex.schema.alterTable("keys", function (table) {
+        table.text('cache_policy')
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    return knex.schema.alterTable("keys", function (table) {
+        table.dropColumn('cache_policy')
+    })
+}
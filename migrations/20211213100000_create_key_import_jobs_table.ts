@@ -0,0 +1,24 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    return knex.schema.createTable("key_import_jobs", function (table) {
+        table.string('id', 255).primary()
+        table.string('method', 64).notNullable()
+        table.text('public_key').notNullable()
+        table.text('private_key_encrypted').notNullable()
+        table.string('tenant_id', 255).notNullable()
+        table.string('owner_id', 255).notNullable()
+        table.boolean('long_lived').notNullable().defaultTo(false)
+        table.dateTime('used_at')
+        table.dateTime('expires_at')
+        table.dateTime('created_at').notNullable()
+        table.dateTime('updated_at').notNullable()
+        table.index(['tenant_id'])
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    return knex.schema.dropTableIfExists('key_import_jobs')
+}
@@ -0,0 +1,37 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    await knex.schema.createTable("key_operation_requests", function (table) {
+        table.string('id', 255).primary()
+        table.string('key_id', 255).notNullable()
+        table.string('tenant_id', 255).notNullable()
+        table.string('operation', 32).notNullable()
+        table.text('payload')
+        table.string('requested_by', 255).notNullable()
+        table.string('status', 32).notNullable()
+        table.integer('approvals_required').notNullable()
+        table.dateTime('expires_at').notNullable()
+        table.dateTime('executed_at')
+        table.dateTime('created_at').notNullable()
+        table.dateTime('updated_at').notNullable()
+        table.index(['tenant_id', 'status'])
+        table.index(['key_id'])
+    })
+
+    return knex.schema.createTable("key_operation_approvals", function (table) {
+        table.string('id', 255).primary()
+        table.string('request_id', 255).notNullable()
+        table.string('approver_id', 255).notNullable()
+        table.string('decision', 16).notNullable()
+        table.text('comment')
+        table.dateTime('created_at').notNullable()
+        table.unique(['request_id', 'approver_id'])
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    await knex.schema.dropTableIfExists('key_operation_approvals')
+    return knex.schema.dropTableIfExists('key_operation_requests')
+}
@@ -0,0 +1,23 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    await knex.schema.alterTable("keys", function (table) {
+        table.string('kek_id', 64).notNullable().defaultTo('')
+    })
+
+    return knex.schema.alterTable("key_versions", function (table) {
+        table.string('kek_id', 64).notNullable().defaultTo('')
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    await knex.schema.alterTable("key_versions", function (table) {
+        table.dropColumn('kek_id')
+    })
+
+    return knex.schema.alterTable("keys", function (table) {
+        table.dropColumn('kek_id')
+    })
+}
@@ -0,0 +1,22 @@
+import * as Knex from "knex";
+
+
+export async function up(knex: Knex): Promise<void> {
+    return knex.schema.createTable("key_escrows", function (table) {
+        table.string('id', 255).primary()
+        table.string('key_id', 255).notNullable()
+        table.integer('version').notNullable()
+        table.integer('threshold').notNullable()
+        table.text('wrapped_key').notNullable()
+        table.text('shares').notNullable()
+        table.dateTime('recovered_at')
+        table.dateTime('created_at').notNullable()
+        table.dateTime('updated_at').notNullable()
+        table.unique(['key_id', 'version'])
+    })
+}
+
+
+export async function down(knex: Knex): Promise<void> {
+    return knex.schema.dropTableIfExists('key_escrows')
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+	"time"
+)
+
+type APIClient struct {
+	ID                     string     `json:"id" gorm:"id"`
+	Name                   string     `json:"name" gorm:"name"`
+	TenantID               string     `json:"tenant_id" gorm:"tenant_id"`
+	APIKeyPrefix           *string    `json:"api_key_prefix" gorm:"api_key_prefix"`
+	APIKeyHash             *string    `json:"-" gorm:"api_key_hash"`
+	CertificateFingerprint *string    `json:"certificate_fingerprint" gorm:"certificate_fingerprint"`
+	IsAdmin                bool       `json:"is_admin" gorm:"is_admin"`
+	SignRateLimit          *RateLimit `json:"sign_rate_limit" gorm:"sign_rate_limit"`
+	RevokedAt              *time.Time `json:"revoked_at,omitempty" gorm:"revoked_at"`
+	CreatedAt              *time.Time `json:"created_at" gorm:"created_at"`
+	UpdatedAt              *time.Time `json:"updated_at" gorm:"updated_at"`
+}
+
+func (m APIClient) TableName() string {
+	return "api_clients"
+}
+
+func NewAPIClient(name string, tenantID string, isAdmin bool) *APIClient {
+	return &APIClient{
+		ID:        utils.GetUUID(),
+		Name:      name,
+		TenantID:  tenantID,
+		IsAdmin:   isAdmin,
+		CreatedAt: utils.GetCurrentDateTime(),
+		UpdatedAt: utils.GetCurrentDateTime(),
+	}
+}
+
+// Principal grants clients every key scope, admin clients also get keys:admin
+func (m APIClient) Principal() *Principal {
+	scopes := []string{
+		string(consts.ScopeKeysGenerate),
+		string(consts.ScopeKeysSign),
+		string(consts.ScopeKeysRead),
+	}
+	if m.IsAdmin {
+		scopes = append(scopes, string(consts.ScopeKeysAdmin))
+	}
+
+	return &Principal{
+		ID:        m.ID,
+		TenantID:  m.TenantID,
+		Type:      string(consts.PrincipalTypeClient),
+		Scopes:    scopes,
+		RateLimit: m.SignRateLimit,
+	}
+}
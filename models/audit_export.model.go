@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditExport keeps the detached signature of an exported batch of audit events,
+// the signature covers the hex encoded SHA-256 digest of the exported bytes
+type AuditExport struct {
+	ID                string     `json:"id" gorm:"id"`
+	ActorID           string     `json:"actor_id" gorm:"actor_id"`
+	TenantID          string     `json:"tenant_id" gorm:"tenant_id"`
+	Format            string     `json:"format" gorm:"format"`
+	Filters           string     `json:"filters" gorm:"filters"`
+	EventCount        int64      `json:"event_count" gorm:"event_count"`
+	FirstSequence     int64      `json:"first_sequence" gorm:"first_sequence"`
+	LastSequence      int64      `json:"last_sequence" gorm:"last_sequence"`
+	Digest            string     `json:"digest" gorm:"digest"`
+	Signature         string     `json:"signature" gorm:"signature"`
+	SigningKeyID      string     `json:"signing_key_id" gorm:"signing_key_id"`
+	SigningKeyVersion int        `json:"signing_key_version" gorm:"signing_key_version"`
+	SigningPublicKey  string     `json:"signing_public_key" gorm:"-"`
+	CreatedAt         *time.Time `json:"created_at" gorm:"created_at"`
+}
+
+func (m AuditExport) TableName() string {
+	return "audit_exports"
+}
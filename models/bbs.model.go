@@ -0,0 +1,24 @@
+package models
+
+// BBSSignature is a BBS signature of a header and many messages made by a BLS12381G2 key
+type BBSSignature struct {
+	KeyID     string `json:"key_id"`
+	Version   int    `json:"version"`
+	Signature string `json:"signature"`
+}
+
+// BBS2023Proof is the base proof value of the bbs-2023 cryptosuite issued by a key with the verification method
+// of its did:key
+type BBS2023Proof struct {
+	KeyID              string `json:"key_id"`
+	Version            int    `json:"version"`
+	VerificationMethod string `json:"verification_method"`
+	ProofValue         string `json:"proof_value"`
+}
+
+// BBS2023DerivedProof is a derived proof value of the bbs-2023 cryptosuite with the canonical N-Quads of the
+// disclosed statements it verifies
+type BBS2023DerivedProof struct {
+	ProofValue string   `json:"proof_value"`
+	Statements []string `json:"statements"`
+}
@@ -0,0 +1,39 @@
+package models
+
+// DIDDocument is the DID document of a did:key or did:jwk, with its single verification method
+// referenced by every verification relationship
+type DIDDocument struct {
+	Context              []string                `json:"@context"`
+	ID                   string                  `json:"id"`
+	VerificationMethod   []DIDVerificationMethod `json:"verificationMethod"`
+	Authentication       []string                `json:"authentication"`
+	AssertionMethod      []string                `json:"assertionMethod"`
+	CapabilityInvocation []string                `json:"capabilityInvocation"`
+	CapabilityDelegation []string                `json:"capabilityDelegation"`
+}
+
+// DIDVerificationMethod holds the public key in the one property its type defines, the context is only set
+// when the verification method is returned on its own
+type DIDVerificationMethod struct {
+	Context            string      `json:"@context,omitempty"`
+	ID                 string      `json:"id"`
+	Type               string      `json:"type"`
+	Controller         string      `json:"controller"`
+	PublicKeyJWK       interface{} `json:"publicKeyJwk,omitempty"`
+	PublicKeyMultibase string      `json:"publicKeyMultibase,omitempty"`
+	PublicKeyPEM       string      `json:"publicKeyPem,omitempty"`
+}
+
+func NewDIDDocument(context []string, verificationMethod *DIDVerificationMethod) *DIDDocument {
+	relationships := []string{verificationMethod.ID}
+
+	return &DIDDocument{
+		Context:              context,
+		ID:                   verificationMethod.Controller,
+		VerificationMethod:   []DIDVerificationMethod{*verificationMethod},
+		Authentication:       relationships,
+		AssertionMethod:      relationships,
+		CapabilityInvocation: relationships,
+		CapabilityDelegation: relationships,
+	}
+}
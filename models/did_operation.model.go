@@ -0,0 +1,22 @@
+package models
+
+// DIDOperation is the canonical payload of an operation of the DID registry, its JSON keeps the member order
+// of the struct and leaves out the members the operation does not use
+type DIDOperation struct {
+	Operation  string `json:"operation"`
+	DIDAddress string `json:"did_address,omitempty"`
+	PublicKey  string `json:"public_key,omitempty"`
+	KeyType    string `json:"key_type,omitempty"`
+	KeyID      string `json:"key_id,omitempty"`
+	Controller string `json:"controller,omitempty"`
+	Nonce      string `json:"nonce,omitempty"`
+}
+
+// DIDOperationEnvelope is what the DID registry takes: the base64 of the JSON of the operation and its signature
+type DIDOperationEnvelope struct {
+	Message   string        `json:"message"`
+	Signature string        `json:"signature"`
+	KeyID     string        `json:"key_id"`
+	Version   int           `json:"version"`
+	Payload   *DIDOperation `json:"payload"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+// IdempotencyRecord keeps the first response to a request sent with an Idempotency-Key header,
+// retries with the same key and request get that response back
+type IdempotencyRecord struct {
+	ID             string     `json:"id" gorm:"id"`
+	PrincipalID    string     `json:"principal_id" gorm:"principal_id"`
+	IdempotencyKey string     `json:"idempotency_key" gorm:"idempotency_key"`
+	Method         string     `json:"method" gorm:"method"`
+	Path           string     `json:"path" gorm:"path"`
+	RequestHash    string     `json:"request_hash" gorm:"request_hash"`
+	Status         string     `json:"status" gorm:"status"`
+	ResponseStatus int        `json:"response_status" gorm:"response_status"`
+	ResponseBody   string     `json:"response_body" gorm:"response_body"`
+	ExpiresAt      *time.Time `json:"expires_at" gorm:"expires_at"`
+	CreatedAt      *time.Time `json:"created_at" gorm:"created_at"`
+	UpdatedAt      *time.Time `json:"updated_at" gorm:"updated_at"`
+}
+
+func (m IdempotencyRecord) TableName() string {
+	return "idempotency_records"
+}
+
+func NewIdempotencyRecord(principalID string, idempotencyKey string, method string, path string, requestHash string, retention time.Duration) *IdempotencyRecord {
+	expiresAt := utils.GetCurrentDateTime().Add(retention)
+	return &IdempotencyRecord{
+		ID:             utils.GetUUID(),
+		PrincipalID:    principalID,
+		IdempotencyKey: idempotencyKey,
+		Method:         method,
+		Path:           path,
+		RequestHash:    requestHash,
+		Status:         string(consts.IdempotencyStatusInProgress),
+		ExpiresAt:      &expiresAt,
+		CreatedAt:      utils.GetCurrentDateTime(),
+		UpdatedAt:      utils.GetCurrentDateTime(),
+	}
+}
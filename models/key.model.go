@@ -6,25 +6,39 @@ import (
 )
 
 type Key struct {
-	ID                  string     `json:"id" gorm:"id"`
-	PublicKey           string     `json:"public_key" gorm:"public_key"`
-	PrivateKeyEncrypted string     `json:"private_key_encrypted" gorm:"private_key_encrypted"`
-	Type                string     `json:"type" gorm:"type"`
-	CreatedAt           *time.Time `json:"created_at" gorm:"created_at"`
-	UpdatedAt           *time.Time `json:"updated_at" gorm:"updated_at"`
-	DeletedAt           *time.Time `json:"deleted_at,omitempty" gorm:"deleted_at"`
+	ID                  string          `json:"id" gorm:"id"`
+	PublicKey           string          `json:"public_key" gorm:"public_key"`
+	PrivateKeyEncrypted string          `json:"private_key_encrypted" gorm:"private_key_encrypted"`
+	KEKID               string          `json:"kek_id" gorm:"kek_id"`
+	Type                string          `json:"type" gorm:"type"`
+	Version             int             `json:"version" gorm:"version"`
+	TenantID            string          `json:"tenant_id" gorm:"tenant_id"`
+	OwnerID             string          `json:"owner_id" gorm:"owner_id"`
+	Alias               *string         `json:"alias" gorm:"alias"`
+	Tags                []KeyTag        `json:"tags" gorm:"foreignKey:KeyID"`
+	Policy              *KeyPolicy      `json:"policy" gorm:"policy"`
+	SignRateLimit       *RateLimit      `json:"sign_rate_limit" gorm:"sign_rate_limit"`
+	CachePolicy         *KeyCachePolicy `json:"cache_policy" gorm:"cache_policy"`
+	CreatedAt           *time.Time      `json:"created_at" gorm:"created_at"`
+	UpdatedAt           *time.Time      `json:"updated_at" gorm:"updated_at"`
+	DeletedAt           *time.Time      `json:"deleted_at,omitempty" gorm:"deleted_at"`
+	DIDKey              string          `json:"did_key,omitempty" gorm:"-"`
+	DIDJWK              string          `json:"did_jwk,omitempty" gorm:"-"`
 }
 
 func (m Key) TableName() string {
 	return "keys"
 }
 
-func NewKey(publicKey string, encryptedPrivateKey string, keyType string) *Key {
+func NewKey(publicKey string, encryptedPrivateKey string, keyType string, owner *Principal) *Key {
 	return &Key{
 		ID:                  utils.GetUUID(),
 		PublicKey:           publicKey,
 		PrivateKeyEncrypted: encryptedPrivateKey,
 		Type:                keyType,
+		Version:             1,
+		TenantID:            owner.TenantID,
+		OwnerID:             owner.ID,
 		CreatedAt:           utils.GetCurrentDateTime(),
 		UpdatedAt:           utils.GetCurrentDateTime(),
 	}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+type KeyAuditEvent struct {
+	ID            string     `json:"id" gorm:"id"`
+	Sequence      int64      `json:"sequence" gorm:"sequence"`
+	ActorID       string     `json:"actor_id" gorm:"actor_id"`
+	ActorType     string     `json:"actor_type" gorm:"actor_type"`
+	TenantID      string     `json:"tenant_id" gorm:"tenant_id"`
+	Operation     string     `json:"operation" gorm:"operation"`
+	KeyID         string     `json:"key_id" gorm:"key_id"`
+	KeyVersion    int        `json:"key_version" gorm:"key_version"`
+	MessageDigest string     `json:"message_digest" gorm:"message_digest"`
+	Outcome       string     `json:"outcome" gorm:"outcome"`
+	ErrorCode     string     `json:"error_code" gorm:"error_code"`
+	RequestID     string     `json:"request_id" gorm:"request_id"`
+	PreviousHash  string     `json:"previous_hash" gorm:"previous_hash"`
+	Hash          string     `json:"hash" gorm:"hash"`
+	CreatedAt     *time.Time `json:"created_at" gorm:"created_at"`
+}
+
+func (m KeyAuditEvent) TableName() string {
+	return "key_audit_events"
+}
+
+// ComputeHash commits to every field of the event and to the previous event through PreviousHash,
+// created_at is hashed in seconds because that is what the database keeps
+func (m KeyAuditEvent) ComputeHash() string {
+	var createdAt int64
+	if m.CreatedAt != nil {
+		createdAt = m.CreatedAt.Unix()
+	}
+
+	content, _ := json.Marshal([]interface{}{
+		m.PreviousHash,
+		m.ID,
+		m.Sequence,
+		m.ActorID,
+		m.ActorType,
+		m.TenantID,
+		m.Operation,
+		m.KeyID,
+		m.KeyVersion,
+		m.MessageDigest,
+		m.Outcome,
+		m.ErrorCode,
+		m.RequestID,
+		createdAt,
+	})
+	hash := sha256.Sum256(content)
+
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+)
+
+// KeyBackupManifest describes a backup archive, it is protected by an HMAC and protects the files by their digests
+type KeyBackupManifest struct {
+	FormatVersion int        `json:"format_version"`
+	CreatedAt     *time.Time `json:"created_at"`
+	// HSMKEKID is the KEK of the HSM the backup was made with
+	HSMKEKID string          `json:"hsm_kek_id"`
+	KEKs     []KeyBackupKEK  `json:"keks"`
+	Files    []KeyBackupFile `json:"files"`
+}
+
+// KeyBackupKEK counts the private keys a KEK is needed for, an empty KEKID counts the keys stored before
+// the KEK was recorded
+type KeyBackupKEK struct {
+	KEKID       string `json:"kek_id"`
+	Keys        int    `json:"keys"`
+	KeyVersions int    `json:"key_versions"`
+}
+
+type KeyBackupFile struct {
+	Name   string `json:"name"`
+	Rows   int    `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// KeyVersionBackupRecord is a key_versions row with the columns KeyVersion keeps out of JSON
+type KeyVersionBackupRecord struct {
+	ID                  string     `json:"id" gorm:"id"`
+	KeyID               string     `json:"key_id" gorm:"key_id"`
+	Version             int        `json:"version" gorm:"version"`
+	PublicKey           string     `json:"public_key" gorm:"public_key"`
+	PrivateKeyEncrypted string     `json:"private_key_encrypted" gorm:"private_key_encrypted"`
+	KEKID               string     `json:"kek_id" gorm:"kek_id"`
+	CreatedAt           *time.Time `json:"created_at" gorm:"created_at"`
+	UpdatedAt           *time.Time `json:"updated_at" gorm:"updated_at"`
+}
+
+func (m KeyVersionBackupRecord) TableName() string {
+	return "key_versions"
+}
+
+// KeyTagBackupRecord is a key_tags row with the columns KeyTag keeps out of JSON
+type KeyTagBackupRecord struct {
+	ID        string     `json:"id" gorm:"id"`
+	KeyID     string     `json:"key_id" gorm:"key_id"`
+	Name      string     `json:"name" gorm:"name"`
+	Value     string     `json:"value" gorm:"value"`
+	CreatedAt *time.Time `json:"created_at" gorm:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at" gorm:"updated_at"`
+}
+
+func (m KeyTagBackupRecord) TableName() string {
+	return "key_tags"
+}
+
+// KeyEscrowBackupRecord is a key_escrows row with the columns KeyEscrow keeps out of JSON
+type KeyEscrowBackupRecord struct {
+	ID          string          `json:"id" gorm:"id"`
+	KeyID       string          `json:"key_id" gorm:"key_id"`
+	Version     int             `json:"version" gorm:"version"`
+	Threshold   int             `json:"threshold" gorm:"threshold"`
+	WrappedKey  string          `json:"wrapped_key" gorm:"wrapped_key"`
+	Shares      KeyEscrowShares `json:"shares" gorm:"shares"`
+	RecoveredAt *time.Time      `json:"recovered_at" gorm:"recovered_at"`
+	CreatedAt   *time.Time      `json:"created_at" gorm:"created_at"`
+	UpdatedAt   *time.Time      `json:"updated_at" gorm:"updated_at"`
+}
+
+func (m KeyEscrowBackupRecord) TableName() string {
+	return "key_escrows"
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// KeyCachePolicy opts a key in to keeping its parsed private key in memory after a signature,
+// the cached key is dropped after TTLSeconds or MaxUses signatures, whichever comes first
+type KeyCachePolicy struct {
+	TTLSeconds int `json:"ttl_seconds"`
+	MaxUses    int `json:"max_uses,omitempty"`
+}
+
+func (m KeyCachePolicy) Value() (driver.Value, error) {
+	value, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(value), nil
+}
+
+func (m *KeyCachePolicy) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case nil:
+		return nil
+	}
+
+	return errors.New("key cache policy: unsupported column type")
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"ssi-gitlab.teda.th/ssi/core/utils"
+	"time"
+)
+
+type KeyDelegation struct {
+	ID          string     `json:"id" gorm:"id"`
+	KeyID       string     `json:"key_id" gorm:"key_id"`
+	PrincipalID string     `json:"principal_id" gorm:"principal_id"`
+	CreatedAt   *time.Time `json:"created_at" gorm:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at" gorm:"updated_at"`
+}
+
+func (m KeyDelegation) TableName() string {
+	return "key_delegations"
+}
+
+func NewKeyDelegation(keyID string, principalID string) *KeyDelegation {
+	return &KeyDelegation{
+		ID:          utils.GetUUID(),
+		KeyID:       keyID,
+		PrincipalID: principalID,
+		CreatedAt:   utils.GetCurrentDateTime(),
+		UpdatedAt:   utils.GetCurrentDateTime(),
+	}
+}
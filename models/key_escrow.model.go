@@ -0,0 +1,92 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+// KeyEscrowPolicy asks for a recovery copy of a key whose recovery key is split between the custodians,
+// Threshold of them must submit their share to recover the key
+type KeyEscrowPolicy struct {
+	Threshold int `json:"threshold"`
+	// Custodians are PKIX PEMs of the public keys the shares are encrypted to, one share each
+	Custodians []string `json:"custodians"`
+}
+
+// KeyEscrow is the recovery copy of a version of a key, the private key is wrapped with AES-KWP under a random
+// recovery key that only exists as Shamir shares encrypted to the custodians
+type KeyEscrow struct {
+	ID          string          `json:"id" gorm:"id"`
+	KeyID       string          `json:"key_id" gorm:"key_id"`
+	Version     int             `json:"version" gorm:"version"`
+	Threshold   int             `json:"threshold" gorm:"threshold"`
+	WrappedKey  string          `json:"-" gorm:"wrapped_key"`
+	Shares      KeyEscrowShares `json:"shares" gorm:"shares"`
+	RecoveredAt *time.Time      `json:"recovered_at" gorm:"recovered_at"`
+	CreatedAt   *time.Time      `json:"created_at" gorm:"created_at"`
+	UpdatedAt   *time.Time      `json:"updated_at" gorm:"updated_at"`
+}
+
+func (m KeyEscrow) TableName() string {
+	return "key_escrows"
+}
+
+// Policy returns the policy the escrow was made with, so the next version of the key is escrowed the same way
+func (m KeyEscrow) Policy() *KeyEscrowPolicy {
+	policy := &KeyEscrowPolicy{Threshold: m.Threshold, Custodians: make([]string, 0, len(m.Shares))}
+	for _, share := range m.Shares {
+		policy.Custodians = append(policy.Custodians, share.CustodianPublicKey)
+	}
+
+	return policy
+}
+
+func NewKeyEscrow(keyID string, version int, threshold int, wrappedKey string, shares KeyEscrowShares) *KeyEscrow {
+	return &KeyEscrow{
+		ID:         utils.GetUUID(),
+		KeyID:      keyID,
+		Version:    version,
+		Threshold:  threshold,
+		WrappedKey: wrappedKey,
+		Shares:     shares,
+		CreatedAt:  utils.GetCurrentDateTime(),
+		UpdatedAt:  utils.GetCurrentDateTime(),
+	}
+}
+
+// KeyEscrowShare is the share of a custodian, encrypted to its public key the same way an export is wrapped
+type KeyEscrowShare struct {
+	Index                int    `json:"index"`
+	CustodianPublicKey   string `json:"custodian_public_key"`
+	CustodianFingerprint string `json:"custodian_fingerprint"`
+	Format               string `json:"format"`
+	EncryptedShare       string `json:"encrypted_share"`
+}
+
+type KeyEscrowShares []KeyEscrowShare
+
+func (m KeyEscrowShares) Value() (driver.Value, error) {
+	value, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(value), nil
+}
+
+func (m *KeyEscrowShares) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case nil:
+		return nil
+	}
+
+	return errors.New("key escrow shares: unsupported column type")
+}
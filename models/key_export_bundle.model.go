@@ -0,0 +1,57 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"time"
+)
+
+// KeyExportBundle carries a key wrapped under the public key of an import job of another deployment,
+// with the metadata needed to recreate it there
+type KeyExportBundle struct {
+	// Format is the import method of the receiving job, or JWE
+	Format                 string              `json:"format"`
+	WrappedKey             string              `json:"wrapped_key"`
+	WrappingKeyFingerprint string              `json:"wrapping_key_fingerprint"`
+	Key                    *KeyExportBundleKey `json:"key"`
+	ExportedAt             *time.Time          `json:"exported_at"`
+}
+
+type KeyExportBundleKey struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Version   int               `json:"version"`
+	PublicKey string            `json:"public_key"`
+	Alias     *string           `json:"alias"`
+	Tags      map[string]string `json:"tags"`
+	Policy    *KeyPolicy        `json:"policy"`
+}
+
+func NewKeyExportBundleKey(key *Key) *KeyExportBundleKey {
+	tags := make(map[string]string)
+	for _, tag := range key.Tags {
+		tags[tag.Name] = tag.Value
+	}
+
+	return &KeyExportBundleKey{
+		ID:        key.ID,
+		Type:      key.Type,
+		Version:   key.Version,
+		PublicKey: key.PublicKey,
+		Alias:     key.Alias,
+		Tags:      tags,
+		Policy:    key.Policy,
+	}
+}
+
+// Digest is the SHA-256 of the metadata, the wrapping of the key authenticates it so the receiving deployment
+// cannot be handed a key with a changed policy
+func (m KeyExportBundleKey) Digest() ([]byte, error) {
+	metadata, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(metadata)
+
+	return digest[:], nil
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+// KeyImportJob publishes a transport public key that clients wrap the private key they import to,
+// the transport private key is encrypted by the HSM like any other key. Ephemeral jobs expire and can be
+// used once, long-lived jobs have no expiry and can be used any number of times
+type KeyImportJob struct {
+	ID                  string     `json:"id" gorm:"id"`
+	Method              string     `json:"method" gorm:"method"`
+	PublicKey           string     `json:"public_key" gorm:"public_key"`
+	PrivateKeyEncrypted string     `json:"-" gorm:"private_key_encrypted"`
+	TenantID            string     `json:"tenant_id" gorm:"tenant_id"`
+	OwnerID             string     `json:"owner_id" gorm:"owner_id"`
+	LongLived           bool       `json:"long_lived" gorm:"long_lived"`
+	UsedAt              *time.Time `json:"used_at" gorm:"used_at"`
+	ExpiresAt           *time.Time `json:"expires_at" gorm:"expires_at"`
+	CreatedAt           *time.Time `json:"created_at" gorm:"created_at"`
+	UpdatedAt           *time.Time `json:"updated_at" gorm:"updated_at"`
+}
+
+func (m KeyImportJob) TableName() string {
+	return "key_import_jobs"
+}
+
+// NewKeyImportJob creates a long-lived job when ttl is 0
+func NewKeyImportJob(method string, publicKey string, encryptedPrivateKey string, owner *Principal, ttl time.Duration) *KeyImportJob {
+	job := &KeyImportJob{
+		ID:                  utils.GetUUID(),
+		Method:              method,
+		PublicKey:           publicKey,
+		PrivateKeyEncrypted: encryptedPrivateKey,
+		TenantID:            owner.TenantID,
+		OwnerID:             owner.ID,
+		LongLived:           ttl == 0,
+		CreatedAt:           utils.GetCurrentDateTime(),
+		UpdatedAt:           utils.GetCurrentDateTime(),
+	}
+	if ttl > 0 {
+		expiresAt := utils.GetCurrentDateTime().Add(ttl)
+		job.ExpiresAt = &expiresAt
+	}
+
+	return job
+}
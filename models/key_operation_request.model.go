@@ -0,0 +1,109 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+// KeyOperationRequest is an operation on a key whose policy needs a quorum, it is executed by the requester
+// once ApprovalsRequired distinct approvers other than the requester approved it and before it expires
+type KeyOperationRequest struct {
+	ID                string                      `json:"id" gorm:"id"`
+	KeyID             string                      `json:"key_id" gorm:"key_id"`
+	TenantID          string                      `json:"tenant_id" gorm:"tenant_id"`
+	Operation         string                      `json:"operation" gorm:"operation"`
+	Payload           *KeyOperationRequestPayload `json:"payload" gorm:"payload"`
+	RequestedBy       string                      `json:"requested_by" gorm:"requested_by"`
+	Status            string                      `json:"status" gorm:"status"`
+	ApprovalsRequired int                         `json:"approvals_required" gorm:"approvals_required"`
+	Approvals         []KeyOperationApproval      `json:"approvals" gorm:"foreignKey:RequestID"`
+	ExpiresAt         *time.Time                  `json:"expires_at" gorm:"expires_at"`
+	ExecutedAt        *time.Time                  `json:"executed_at" gorm:"executed_at"`
+	CreatedAt         *time.Time                  `json:"created_at" gorm:"created_at"`
+	UpdatedAt         *time.Time                  `json:"updated_at" gorm:"updated_at"`
+}
+
+func (m KeyOperationRequest) TableName() string {
+	return "key_operation_requests"
+}
+
+func (m KeyOperationRequest) IsExpired() bool {
+	return m.ExpiresAt != nil && m.ExpiresAt.Before(*utils.GetCurrentDateTime())
+}
+
+func NewKeyOperationRequest(key *Key, operation consts.KeyApprovalOperation, payload *KeyOperationRequestPayload, requester *Principal, ttl time.Duration) *KeyOperationRequest {
+	expiresAt := utils.GetCurrentDateTime().Add(ttl)
+
+	return &KeyOperationRequest{
+		ID:                utils.GetUUID(),
+		KeyID:             key.ID,
+		TenantID:          key.TenantID,
+		Operation:         string(operation),
+		Payload:           payload,
+		RequestedBy:       requester.ID,
+		Status:            string(consts.KeyApprovalStatusPending),
+		ApprovalsRequired: key.Policy.ApprovalsRequired,
+		Approvals:         make([]KeyOperationApproval, 0),
+		ExpiresAt:         &expiresAt,
+		CreatedAt:         utils.GetCurrentDateTime(),
+		UpdatedAt:         utils.GetCurrentDateTime(),
+	}
+}
+
+// KeyOperationRequestPayload holds the arguments the operation is executed with, so approvers see exactly what runs
+type KeyOperationRequestPayload struct {
+	Policy            *KeyPolicy `json:"policy,omitempty"`
+	WrappingPublicKey string     `json:"wrapping_public_key,omitempty"`
+	Format            string     `json:"format,omitempty"`
+}
+
+func (m KeyOperationRequestPayload) Value() (driver.Value, error) {
+	value, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(value), nil
+}
+
+func (m *KeyOperationRequestPayload) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case nil:
+		return nil
+	}
+
+	return errors.New("key operation request payload: unsupported column type")
+}
+
+type KeyOperationApproval struct {
+	ID         string     `json:"id" gorm:"id"`
+	RequestID  string     `json:"request_id" gorm:"request_id"`
+	ApproverID string     `json:"approver_id" gorm:"approver_id"`
+	Decision   string     `json:"decision" gorm:"decision"`
+	Comment    string     `json:"comment,omitempty" gorm:"comment"`
+	CreatedAt  *time.Time `json:"created_at" gorm:"created_at"`
+}
+
+func (m KeyOperationApproval) TableName() string {
+	return "key_operation_approvals"
+}
+
+func NewKeyOperationApproval(requestID string, approver *Principal, decision consts.KeyApprovalDecision, comment string) *KeyOperationApproval {
+	return &KeyOperationApproval{
+		ID:         utils.GetUUID(),
+		RequestID:  requestID,
+		ApproverID: approver.ID,
+		Decision:   string(decision),
+		Comment:    comment,
+		CreatedAt:  utils.GetCurrentDateTime(),
+	}
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+)
+
+// KeyPolicy restricts how a key may be used, an empty list allows everything of that kind
+type KeyPolicy struct {
+	Usages         []consts.KeyUsage         `json:"usages,omitempty"`
+	Algorithms     []consts.SigningAlgorithm `json:"algorithms,omitempty"`
+	Callers        []string                  `json:"callers,omitempty"`
+	MessageFormats []consts.MessageFormat    `json:"message_formats,omitempty"`
+	// MaxSignatures is the number of signatures allowed in every WindowSeconds, 0 means unlimited
+	MaxSignatures int `json:"max_signatures,omitempty"`
+	WindowSeconds int `json:"window_seconds,omitempty"`
+	// Exportable allows the key to leave the service wrapped under another public key, unlike the lists above
+	// it denies by default. ExportWrappingKeys limits the wrapping keys to these SHA-256 fingerprints of their PKIX DER
+	Exportable         bool     `json:"exportable,omitempty"`
+	ExportWrappingKeys []string `json:"export_wrapping_keys,omitempty"`
+	// ApprovalsRequired makes exporting, deleting and changing the policy of the key wait for that many distinct
+	// approvers, they are the principals in Approvers or admins of the tenant when it is empty
+	ApprovalsRequired int      `json:"approvals_required,omitempty"`
+	Approvers         []string `json:"approvers,omitempty"`
+}
+
+func (m KeyPolicy) Value() (driver.Value, error) {
+	value, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(value), nil
+}
+
+func (m *KeyPolicy) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case nil:
+		return nil
+	}
+
+	return errors.New("key policy: unsupported column type")
+}
+
+func (m KeyPolicy) AllowsUsage(usage consts.KeyUsage) bool {
+	if len(m.Usages) == 0 {
+		return true
+	}
+	for _, allowed := range m.Usages {
+		if allowed == usage {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m KeyPolicy) AllowsAlgorithm(algorithm consts.SigningAlgorithm) bool {
+	if len(m.Algorithms) == 0 {
+		return true
+	}
+	for _, allowed := range m.Algorithms {
+		if allowed == algorithm {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m KeyPolicy) AllowsCaller(principalID string) bool {
+	if len(m.Callers) == 0 {
+		return true
+	}
+	for _, allowed := range m.Callers {
+		if allowed == principalID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m KeyPolicy) AllowsExportTo(wrappingKeyFingerprint string) bool {
+	if !m.Exportable {
+		return false
+	}
+	if len(m.ExportWrappingKeys) == 0 {
+		return true
+	}
+	for _, allowed := range m.ExportWrappingKeys {
+		if allowed == wrappingKeyFingerprint {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m KeyPolicy) RequiresApproval() bool {
+	return m.ApprovalsRequired > 0
+}
+
+// IsApprover tells whether principalID is listed as an approver, an empty list leaves it to the scope of the caller
+func (m KeyPolicy) IsApprover(principalID string) bool {
+	for _, approver := range m.Approvers {
+		if approver == principalID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsStricterThan tells whether the policy allows nothing that other does not allow, a nil policy allows everything
+// but export
+func (m KeyPolicy) IsStricterThan(other *KeyPolicy) bool {
+	if other == nil {
+		other = &KeyPolicy{}
+	}
+
+	for _, usage := range m.Usages {
+		if !other.AllowsUsage(usage) {
+			return false
+		}
+	}
+	for _, algorithm := range m.Algorithms {
+		if !other.AllowsAlgorithm(algorithm) {
+			return false
+		}
+	}
+	for _, caller := range m.Callers {
+		if !other.AllowsCaller(caller) {
+			return false
+		}
+	}
+	if !isSubset(m.messageFormats(), other.messageFormats()) {
+		return false
+	}
+	// an empty list allows everything, it only restricts as much as an empty list
+	if (len(m.Usages) == 0 && len(other.Usages) > 0) || (len(m.Algorithms) == 0 && len(other.Algorithms) > 0) ||
+		(len(m.Callers) == 0 && len(other.Callers) > 0) || (len(m.MessageFormats) == 0 && len(other.MessageFormats) > 0) {
+		return false
+	}
+
+	if other.MaxSignatures > 0 &&
+		(m.MaxSignatures <= 0 || m.MaxSignatures > other.MaxSignatures || m.WindowSeconds < other.WindowSeconds) {
+		return false
+	}
+
+	if m.Exportable {
+		if !other.Exportable || (len(m.ExportWrappingKeys) == 0 && len(other.ExportWrappingKeys) > 0) {
+			return false
+		}
+		for _, fingerprint := range m.ExportWrappingKeys {
+			if !other.AllowsExportTo(fingerprint) {
+				return false
+			}
+		}
+	}
+
+	// approvers only restrict the admins of the tenant when other names none, so a list cannot replace them
+	if m.ApprovalsRequired < other.ApprovalsRequired || (len(m.Approvers) == 0) != (len(other.Approvers) == 0) {
+		return false
+	}
+	return isSubset(m.Approvers, other.Approvers)
+}
+
+func (m KeyPolicy) messageFormats() []string {
+	formats := make([]string, len(m.MessageFormats))
+	for i, format := range m.MessageFormats {
+		formats[i] = string(format)
+	}
+
+	return formats
+}
+
+func isSubset(values []string, of []string) bool {
+	for _, value := range values {
+		found := false
+		for _, allowed := range of {
+			if allowed == value {
+				found = true
+				break
+			}
+		}
+		if !found && len(of) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
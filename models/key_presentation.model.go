@@ -0,0 +1,12 @@
+package models
+
+// KeyPresentation is a verifiable presentation signed by a key, the presentation with its proof for the
+// data_integrity format and the JWT for the jwt format
+type KeyPresentation struct {
+	KeyID              string                 `json:"key_id"`
+	Version            int                    `json:"version"`
+	Format             string                 `json:"format"`
+	VerificationMethod string                 `json:"verification_method"`
+	Presentation       map[string]interface{} `json:"presentation,omitempty"`
+	JWT                string                 `json:"jwt,omitempty"`
+}
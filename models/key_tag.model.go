@@ -0,0 +1,35 @@
+package models
+
+import (
+	"ssi-gitlab.teda.th/ssi/core/utils"
+	"time"
+)
+
+type KeyTag struct {
+	ID        string     `json:"-" gorm:"id"`
+	KeyID     string     `json:"-" gorm:"key_id"`
+	Name      string     `json:"name" gorm:"name"`
+	Value     string     `json:"value" gorm:"value"`
+	CreatedAt *time.Time `json:"-" gorm:"created_at"`
+	UpdatedAt *time.Time `json:"-" gorm:"updated_at"`
+}
+
+func (m KeyTag) TableName() string {
+	return "key_tags"
+}
+
+func NewKeyTags(keyID string, tags map[string]string) []KeyTag {
+	keyTags := make([]KeyTag, 0)
+	for name, value := range tags {
+		keyTags = append(keyTags, KeyTag{
+			ID:        utils.GetUUID(),
+			KeyID:     keyID,
+			Name:      name,
+			Value:     value,
+			CreatedAt: utils.GetCurrentDateTime(),
+			UpdatedAt: utils.GetCurrentDateTime(),
+		})
+	}
+
+	return keyTags
+}
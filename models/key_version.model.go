@@ -0,0 +1,33 @@
+package models
+
+import (
+	"ssi-gitlab.teda.th/ssi/core/utils"
+	"time"
+)
+
+type KeyVersion struct {
+	ID                  string     `json:"id" gorm:"id"`
+	KeyID               string     `json:"key_id" gorm:"key_id"`
+	Version             int        `json:"version" gorm:"version"`
+	PublicKey           string     `json:"public_key" gorm:"public_key"`
+	PrivateKeyEncrypted string     `json:"-" gorm:"private_key_encrypted"`
+	KEKID               string     `json:"kek_id" gorm:"kek_id"`
+	CreatedAt           *time.Time `json:"created_at" gorm:"created_at"`
+	UpdatedAt           *time.Time `json:"updated_at" gorm:"updated_at"`
+}
+
+func (m KeyVersion) TableName() string {
+	return "key_versions"
+}
+
+func NewKeyVersion(keyID string, version int, publicKey string, encryptedPrivateKey string) *KeyVersion {
+	return &KeyVersion{
+		ID:                  utils.GetUUID(),
+		KeyID:               keyID,
+		Version:             version,
+		PublicKey:           publicKey,
+		PrivateKeyEncrypted: encryptedPrivateKey,
+		CreatedAt:           utils.GetCurrentDateTime(),
+		UpdatedAt:           utils.GetCurrentDateTime(),
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+)
+
+// Principal is the caller a key operation is performed on behalf of, it is not persisted
+type Principal struct {
+	ID       string   `json:"id"`
+	TenantID string   `json:"tenant_id"`
+	Type     string   `json:"type"`
+	Scopes   []string `json:"scopes"`
+	// RateLimit overrides the default signing rate limit of clients
+	RateLimit *RateLimit `json:"-"`
+}
+
+// NewAnonymousPrincipal is used when the request carries no identity, it only owns keys created without one
+func NewAnonymousPrincipal() *Principal {
+	return &Principal{}
+}
+
+// HasScope treats keys:admin as granting every scope
+func (m Principal) HasScope(scope consts.Scope) bool {
+	for _, s := range m.Scopes {
+		if s == string(scope) || s == string(consts.ScopeKeysAdmin) {
+			return true
+		}
+	}
+
+	return false
+}
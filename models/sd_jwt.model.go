@@ -0,0 +1,11 @@
+package models
+
+// SDJWT is an SD-JWT issued by a key: the combined format of the issuer-signed JWT followed by its disclosures,
+// each terminated by "~", and its parts
+type SDJWT struct {
+	KeyID       string   `json:"key_id"`
+	Version     int      `json:"version"`
+	SDJWT       string   `json:"sd_jwt"`
+	JWT         string   `json:"jwt"`
+	Disclosures []string `json:"disclosures"`
+}
@@ -0,0 +1,25 @@
+package requests
+
+import (
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type APIClientCreate struct {
+	core.BaseValidator
+	Name                   *string           `json:"name"`
+	TenantID               *string           `json:"tenant_id"`
+	CertificateFingerprint *string           `json:"certificate_fingerprint"`
+	IsAdmin                *bool             `json:"is_admin"`
+	SignRateLimit          *models.RateLimit `json:"sign_rate_limit"`
+}
+
+func (r APIClientCreate) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.Name, "name"))
+	r.Must(r.IsStrMax(r.Name, 255, "name"))
+	r.Must(r.IsStrMax(r.TenantID, 255, "tenant_id"))
+	r.Must(r.IsStrMax(r.CertificateFingerprint, 255, "certificate_fingerprint"))
+	r.Must(isRateLimit(r.SignRateLimit, "sign_rate_limit"))
+
+	return r.Error()
+}
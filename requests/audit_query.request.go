@@ -0,0 +1,55 @@
+package requests
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type AuditQuery struct {
+	core.BaseValidator
+	KeyID     *string `query:"key_id"`
+	ActorID   *string `query:"actor_id"`
+	Operation *string `query:"operation"`
+	Outcome   *string `query:"outcome"`
+	From      *string `query:"from"`
+	To        *string `query:"to"`
+	Format    *string `query:"format"`
+}
+
+func (r AuditQuery) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrIn(r.Outcome, fmt.Sprintf("%s|%s", consts.AuditOutcomeSuccess, consts.AuditOutcomeFailure), "outcome"))
+	r.Must(r.IsStrIn(r.Format, fmt.Sprintf("%s|%s", consts.AuditExportFormatJSONLines, consts.AuditExportFormatCSV), "format"))
+	r.Must(isRFC3339(r.From, "from"))
+	r.Must(isRFC3339(r.To, "to"))
+
+	return r.Error()
+}
+
+func isRFC3339(value *string, fieldPath string) (bool, *core.IValidMessage) {
+	if value == nil || *value == "" {
+		return true, nil
+	}
+
+	if _, err := time.Parse(time.RFC3339, *value); err != nil {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_DATETIME",
+			Message: "The " + fieldPath + " must be an RFC 3339 date-time",
+		}
+	}
+
+	return true, nil
+}
+
+// Time returns the parsed value of an already validated date-time field
+func (r AuditQuery) Time(value *string) *time.Time {
+	if value == nil || *value == "" {
+		return nil
+	}
+
+	t, _ := time.Parse(time.RFC3339, *value)
+	return &t
+}
@@ -0,0 +1,42 @@
+package requests
+
+import (
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type BBS2023Derive struct {
+	core.BaseValidator
+	ProofValue         *string  `json:"proof_value"`
+	Statements         []string `json:"statements"`
+	MandatoryIndexes   []int    `json:"mandatory_indexes"`
+	SelectiveIndexes   []int    `json:"selective_indexes"`
+	PresentationHeader *string  `json:"presentation_header"`
+}
+
+func (r BBS2023Derive) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.ProofValue, "proof_value"))
+	r.Must(isBBSMessages(r.Statements, "statements"))
+	r.Must(isStatementIndexes(r.MandatoryIndexes, len(r.Statements), "mandatory_indexes"))
+	r.Must(isStatementIndexes(r.SelectiveIndexes, len(r.Statements), "selective_indexes"))
+	r.Must(isBase64(r.PresentationHeader, "presentation_header"))
+
+	return r.Error()
+}
+
+type BBS2023Verify struct {
+	core.BaseValidator
+	VerificationMethod *string  `json:"verification_method"`
+	ProofValue         *string  `json:"proof_value"`
+	ProofConfig        *string  `json:"proof_config"`
+	Statements         []string `json:"statements"`
+}
+
+func (r BBS2023Verify) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.VerificationMethod, "verification_method"))
+	r.Must(isDIDURL(r.VerificationMethod, "verification_method"))
+	r.Must(r.IsStrRequired(r.ProofValue, "proof_value"))
+	r.Must(r.IsStrRequired(r.ProofConfig, "proof_config"))
+	r.Must(isBBSMessages(r.Statements, "statements"))
+
+	return r.Error()
+}
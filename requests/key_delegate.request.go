@@ -0,0 +1,16 @@
+package requests
+
+import (
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyDelegate struct {
+	core.BaseValidator
+	PrincipalID *string `json:"principal_id"`
+}
+
+func (r KeyDelegate) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.PrincipalID, "principal_id"))
+
+	return r.Error()
+}
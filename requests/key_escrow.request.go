@@ -0,0 +1,77 @@
+package requests
+
+import (
+	"fmt"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyRecover struct {
+	core.BaseValidator
+	Shares []helpers.SecretBytes `json:"shares"`
+}
+
+func (r KeyRecover) Valid(ctx core.IContext) core.IError {
+	r.Must(isEscrowShares(r.Shares, "shares"))
+
+	return r.Error()
+}
+
+func isEscrowShares(shares []helpers.SecretBytes, fieldPath string) (bool, *core.IValidMessage) {
+	if len(shares) < consts.KeyEscrowMinThreshold {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_SHARES",
+			Message: fmt.Sprintf("The %s must contain at least %d shares", fieldPath, consts.KeyEscrowMinThreshold),
+		}
+	}
+	for _, share := range shares {
+		if len(share) == 0 {
+			return false, &core.IValidMessage{
+				Name:    fieldPath,
+				Code:    "INVALID_SHARES",
+				Message: "The " + fieldPath + " must not contain empty shares",
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func isKeyEscrowPolicy(policy *models.KeyEscrowPolicy, fieldPath string) (bool, *core.IValidMessage) {
+	if policy == nil {
+		return true, nil
+	}
+
+	invalid := func(field string, message string) (bool, *core.IValidMessage) {
+		return false, &core.IValidMessage{
+			Name:    fieldPath + "." + field,
+			Code:    "INVALID_ESCROW",
+			Message: "The " + fieldPath + "." + field + " " + message,
+		}
+	}
+
+	if len(policy.Custodians) < consts.KeyEscrowMinThreshold || len(policy.Custodians) > consts.KeyEscrowMaxCustodians {
+		return invalid("custodians", fmt.Sprintf("must contain between %d and %d public keys",
+			consts.KeyEscrowMinThreshold, consts.KeyEscrowMaxCustodians))
+	}
+	fingerprints := make(map[string]bool)
+	for _, custodian := range policy.Custodians {
+		fingerprint, _, err := helpers.PublicKeyFingerprint(custodian)
+		if err != nil {
+			return invalid("custodians", "must only contain PKIX public key PEMs")
+		}
+		if fingerprints[fingerprint] {
+			return invalid("custodians", "must not contain the same public key twice")
+		}
+		fingerprints[fingerprint] = true
+	}
+	if policy.Threshold < consts.KeyEscrowMinThreshold || policy.Threshold > len(policy.Custodians) {
+		return invalid("threshold", fmt.Sprintf("must be between %d and the number of custodians", consts.KeyEscrowMinThreshold))
+	}
+
+	return true, nil
+}
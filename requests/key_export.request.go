@@ -0,0 +1,54 @@
+package requests
+
+import (
+	"fmt"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyExport struct {
+	core.BaseValidator
+	WrappingPublicKey *string `json:"wrapping_public_key"`
+	Format            *string `json:"format"`
+}
+
+func (r KeyExport) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.WrappingPublicKey, "wrapping_public_key"))
+	r.Must(r.IsStrIn(r.Format, fmt.Sprintf("%s|%s", consts.KeyExportFormatAESKWP, consts.KeyExportFormatJWE), "format"))
+
+	return r.Error()
+}
+
+type KeyImportBundle struct {
+	core.BaseValidator
+	ImportJobID *string                 `json:"import_job_id"`
+	Bundle      *models.KeyExportBundle `json:"bundle"`
+	Alias       *string                 `json:"alias"`
+	Tags        map[string]string       `json:"tags"`
+	Policy      *models.KeyPolicy       `json:"policy"`
+}
+
+func (r KeyImportBundle) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.ImportJobID, "import_job_id"))
+	r.Must(isKeyExportBundle(r.Bundle, "bundle"))
+	r.Must(isKeyAlias(r.Alias, "alias"))
+	r.Must(isKeyTags(r.Tags, "tags"))
+	r.Must(isKeyPolicy(r.Policy, "policy"))
+
+	return r.Error()
+}
+
+func isKeyExportBundle(bundle *models.KeyExportBundle, fieldPath string) (bool, *core.IValidMessage) {
+	if bundle == nil || bundle.Format == "" || bundle.WrappedKey == "" || bundle.WrappingKeyFingerprint == "" ||
+		bundle.Key == nil || bundle.Key.PublicKey == "" || bundle.Key.Type == "" {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_BUNDLE",
+			Message: "The " + fieldPath + " must be a bundle returned by the export of a key",
+		}
+	}
+
+	return isKeyPolicy(bundle.Key.Policy, fieldPath+".key.policy")
+}
@@ -0,0 +1,23 @@
+package requests
+
+import (
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyGenerate struct {
+	core.BaseValidator
+	Alias  *string                 `json:"alias"`
+	Tags   map[string]string       `json:"tags"`
+	Policy *models.KeyPolicy       `json:"policy"`
+	Escrow *models.KeyEscrowPolicy `json:"escrow"`
+}
+
+func (r KeyGenerate) Valid(ctx core.IContext) core.IError {
+	r.Must(isKeyAlias(r.Alias, "alias"))
+	r.Must(isKeyTags(r.Tags, "tags"))
+	r.Must(isKeyPolicy(r.Policy, "policy"))
+	r.Must(isKeyEscrowPolicy(r.Escrow, "escrow"))
+
+	return r.Error()
+}
@@ -0,0 +1,92 @@
+package requests
+
+import (
+	"fmt"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyImportJobCreate struct {
+	core.BaseValidator
+	Method     *string `json:"method"`
+	TTLSeconds *int    `json:"ttl_seconds"`
+	LongLived  *bool   `json:"long_lived"`
+}
+
+func (r KeyImportJobCreate) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.Method, "method"))
+	r.Must(r.IsStrIn(r.Method, fmt.Sprintf("%s|%s", consts.KeyImportMethodRSAOAEPAESKWP, consts.KeyImportMethodECDHAESKWP), "method"))
+	r.Must(isImportJobTTL(r.TTLSeconds, r.LongLived, "ttl_seconds"))
+
+	return r.Error()
+}
+
+// TTL returns the lifetime of the job in seconds, 0 for a long-lived job
+func (r KeyImportJobCreate) TTL() int {
+	if r.LongLived != nil && *r.LongLived {
+		return 0
+	}
+	if r.TTLSeconds == nil {
+		return consts.KeyImportJobDefaultTTLSeconds
+	}
+
+	return *r.TTLSeconds
+}
+
+type KeyImport struct {
+	core.BaseValidator
+	ImportJobID *string           `json:"import_job_id"`
+	WrappedKey  *string           `json:"wrapped_key"`
+	PublicKey   *string           `json:"public_key"`
+	KeyType     *string           `json:"key_type"`
+	Alias       *string           `json:"alias"`
+	Tags        map[string]string `json:"tags"`
+	Policy      *models.KeyPolicy `json:"policy"`
+}
+
+func (r KeyImport) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.ImportJobID, "import_job_id"))
+	r.Must(r.IsStrRequired(r.WrappedKey, "wrapped_key"))
+	r.Must(isBase64(r.WrappedKey, "wrapped_key"))
+	r.Must(r.IsStrIn(r.KeyType, fmt.Sprintf("%s|%s", consts.KeyTypeECDSA, consts.KeyTypeRSA), "key_type"))
+	r.Must(isKeyAlias(r.Alias, "alias"))
+	r.Must(isKeyTags(r.Tags, "tags"))
+	r.Must(isKeyPolicy(r.Policy, "policy"))
+
+	return r.Error()
+}
+
+func isImportJobTTL(ttl *int, longLived *bool, fieldPath string) (bool, *core.IValidMessage) {
+	if ttl == nil {
+		return true, nil
+	}
+
+	if (longLived != nil && *longLived) || *ttl < 1 || *ttl > consts.KeyImportJobMaxTTLSeconds {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_TTL",
+			Message: fmt.Sprintf("The %s must be between 1 and %d and cannot be set on a long-lived job", fieldPath, consts.KeyImportJobMaxTTLSeconds),
+		}
+	}
+
+	return true, nil
+}
+
+func isBase64(value *string, fieldPath string) (bool, *core.IValidMessage) {
+	if value == nil || *value == "" {
+		return true, nil
+	}
+
+	if !helpers.IsMessageFormat(*value, consts.MessageFormatBase64) {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_BASE64",
+			Message: "The " + fieldPath + " must be base64 encoded",
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,44 @@
+package requests
+
+import (
+	"regexp"
+	"strings"
+
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	keyAliasPattern = regexp.MustCompile(`^[^@\s]{1,255}$`)
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// isKeyAlias checks that an alias can never be confused with a key ID or an "id@version" reference
+func isKeyAlias(alias *string, fieldPath string) (bool, *core.IValidMessage) {
+	if alias == nil || *alias == "" {
+		return true, nil
+	}
+
+	if !keyAliasPattern.MatchString(*alias) || uuidPattern.MatchString(*alias) {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_ALIAS",
+			Message: "The " + fieldPath + " must not contain whitespace or '@', must not be a UUID and must be at most 255 characters",
+		}
+	}
+
+	return true, nil
+}
+
+func isKeyTags(tags map[string]string, fieldPath string) (bool, *core.IValidMessage) {
+	for name, value := range tags {
+		if strings.TrimSpace(name) == "" || len(name) > 255 || len(value) > 255 {
+			return false, &core.IValidMessage{
+				Name:    fieldPath,
+				Code:    "INVALID_TAGS",
+				Message: "The " + fieldPath + " must have non-empty names and names and values of at most 255 characters",
+			}
+		}
+	}
+
+	return true, nil
+}
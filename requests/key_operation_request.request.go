@@ -0,0 +1,83 @@
+package requests
+
+import (
+	"fmt"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyOperationRequestCreate struct {
+	core.BaseValidator
+	Operation         *string           `json:"operation"`
+	Policy            *models.KeyPolicy `json:"policy"`
+	WrappingPublicKey *string           `json:"wrapping_public_key"`
+	Format            *string           `json:"format"`
+	TTLSeconds        *int              `json:"ttl_seconds"`
+}
+
+func (r KeyOperationRequestCreate) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.Operation, "operation"))
+	r.Must(r.IsStrIn(r.Operation, fmt.Sprintf("%s|%s|%s", consts.KeyApprovalOperationExport,
+		consts.KeyApprovalOperationDelete, consts.KeyApprovalOperationUpdatePolicy), "operation"))
+	if r.Operation != nil && *r.Operation == string(consts.KeyApprovalOperationUpdatePolicy) {
+		r.Must(isKeyPolicyRequired(r.Policy, "policy"))
+		r.Must(isKeyPolicy(r.Policy, "policy"))
+	}
+	if r.Operation != nil && *r.Operation == string(consts.KeyApprovalOperationExport) {
+		r.Must(r.IsStrRequired(r.WrappingPublicKey, "wrapping_public_key"))
+		r.Must(r.IsStrIn(r.Format, fmt.Sprintf("%s|%s", consts.KeyExportFormatAESKWP, consts.KeyExportFormatJWE), "format"))
+	}
+	r.Must(isOperationRequestTTL(r.TTLSeconds, "ttl_seconds"))
+
+	return r.Error()
+}
+
+// TTL returns the lifetime of the request in seconds
+func (r KeyOperationRequestCreate) TTL() int {
+	if r.TTLSeconds == nil {
+		return consts.KeyApprovalDefaultTTLSeconds
+	}
+
+	return *r.TTLSeconds
+}
+
+type KeyOperationRequestDecide struct {
+	core.BaseValidator
+	Comment *string `json:"comment"`
+}
+
+func (r KeyOperationRequestDecide) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrMax(r.Comment, 1000, "comment"))
+
+	return r.Error()
+}
+
+func isKeyPolicyRequired(policy *models.KeyPolicy, fieldPath string) (bool, *core.IValidMessage) {
+	if policy == nil {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "REQUIRED",
+			Message: "The " + fieldPath + " field is required",
+		}
+	}
+
+	return true, nil
+}
+
+func isOperationRequestTTL(ttl *int, fieldPath string) (bool, *core.IValidMessage) {
+	if ttl == nil {
+		return true, nil
+	}
+
+	if *ttl < 1 || *ttl > consts.KeyApprovalMaxTTLSeconds {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_TTL",
+			Message: fmt.Sprintf("The %s must be between 1 and %d", fieldPath, consts.KeyApprovalMaxTTLSeconds),
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,104 @@
+package requests
+
+import (
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	keyUsages = map[consts.KeyUsage]bool{
+		consts.KeyUsageSign:    true,
+		consts.KeyUsageVerify:  true,
+		consts.KeyUsageEncrypt: true,
+		consts.KeyUsageDerive:  true,
+	}
+	signingAlgorithms = map[consts.SigningAlgorithm]bool{
+		consts.SigningAlgorithmES256: true,
+		consts.SigningAlgorithmRS256: true,
+		consts.SigningAlgorithmBBS:   true,
+	}
+	messageFormats = map[consts.MessageFormat]bool{
+		consts.MessageFormatText:   true,
+		consts.MessageFormatHex:    true,
+		consts.MessageFormatBase64: true,
+		consts.MessageFormatJSON:   true,
+		consts.MessageFormatJWS:    true,
+	}
+)
+
+func isKeyCachePolicy(policy *models.KeyCachePolicy, fieldPath string) (bool, *core.IValidMessage) {
+	if policy == nil {
+		return true, nil
+	}
+
+	if policy.TTLSeconds < 0 || policy.MaxUses < 0 {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_CACHE_POLICY",
+			Message: "The " + fieldPath + " must have a ttl_seconds of 0 to disable caching or more and a max_uses of 0 for unlimited or more",
+		}
+	}
+
+	return true, nil
+}
+
+func isKeyPolicy(policy *models.KeyPolicy, fieldPath string) (bool, *core.IValidMessage) {
+	if policy == nil {
+		return true, nil
+	}
+
+	invalid := func(field string, message string) (bool, *core.IValidMessage) {
+		return false, &core.IValidMessage{
+			Name:    fieldPath + "." + field,
+			Code:    "INVALID_POLICY",
+			Message: "The " + fieldPath + "." + field + " " + message,
+		}
+	}
+
+	for _, usage := range policy.Usages {
+		if !keyUsages[usage] {
+			return invalid("usages", "must only contain sign, verify, encrypt or derive")
+		}
+	}
+	for _, algorithm := range policy.Algorithms {
+		if !signingAlgorithms[algorithm] {
+			return invalid("algorithms", "must only contain ES256, RS256 or BBS")
+		}
+	}
+	for _, format := range policy.MessageFormats {
+		if !messageFormats[format] {
+			return invalid("message_formats", "must only contain text, hex, base64, json or jws")
+		}
+	}
+	for _, caller := range policy.Callers {
+		if caller == "" {
+			return invalid("callers", "must not contain empty principal IDs")
+		}
+	}
+	if policy.MaxSignatures < 0 {
+		return invalid("max_signatures", "must not be negative")
+	}
+	if policy.MaxSignatures > 0 && policy.WindowSeconds <= 0 {
+		return invalid("window_seconds", "must be positive when max_signatures is set")
+	}
+	for _, fingerprint := range policy.ExportWrappingKeys {
+		if len(fingerprint) != 64 || !helpers.IsMessageFormat(fingerprint, consts.MessageFormatHex) {
+			return invalid("export_wrapping_keys", "must only contain hex SHA-256 fingerprints")
+		}
+	}
+	if policy.ApprovalsRequired < 0 {
+		return invalid("approvals_required", "must not be negative")
+	}
+	if len(policy.Approvers) > 0 && policy.ApprovalsRequired > len(policy.Approvers) {
+		return invalid("approvals_required", "must not be more than the number of approvers")
+	}
+	for _, approver := range policy.Approvers {
+		if approver == "" {
+			return invalid("approvers", "must not contain empty principal IDs")
+		}
+	}
+
+	return true, nil
+}
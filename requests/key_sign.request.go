@@ -1,7 +1,6 @@
 package requests
 
 import (
-	"gitlab.finema.co/finema/etda/key-repository-api/models"
 	core "ssi-gitlab.teda.th/ssi/core"
 )
 
@@ -13,7 +12,6 @@ type KeySign struct {
 
 func (r KeySign) Valid(ctx core.IContext) core.IError {
 	r.Must(r.IsStrRequired(r.ID, "id"))
-	r.Must(r.IsExists(ctx, r.ID, models.Key{}.TableName(), "id", "id"))
 	r.Must(r.IsStrRequired(r.Message, "message"))
 
 	return r.Error()
@@ -0,0 +1,40 @@
+package requests
+
+import (
+	"fmt"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeySignBatchItem struct {
+	ID      *string `json:"id"`
+	Message *string `json:"message"`
+}
+
+type KeySignBatch struct {
+	core.BaseValidator
+	Items []KeySignBatchItem `json:"items"`
+}
+
+func (r KeySignBatch) Valid(ctx core.IContext) core.IError {
+	r.Must(isBatchSize(len(r.Items), "items"))
+	for i, item := range r.Items {
+		r.Must(r.IsStrRequired(item.ID, fmt.Sprintf("items[%d].id", i)))
+		r.Must(r.IsStrRequired(item.Message, fmt.Sprintf("items[%d].message", i)))
+	}
+
+	return r.Error()
+}
+
+func isBatchSize(size int, fieldPath string) (bool, *core.IValidMessage) {
+	if size < 1 || size > consts.KeySignBatchMaxItems {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_BATCH_SIZE",
+			Message: fmt.Sprintf("The %s must have between 1 and %d items", fieldPath, consts.KeySignBatchMaxItems),
+		}
+	}
+
+	return true, nil
+}
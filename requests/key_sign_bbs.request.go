@@ -0,0 +1,88 @@
+package requests
+
+import (
+	"fmt"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeySignBBS struct {
+	core.BaseValidator
+	ID       *string  `json:"id"`
+	Header   *string  `json:"header"`
+	Messages []string `json:"messages"`
+}
+
+func (r KeySignBBS) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.ID, "id"))
+	r.Must(isBase64(r.Header, "header"))
+	r.Must(isBBSMessages(r.Messages, "messages"))
+
+	return r.Error()
+}
+
+type KeySignBBS2023 struct {
+	core.BaseValidator
+	ID                *string  `json:"id"`
+	ProofConfig       *string  `json:"proof_config"`
+	Statements        []string `json:"statements"`
+	MandatoryIndexes  []int    `json:"mandatory_indexes"`
+	MandatoryPointers []string `json:"mandatory_pointers"`
+	HMACKey           *string  `json:"hmac_key"`
+}
+
+func (r KeySignBBS2023) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.ID, "id"))
+	r.Must(r.IsStrRequired(r.ProofConfig, "proof_config"))
+	r.Must(isBBSMessages(r.Statements, "statements"))
+	r.Must(isStatementIndexes(r.MandatoryIndexes, len(r.Statements), "mandatory_indexes"))
+	r.Must(isBBS2023HMACKey(r.HMACKey, "hmac_key"))
+
+	return r.Error()
+}
+
+func isBBSMessages(messages []string, fieldPath string) (bool, *core.IValidMessage) {
+	if len(messages) == 0 {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "REQUIRED",
+			Message: "The " + fieldPath + " field is required",
+		}
+	}
+
+	return true, nil
+}
+
+// isStatementIndexes checks that the indexes are ascending indexes of a list of size items
+func isStatementIndexes(indexes []int, size int, fieldPath string) (bool, *core.IValidMessage) {
+	for i, index := range indexes {
+		if index < 0 || index >= size || i > 0 && index <= indexes[i-1] {
+			return false, &core.IValidMessage{
+				Name:    fieldPath,
+				Code:    "INVALID_INDEXES",
+				Message: "The " + fieldPath + " must be ascending indexes of the statements",
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func isBBS2023HMACKey(value *string, fieldPath string) (bool, *core.IValidMessage) {
+	if value == nil || *value == "" {
+		return true, nil
+	}
+
+	key, err := helpers.DecodeBase64(*value)
+	if err != nil || len(key) != consts.BBS2023HMACKeySize {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_HMAC_KEY",
+			Message: fmt.Sprintf("The %s must be %d base64 encoded bytes", fieldPath, consts.BBS2023HMACKeySize),
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,49 @@
+package requests
+
+import (
+	"fmt"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeySignDIDOperation struct {
+	core.BaseValidator
+	ID         *string `json:"id"`
+	Operation  *string `json:"operation"`
+	DIDAddress *string `json:"did_address"`
+	PublicKey  *string `json:"public_key"`
+	KeyType    *string `json:"key_type"`
+	KeyID      *string `json:"key_id"`
+	Controller *string `json:"controller"`
+	Nonce      *string `json:"nonce"`
+}
+
+func (r KeySignDIDOperation) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.ID, "id"))
+	r.Must(r.IsStrRequired(r.Operation, "operation"))
+	r.Must(r.IsStrIn(r.Operation, fmt.Sprintf("%s|%s|%s|%s|%s", consts.DIDOperationRegister, consts.DIDOperationAddKey,
+		consts.DIDOperationRevokeKey, consts.DIDOperationAddController, consts.DIDOperationDeactivate), "operation"))
+	r.Must(r.IsStrIn(r.KeyType, didVerificationMethodTypes, "key_type"))
+
+	if r.Operation == nil {
+		return r.Error()
+	}
+	operation := consts.DIDOperation(*r.Operation)
+	if operation != consts.DIDOperationRegister {
+		r.Must(r.IsStrRequired(r.DIDAddress, "did_address"))
+		r.Must(isDID(r.DIDAddress, "did_address"))
+		r.Must(r.IsStrRequired(r.Nonce, "nonce"))
+	}
+	switch operation {
+	case consts.DIDOperationAddKey:
+		r.Must(r.IsStrRequired(r.PublicKey, "public_key"))
+	case consts.DIDOperationRevokeKey:
+		r.Must(r.IsStrRequired(r.KeyID, "key_id"))
+	case consts.DIDOperationAddController:
+		r.Must(r.IsStrRequired(r.Controller, "controller"))
+		r.Must(isDID(r.Controller, "controller"))
+	}
+
+	return r.Error()
+}
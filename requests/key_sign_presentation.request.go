@@ -0,0 +1,93 @@
+package requests
+
+import (
+	"fmt"
+	"regexp"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var didURLPattern = regexp.MustCompile(`^did:[a-z0-9]+:[A-Za-z0-9._%:-]+#[A-Za-z0-9._~-]+$`)
+
+type KeySignPresentation struct {
+	core.BaseValidator
+	ID                 *string                `json:"id"`
+	Presentation       map[string]interface{} `json:"presentation"`
+	Challenge          *string                `json:"challenge"`
+	Domain             *string                `json:"domain"`
+	Format             *string                `json:"format"`
+	VerificationMethod *string                `json:"verification_method"`
+}
+
+func (r KeySignPresentation) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.ID, "id"))
+	r.Must(isVerifiablePresentation(r.Presentation, "presentation"))
+	r.Must(r.IsStrRequired(r.Challenge, "challenge"))
+	r.Must(r.IsStrIn(r.Format, fmt.Sprintf("%s|%s", consts.PresentationFormatDataIntegrity, consts.PresentationFormatJWT), "format"))
+	r.Must(isDIDURL(r.VerificationMethod, "verification_method"))
+
+	return r.Error()
+}
+
+// isVerifiablePresentation checks that the presentation is unsigned, has the VerifiablePresentation type and,
+// when it has a holder, that the holder is a DID
+func isVerifiablePresentation(presentation map[string]interface{}, fieldPath string) (bool, *core.IValidMessage) {
+	invalid := func(message string) (bool, *core.IValidMessage) {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_PRESENTATION",
+			Message: "The " + fieldPath + " " + message,
+		}
+	}
+
+	if presentation == nil {
+		return invalid("is required")
+	}
+	if !hasType(presentation["type"], consts.PresentationTypeVerifiablePresentation) {
+		return invalid("must have the " + consts.PresentationTypeVerifiablePresentation + " type")
+	}
+	if _, ok := presentation["proof"]; ok {
+		return invalid("must not have a proof")
+	}
+	if holder, ok := presentation["holder"]; ok {
+		holder, ok := holder.(string)
+		if !ok || !didPattern.MatchString(holder) {
+			return invalid("holder must be a DID")
+		}
+	}
+
+	return true, nil
+}
+
+// hasType accepts both the string and the array form of a JSON-LD "type"
+func hasType(value interface{}, expected string) bool {
+	switch value := value.(type) {
+	case string:
+		return value == expected
+	case []interface{}:
+		for _, item := range value {
+			if item == expected {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isDIDURL(value *string, fieldPath string) (bool, *core.IValidMessage) {
+	if value == nil || *value == "" {
+		return true, nil
+	}
+
+	if !didURLPattern.MatchString(*value) {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_DID_URL",
+			Message: "The " + fieldPath + " must be a DID followed by a '#' fragment",
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,73 @@
+package requests
+
+import (
+	"strings"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeySignSDJWT struct {
+	core.BaseValidator
+	ID          *string                `json:"id"`
+	Claims      map[string]interface{} `json:"claims"`
+	Disclosable []string               `json:"disclosable"`
+	HolderKeyID *string                `json:"holder_key_id"`
+	Kid         *string                `json:"kid"`
+}
+
+func (r KeySignSDJWT) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.ID, "id"))
+	r.Must(isSDJWTClaims(r.Claims, "claims"))
+	r.Must(isSDJWTDisclosable(r.Disclosable, "disclosable"))
+
+	return r.Error()
+}
+
+func isSDJWTClaims(claims map[string]interface{}, fieldPath string) (bool, *core.IValidMessage) {
+	if claims == nil {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "REQUIRED",
+			Message: "The " + fieldPath + " field is required",
+		}
+	}
+	for _, name := range []string{"_sd", "_sd_alg"} {
+		if _, ok := claims[name]; ok {
+			return false, &core.IValidMessage{
+				Name:    fieldPath + "." + name,
+				Code:    "INVALID_CLAIMS",
+				Message: "The " + fieldPath + " must not have the " + name + " claim",
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// isSDJWTDisclosable checks that the disclosable paths are JSON Pointers to claims other than those a verifier of
+// an SD-JWT VC needs to process it
+func isSDJWTDisclosable(pointers []string, fieldPath string) (bool, *core.IValidMessage) {
+	for _, pointer := range pointers {
+		path, err := helpers.ParseSDJWTPath(pointer)
+		if err != nil {
+			return false, &core.IValidMessage{
+				Name:    fieldPath,
+				Code:    "INVALID_PATH",
+				Message: "The " + fieldPath + " must only contain JSON Pointers such as /address/street_address",
+			}
+		}
+		for _, name := range consts.SDJWTNonDisclosableClaims {
+			if len(path) == 1 && path[0] == name {
+				return false, &core.IValidMessage{
+					Name:    fieldPath,
+					Code:    "INVALID_PATH",
+					Message: "The " + fieldPath + " must not contain " + strings.Join(consts.SDJWTNonDisclosableClaims, ", "),
+				}
+			}
+		}
+	}
+
+	return true, nil
+}
@@ -3,21 +3,42 @@ package requests
 import (
 	"fmt"
 	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
 	core "ssi-gitlab.teda.th/ssi/core"
 )
 
 type KeyStore struct {
 	core.BaseValidator
-	PublicKey  *string `json:"public_key"`
-	PrivateKey *string `json:"private_key"`
-	KeyType    *string `json:"key_type"`
+	PublicKey          *string                 `json:"public_key"`
+	PrivateKey         helpers.SecretBytes     `json:"private_key"`
+	PrivateKeyPassword helpers.SecretBytes     `json:"private_key_password"`
+	KeyType            *string                 `json:"key_type"`
+	Alias              *string                 `json:"alias"`
+	Tags               map[string]string       `json:"tags"`
+	Policy             *models.KeyPolicy       `json:"policy"`
+	Escrow             *models.KeyEscrowPolicy `json:"escrow"`
 }
 
 func (r KeyStore) Valid(ctx core.IContext) core.IError {
-	r.Must(r.IsStrRequired(r.PublicKey, "public_key"))
-	r.Must(r.IsStrRequired(r.PrivateKey, "private_key"))
+	r.Must(isSecretRequired(r.PrivateKey, "private_key"))
 	r.Must(r.IsStrIn(r.KeyType, fmt.Sprintf("%s|%s", consts.KeyTypeECDSA, consts.KeyTypeRSA), "key_type"))
-	r.Must(r.IsStrRequired(r.KeyType, "key_type"))
+	r.Must(isKeyAlias(r.Alias, "alias"))
+	r.Must(isKeyTags(r.Tags, "tags"))
+	r.Must(isKeyPolicy(r.Policy, "policy"))
+	r.Must(isKeyEscrowPolicy(r.Escrow, "escrow"))
 
 	return r.Error()
 }
+
+func isSecretRequired(secret helpers.SecretBytes, fieldPath string) (bool, *core.IValidMessage) {
+	if len(secret) == 0 {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "REQUIRED",
+			Message: "The " + fieldPath + " field is required",
+		}
+	}
+
+	return true, nil
+}
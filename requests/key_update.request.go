@@ -0,0 +1,25 @@
+package requests
+
+import (
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyUpdate struct {
+	core.BaseValidator
+	Alias         *string                `json:"alias"`
+	Tags          map[string]string      `json:"tags"`
+	Policy        *models.KeyPolicy      `json:"policy"`
+	SignRateLimit *models.RateLimit      `json:"sign_rate_limit"`
+	CachePolicy   *models.KeyCachePolicy `json:"cache_policy"`
+}
+
+func (r KeyUpdate) Valid(ctx core.IContext) core.IError {
+	r.Must(isKeyAlias(r.Alias, "alias"))
+	r.Must(isKeyTags(r.Tags, "tags"))
+	r.Must(isKeyPolicy(r.Policy, "policy"))
+	r.Must(isRateLimit(r.SignRateLimit, "sign_rate_limit"))
+	r.Must(isKeyCachePolicy(r.CachePolicy, "cache_policy"))
+
+	return r.Error()
+}
@@ -0,0 +1,68 @@
+package requests
+
+import (
+	"fmt"
+	"regexp"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+var (
+	didPattern                 = regexp.MustCompile(`^did:[a-z0-9]+:[A-Za-z0-9._%:-]+$`)
+	didFragmentPattern         = regexp.MustCompile(`^[A-Za-z0-9._~-]+$`)
+	didVerificationMethodTypes = fmt.Sprintf("%s|%s|%s|%s|%s",
+		consts.DIDVerificationMethodJsonWebKey2020,
+		consts.DIDVerificationMethodEcdsaSecp256r1VerificationKey2019,
+		consts.DIDVerificationMethodMultikey,
+		consts.DIDVerificationMethodSecp256r1VerificationKey2018,
+		consts.DIDVerificationMethodRsaVerificationKey2018)
+)
+
+type KeyVerificationMethod struct {
+	core.BaseValidator
+	Controller *string `query:"controller"`
+	Type       *string `query:"type"`
+	Fragment   *string `query:"fragment"`
+}
+
+func (r KeyVerificationMethod) Valid(ctx core.IContext) core.IError {
+	r.Must(r.IsStrRequired(r.Controller, "controller"))
+	r.Must(isDID(r.Controller, "controller"))
+	r.Must(r.IsStrIn(r.Type, didVerificationMethodTypes, "type"))
+	r.Must(isDIDFragment(r.Fragment, "fragment"))
+
+	return r.Error()
+}
+
+func isDID(value *string, fieldPath string) (bool, *core.IValidMessage) {
+	if value == nil || *value == "" {
+		return true, nil
+	}
+
+	if !didPattern.MatchString(*value) {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_DID",
+			Message: "The " + fieldPath + " must be a DID without path, query or fragment",
+		}
+	}
+
+	return true, nil
+}
+
+func isDIDFragment(value *string, fieldPath string) (bool, *core.IValidMessage) {
+	if value == nil || *value == "" {
+		return true, nil
+	}
+
+	if !didFragmentPattern.MatchString(*value) {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_FRAGMENT",
+			Message: "The " + fieldPath + " must only contain letters, digits, '.', '_', '~' or '-', without the '#'",
+		}
+	}
+
+	return true, nil
+}
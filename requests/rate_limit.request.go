@@ -0,0 +1,22 @@
+package requests
+
+import (
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+func isRateLimit(limit *models.RateLimit, fieldPath string) (bool, *core.IValidMessage) {
+	if limit == nil {
+		return true, nil
+	}
+
+	if limit.Rate < 0 || limit.Burst < 0 || (limit.Rate > 0 && limit.Burst < 1) {
+		return false, &core.IValidMessage{
+			Name:    fieldPath,
+			Code:    "INVALID_RATE_LIMIT",
+			Message: "The " + fieldPath + " must have a rate of 0 for unlimited or a positive rate with a burst of at least 1",
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"crypto/x509"
+	"errors"
+	"strings"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gorm.io/gorm"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type APIClientCreatePayload struct {
+	Name                   string
+	TenantID               string
+	CertificateFingerprint string
+	IsAdmin                bool
+	SignRateLimit          *models.RateLimit
+}
+
+type APIClientWithKey struct {
+	*models.APIClient
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type IAPIClientService interface {
+	Find(id string) (*models.APIClient, core.IError)
+	Pagination(pageOptions *core.PageOptions) ([]models.APIClient, *core.PageResponse, core.IError)
+	Create(payload *APIClientCreatePayload) (*APIClientWithKey, core.IError)
+	Revoke(id string) core.IError
+	AuthenticateAPIKey(apiKey string) (*models.APIClient, core.IError)
+	AuthenticateCertificate(certificate *x509.Certificate) (*models.APIClient, core.IError)
+}
+
+type apiClientService struct {
+	ctx core.IContext
+}
+
+func NewAPIClientService(ctx core.IContext) IAPIClientService {
+	return &apiClientService{ctx: ctx}
+}
+
+func (s apiClientService) Find(id string) (*models.APIClient, core.IError) {
+	client := &models.APIClient{}
+	err := s.ctx.DB().First(client, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, emsgs.APIClientNotFoundError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return client, nil
+}
+
+func (s apiClientService) Pagination(pageOptions *core.PageOptions) ([]models.APIClient, *core.PageResponse, core.IError) {
+	clients := make([]models.APIClient, 0)
+
+	db := s.ctx.DB()
+	if pageOptions.Q != "" {
+		db = db.Where("name LIKE ?", "%"+pageOptions.Q+"%")
+	}
+
+	pageResponse, err := core.Paginate(db, &clients, pageOptions)
+	if err != nil {
+		return nil, nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return clients, pageResponse, nil
+}
+
+// Create registers a client, the API key is only returned here, only its hash is stored
+func (s apiClientService) Create(payload *APIClientCreatePayload) (*APIClientWithKey, core.IError) {
+	prefix, apiKey, err := helpers.GenerateAPIKey()
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+
+	apiKeyHash := helpers.HashAPIKey(apiKey)
+	client := models.NewAPIClient(payload.Name, payload.TenantID, payload.IsAdmin)
+	client.APIKeyPrefix = &prefix
+	client.APIKeyHash = &apiKeyHash
+	client.SignRateLimit = payload.SignRateLimit
+	if payload.CertificateFingerprint != "" {
+		fingerprint := strings.ToLower(strings.ReplaceAll(payload.CertificateFingerprint, ":", ""))
+		client.CertificateFingerprint = &fingerprint
+	}
+
+	err = s.ctx.DB().Create(client).Error
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return &APIClientWithKey{
+		APIClient: client,
+		APIKey:    apiKey,
+	}, nil
+}
+
+func (s apiClientService) Revoke(id string) core.IError {
+	client, ierr := s.Find(id)
+	if ierr != nil {
+		return s.ctx.NewError(ierr, ierr)
+	}
+
+	err := s.ctx.DB().Model(client).Updates(map[string]interface{}{
+		"revoked_at": utils.GetCurrentDateTime(),
+		"updated_at": utils.GetCurrentDateTime(),
+	}).Error
+	if err != nil {
+		return s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return nil
+}
+
+func (s apiClientService) AuthenticateAPIKey(apiKey string) (*models.APIClient, core.IError) {
+	client := &models.APIClient{}
+	err := s.ctx.DB().First(client, "api_key_prefix = ? AND revoked_at IS NULL", helpers.APIKeyPrefix(apiKey)).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, emsgs.UnauthorizedError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	if client.APIKeyHash == nil || !helpers.CompareAPIKeyHash(apiKey, *client.APIKeyHash) {
+		return nil, s.ctx.NewError(emsgs.UnauthorizedError, emsgs.UnauthorizedError)
+	}
+
+	return client, nil
+}
+
+func (s apiClientService) AuthenticateCertificate(certificate *x509.Certificate) (*models.APIClient, core.IError) {
+	client := &models.APIClient{}
+	err := s.ctx.DB().First(client, "certificate_fingerprint = ? AND revoked_at IS NULL", helpers.CertificateFingerprint(certificate)).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, emsgs.UnauthorizedError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return client, nil
+}
@@ -0,0 +1,74 @@
+// +build e2e
+
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type APIClientServiceTestSuite struct {
+	suite.Suite
+	rCtx core.IContext
+	racs IAPIClientService
+}
+
+func TestAPIClientServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(APIClientServiceTestSuite))
+}
+
+func (a *APIClientServiceTestSuite) SetupSuite() {
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	a.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+}
+
+func (a *APIClientServiceTestSuite) SetupTest() {
+	a.racs = NewAPIClientService(a.rCtx)
+}
+
+func (a *APIClientServiceTestSuite) TestAPIClientService_AuthenticateAPIKey_ExpectSuccess() {
+	client, ierr := a.racs.Create(&APIClientCreatePayload{Name: "issuer", TenantID: "tenant-1"})
+	a.Require().NoError(ierr)
+	a.Equal(*client.APIKeyPrefix, helpers.APIKeyPrefix(client.APIKey))
+
+	authenticated, ierr := a.racs.AuthenticateAPIKey(client.APIKey)
+	a.Require().NoError(ierr)
+	a.Equal(client.ID, authenticated.ID)
+
+	principal := authenticated.Principal()
+	a.Equal(client.ID, principal.ID)
+	a.Equal("tenant-1", principal.TenantID)
+	a.True(principal.HasScope(consts.ScopeKeysSign))
+	a.False(principal.HasScope(consts.ScopeKeysAdmin))
+}
+
+func (a *APIClientServiceTestSuite) TestAPIClientService_AuthenticateAPIKey_ExpectUnauthorized() {
+	client, ierr := a.racs.Create(&APIClientCreatePayload{Name: "issuer", TenantID: "tenant-1"})
+	a.Require().NoError(ierr)
+
+	// Expect error on another secret with the prefix of the client
+	_, ierr = a.racs.AuthenticateAPIKey(client.APIKey[:len(client.APIKey)-1] + "x")
+	a.Error(ierr)
+	a.Equal(emsgs.UnauthorizedError.GetCode(), ierr.GetCode())
+
+	// Expect error on an unknown prefix
+	_, ierr = a.racs.AuthenticateAPIKey("unknown")
+	a.Error(ierr)
+	a.Equal(emsgs.UnauthorizedError.GetCode(), ierr.GetCode())
+
+	// Expect error on the key of a revoked client
+	ierr = a.racs.Revoke(client.ID)
+	a.Require().NoError(ierr)
+	_, ierr = a.racs.AuthenticateAPIKey(client.APIKey)
+	a.Error(ierr)
+	a.Equal(emsgs.UnauthorizedError.GetCode(), ierr.GetCode())
+}
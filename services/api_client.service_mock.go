@@ -0,0 +1,47 @@
+package services
+
+import (
+	"crypto/x509"
+
+	"github.com/stretchr/testify/mock"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type MockAPIClientService struct {
+	mock.Mock
+}
+
+func NewMockAPIClientService() *MockAPIClientService {
+	return &MockAPIClientService{}
+}
+
+func (m *MockAPIClientService) Find(id string) (*models.APIClient, core.IError) {
+	args := m.Called(id)
+	return args.Get(0).(*models.APIClient), core.MockIError(args, 1)
+}
+
+func (m *MockAPIClientService) Pagination(pageOptions *core.PageOptions) ([]models.APIClient, *core.PageResponse, core.IError) {
+	args := m.Called(pageOptions)
+	return args.Get(0).([]models.APIClient), args.Get(1).(*core.PageResponse), core.MockIError(args, 2)
+}
+
+func (m *MockAPIClientService) Create(payload *APIClientCreatePayload) (*APIClientWithKey, core.IError) {
+	args := m.Called(payload)
+	return args.Get(0).(*APIClientWithKey), core.MockIError(args, 1)
+}
+
+func (m *MockAPIClientService) Revoke(id string) core.IError {
+	args := m.Called(id)
+	return core.MockIError(args, 0)
+}
+
+func (m *MockAPIClientService) AuthenticateAPIKey(apiKey string) (*models.APIClient, core.IError) {
+	args := m.Called(apiKey)
+	return args.Get(0).(*models.APIClient), core.MockIError(args, 1)
+}
+
+func (m *MockAPIClientService) AuthenticateCertificate(certificate *x509.Certificate) (*models.APIClient, core.IError) {
+	args := m.Called(certificate)
+	return args.Get(0).(*models.APIClient), core.MockIError(args, 1)
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+const auditRecordMaxRetry = 3
+const auditVerifyBatchSize = 1000
+
+type AuditEventPayload struct {
+	Operation     consts.AuditOperation
+	KeyID         string
+	KeyVersion    int
+	MessageDigest string
+	Error         core.IError
+}
+
+type AuditVerification struct {
+	Valid        bool   `json:"valid"`
+	Count        int64  `json:"count"`
+	HeadSequence int64  `json:"head_sequence"`
+	HeadHash     string `json:"head_hash"`
+	// the first event that breaks the chain, if any
+	BrokenSequence int64  `json:"broken_sequence,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+type AuditFilterPayload struct {
+	KeyID     string     `json:"key_id,omitempty"`
+	ActorID   string     `json:"actor_id,omitempty"`
+	Operation string     `json:"operation,omitempty"`
+	Outcome   string     `json:"outcome,omitempty"`
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+}
+
+type IAuditService interface {
+	Find(sequence int64) (*models.KeyAuditEvent, core.IError)
+	Pagination(payload *AuditFilterPayload, pageOptions *core.PageOptions) ([]models.KeyAuditEvent, *core.PageResponse, core.IError)
+	Record(payload *AuditEventPayload) core.IError
+	RecordBatch(payloads []*AuditEventPayload) core.IError
+	Verify() (*AuditVerification, core.IError)
+}
+
+type auditService struct {
+	ctx core.IContext
+}
+
+func NewAuditService(ctx core.IContext) IAuditService {
+	return &auditService{ctx: ctx}
+}
+
+func (s auditService) Find(sequence int64) (*models.KeyAuditEvent, core.IError) {
+	event := &models.KeyAuditEvent{}
+	err := s.ctx.DB().First(event, "sequence = ?", sequence).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, emsgs.AuditEventNotFoundError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return event, nil
+}
+
+func (s auditService) Pagination(payload *AuditFilterPayload, pageOptions *core.PageOptions) ([]models.KeyAuditEvent, *core.PageResponse, core.IError) {
+	events := make([]models.KeyAuditEvent, 0)
+
+	db := auditFilter(s.ctx, payload).Order("sequence desc")
+	pageResponse, err := core.Paginate(db, &events, pageOptions)
+	if err != nil {
+		return nil, nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return events, pageResponse, nil
+}
+
+// auditFilter scopes the events to the tenant of the principal, only admins can read across tenants
+func auditFilter(ctx core.IContext, payload *AuditFilterPayload) *gorm.DB {
+	db := ctx.DB().Model(&models.KeyAuditEvent{})
+
+	principal := helpers.GetPrincipal(ctx)
+	if !principal.HasScope(consts.ScopeKeysAdmin) {
+		db = db.Where("tenant_id = ?", principal.TenantID)
+	}
+	if payload.KeyID != "" {
+		db = db.Where("key_id = ?", payload.KeyID)
+	}
+	if payload.ActorID != "" {
+		db = db.Where("actor_id = ?", payload.ActorID)
+	}
+	if payload.Operation != "" {
+		db = db.Where("operation = ?", payload.Operation)
+	}
+	if payload.Outcome != "" {
+		db = db.Where("outcome = ?", payload.Outcome)
+	}
+	if payload.From != nil {
+		db = db.Where("created_at >= ?", payload.From)
+	}
+	if payload.To != nil {
+		db = db.Where("created_at < ?", payload.To)
+	}
+
+	return db
+}
+
+// Record appends an event to the hash chain, the last event is locked so concurrent writers
+// of every replica extend the chain one after another
+func (s auditService) Record(payload *AuditEventPayload) core.IError {
+	return s.RecordBatch([]*AuditEventPayload{payload})
+}
+
+// RecordBatch appends the events in order within a single lock of the chain
+func (s auditService) RecordBatch(payloads []*AuditEventPayload) core.IError {
+	if len(payloads) == 0 {
+		return nil
+	}
+
+	principal := helpers.GetPrincipal(s.ctx)
+	requestID := helpers.GetRequestID(s.ctx)
+	events := make([]*models.KeyAuditEvent, 0, len(payloads))
+	for _, payload := range payloads {
+		event := &models.KeyAuditEvent{
+			ID:            utils.GetUUID(),
+			ActorID:       principal.ID,
+			ActorType:     principal.Type,
+			TenantID:      principal.TenantID,
+			Operation:     string(payload.Operation),
+			KeyID:         payload.KeyID,
+			KeyVersion:    payload.KeyVersion,
+			MessageDigest: payload.MessageDigest,
+			Outcome:       string(consts.AuditOutcomeSuccess),
+			RequestID:     requestID,
+		}
+		if payload.Error != nil {
+			event.Outcome = string(consts.AuditOutcomeFailure)
+			event.ErrorCode = payload.Error.GetCode()
+		}
+		events = append(events, event)
+	}
+
+	var err error
+	for retry := 0; retry < auditRecordMaxRetry; retry++ {
+		err = s.ctx.DB().Transaction(func(tx *gorm.DB) error {
+			last := &models.KeyAuditEvent{}
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Order("sequence desc").First(last).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				last.PreviousHash = consts.AuditGenesisHash
+				last.Hash = consts.AuditGenesisHash
+			} else if err != nil {
+				return err
+			}
+
+			createdAt := helpers.TruncateToSecond(utils.GetCurrentDateTime())
+			for _, event := range events {
+				event.Sequence = last.Sequence + 1
+				event.PreviousHash = last.Hash
+				event.CreatedAt = createdAt
+				event.Hash = event.ComputeHash()
+				last = event
+			}
+
+			return tx.CreateInBatches(events, auditVerifyBatchSize).Error
+		})
+		// a unique sequence conflict means another replica appended first
+		if err == nil {
+			return nil
+		}
+	}
+
+	s.ctx.Log().Error(fmt.Sprintf("audit: cannot record %d events starting with %s of key %s: %v",
+		len(events), events[0].Operation, events[0].KeyID, err))
+	return s.ctx.NewError(err, emsgs.AuditRecordError)
+}
+
+// Verify walks the whole chain and reports the first event that was altered, removed or inserted
+func (s auditService) Verify() (*AuditVerification, core.IError) {
+	verification := &AuditVerification{
+		Valid:    true,
+		HeadHash: consts.AuditGenesisHash,
+	}
+
+	for {
+		events := make([]models.KeyAuditEvent, 0)
+		err := s.ctx.DB().
+			Where("sequence > ?", verification.HeadSequence).
+			Order("sequence asc").
+			Limit(auditVerifyBatchSize).
+			Find(&events).Error
+		if err != nil {
+			return nil, s.ctx.NewError(err, errmsgs.DBError)
+		}
+
+		for _, event := range events {
+			reason := ""
+			if event.Sequence != verification.HeadSequence+1 {
+				reason = fmt.Sprintf("events %d to %d are missing", verification.HeadSequence+1, event.Sequence-1)
+			} else if event.PreviousHash != verification.HeadHash {
+				reason = "previous hash does not match the preceding event"
+			} else if event.Hash != event.ComputeHash() {
+				reason = "event content does not match its hash"
+			}
+			if reason != "" {
+				verification.Valid = false
+				verification.BrokenSequence = event.Sequence
+				verification.Reason = reason
+				return verification, nil
+			}
+
+			verification.Count++
+			verification.HeadSequence = event.Sequence
+			verification.HeadHash = event.Hash
+		}
+
+		if len(events) < auditVerifyBatchSize {
+			return verification, nil
+		}
+	}
+}
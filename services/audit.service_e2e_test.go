@@ -0,0 +1,53 @@
+// +build e2e
+
+package services
+
+import (
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"testing"
+)
+
+type AuditServiceTestSuite struct {
+	suite.Suite
+	rCtx core.IContext
+	ras  IAuditService
+}
+
+func TestAuditServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditServiceTestSuite))
+}
+
+func (a *AuditServiceTestSuite) SetupSuite() {
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	a.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+}
+
+func (a *AuditServiceTestSuite) SetupTest() {
+	a.ras = NewAuditService(a.rCtx)
+}
+
+func (a *AuditServiceTestSuite) TestAuditService_Record_ExpectSuccess() {
+	before, ierr := a.ras.Verify()
+	a.NoError(ierr)
+	a.True(before.Valid)
+
+	ierr = a.ras.Record(&AuditEventPayload{
+		Operation:     consts.AuditOperationSign,
+		KeyID:         "key-id",
+		KeyVersion:    1,
+		MessageDigest: "digest",
+	})
+	a.NoError(ierr)
+
+	after, ierr := a.ras.Verify()
+	a.NoError(ierr)
+	a.True(after.Valid)
+	a.Equal(before.Count+1, after.Count)
+	a.NotEqual(before.HeadHash, after.HeadHash)
+}
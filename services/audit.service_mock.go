@@ -0,0 +1,40 @@
+package services
+
+import (
+	"github.com/stretchr/testify/mock"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func NewMockAuditService() *MockAuditService {
+	return &MockAuditService{}
+}
+
+func (m *MockAuditService) Find(sequence int64) (*models.KeyAuditEvent, core.IError) {
+	args := m.Called(sequence)
+	return args.Get(0).(*models.KeyAuditEvent), core.MockIError(args, 1)
+}
+
+func (m *MockAuditService) Pagination(payload *AuditFilterPayload, pageOptions *core.PageOptions) ([]models.KeyAuditEvent, *core.PageResponse, core.IError) {
+	args := m.Called(payload, pageOptions)
+	return args.Get(0).([]models.KeyAuditEvent), args.Get(1).(*core.PageResponse), core.MockIError(args, 2)
+}
+
+func (m *MockAuditService) Record(payload *AuditEventPayload) core.IError {
+	args := m.Called(payload)
+	return core.MockIError(args, 0)
+}
+
+func (m *MockAuditService) RecordBatch(payloads []*AuditEventPayload) core.IError {
+	args := m.Called(payloads)
+	return core.MockIError(args, 0)
+}
+
+func (m *MockAuditService) Verify() (*AuditVerification, core.IError) {
+	args := m.Called()
+	return args.Get(0).(*AuditVerification), core.MockIError(args, 1)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+const auditVerifyQuery = "SELECT \\* FROM `key_audit_events` WHERE sequence > \\?"
+
+type AuditChainTestSuite struct {
+	suite.Suite
+	mCtx   *core.ContextMock
+	ras    IAuditService
+	events []models.KeyAuditEvent
+}
+
+func TestAuditChainTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditChainTestSuite))
+}
+
+func (a *AuditChainTestSuite) SetupTest() {
+	a.mCtx = core.NewMockContext()
+	a.mCtx.On("DB").Return(a.mCtx.MockDB.Gorm)
+	a.ras = NewAuditService(a.mCtx)
+
+	// a chain of three events as Record appends them
+	createdAt := time.Now().Truncate(time.Second)
+	previousHash := consts.AuditGenesisHash
+	a.events = make([]models.KeyAuditEvent, 3)
+	for i := range a.events {
+		event := models.KeyAuditEvent{
+			ID:            fmt.Sprintf("event-%d", i+1),
+			Sequence:      int64(i + 1),
+			ActorID:       "client-1",
+			ActorType:     string(consts.PrincipalTypeClient),
+			TenantID:      "tenant-1",
+			Operation:     string(consts.AuditOperationSign),
+			KeyID:         "key-1",
+			KeyVersion:    1,
+			MessageDigest: fmt.Sprintf("digest-%d", i+1),
+			Outcome:       string(consts.AuditOutcomeSuccess),
+			PreviousHash:  previousHash,
+			CreatedAt:     &createdAt,
+		}
+		event.Hash = event.ComputeHash()
+		previousHash = event.Hash
+		a.events[i] = event
+	}
+}
+
+// expectEvents answers the query of Verify with the events in the given order
+func (a *AuditChainTestSuite) expectEvents(events ...models.KeyAuditEvent) {
+	rows := sqlmock.NewRows([]string{"id", "sequence", "actor_id", "actor_type", "tenant_id", "operation", "key_id", "key_version",
+		"message_digest", "outcome", "error_code", "request_id", "previous_hash", "hash", "created_at"})
+	for _, event := range events {
+		rows.AddRow(event.ID, event.Sequence, event.ActorID, event.ActorType, event.TenantID, event.Operation, event.KeyID, event.KeyVersion,
+			event.MessageDigest, event.Outcome, event.ErrorCode, event.RequestID, event.PreviousHash, event.Hash, *event.CreatedAt)
+	}
+	a.mCtx.MockDB.Mock.ExpectQuery(auditVerifyQuery).WillReturnRows(rows)
+}
+
+func (a *AuditChainTestSuite) TestAuditService_Verify_ExpectValid() {
+	a.expectEvents(a.events...)
+
+	verification, ierr := a.ras.Verify()
+	a.Require().NoError(ierr)
+	a.True(verification.Valid)
+	a.Equal(int64(3), verification.Count)
+	a.Equal(int64(3), verification.HeadSequence)
+	a.Equal(a.events[2].Hash, verification.HeadHash)
+	a.NoError(a.mCtx.MockDB.Mock.ExpectationsWereMet())
+}
+
+func (a *AuditChainTestSuite) TestAuditService_Verify_ExpectTamperedEvent() {
+	tampered := a.events[1]
+	tampered.Outcome = string(consts.AuditOutcomeFailure)
+	a.expectEvents(a.events[0], tampered, a.events[2])
+
+	verification, ierr := a.ras.Verify()
+	a.Require().NoError(ierr)
+	a.False(verification.Valid)
+	a.Equal(int64(2), verification.BrokenSequence)
+	a.Equal("event content does not match its hash", verification.Reason)
+	a.Equal(int64(1), verification.Count)
+}
+
+func (a *AuditChainTestSuite) TestAuditService_Verify_ExpectDeletedEvent() {
+	a.expectEvents(a.events[0], a.events[2])
+
+	verification, ierr := a.ras.Verify()
+	a.Require().NoError(ierr)
+	a.False(verification.Valid)
+	a.Equal(int64(3), verification.BrokenSequence)
+	a.Equal("events 2 to 2 are missing", verification.Reason)
+}
+
+func (a *AuditChainTestSuite) TestAuditService_Verify_ExpectReorderedEvents() {
+	// the second and the third event swap their sequences
+	second, third := a.events[1], a.events[2]
+	second.Sequence, third.Sequence = third.Sequence, second.Sequence
+	a.expectEvents(a.events[0], third, second)
+
+	verification, ierr := a.ras.Verify()
+	a.Require().NoError(ierr)
+	a.False(verification.Valid)
+	a.Equal(int64(2), verification.BrokenSequence)
+	a.Equal("previous hash does not match the preceding event", verification.Reason)
+
+	// a reordered event that keeps the previous hash of its new position still does not match its own hash
+	third.PreviousHash = a.events[0].Hash
+	a.expectEvents(a.events[0], third, second)
+
+	verification, ierr = a.ras.Verify()
+	a.Require().NoError(ierr)
+	a.False(verification.Valid)
+	a.Equal(int64(2), verification.BrokenSequence)
+	a.Equal("event content does not match its hash", verification.Reason)
+}
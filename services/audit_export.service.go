@@ -0,0 +1,233 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gorm.io/gorm"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+const auditExportBatchSize = 1000
+
+var auditExportCSVHeader = []string{
+	"sequence", "id", "created_at", "actor_id", "actor_type", "tenant_id", "operation", "key_id", "key_version",
+	"message_digest", "outcome", "error_code", "request_id", "previous_hash", "hash",
+}
+
+type AuditExportPayload struct {
+	ID     string
+	Format consts.AuditExportFormat
+	Filter *AuditFilterPayload
+}
+
+type IAuditExportService interface {
+	Find(id string) (*models.AuditExport, core.IError)
+	SigningKey() (*models.Key, core.IError)
+	Export(payload *AuditExportPayload, w io.Writer) (*models.AuditExport, core.IError)
+}
+
+type auditExportService struct {
+	ctx        core.IContext
+	hsmService IHSMService
+}
+
+func NewAuditExportService(ctx core.IContext, hsmService IHSMService) IAuditExportService {
+	return &auditExportService{
+		ctx:        ctx,
+		hsmService: hsmService,
+	}
+}
+
+func (s auditExportService) Find(id string) (*models.AuditExport, core.IError) {
+	export := &models.AuditExport{}
+	db := s.ctx.DB()
+	principal := helpers.GetPrincipal(s.ctx)
+	if !principal.HasScope(consts.ScopeKeysAdmin) {
+		db = db.Where("tenant_id = ?", principal.TenantID)
+	}
+	err := db.First(export, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, emsgs.AuditExportNotFoundError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	signingKey := &models.Key{}
+	err = s.ctx.DB().Unscoped().First(signingKey, "id = ?", export.SigningKeyID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+	export.SigningPublicKey = signingKey.PublicKey
+	if signingKey.Version != export.SigningKeyVersion {
+		version := &models.KeyVersion{}
+		err = s.ctx.DB().First(version, "key_id = ? AND version = ?", export.SigningKeyID, export.SigningKeyVersion).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, s.ctx.NewError(err, errmsgs.DBError)
+		}
+		export.SigningPublicKey = version.PublicKey
+	}
+
+	return export, nil
+}
+
+// SigningKey returns the key whose ID is AUDIT_SIGNING_KEY_ID, it is looked up across tenants because it belongs
+// to the operator of the repository rather than to a client, so it is never resolved by an alias a tenant could claim
+func (s auditExportService) SigningKey() (*models.Key, core.IError) {
+	id := s.ctx.ENV().String(consts.ENVAuditSigningKeyID)
+	if id == "" {
+		return nil, s.ctx.NewError(emsgs.AuditSigningKeyNotConfiguredError, emsgs.AuditSigningKeyNotConfiguredError)
+	}
+
+	key := &models.Key{}
+	err := s.ctx.DB().Where("deleted_at IS NULL").Where("id = ?", id).First(key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, emsgs.AuditSigningKeyNotConfiguredError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return key, nil
+}
+
+// Export streams the matching events to w in sequence order, then signs the SHA-256 digest of the streamed bytes
+// with the audit signing key and keeps the detached signature so the export can be verified later
+func (s auditExportService) Export(payload *AuditExportPayload, w io.Writer) (*models.AuditExport, core.IError) {
+	signingKey, ierr := s.SigningKey()
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	digest := sha256.New()
+	out := io.MultiWriter(w, digest)
+	flusher, _ := w.(http.Flusher)
+
+	var csvWriter *csv.Writer
+	if payload.Format == consts.AuditExportFormatCSV {
+		csvWriter = csv.NewWriter(out)
+		if err := csvWriter.Write(auditExportCSVHeader); err != nil {
+			return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+		}
+	}
+
+	principal := helpers.GetPrincipal(s.ctx)
+	export := &models.AuditExport{
+		ID:       payload.ID,
+		ActorID:  principal.ID,
+		TenantID: principal.TenantID,
+		Format:   string(payload.Format),
+	}
+	filters, _ := json.Marshal(payload.Filter)
+	export.Filters = string(filters)
+
+	for {
+		events := make([]models.KeyAuditEvent, 0)
+		err := auditFilter(s.ctx, payload.Filter).
+			Where("sequence > ?", export.LastSequence).
+			Order("sequence asc").
+			Limit(auditExportBatchSize).
+			Find(&events).Error
+		if err != nil {
+			return nil, s.ctx.NewError(err, errmsgs.DBError)
+		}
+
+		for _, event := range events {
+			if csvWriter != nil {
+				err = csvWriter.Write(auditExportCSVRecord(&event))
+			} else {
+				err = writeJSONLine(out, &event)
+			}
+			if err != nil {
+				return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+			}
+
+			if export.EventCount == 0 {
+				export.FirstSequence = event.Sequence
+			}
+			export.EventCount++
+			export.LastSequence = event.Sequence
+		}
+
+		if csvWriter != nil {
+			csvWriter.Flush()
+			if err := csvWriter.Error(); err != nil {
+				return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+
+		if len(events) < auditExportBatchSize {
+			break
+		}
+	}
+
+	export.Digest = hex.EncodeToString(digest.Sum(nil))
+	signature, ierr := signWithKey(s.ctx, s.hsmService, signingKey, export.Digest)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	export.Signature = signature
+	export.SigningKeyID = signingKey.ID
+	export.SigningKeyVersion = signingKey.Version
+	export.SigningPublicKey = signingKey.PublicKey
+	export.CreatedAt = utils.GetCurrentDateTime()
+
+	err := s.ctx.DB().Create(export).Error
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return export, nil
+}
+
+func writeJSONLine(w io.Writer, event *models.KeyAuditEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+func auditExportCSVRecord(event *models.KeyAuditEvent) []string {
+	createdAt := ""
+	if event.CreatedAt != nil {
+		createdAt = event.CreatedAt.UTC().Format(time.RFC3339)
+	}
+
+	return []string{
+		strconv.FormatInt(event.Sequence, 10),
+		event.ID,
+		createdAt,
+		event.ActorID,
+		event.ActorType,
+		event.TenantID,
+		event.Operation,
+		event.KeyID,
+		strconv.Itoa(event.KeyVersion),
+		event.MessageDigest,
+		event.Outcome,
+		event.ErrorCode,
+		event.RequestID,
+		event.PreviousHash,
+		event.Hash,
+	}
+}
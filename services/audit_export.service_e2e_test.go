@@ -0,0 +1,112 @@
+// +build e2e
+
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+// auditExportTestENV sets AUDIT_SIGNING_KEY_ID on top of the environment
+type auditExportTestENV struct {
+	core.IENV
+	signingKeyID string
+}
+
+func (e auditExportTestENV) String(key string) string {
+	if key == consts.ENVAuditSigningKeyID {
+		return e.signingKeyID
+	}
+
+	return e.IENV.String(key)
+}
+
+type AuditExportServiceTestSuite struct {
+	suite.Suite
+	rCtx core.IContext
+	env  core.IENV
+	rks  IKeyService
+	ras  IAuditService
+}
+
+func TestAuditExportServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(AuditExportServiceTestSuite))
+}
+
+func (a *AuditExportServiceTestSuite) SetupSuite() {
+	a.env = core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(a.env.Config()).Connect()
+	a.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: a.env,
+	})
+}
+
+func (a *AuditExportServiceTestSuite) SetupTest() {
+	a.ras = NewAuditService(a.rCtx)
+	a.rks = NewKeyService(a.rCtx, NewHSMService(a.rCtx), a.ras)
+}
+
+// exportService returns the service of a context whose AUDIT_SIGNING_KEY_ID is signingKeyID
+func (a *AuditExportServiceTestSuite) exportService(signingKeyID string) IAuditExportService {
+	ctx := core.NewContext(&core.ContextOptions{
+		DB:  a.rCtx.DB(),
+		ENV: auditExportTestENV{IENV: a.env, signingKeyID: signingKeyID},
+	})
+
+	return NewAuditExportService(ctx, NewHSMService(ctx))
+}
+
+func (a *AuditExportServiceTestSuite) TestAuditExportService_Export_ExpectVerifiedSignature() {
+	signingKey, ierr := a.rks.Generate(&KeyGeneratePayload{})
+	a.Require().NoError(ierr)
+	ierr = a.ras.Record(&AuditEventPayload{
+		Operation:     consts.AuditOperationSign,
+		KeyID:         signingKey.ID,
+		KeyVersion:    signingKey.Version,
+		MessageDigest: "digest",
+	})
+	a.Require().NoError(ierr)
+
+	body := &bytes.Buffer{}
+	export, ierr := a.exportService(signingKey.ID).Export(&AuditExportPayload{
+		ID:     utils.GetUUID(),
+		Format: consts.AuditExportFormatJSONLines,
+		Filter: &AuditFilterPayload{KeyID: signingKey.ID},
+	}, body)
+	a.Require().NoError(ierr)
+	a.Equal(int64(2), export.EventCount)
+	a.Equal(signingKey.ID, export.SigningKeyID)
+
+	digest := sha256.Sum256(body.Bytes())
+	a.Equal(hex.EncodeToString(digest[:]), export.Digest)
+	valid, err := utils.VerifySignature(export.SigningPublicKey, export.Signature, export.Digest)
+	a.Require().NoError(err)
+	a.True(valid)
+
+	// the stored export verifies with the public key of the version that signed it
+	found, ierr := a.exportService(signingKey.ID).Find(export.ID)
+	a.Require().NoError(ierr)
+	valid, err = utils.VerifySignature(found.SigningPublicKey, found.Signature, found.Digest)
+	a.Require().NoError(err)
+	a.True(valid)
+}
+
+func (a *AuditExportServiceTestSuite) TestAuditExportService_SigningKey_ExpectIDOnly() {
+	alias := "audit-signing-" + utils.GetUUID()
+	_, ierr := a.rks.Generate(&KeyGeneratePayload{Alias: alias})
+	a.Require().NoError(ierr)
+
+	// Expect error on a key that only has the configured value as its alias
+	_, ierr = a.exportService(alias).SigningKey()
+	a.Error(ierr)
+	a.Equal(emsgs.AuditSigningKeyNotConfiguredError.GetCode(), ierr.GetCode())
+}
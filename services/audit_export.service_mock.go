@@ -0,0 +1,32 @@
+package services
+
+import (
+	"io"
+
+	"github.com/stretchr/testify/mock"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type MockAuditExportService struct {
+	mock.Mock
+}
+
+func NewMockAuditExportService() *MockAuditExportService {
+	return &MockAuditExportService{}
+}
+
+func (m *MockAuditExportService) Find(id string) (*models.AuditExport, core.IError) {
+	args := m.Called(id)
+	return args.Get(0).(*models.AuditExport), core.MockIError(args, 1)
+}
+
+func (m *MockAuditExportService) SigningKey() (*models.Key, core.IError) {
+	args := m.Called()
+	return args.Get(0).(*models.Key), core.MockIError(args, 1)
+}
+
+func (m *MockAuditExportService) Export(payload *AuditExportPayload, w io.Writer) (*models.AuditExport, core.IError) {
+	args := m.Called(payload, w)
+	return args.Get(0).(*models.AuditExport), core.MockIError(args, 1)
+}
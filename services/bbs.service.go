@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+)
+
+type BBS2023DerivePayload struct {
+	// ProofValue is the base proof issued for the statements
+	ProofValue string
+	// Statements are the canonical N-Quads of the issued document, the indexes refer to them
+	Statements         []string
+	MandatoryIndexes   []int
+	SelectiveIndexes   []int
+	PresentationHeader []byte
+}
+
+type BBS2023VerifyPayload struct {
+	// VerificationMethod is the did:key verification method of the issuer
+	VerificationMethod string
+	ProofValue         string
+	ProofConfig        string
+	// Statements are the canonical N-Quads of the disclosed document
+	Statements []string
+}
+
+type IBBSService interface {
+	DeriveBBS2023(payload *BBS2023DerivePayload) (*models.BBS2023DerivedProof, core.IError)
+	VerifyBBS2023(payload *BBS2023VerifyPayload) core.IError
+}
+
+type bbsService struct {
+	ctx core.IContext
+}
+
+func NewBBSService(ctx core.IContext) IBBSService {
+	return &bbsService{
+		ctx: ctx,
+	}
+}
+
+// DeriveBBS2023 derives a proof that discloses the mandatory statements and the selected ones from a base proof,
+// only the holder's copy of the document is needed so no key is looked up
+func (s bbsService) DeriveBBS2023(payload *BBS2023DerivePayload) (*models.BBS2023DerivedProof, core.IError) {
+	proofValue, statements, err := helpers.DeriveBBS2023Proof(payload.ProofValue, payload.Statements,
+		payload.MandatoryIndexes, payload.SelectiveIndexes, payload.PresentationHeader)
+	if ierr := bbs2023Error(s.ctx, err); ierr != nil {
+		return nil, ierr
+	}
+
+	return &models.BBS2023DerivedProof{
+		ProofValue: proofValue,
+		Statements: statements,
+	}, nil
+}
+
+// VerifyBBS2023 verifies a derived proof with the public key of the did:key verification method of the issuer
+func (s bbsService) VerifyBBS2023(payload *BBS2023VerifyPayload) core.IError {
+	did := strings.SplitN(payload.VerificationMethod, "#", 2)[0]
+	resolved, err := helpers.ResolveDID(did)
+	if err != nil || strings.Contains(payload.VerificationMethod, "#") && payload.VerificationMethod != resolved.VerificationMethodID {
+		return s.ctx.NewError(helpers.ErrDID, emsgs.InvalidDIDError)
+	}
+	publicKey, ok := resolved.PublicKey.(helpers.BBSPublicKey)
+	if !ok {
+		return s.ctx.NewError(emsgs.BBSKeyRequiredError, emsgs.BBSKeyRequiredError)
+	}
+
+	err = helpers.VerifyBBS2023Proof(publicKey, payload.ProofValue, payload.ProofConfig, payload.Statements)
+	if errors.Is(err, helpers.ErrBBSProof) {
+		return s.ctx.NewError(err, emsgs.BBS2023ProofVerificationError)
+	}
+
+	return bbs2023Error(s.ctx, err)
+}
+
+func bbs2023Error(ctx core.IContext, err error) core.IError {
+	switch err {
+	case nil:
+		return nil
+	case helpers.ErrBBS2023ProofValue:
+		return ctx.NewError(err, emsgs.InvalidBBS2023ProofValueError)
+	case helpers.ErrBBS2023Statement, helpers.ErrBBS2023Indexes, helpers.ErrBBSIndexes:
+		return ctx.NewError(err, emsgs.InvalidBBS2023StatementsError)
+	case helpers.ErrBBS2023BaseProof:
+		return ctx.NewError(err, emsgs.BBS2023BaseProofMismatchError)
+	}
+
+	return ctx.NewError(err, errmsgs.InternalServerError)
+}
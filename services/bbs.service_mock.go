@@ -0,0 +1,25 @@
+package services
+
+import (
+	"github.com/stretchr/testify/mock"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type MockBBSService struct {
+	mock.Mock
+}
+
+func NewMockBBSService() *MockBBSService {
+	return &MockBBSService{}
+}
+
+func (m *MockBBSService) DeriveBBS2023(payload *BBS2023DerivePayload) (*models.BBS2023DerivedProof, core.IError) {
+	args := m.Called(payload)
+	return args.Get(0).(*models.BBS2023DerivedProof), core.MockIError(args, 1)
+}
+
+func (m *MockBBSService) VerifyBBS2023(payload *BBS2023VerifyPayload) core.IError {
+	args := m.Called(payload)
+	return core.MockIError(args, 0)
+}
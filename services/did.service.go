@@ -0,0 +1,54 @@
+package services
+
+import (
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type IDIDService interface {
+	Resolve(did string) (*models.DIDDocument, core.IError)
+}
+
+type didService struct {
+	ctx core.IContext
+}
+
+func NewDIDService(ctx core.IContext) IDIDService {
+	return &didService{
+		ctx: ctx,
+	}
+}
+
+// Resolve builds the DID document of a did:key or did:jwk from the DID alone, the key does not have to be stored here
+func (s didService) Resolve(did string) (*models.DIDDocument, core.IError) {
+	resolved, err := helpers.ResolveDID(did)
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.InvalidDIDError)
+	}
+
+	// keys without a JWK form are rendered as a Multikey
+	methodType := consts.DIDVerificationMethodJsonWebKey2020
+	if resolved.JWK == nil {
+		methodType = consts.DIDVerificationMethodMultikey
+	}
+	verificationMethod, err := helpers.NewVerificationMethod(resolved.PublicKey, methodType,
+		resolved.DID, resolved.VerificationMethodID)
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.InvalidDIDError)
+	}
+
+	return models.NewDIDDocument([]string{
+		consts.DIDContextV1,
+		consts.DIDVerificationMethodContexts[methodType],
+	}, verificationMethod), nil
+}
+
+// setKeyDIDs derives the DIDs of the public key, keys are only stored once their public key was parsed
+// so a key type without DIDs is the only reason to leave them empty
+func setKeyDIDs(key *models.Key) {
+	key.DIDKey, _ = helpers.DIDKey(key.PublicKey)
+	key.DIDJWK, _ = helpers.DIDJWK(key.PublicKey)
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"github.com/stretchr/testify/mock"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type MockDIDService struct {
+	mock.Mock
+}
+
+func NewMockDIDService() *MockDIDService {
+	return &MockDIDService{}
+}
+
+func (m *MockDIDService) Resolve(did string) (*models.DIDDocument, core.IError) {
+	args := m.Called(did)
+	return args.Get(0).(*models.DIDDocument), core.MockIError(args, 1)
+}
@@ -2,8 +2,13 @@ package services
 
 import (
 	"bytes"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/miekg/pkcs11"
@@ -16,8 +21,11 @@ import (
 )
 
 type IHSMService interface {
-	Decrypt(encryptedPrivateKey string) (string, core.IError)
-	Encrypt(privateKey string) (string, core.IError)
+	// Decrypt returns the private key in a buffer the caller must zero with helpers.Zeroize once it is parsed
+	Decrypt(encryptedPrivateKey string) ([]byte, core.IError)
+	Encrypt(privateKey []byte) (string, core.IError)
+	// KEKID identifies the HSM key that Encrypt uses by the hex SHA-256 of its PKIX public key
+	KEKID() (string, core.IError)
 }
 type hsmService struct {
 	ctx core.IContext
@@ -27,8 +35,8 @@ func NewHSMService(ctx core.IContext) IHSMService {
 	return &hsmService{ctx: ctx}
 }
 
-func (s *hsmService) Encrypt(privateKey string) (string, core.IError) {
-	message := []byte(privateKey)
+func (s *hsmService) Encrypt(privateKey []byte) (string, core.IError) {
+	message := privateKey
 	maxLength := 190 // statically set for RSA 2048 with SHA-256
 	messages := make([][]byte, 0)
 
@@ -44,6 +52,11 @@ func (s *hsmService) Encrypt(privateKey string) (string, core.IError) {
 		messages = append(messages, newMassage)
 		i++
 	}
+	defer func() {
+		for _, message := range messages {
+			helpers.Zeroize(message)
+		}
+	}()
 
 	cipherTexts := make([][]byte, 0)
 	for _, message := range messages {
@@ -59,21 +72,50 @@ func (s *hsmService) Encrypt(privateKey string) (string, core.IError) {
 	return encryptedMessage, nil
 }
 
-func (s *hsmService) Decrypt(encryptedPrivateKey string) (string, core.IError) {
+func (s *hsmService) Decrypt(encryptedPrivateKey string) ([]byte, core.IError) {
 	cipherTexts, err := helpers.Base64StringJoinedToByteArraySeries(encryptedPrivateKey, ".")
 	if err != nil {
-		return "", s.ctx.NewError(err, errmsgs.InternalServerError)
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
 	}
 	messages := make([][]byte, 0)
 	for _, cipherText := range cipherTexts {
 		message, err := s.decrypt(cipherText)
 		if err != nil {
-			return "", s.ctx.NewError(err, errmsgs.InternalServerError)
+			for _, message := range messages {
+				helpers.Zeroize(message)
+			}
+			return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
 		}
 		messages = append(messages, bytes.Trim(message, "\x00"))
 	}
 
-	return helpers.ByteArraySeriesToString(messages), nil
+	return helpers.ByteArraySeriesConcat(messages), nil
+}
+
+func (s *hsmService) KEKID() (string, core.IError) {
+	publicKey, ierr := s.findPublicKey()
+	if ierr != nil {
+		return "", s.ctx.NewError(ierr, ierr)
+	}
+
+	modulus, err := p11.Object(*publicKey).Attribute(pkcs11.CKA_MODULUS)
+	if err != nil {
+		return "", s.ctx.NewError(emsgs.HSMObjectError(err), emsgs.HSMObjectError(err))
+	}
+	exponent, err := p11.Object(*publicKey).Attribute(pkcs11.CKA_PUBLIC_EXPONENT)
+	if err != nil {
+		return "", s.ctx.NewError(emsgs.HSMObjectError(err), emsgs.HSMObjectError(err))
+	}
+	der, err := x509.MarshalPKIXPublicKey(&rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	})
+	if err != nil {
+		return "", s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	digest := sha256.Sum256(der)
+
+	return hex.EncodeToString(digest[:]), nil
 }
 
 func (s *hsmService) reconnect() (p11.Session, core.IError) {
@@ -120,7 +162,8 @@ func (s *hsmService) getPrivateKey(session p11.Session) (*p11.PrivateKey, error)
 	return &privateKey, nil
 }
 
-func (s *hsmService) encrypt(plaintext []byte) ([]byte, core.IError) {
+// findPublicKey finds the public key of the HSM, reconnecting once when the session was lost
+func (s *hsmService) findPublicKey() (*p11.PublicKey, core.IError) {
 	session, ok := s.ctx.GetData(consts.ContextKeyHSMSession).(p11.Session)
 	if !ok {
 		err := errors.New("cannot get session from context")
@@ -133,7 +176,6 @@ func (s *hsmService) encrypt(plaintext []byte) ([]byte, core.IError) {
 		if ierr != nil {
 			return nil, s.ctx.NewError(ierr, ierr)
 		}
-		session = newSesion
 		s.ctx.SetData(consts.ContextKeyHSMSession, newSesion)
 		publicKey, err = s.getPublicKey(newSesion)
 		if err != nil {
@@ -141,6 +183,15 @@ func (s *hsmService) encrypt(plaintext []byte) ([]byte, core.IError) {
 		}
 	}
 
+	return publicKey, nil
+}
+
+func (s *hsmService) encrypt(plaintext []byte) ([]byte, core.IError) {
+	publicKey, ierr := s.findPublicKey()
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
 	mechanism := pkcs11.NewMechanism(pkcs11.CKM_RSA_PKCS_OAEP, pkcs11.NewOAEPParams(pkcs11.CKM_SHA256, pkcs11.CKG_MGF1_SHA256, pkcs11.CKZ_DATA_SPECIFIED, make([]byte, 0)))
 
 	cipher, err := publicKey.Encrypt(*mechanism, plaintext)
@@ -13,12 +13,18 @@ func NewMockHSMService() *MockHSMService {
 	return &MockHSMService{}
 }
 
-func (m *MockHSMService) Decrypt(encryptedPrivateKey string) (string, core.IError) {
+func (m *MockHSMService) Decrypt(encryptedPrivateKey string) ([]byte, core.IError) {
 	args := m.Called(encryptedPrivateKey)
-	return args.String(0), core.MockIError(args, 1)
+	privateKey, _ := args.Get(0).([]byte)
+	return privateKey, core.MockIError(args, 1)
 }
 
-func (m *MockHSMService) Encrypt(privateKey string) (string, core.IError) {
+func (m *MockHSMService) Encrypt(privateKey []byte) (string, core.IError) {
 	args := m.Called(privateKey)
 	return args.String(0), core.MockIError(args, 1)
 }
+
+func (m *MockHSMService) KEKID() (string, core.IError) {
+	args := m.Called()
+	return args.String(0), core.MockIError(args, 1)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gorm.io/gorm"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type IdempotencyBeginPayload struct {
+	IdempotencyKey string
+	Method         string
+	Path           string
+	RequestHash    string
+}
+
+type IIdempotencyService interface {
+	Begin(payload *IdempotencyBeginPayload) (*models.IdempotencyRecord, core.IError)
+	Complete(record *models.IdempotencyRecord, status int, body []byte) core.IError
+	Release(record *models.IdempotencyRecord) core.IError
+	DeleteExpired() (int64, core.IError)
+}
+
+type idempotencyService struct {
+	ctx core.IContext
+}
+
+func NewIdempotencyService(ctx core.IContext) IIdempotencyService {
+	return &idempotencyService{ctx: ctx}
+}
+
+// Begin claims the idempotency key of the current principal, a completed record means the request was already
+// answered and its response must be replayed, an in progress record is returned only to the caller that claimed it
+func (s idempotencyService) Begin(payload *IdempotencyBeginPayload) (*models.IdempotencyRecord, core.IError) {
+	principal := helpers.GetPrincipal(s.ctx)
+
+	for retry := 0; retry < 2; retry++ {
+		record := models.NewIdempotencyRecord(principal.ID, payload.IdempotencyKey, payload.Method, payload.Path,
+			payload.RequestHash, s.retention())
+		createErr := s.ctx.DB().Create(record).Error
+		if createErr == nil {
+			return record, nil
+		}
+
+		// the unique index on principal_id and idempotency_key tells that the key was already claimed
+		existing := &models.IdempotencyRecord{}
+		err := s.ctx.DB().First(existing, "principal_id = ? AND idempotency_key = ?", principal.ID, payload.IdempotencyKey).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, s.ctx.NewError(createErr, errmsgs.DBError)
+		}
+		if err != nil {
+			return nil, s.ctx.NewError(err, errmsgs.DBError)
+		}
+
+		if existing.ExpiresAt != nil && existing.ExpiresAt.Before(*utils.GetCurrentDateTime()) {
+			err = s.ctx.DB().Delete(&models.IdempotencyRecord{}, "id = ?", existing.ID).Error
+			if err != nil {
+				return nil, s.ctx.NewError(err, errmsgs.DBError)
+			}
+			continue
+		}
+
+		if existing.Method != payload.Method || existing.Path != payload.Path || existing.RequestHash != payload.RequestHash {
+			return nil, s.ctx.NewError(emsgs.IdempotencyKeyReusedError, emsgs.IdempotencyKeyReusedError)
+		}
+		if existing.Status != string(consts.IdempotencyStatusCompleted) {
+			return nil, s.ctx.NewError(emsgs.IdempotencyRequestInProgressError, emsgs.IdempotencyRequestInProgressError)
+		}
+
+		return existing, nil
+	}
+
+	return nil, s.ctx.NewError(emsgs.IdempotencyRequestInProgressError, emsgs.IdempotencyRequestInProgressError)
+}
+
+func (s idempotencyService) Complete(record *models.IdempotencyRecord, status int, body []byte) core.IError {
+	record.Status = string(consts.IdempotencyStatusCompleted)
+	record.ResponseStatus = status
+	record.ResponseBody = string(body)
+	err := s.ctx.DB().Model(&models.IdempotencyRecord{}).Where("id = ?", record.ID).Updates(map[string]interface{}{
+		"status":          record.Status,
+		"response_status": record.ResponseStatus,
+		"response_body":   record.ResponseBody,
+		"updated_at":      utils.GetCurrentDateTime(),
+	}).Error
+	if err != nil {
+		return s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return nil
+}
+
+// Release gives the key back so the request can be retried, used when it failed without a result worth replaying
+func (s idempotencyService) Release(record *models.IdempotencyRecord) core.IError {
+	err := s.ctx.DB().Delete(&models.IdempotencyRecord{}, "id = ?", record.ID).Error
+	if err != nil {
+		return s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return nil
+}
+
+// DeleteExpired deletes the records past their retention, Begin only deletes the expired record of a key that is sent again
+func (s idempotencyService) DeleteExpired() (int64, core.IError) {
+	result := s.ctx.DB().Delete(&models.IdempotencyRecord{}, "expires_at < ?", utils.GetCurrentDateTime())
+	if result.Error != nil {
+		return 0, s.ctx.NewError(result.Error, errmsgs.DBError)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// SweepIdempotencyRecords deletes the expired records every interval, it never returns
+func SweepIdempotencyRecords(ctx core.IContext, interval time.Duration) {
+	idempotencySvc := NewIdempotencyService(ctx)
+	for range time.Tick(interval) {
+		if _, ierr := idempotencySvc.DeleteExpired(); ierr != nil {
+			ctx.Log().Error(fmt.Sprintf("idempotency: cannot delete the expired records: %v", ierr))
+		}
+	}
+}
+
+func (s idempotencyService) retention() time.Duration {
+	hours := s.ctx.ENV().Int(consts.ENVIdempotencyRetentionHours)
+	if hours <= 0 {
+		hours = consts.IdempotencyDefaultRetentionHours
+	}
+
+	return time.Duration(hours) * time.Hour
+}
@@ -0,0 +1,118 @@
+// +build e2e
+
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type IdempotencyServiceTestSuite struct {
+	suite.Suite
+	rCtx core.IContext
+	ris  IIdempotencyService
+}
+
+func TestIdempotencyServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyServiceTestSuite))
+}
+
+func (i *IdempotencyServiceTestSuite) SetupSuite() {
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	i.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+}
+
+func (i *IdempotencyServiceTestSuite) SetupTest() {
+	i.ris = NewIdempotencyService(i.rCtx)
+}
+
+func (i *IdempotencyServiceTestSuite) payload(body string) *IdempotencyBeginPayload {
+	return &IdempotencyBeginPayload{
+		IdempotencyKey: utils.GetUUID(),
+		Method:         http.MethodPost,
+		Path:           "/key/generate",
+		RequestHash:    helpers.MessageDigest(body),
+	}
+}
+
+func (i *IdempotencyServiceTestSuite) TestIdempotencyService_Begin_ExpectReplay() {
+	payload := i.payload(`{"alias":"issuer"}`)
+	record, ierr := i.ris.Begin(payload)
+	i.Require().NoError(ierr)
+	i.Equal(string(consts.IdempotencyStatusInProgress), record.Status)
+
+	// Expect error on a retry while the first request is in progress
+	_, ierr = i.ris.Begin(payload)
+	i.Error(ierr)
+	i.Equal(emsgs.IdempotencyRequestInProgressError.GetCode(), ierr.GetCode())
+
+	ierr = i.ris.Complete(record, http.StatusCreated, []byte(`{"id":"key-1"}`))
+	i.Require().NoError(ierr)
+
+	replayed, ierr := i.ris.Begin(payload)
+	i.Require().NoError(ierr)
+	i.Equal(string(consts.IdempotencyStatusCompleted), replayed.Status)
+	i.Equal(http.StatusCreated, replayed.ResponseStatus)
+	i.Equal(`{"id":"key-1"}`, replayed.ResponseBody)
+}
+
+func (i *IdempotencyServiceTestSuite) TestIdempotencyService_Begin_ExpectKeyReused() {
+	payload := i.payload(`{"alias":"issuer"}`)
+	record, ierr := i.ris.Begin(payload)
+	i.Require().NoError(ierr)
+	i.Require().NoError(i.ris.Complete(record, http.StatusCreated, []byte(`{}`)))
+
+	// Expect error on the key sent with another body or to another route
+	other := *payload
+	other.RequestHash = helpers.MessageDigest(`{"alias":"holder"}`)
+	_, ierr = i.ris.Begin(&other)
+	i.Error(ierr)
+	i.Equal(emsgs.IdempotencyKeyReusedError.GetCode(), ierr.GetCode())
+
+	other = *payload
+	other.Path = "/key/store"
+	_, ierr = i.ris.Begin(&other)
+	i.Error(ierr)
+	i.Equal(emsgs.IdempotencyKeyReusedError.GetCode(), ierr.GetCode())
+}
+
+func (i *IdempotencyServiceTestSuite) TestIdempotencyService_Release_ExpectRetry() {
+	payload := i.payload(`{}`)
+	record, ierr := i.ris.Begin(payload)
+	i.Require().NoError(ierr)
+	i.Require().NoError(i.ris.Release(record))
+
+	retried, ierr := i.ris.Begin(payload)
+	i.Require().NoError(ierr)
+	i.Equal(string(consts.IdempotencyStatusInProgress), retried.Status)
+	i.NotEqual(record.ID, retried.ID)
+}
+
+func (i *IdempotencyServiceTestSuite) TestIdempotencyService_DeleteExpired_ExpectDeleted() {
+	payload := i.payload(`{}`)
+	expired := models.NewIdempotencyRecord("", payload.IdempotencyKey, payload.Method, payload.Path, payload.RequestHash, -time.Hour)
+	i.Require().NoError(i.rCtx.DB().Create(expired).Error)
+	kept, ierr := i.ris.Begin(i.payload(`{}`))
+	i.Require().NoError(ierr)
+
+	deleted, ierr := i.ris.DeleteExpired()
+	i.Require().NoError(ierr)
+	i.True(deleted >= 1)
+
+	var count int64
+	i.Require().NoError(i.rCtx.DB().Model(&models.IdempotencyRecord{}).Where("id IN ?", []string{expired.ID, kept.ID}).Count(&count).Error)
+	i.Equal(int64(1), count)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"github.com/stretchr/testify/mock"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type MockIdempotencyService struct {
+	mock.Mock
+}
+
+func NewMockIdempotencyService() *MockIdempotencyService {
+	return &MockIdempotencyService{}
+}
+
+func (m *MockIdempotencyService) Begin(payload *IdempotencyBeginPayload) (*models.IdempotencyRecord, core.IError) {
+	args := m.Called(payload)
+	return args.Get(0).(*models.IdempotencyRecord), core.MockIError(args, 1)
+}
+
+func (m *MockIdempotencyService) Complete(record *models.IdempotencyRecord, status int, body []byte) core.IError {
+	args := m.Called(record, status, body)
+	return core.MockIError(args, 0)
+}
+
+func (m *MockIdempotencyService) Release(record *models.IdempotencyRecord) core.IError {
+	args := m.Called(record)
+	return core.MockIError(args, 0)
+}
+
+func (m *MockIdempotencyService) DeleteExpired() (int64, core.IError) {
+	args := m.Called()
+	return args.Get(0).(int64), core.MockIError(args, 1)
+}
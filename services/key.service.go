@@ -1,19 +1,35 @@
 package services
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
 	"crypto/x509"
 	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
 
 	"gitlab.finema.co/finema/etda/key-repository-api/consts"
 	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
 	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	core "ssi-gitlab.teda.th/ssi/core"
 	"ssi-gitlab.teda.th/ssi/core/errmsgs"
 	"ssi-gitlab.teda.th/ssi/core/utils"
-	"gorm.io/gorm"
 )
 
 type KeyGeneratePayload struct {
+	Alias  string
+	Tags   map[string]string
+	Policy *models.KeyPolicy
+	Escrow *models.KeyEscrowPolicy
 }
 
 type KeySignPayload struct {
@@ -22,33 +38,146 @@ type KeySignPayload struct {
 }
 
 type KeyStorePayload struct {
-	PublicKey  string
-	PrivateKey string
-	KeyType    string
+	PublicKey string
+	// PrivateKey and PrivateKeyPassword are buffers owned by the caller, who zeroes them once Store returns
+	PrivateKey         []byte
+	PrivateKeyPassword []byte
+	KeyType            string
+	Alias              string
+	Tags               map[string]string
+	Policy             *models.KeyPolicy
+	Escrow             *models.KeyEscrowPolicy
+}
+
+type KeyUpdatePayload struct {
+	Alias         *string
+	Tags          map[string]string
+	Policy        *models.KeyPolicy
+	SignRateLimit *models.RateLimit
+	CachePolicy   *models.KeyCachePolicy
+}
+
+type KeyPaginationPayload struct {
+	Alias string
+	Type  string
+	Tags  map[string]string
+}
+
+type KeySignature struct {
+	KeyID     string `json:"key_id"`
+	Version   int    `json:"version"`
+	Signature string `json:"signature"`
+}
+
+type KeySignBatchItem struct {
+	ID      string
+	Message string
+}
+
+type KeySignBatchResult struct {
+	KeyID     string      `json:"key_id"`
+	Version   int         `json:"version,omitempty"`
+	Signature string      `json:"signature,omitempty"`
+	Error     interface{} `json:"error,omitempty"`
+}
+
+// keySignGroup holds the items of a batch that are signed with the same version of a key
+type keySignGroup struct {
+	key     *models.Key
+	indexes []int
 }
 
 type IKeyService interface {
 	Find(id string) (*models.Key, core.IError)
+	Pagination(payload *KeyPaginationPayload, pageOptions *core.PageOptions) ([]models.Key, *core.PageResponse, core.IError)
+	Versions(id string) ([]models.KeyVersion, core.IError)
 	Store(payload *KeyStorePayload) (*models.Key, core.IError)
-	Generate() (*models.Key, core.IError)
-	GenerateRSA() (*models.Key, core.IError)
-	Sign(id string, message string) (string, core.IError)
+	Import(payload *KeyStorePayload) (*models.Key, core.IError)
+	Export(id string, payload *KeyExportPayload) (*models.KeyExportBundle, core.IError)
+	Update(id string, payload *KeyUpdatePayload) (*models.Key, core.IError)
+	Generate(payload *KeyGeneratePayload) (*models.Key, core.IError)
+	GenerateRSA(payload *KeyGeneratePayload) (*models.Key, core.IError)
+	GenerateBBS(payload *KeyGeneratePayload) (*models.Key, core.IError)
+	Rotate(id string) (*models.Key, core.IError)
+	Sign(id string, message string) (*KeySignature, core.IError)
+	SignBatch(items []KeySignBatchItem) ([]KeySignBatchResult, core.IError)
+	Delete(id string) core.IError
+	ExecuteApproved(request *models.KeyOperationRequest) (*KeyOperationResult, core.IError)
+	Delegations(id string) ([]models.KeyDelegation, core.IError)
+	Delegate(id string, principalID string) (*models.KeyDelegation, core.IError)
+	Undelegate(id string, principalID string) core.IError
+	Escrows(id string) ([]models.KeyEscrow, core.IError)
+	Recover(id string, payload *KeyRecoverPayload) (*models.Key, core.IError)
+	VerificationMethod(id string, payload *KeyVerificationMethodPayload) (*models.DIDVerificationMethod, core.IError)
+	SignDIDOperation(id string, payload *KeyDIDOperationPayload) (*models.DIDOperationEnvelope, core.IError)
+	SignPresentation(id string, payload *KeyPresentationPayload) (*models.KeyPresentation, core.IError)
+	IssueSDJWT(id string, payload *KeySDJWTPayload) (*models.SDJWT, core.IError)
+	SignBBS(id string, payload *KeyBBSPayload) (*models.BBSSignature, core.IError)
+	SignBBS2023(id string, payload *KeyBBS2023Payload) (*models.BBS2023Proof, core.IError)
 }
 type keyService struct {
-	ctx        core.IContext
-	hsmService IHSMService
+	ctx          core.IContext
+	hsmService   IHSMService
+	auditService IAuditService
 }
 
-func NewKeyService(ctx core.IContext, hsmService IHSMService) IKeyService {
+func NewKeyService(ctx core.IContext, hsmService IHSMService, auditService IAuditService) IKeyService {
 	return &keyService{
-		ctx:        ctx,
-		hsmService: hsmService,
+		ctx:          ctx,
+		hsmService:   hsmService,
+		auditService: auditService,
 	}
 }
 
+// Find resolves "id" or "alias" to the latest version of a key and "id@version" or "alias@version" to that specific version
 func (s keyService) Find(id string) (*models.Key, core.IError) {
+	return s.findAuthorized(id, consts.KeyOperationRead)
+}
+
+// findAuthorized answers with the same error whether the key or its version does not exist or the caller may not use it,
+// so keys of other tenants cannot be discovered, the version is only looked up once the caller is authorized on the key
+func (s keyService) findAuthorized(id string, operation consts.KeyOperation) (*models.Key, core.IError) {
+	keyID, version, err := helpers.ParseKeyReference(id)
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.KeyAccessDeniedError)
+	}
+
+	key, ierr := s.find(keyID)
+	if ierr != nil && ierr.GetCode() == emsgs.KeyNotFoundError.GetCode() {
+		return nil, s.ctx.NewError(ierr, emsgs.KeyAccessDeniedError)
+	}
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	ierr = s.authorize(key, operation)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	if operation == consts.KeyOperationSign && version != 0 && version != key.Version {
+		return nil, s.ctx.NewError(emsgs.KeyVersionNotSignableError, emsgs.KeyVersionNotSignableError)
+	}
+
+	ierr = s.findVersion(key, version)
+	if ierr != nil && ierr.GetCode() == emsgs.KeyVersionNotFoundError.GetCode() {
+		return nil, s.ctx.NewError(ierr, emsgs.KeyAccessDeniedError)
+	}
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	setKeyDIDs(key)
+
+	return key, nil
+}
+
+// find finds the latest version of a key by its id or by its alias in the tenant of the principal
+func (s keyService) find(keyID string) (*models.Key, core.IError) {
+	principal := helpers.GetPrincipal(s.ctx)
 	key := &models.Key{}
-	err := s.ctx.DB().First(&key, "id = ?", id).Error
+	err := s.ctx.DB().Preload("Tags").
+		Where("deleted_at IS NULL").
+		First(&key, "id = ? OR (alias = ? AND tenant_id = ?)", keyID, keyID, principal.TenantID).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, s.ctx.NewError(err, emsgs.KeyNotFoundError)
 	}
@@ -59,91 +188,1214 @@ func (s keyService) Find(id string) (*models.Key, core.IError) {
 	return key, nil
 }
 
-func (s keyService) Generate() (*models.Key, core.IError) {
-	kp, err := utils.GenerateKeyPair()
+// findVersion replaces the key material of the latest version with the one of the version, 0 keeps the latest version
+func (s keyService) findVersion(key *models.Key, version int) core.IError {
+	if version == 0 || version == key.Version {
+		return nil
+	}
+
+	keyVersion := &models.KeyVersion{}
+	err := s.ctx.DB().First(keyVersion, "key_id = ? AND version = ?", key.ID, version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return s.ctx.NewError(err, emsgs.KeyVersionNotFoundError)
+	}
 	if err != nil {
-		return nil, s.ctx.NewError(err, emsgs.GenerateKeyError)
+		return s.ctx.NewError(err, errmsgs.DBError)
 	}
 
-	return s.Store(&KeyStorePayload{
-		PublicKey:  kp.PublicKeyPem,
-		PrivateKey: kp.PrivateKeyPem,
-		KeyType:    string(consts.KeyTypeECDSA),
-	})
+	key.PublicKey = keyVersion.PublicKey
+	key.PrivateKeyEncrypted = keyVersion.PrivateKeyEncrypted
+	key.KEKID = keyVersion.KEKID
+	key.Version = keyVersion.Version
+
+	return nil
 }
 
-func (s keyService) GenerateRSA() (*models.Key, core.IError) {
-	kp, err := utils.GenerateKeyPairWithOption(&utils.GenerateKeyPairOption{
-		Algorithm: x509.SHA256WithRSA,
-		KeySize:   utils.EncryptRSA2048Bits,
-	})
+func (s keyService) Pagination(payload *KeyPaginationPayload, pageOptions *core.PageOptions) ([]models.Key, *core.PageResponse, core.IError) {
+	keys := make([]models.Key, 0)
+
+	principal := helpers.GetPrincipal(s.ctx)
+	db := s.ctx.DB().Preload("Tags").
+		Where("deleted_at IS NULL AND tenant_id = ?", principal.TenantID).
+		Where("owner_id = ? OR id IN (?)", principal.ID, s.ctx.DB().Model(&models.KeyDelegation{}).Select("key_id").Where("principal_id = ?", principal.ID))
+	if payload.Alias != "" {
+		db = db.Where("alias = ?", payload.Alias)
+	}
+	if payload.Type != "" {
+		db = db.Where("type = ?", payload.Type)
+	}
+	for name, value := range payload.Tags {
+		db = db.Where("id IN (?)", s.ctx.DB().Model(&models.KeyTag{}).Select("key_id").Where("name = ? AND value = ?", name, value))
+	}
+	if pageOptions.Q != "" {
+		db = db.Where("alias LIKE ?", "%"+pageOptions.Q+"%")
+	}
 
+	pageResponse, err := core.Paginate(db, &keys, pageOptions)
 	if err != nil {
-		return nil, s.ctx.NewError(err, emsgs.GenerateKeyError)
+		return nil, nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+	for i := range keys {
+		setKeyDIDs(&keys[i])
 	}
 
-	rsaKeyPair, ok := kp.(*utils.RSAKeyPair)
-	if !ok {
-		return nil, s.ctx.NewError(emsgs.GenerateKeyError, emsgs.GenerateKeyError)
+	return keys, pageResponse, nil
+}
+
+func (s keyService) Versions(id string) ([]models.KeyVersion, core.IError) {
+	key, ierr := s.Find(id)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
 	}
 
-	return s.Store(&KeyStorePayload{
-		PublicKey:  rsaKeyPair.PublicKeyPem,
-		PrivateKey: rsaKeyPair.PrivateKeyPem,
-		KeyType:    string(consts.KeyTypeRSA),
+	versions := make([]models.KeyVersion, 0)
+	err := s.ctx.DB().Where("key_id = ?", key.ID).Order("version desc").Find(&versions).Error
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return versions, nil
+}
+
+func (s keyService) Generate(payload *KeyGeneratePayload) (*models.Key, core.IError) {
+	key, ierr := s.generate(consts.KeyTypeECDSA, payload)
+	return s.auditedKey(consts.AuditOperationGenerate, "", key, ierr)
+}
+
+func (s keyService) GenerateRSA(payload *KeyGeneratePayload) (*models.Key, core.IError) {
+	key, ierr := s.generate(consts.KeyTypeRSA, payload)
+	return s.auditedKey(consts.AuditOperationGenerate, "", key, ierr)
+}
+
+// GenerateBBS generates a BLS12381G2 key, which makes BBS signatures of many messages instead of signatures of one message
+func (s keyService) GenerateBBS(payload *KeyGeneratePayload) (*models.Key, core.IError) {
+	key, ierr := s.generate(consts.KeyTypeBLS12381G2, payload)
+	return s.auditedKey(consts.AuditOperationGenerate, "", key, ierr)
+}
+
+func (s keyService) generate(keyType consts.KeyType, payload *KeyGeneratePayload) (*models.Key, core.IError) {
+	publicKey, privateKey, ierr := s.generateKeyPair(keyType)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	defer helpers.Zeroize(privateKey)
+
+	return s.store(&KeyStorePayload{
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+		KeyType:    string(keyType),
+		Alias:      payload.Alias,
+		Tags:       payload.Tags,
+		Policy:     payload.Policy,
+		Escrow:     payload.Escrow,
 	})
 }
 
-func (s keyService) Sign(id string, message string) (string, core.IError) {
-	key, ierr := s.Find(id)
+// Rotate generates a new key pair of the same type and makes it the latest version of the key,
+// older versions stay available through "id@version" to verify the signatures they made but no longer sign
+func (s keyService) Rotate(id string) (*models.Key, core.IError) {
+	key, ierr := s.rotate(id)
+	return s.auditedKey(consts.AuditOperationRotate, id, key, ierr)
+}
+
+func (s keyService) rotate(id string) (*models.Key, core.IError) {
+	key, ierr := s.findAuthorized(id, consts.KeyOperationManage)
 	if ierr != nil {
-		return "", s.ctx.NewError(ierr, ierr)
+		return nil, s.ctx.NewError(ierr, ierr)
 	}
 
-	decryptedPrivateKey, ierr := s.hsmService.Decrypt(key.PrivateKeyEncrypted)
+	publicKey, privateKey, ierr := s.generateKeyPair(consts.KeyType(key.Type))
 	if ierr != nil {
-		return "", s.ctx.NewError(ierr, ierr)
+		return nil, s.ctx.NewError(ierr, ierr)
 	}
+	defer helpers.Zeroize(privateKey)
 
-	var signature string
-	if key.Type == string(consts.KeyTypeECDSA) {
-		privateKey, err := utils.LoadPrivateKey(decryptedPrivateKey)
+	encryptedPrivateKey, ierr := s.hsmService.Encrypt(privateKey)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	kekID, ierr := s.hsmService.KEKID()
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	var escrowErr core.IError
+	err := s.ctx.DB().Transaction(func(tx *gorm.DB) error {
+		// the lock serializes concurrent rotations of the key, each one reads the version the previous one wrote
+		latest := &models.Key{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("version").Where("id = ?", key.ID).First(latest).Error
 		if err != nil {
-			return "", s.ctx.NewError(ierr, errmsgs.InternalServerError)
+			return err
 		}
-		signature, err = utils.SignMessage(privateKey, message)
-		if err != nil {
-			return "", s.ctx.NewError(ierr, errmsgs.InternalServerError)
+
+		keyVersion := models.NewKeyVersion(key.ID, latest.Version+1, publicKey, encryptedPrivateKey)
+		keyVersion.KEKID = kekID
+		if err := tx.Create(keyVersion).Error; err != nil {
+			return err
 		}
-	} else if key.Type == string(consts.KeyTypeRSA) {
-		privateKey, err := utils.LoadRSAPrivateKey(decryptedPrivateKey)
+
+		// the new version is escrowed to the same custodians as the version it replaces
+		latestEscrow := &models.KeyEscrow{}
+		err = tx.Where("key_id = ? AND version = ?", key.ID, latest.Version).First(latestEscrow).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			escrow, ierr := s.escrow(&models.Key{ID: key.ID, Version: keyVersion.Version, Type: key.Type}, privateKey, latestEscrow.Policy())
+			if ierr != nil {
+				escrowErr = ierr
+				return ierr
+			}
+			if err := tx.Create(escrow).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Key{}).Where("id = ?", key.ID).Updates(map[string]interface{}{
+			"public_key":            keyVersion.PublicKey,
+			"private_key_encrypted": keyVersion.PrivateKeyEncrypted,
+			"kek_id":                keyVersion.KEKID,
+			"version":               keyVersion.Version,
+			"updated_at":            utils.GetCurrentDateTime(),
+		}).Error
+	})
+	if escrowErr != nil {
+		return nil, s.ctx.NewError(escrowErr, escrowErr)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+	s.invalidateCachedKey(key.ID)
+
+	return s.Find(key.ID)
+}
+
+func (s keyService) Sign(id string, message string) (*KeySignature, core.IError) {
+	signature, ierr := s.sign(id, message)
+
+	payload := &AuditEventPayload{
+		Operation:     consts.AuditOperationSign,
+		KeyID:         id,
+		MessageDigest: helpers.MessageDigest(message),
+	}
+	if signature != nil {
+		payload.KeyID = signature.KeyID
+		payload.KeyVersion = signature.Version
+	}
+	ierr = s.audit(payload, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return signature, nil
+}
+
+func (s keyService) sign(id string, message string) (*KeySignature, core.IError) {
+	key, ierr := s.findSigningKey(id)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	// the policy is evaluated before the private key ever leaves the HSM
+	ierr = s.enforceSignPolicy(key, message)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	signature, ierr := signWithKey(s.ctx, s.hsmService, key, message)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	return &KeySignature{
+		KeyID:     key.ID,
+		Version:   key.Version,
+		Signature: signature,
+	}, nil
+}
+
+// findSigningKey finds a key the current principal may sign with and takes a signature from the rate limits of
+// the principal and of the key, with signJWS it holds the signatures made outside of Sign to the rules of Sign,
+// the tokens are only taken once the principal may sign with the key so refused requests spend none
+func (s keyService) findSigningKey(id string) (*models.Key, core.IError) {
+	key, ierr := s.findAuthorized(id, consts.KeyOperationSign)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	principal := helpers.GetPrincipal(s.ctx)
+	ierr = s.takeRateLimit("sign:client:"+principal.ID, principal.RateLimit,
+		consts.ENVRateLimitClientRate, consts.ENVRateLimitClientBurst, emsgs.ClientRateLimitExceededError)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	ierr = s.takeRateLimit("sign:key:"+key.ID, key.SignRateLimit,
+		consts.ENVRateLimitKeyRate, consts.ENVRateLimitKeyBurst, emsgs.KeyRateLimitExceededError)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	return key, nil
+}
+
+// SignBatch signs many messages with one or several keys, every key is decrypted once per batch and the messages
+// are signed in parallel, a failing item does not fail the others and every item is audited on its own
+func (s keyService) SignBatch(items []KeySignBatchItem) ([]KeySignBatchResult, core.IError) {
+	results, itemErrors := s.signBatch(items)
+
+	payloads := make([]*AuditEventPayload, len(items))
+	for i, item := range items {
+		payloads[i] = &AuditEventPayload{
+			Operation:     consts.AuditOperationSign,
+			KeyID:         results[i].KeyID,
+			KeyVersion:    results[i].Version,
+			MessageDigest: helpers.MessageDigest(item.Message),
+			Error:         itemErrors[i],
+		}
+		if itemErrors[i] != nil {
+			results[i].Signature = ""
+			results[i].Error = itemErrors[i].JSON()
+		}
+	}
+
+	ierr := s.auditService.RecordBatch(payloads)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	return results, nil
+}
+
+func (s keyService) signBatch(items []KeySignBatchItem) ([]KeySignBatchResult, []core.IError) {
+	results := make([]KeySignBatchResult, len(items))
+	itemErrors := make([]core.IError, len(items))
+
+	// every reference is resolved once, the id and the alias of a key end up in the same group
+	keys := make(map[string]*models.Key)
+	referenceErrors := make(map[string]core.IError)
+	groups := make(map[string]*keySignGroup)
+	groupIDs := make([]string, 0)
+	for i, item := range items {
+		results[i].KeyID = item.ID
+		if ierr, ok := referenceErrors[item.ID]; ok {
+			itemErrors[i] = ierr
+			continue
+		}
+
+		key, ok := keys[item.ID]
+		if !ok {
+			var ierr core.IError
+			key, ierr = s.findAuthorized(item.ID, consts.KeyOperationSign)
+			if ierr != nil {
+				referenceErrors[item.ID] = ierr
+				itemErrors[i] = ierr
+				continue
+			}
+			keys[item.ID] = key
+		}
+
+		results[i].KeyID = key.ID
+		results[i].Version = key.Version
+		groupID := fmt.Sprintf("%s@%d", key.ID, key.Version)
+		group, ok := groups[groupID]
+		if !ok {
+			group = &keySignGroup{key: key}
+			groups[groupID] = group
+			groupIDs = append(groupIDs, groupID)
+		}
+		group.indexes = append(group.indexes, i)
+	}
+
+	// keys are decrypted one after another because the HSM session cannot be shared between goroutines
+	principal := helpers.GetPrincipal(s.ctx)
+	signers := make([]*keySigner, len(items))
+	for _, groupID := range groupIDs {
+		group := groups[groupID]
+
+		// every signature spends a token, once a bucket is empty the remaining items of the group fail with its error
+		granted := make([]int, 0, len(group.indexes))
+		var limitErr core.IError
+		for _, i := range group.indexes {
+			if limitErr == nil {
+				limitErr = s.takeRateLimit("sign:client:"+principal.ID, principal.RateLimit,
+					consts.ENVRateLimitClientRate, consts.ENVRateLimitClientBurst, emsgs.ClientRateLimitExceededError)
+			}
+			if limitErr == nil {
+				limitErr = s.takeRateLimit("sign:key:"+group.key.ID, group.key.SignRateLimit,
+					consts.ENVRateLimitKeyRate, consts.ENVRateLimitKeyBurst, emsgs.KeyRateLimitExceededError)
+			}
+			if limitErr != nil {
+				itemErrors[i] = limitErr
+				continue
+			}
+			granted = append(granted, i)
+		}
+		if len(granted) == 0 {
+			continue
+		}
+
+		messages := make([]string, len(granted))
+		for j, i := range granted {
+			messages[j] = items[i].Message
+		}
+		policyErrors, ierr := s.signPolicyErrors(group.key, messages)
+		if ierr != nil {
+			for _, i := range granted {
+				itemErrors[i] = ierr
+			}
+			continue
+		}
+
+		allowed := make([]int, 0, len(granted))
+		for j, i := range granted {
+			if policyErrors[j] != nil {
+				itemErrors[i] = policyErrors[j]
+				continue
+			}
+			allowed = append(allowed, i)
+		}
+		if len(allowed) == 0 {
+			continue
+		}
+
+		signer, ierr := newKeySigner(s.ctx, s.hsmService, group.key, len(allowed))
+		if signer != nil {
+			defer signer.Release()
+		}
+		for _, i := range allowed {
+			itemErrors[i] = ierr
+			signers[i] = signer
+		}
+	}
+
+	signErrors := make([]error, len(items))
+	workers := make(chan struct{}, runtime.NumCPU())
+	wg := sync.WaitGroup{}
+	for i, signer := range signers {
+		if signer == nil {
+			continue
+		}
+
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, signer *keySigner) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			results[i].Signature, signErrors[i] = signer.Sign(items[i].Message)
+		}(i, signer)
+	}
+	wg.Wait()
+
+	for i, err := range signErrors {
 		if err != nil {
-			return "", s.ctx.NewError(ierr, errmsgs.InternalServerError)
+			itemErrors[i] = signingError(s.ctx, err)
 		}
-		signature, err = utils.SignMessageWithOption(privateKey, message, &utils.SignMessageOption{
-			Algorithm: x509.SHA256WithRSA,
-		})
+	}
+
+	return results, itemErrors
+}
+
+// takeRateLimit spends a token of the bucket, without a limit of its own the bucket uses the default from the environment
+func (s keyService) takeRateLimit(bucket string, limit *models.RateLimit, rateENV string, burstENV string, limitError core.Error) core.IError {
+	limiter, ok := s.ctx.GetData(consts.ContextKeyRateLimiter).(helpers.RateLimiter)
+	if !ok {
+		return nil
+	}
+	if limit == nil {
+		limit = defaultRateLimit(s.ctx, rateENV, burstENV)
+	}
+	if limit.Rate <= 0 {
+		return nil
+	}
+
+	wait, err := limiter.Take(bucket, limit)
+	if err != nil {
+		// the limiter still answers from the buckets of this replica when its shared store is unreachable
+		s.ctx.Log().Error(fmt.Sprintf("rate limit: cannot take from %s: %v", bucket, err))
+	}
+	if wait > 0 {
+		helpers.SetRetryAfter(s.ctx, wait)
+		return s.ctx.NewError(limitError, limitError)
+	}
+
+	return nil
+}
+
+// defaultRateLimit has a rate of 0, meaning unlimited, when the rate is not set and a burst of one second of tokens when the burst is not set
+func defaultRateLimit(ctx core.IContext, rateENV string, burstENV string) *models.RateLimit {
+	rate, _ := strconv.ParseFloat(ctx.ENV().String(rateENV), 64)
+	burst := ctx.ENV().Int(burstENV)
+	if burst <= 0 {
+		burst = int(math.Max(1, math.Ceil(rate)))
+	}
+
+	return &models.RateLimit{Rate: rate, Burst: burst}
+}
+
+// enforceSignPolicy checks the policy of the key for a signature of message by the current principal
+func (s keyService) enforceSignPolicy(key *models.Key, message string) core.IError {
+	policyErrors, ierr := s.signPolicyErrors(key, []string{message})
+	if ierr != nil {
+		return s.ctx.NewError(ierr, ierr)
+	}
+
+	return policyErrors[0]
+}
+
+// signPolicyErrors checks the policy of the key for signatures of messages by the current principal and returns
+// the error of each message, the signature limit counts successful signatures in the audit log so it holds across replicas
+// and the messages are granted in order until the limit is reached
+func (s keyService) signPolicyErrors(key *models.Key, messages []string) ([]core.IError, core.IError) {
+	policyErrors := make([]core.IError, len(messages))
+	if key.Policy == nil {
+		return policyErrors, nil
+	}
+
+	var keyError core.IError
+	policy := key.Policy
+	if !policy.AllowsUsage(consts.KeyUsageSign) {
+		keyError = s.ctx.NewError(emsgs.KeyPolicyUsageDeniedError, emsgs.KeyPolicyUsageDeniedError)
+	} else if !policy.AllowsAlgorithm(signingAlgorithm(key)) {
+		keyError = s.ctx.NewError(emsgs.KeyPolicyAlgorithmDeniedError, emsgs.KeyPolicyAlgorithmDeniedError)
+	} else if !policy.AllowsCaller(helpers.GetPrincipal(s.ctx).ID) {
+		keyError = s.ctx.NewError(emsgs.KeyPolicyCallerDeniedError, emsgs.KeyPolicyCallerDeniedError)
+	}
+	if keyError != nil {
+		for i := range policyErrors {
+			policyErrors[i] = keyError
+		}
+		return policyErrors, nil
+	}
+
+	remaining := int64(len(messages))
+	if policy.MaxSignatures > 0 {
+		since := utils.GetCurrentDateTime().Add(-time.Duration(policy.WindowSeconds) * time.Second)
+		var count int64
+		err := s.ctx.DB().Model(&models.KeyAuditEvent{}).
+			Where("key_id = ? AND operation = ? AND outcome = ? AND created_at >= ?",
+				key.ID, string(consts.AuditOperationSign), string(consts.AuditOutcomeSuccess), since).
+			Count(&count).Error
 		if err != nil {
-			return "", s.ctx.NewError(ierr, errmsgs.InternalServerError)
+			return nil, s.ctx.NewError(err, errmsgs.DBError)
+		}
+		remaining = int64(policy.MaxSignatures) - count
+	}
+
+	for i, message := range messages {
+		if !allowsMessageFormat(policy, message) {
+			policyErrors[i] = s.ctx.NewError(emsgs.KeyPolicyMessageFormatDeniedError, emsgs.KeyPolicyMessageFormatDeniedError)
+			continue
+		}
+		if remaining <= 0 {
+			policyErrors[i] = s.ctx.NewError(emsgs.KeyPolicySignatureLimitError, emsgs.KeyPolicySignatureLimitError)
+			continue
+		}
+		remaining--
+	}
+
+	return policyErrors, nil
+}
+
+func allowsMessageFormat(policy *models.KeyPolicy, message string) bool {
+	if len(policy.MessageFormats) == 0 {
+		return true
+	}
+	for _, format := range policy.MessageFormats {
+		if helpers.IsMessageFormat(message, format) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func signingAlgorithm(key *models.Key) consts.SigningAlgorithm {
+	switch consts.KeyType(key.Type) {
+	case consts.KeyTypeRSA:
+		return consts.SigningAlgorithmRS256
+	case consts.KeyTypeBLS12381G2:
+		return consts.SigningAlgorithmBBS
+	}
+
+	return consts.SigningAlgorithmES256
+}
+
+var (
+	// errBBSMessageSigning is returned by the signers of BLS12381G2 keys, which only sign through SignBBS
+	errBBSMessageSigning = errors.New("a BLS12381G2 key only makes BBS signatures")
+	errBBSKeyRequired    = errors.New("only BLS12381G2 keys make BBS signatures")
+)
+
+// keySigner signs messages with a private key decrypted once, Sign is safe for concurrent use
+// and Release must be called once no more messages are signed
+type keySigner struct {
+	Sign func(message string) (string, error)
+	// SignJWS signs the input with the JWS algorithm of the key and returns the raw JWS signature
+	SignJWS func(signingInput []byte) ([]byte, error)
+	// SignBBS signs the header and the messages at once, the signers of other key types return errBBSKeyRequired
+	SignBBS func(header []byte, messages [][]byte) ([]byte, error)
+	Release func()
+}
+
+// signingError reports a signer used for signatures its key does not make as a bad request
+func signingError(ctx core.IContext, err error) core.IError {
+	switch err {
+	case errBBSMessageSigning:
+		return ctx.NewError(err, emsgs.BBSKeyMessageSigningError)
+	case errBBSKeyRequired:
+		return ctx.NewError(err, emsgs.BBSKeyRequiredError)
+	}
+
+	return ctx.NewError(err, errmsgs.InternalServerError)
+}
+
+// newKeySigner has the HSM decrypt the private key into the memory of the service, where it is parsed and signs,
+// unless the key opted in to the cache and is still cached, uses is the number of messages that will be signed. Callers are responsible for authorizing the use of the key
+func newKeySigner(ctx core.IContext, hsmService IHSMService, key *models.Key, uses int) (*keySigner, core.IError) {
+	cache, _ := ctx.GetData(consts.ContextKeyKeyCache).(*helpers.KeyCache)
+	cacheID := fmt.Sprintf("%s@%d", key.ID, key.Version)
+	fingerprint := helpers.MessageDigest(key.PrivateKeyEncrypted)
+	cacheable := cache != nil && key.CachePolicy != nil && key.CachePolicy.TTLSeconds > 0
+	if cacheable {
+		if privateKey, release, ok := cache.Take(cacheID, fingerprint, uses); ok {
+			return newKeySignerFromPrivateKey(ctx, key, privateKey, release)
+		}
+	}
+
+	decryptedPrivateKey, ierr := hsmService.Decrypt(key.PrivateKeyEncrypted)
+	if ierr != nil {
+		return nil, ctx.NewError(ierr, ierr)
+	}
+	defer helpers.Zeroize(decryptedPrivateKey)
+
+	privateKey, ierr := parsePrivateKey(ctx, key, decryptedPrivateKey)
+	if ierr != nil {
+		return nil, ctx.NewError(ierr, ierr)
+	}
+
+	release := func() {
+		helpers.ZeroizePrivateKey(privateKey)
+	}
+	if cacheable {
+		ttl := time.Duration(key.CachePolicy.TTLSeconds) * time.Second
+		if cacheRelease, ok := cache.Put(cacheID, fingerprint, privateKey, ttl, key.CachePolicy.MaxUses, uses); ok {
+			release = cacheRelease
 		}
-	} else {
-		return "", s.ctx.NewError(emsgs.UnsupportedSigningAlgorithm, emsgs.UnsupportedSigningAlgorithm)
+	}
+
+	return newKeySignerFromPrivateKey(ctx, key, privateKey, release)
+}
+
+// parsePrivateKey parses the decrypted private key PEM of key, the result must be zeroized by the caller
+func parsePrivateKey(ctx core.IContext, key *models.Key, decryptedPrivateKey []byte) (crypto.PrivateKey, core.IError) {
+	var privateKey crypto.PrivateKey
+	var err error
+	switch consts.KeyType(key.Type) {
+	case consts.KeyTypeECDSA:
+		privateKey, err = helpers.ParseECDSAPrivateKeyPEM(decryptedPrivateKey)
+	case consts.KeyTypeRSA:
+		privateKey, err = helpers.ParseRSAPrivateKeyPEM(decryptedPrivateKey)
+	case consts.KeyTypeBLS12381G2:
+		privateKey, err = helpers.ParseBBSPrivateKeyPEM(decryptedPrivateKey)
+	default:
+		return nil, ctx.NewError(emsgs.UnsupportedSigningAlgorithm, emsgs.UnsupportedSigningAlgorithm)
+	}
+	if err != nil {
+		return nil, ctx.NewError(err, errmsgs.InternalServerError)
+	}
+
+	return privateKey, nil
+}
+
+func newKeySignerFromPrivateKey(ctx core.IContext, key *models.Key, privateKey crypto.PrivateKey, release func()) (*keySigner, core.IError) {
+	switch privateKey := privateKey.(type) {
+	case *ecdsa.PrivateKey:
+		return &keySigner{
+			Sign: func(message string) (string, error) {
+				return utils.SignMessage(privateKey, message)
+			},
+			SignJWS: func(signingInput []byte) ([]byte, error) {
+				return helpers.SignJWSSignature(string(signingAlgorithm(key)), privateKey, signingInput)
+			},
+			SignBBS: signBBSUnsupported,
+			Release: release,
+		}, nil
+	case *rsa.PrivateKey:
+		return &keySigner{
+			Sign: func(message string) (string, error) {
+				return utils.SignMessageWithOption(privateKey, message, &utils.SignMessageOption{
+					Algorithm: x509.SHA256WithRSA,
+				})
+			},
+			SignJWS: func(signingInput []byte) ([]byte, error) {
+				return helpers.SignJWSSignature(string(signingAlgorithm(key)), privateKey, signingInput)
+			},
+			SignBBS: signBBSUnsupported,
+			Release: release,
+		}, nil
+	case *helpers.BBSPrivateKey:
+		return &keySigner{
+			Sign: func(message string) (string, error) {
+				return "", errBBSMessageSigning
+			},
+			SignJWS: func(signingInput []byte) ([]byte, error) {
+				return nil, errBBSMessageSigning
+			},
+			SignBBS: func(header []byte, messages [][]byte) ([]byte, error) {
+				return helpers.BBSSign(privateKey, header, messages)
+			},
+			Release: release,
+		}, nil
+	}
+
+	release()
+	return nil, ctx.NewError(emsgs.UnsupportedSigningAlgorithm, emsgs.UnsupportedSigningAlgorithm)
+}
+
+func signBBSUnsupported(header []byte, messages [][]byte) ([]byte, error) {
+	return nil, errBBSKeyRequired
+}
+
+// signWithKey decrypts the private key inside the HSM and signs the message with it,
+// callers are responsible for authorizing the use of the key
+func signWithKey(ctx core.IContext, hsmService IHSMService, key *models.Key, message string) (string, core.IError) {
+	signer, ierr := newKeySigner(ctx, hsmService, key, 1)
+	if ierr != nil {
+		return "", ctx.NewError(ierr, ierr)
+	}
+	defer signer.Release()
+
+	signature, err := signer.Sign(message)
+	if err != nil {
+		return "", signingError(ctx, err)
 	}
 
 	return signature, nil
 }
 
 func (s keyService) Store(payload *KeyStorePayload) (*models.Key, core.IError) {
+	key, ierr := s.importKey(payload)
+	return s.auditedKey(consts.AuditOperationStore, "", key, ierr)
+}
+
+// Import stores a private key unwrapped from a transport key or a wrapped bundle, it is audited as an import
+func (s keyService) Import(payload *KeyStorePayload) (*models.Key, core.IError) {
+	key, ierr := s.importKey(payload)
+	return s.auditedKey(consts.AuditOperationImport, "", key, ierr)
+}
+
+// importKey parses the private key in any accepted format, checks it against the public key and key type
+// when they are given and stores the key pair in its normalized form
+func (s keyService) importKey(payload *KeyStorePayload) (*models.Key, core.IError) {
+	imported, err := helpers.ImportPrivateKey(payload.PrivateKey, payload.PrivateKeyPassword)
+	if errors.Is(err, helpers.ErrPrivateKeyPasswordRequired) {
+		return nil, s.ctx.NewError(err, emsgs.PrivateKeyPasswordRequiredError)
+	}
+	if errors.Is(err, helpers.ErrUnsupportedPrivateKey) {
+		return nil, s.ctx.NewError(err, emsgs.UnsupportedPrivateKeyError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.InvalidPrivateKeyError)
+	}
+	defer helpers.Zeroize(imported.PrivateKey)
+
+	if payload.KeyType != "" && payload.KeyType != string(imported.KeyType) {
+		return nil, s.ctx.NewError(emsgs.KeyTypeMismatchError, emsgs.KeyTypeMismatchError)
+	}
+	if payload.PublicKey != "" {
+		same, err := helpers.SamePublicKey(payload.PublicKey, imported.PublicKey)
+		if err != nil {
+			return nil, s.ctx.NewError(err, emsgs.InvalidPublicKeyError)
+		}
+		if !same {
+			return nil, s.ctx.NewError(emsgs.KeyPairMismatchError, emsgs.KeyPairMismatchError)
+		}
+	}
+
+	return s.store(&KeyStorePayload{
+		PublicKey:  imported.PublicKey,
+		PrivateKey: imported.PrivateKey,
+		KeyType:    string(imported.KeyType),
+		Alias:      payload.Alias,
+		Tags:       payload.Tags,
+		Policy:     payload.Policy,
+		Escrow:     payload.Escrow,
+	})
+}
+
+func (s keyService) store(payload *KeyStorePayload) (*models.Key, core.IError) {
+	principal := helpers.GetPrincipal(s.ctx)
+	ierr := s.checkAlias(principal.TenantID, payload.Alias, "")
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	ierr = s.checkAdminPolicy(nil, payload.Policy)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
 	encryptedPrivateKey, ierr := s.hsmService.Encrypt(payload.PrivateKey)
 	if ierr != nil {
 		return nil, s.ctx.NewError(ierr, ierr)
 	}
+	kekID, ierr := s.hsmService.KEKID()
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
 
-	key := models.NewKey(payload.PublicKey, encryptedPrivateKey, payload.KeyType)
-	err := s.ctx.DB().Create(key).Error
+	key := models.NewKey(payload.PublicKey, encryptedPrivateKey, payload.KeyType, principal)
+	key.KEKID = kekID
+	if payload.Alias != "" {
+		key.Alias = &payload.Alias
+	}
+	key.Tags = models.NewKeyTags(key.ID, payload.Tags)
+	key.Policy = payload.Policy
+
+	var escrow *models.KeyEscrow
+	if payload.Escrow != nil {
+		escrow, ierr = s.escrow(key, payload.PrivateKey, payload.Escrow)
+		if ierr != nil {
+			return nil, s.ctx.NewError(ierr, ierr)
+		}
+	}
+
+	err := s.ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(key).Error; err != nil {
+			return err
+		}
+
+		keyVersion := models.NewKeyVersion(key.ID, key.Version, key.PublicKey, key.PrivateKeyEncrypted)
+		keyVersion.KEKID = key.KEKID
+		if err := tx.Create(keyVersion).Error; err != nil {
+			return err
+		}
+		if escrow != nil {
+			return tx.Create(escrow).Error
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, s.ctx.NewError(err, errmsgs.DBError)
 	}
 
 	return s.Find(key.ID)
 }
+
+// Update changes the metadata of a key, a nil alias keeps the current alias and an empty alias removes it,
+// non-nil tags replace all current tags and a non-nil policy replaces the current policy
+func (s keyService) Update(id string, payload *KeyUpdatePayload) (*models.Key, core.IError) {
+	key, ierr := s.update(id, payload, nil)
+	return s.auditedKey(consts.AuditOperationUpdate, id, key, ierr)
+}
+
+func (s keyService) update(id string, payload *KeyUpdatePayload, approved *models.KeyOperationRequest) (*models.Key, core.IError) {
+	key, ierr := s.findAuthorized(id, consts.KeyOperationManage)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	if payload.Alias != nil {
+		ierr = s.checkAlias(key.TenantID, *payload.Alias, key.ID)
+		if ierr != nil {
+			return nil, s.ctx.NewError(ierr, ierr)
+		}
+	}
+
+	// rate limits protect the HSM shared by every tenant so only admins may change them
+	if payload.SignRateLimit != nil && !helpers.GetPrincipal(s.ctx).HasScope(consts.ScopeKeysAdmin) {
+		ierr = emsgs.InsufficientScopeError(consts.ScopeKeysAdmin)
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	ierr = s.checkAdminPolicy(key.Policy, payload.Policy)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	if payload.Policy != nil {
+		ierr = s.checkApproval(key, consts.KeyApprovalOperationUpdatePolicy, approved)
+		if ierr != nil {
+			return nil, s.ctx.NewError(ierr, ierr)
+		}
+	}
+
+	err := s.ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if err := markExecuted(tx, approved); err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"updated_at": utils.GetCurrentDateTime(),
+		}
+		if payload.Alias != nil {
+			updates["alias"] = gorm.Expr("NULLIF(?, '')", *payload.Alias)
+		}
+		if payload.Policy != nil {
+			updates["policy"] = payload.Policy
+		}
+		if payload.SignRateLimit != nil {
+			updates["sign_rate_limit"] = payload.SignRateLimit
+		}
+		if payload.CachePolicy != nil {
+			updates["cache_policy"] = payload.CachePolicy
+		}
+		if err := tx.Model(&models.Key{}).Where("id = ?", key.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if payload.Tags == nil {
+			return nil
+		}
+		if err := tx.Where("key_id = ?", key.ID).Delete(&models.KeyTag{}).Error; err != nil {
+			return err
+		}
+		tags := models.NewKeyTags(key.ID, payload.Tags)
+		if len(tags) == 0 {
+			return nil
+		}
+
+		return tx.Create(&tags).Error
+	})
+	if errors.Is(err, emsgs.KeyOperationRequestNotApprovedError) {
+		return nil, s.ctx.NewError(err, emsgs.KeyOperationRequestNotApprovedError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+	if payload.CachePolicy != nil {
+		s.invalidateCachedKey(key.ID)
+	}
+
+	return s.Find(key.ID)
+}
+
+func (s keyService) Delete(id string) core.IError {
+	return s.audit(&AuditEventPayload{
+		Operation: consts.AuditOperationDelete,
+		KeyID:     id,
+	}, s.delete(id, nil))
+}
+
+func (s keyService) delete(id string, approved *models.KeyOperationRequest) core.IError {
+	key, ierr := s.findAuthorized(id, consts.KeyOperationDelete)
+	if ierr != nil {
+		return s.ctx.NewError(ierr, ierr)
+	}
+	ierr = s.checkApproval(key, consts.KeyApprovalOperationDelete, approved)
+	if ierr != nil {
+		return s.ctx.NewError(ierr, ierr)
+	}
+
+	err := s.ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if err := markExecuted(tx, approved); err != nil {
+			return err
+		}
+
+		// the alias is released so it can be given to another key
+		return tx.Model(&models.Key{}).Where("id = ?", key.ID).Updates(map[string]interface{}{
+			"alias":      nil,
+			"deleted_at": utils.GetCurrentDateTime(),
+		}).Error
+	})
+	if errors.Is(err, emsgs.KeyOperationRequestNotApprovedError) {
+		return s.ctx.NewError(err, emsgs.KeyOperationRequestNotApprovedError)
+	}
+	if err != nil {
+		return s.ctx.NewError(err, errmsgs.DBError)
+	}
+	s.invalidateCachedKey(key.ID)
+
+	return nil
+}
+
+// ExecuteApproved runs the operation of an approved request as its requester, the request is marked executed
+// together with the operation and a request that is no longer approved fails with KeyOperationRequestNotApprovedError
+func (s keyService) ExecuteApproved(request *models.KeyOperationRequest) (*KeyOperationResult, core.IError) {
+	result := &KeyOperationResult{Request: request}
+	var ierr core.IError
+	switch consts.KeyApprovalOperation(request.Operation) {
+	case consts.KeyApprovalOperationExport:
+		result.Bundle, ierr = s.auditedExport(request.KeyID, &KeyExportPayload{
+			WrappingPublicKey: request.Payload.WrappingPublicKey,
+			Format:            consts.KeyExportFormat(request.Payload.Format),
+		}, request)
+	case consts.KeyApprovalOperationDelete:
+		ierr = s.audit(&AuditEventPayload{
+			Operation: consts.AuditOperationDelete,
+			KeyID:     request.KeyID,
+		}, s.delete(request.KeyID, request))
+	case consts.KeyApprovalOperationUpdatePolicy:
+		var key *models.Key
+		key, ierr = s.update(request.KeyID, &KeyUpdatePayload{Policy: request.Payload.Policy}, request)
+		result.Key, ierr = s.auditedKey(consts.AuditOperationUpdate, request.KeyID, key, ierr)
+	}
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	request.Status = string(consts.KeyApprovalStatusExecuted)
+	request.ExecutedAt = utils.GetCurrentDateTime()
+
+	return result, nil
+}
+
+func (s keyService) Delegations(id string) ([]models.KeyDelegation, core.IError) {
+	key, ierr := s.findAuthorized(id, consts.KeyOperationManage)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	delegations := make([]models.KeyDelegation, 0)
+	err := s.ctx.DB().Where("key_id = ?", key.ID).Order("created_at asc").Find(&delegations).Error
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return delegations, nil
+}
+
+// Delegate lets another principal of the same tenant read, sign with and delete the key
+func (s keyService) Delegate(id string, principalID string) (*models.KeyDelegation, core.IError) {
+	delegation, ierr := s.delegate(id, principalID)
+	ierr = s.audit(&AuditEventPayload{
+		Operation: consts.AuditOperationDelegate,
+		KeyID:     id,
+	}, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return delegation, nil
+}
+
+func (s keyService) delegate(id string, principalID string) (*models.KeyDelegation, core.IError) {
+	key, ierr := s.findAuthorized(id, consts.KeyOperationManage)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	delegation := &models.KeyDelegation{}
+	err := s.ctx.DB().First(delegation, "key_id = ? AND principal_id = ?", key.ID, principalID).Error
+	if err == nil {
+		return delegation, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	delegation = models.NewKeyDelegation(key.ID, principalID)
+	err = s.ctx.DB().Create(delegation).Error
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return delegation, nil
+}
+
+func (s keyService) Undelegate(id string, principalID string) core.IError {
+	return s.audit(&AuditEventPayload{
+		Operation: consts.AuditOperationUndelegate,
+		KeyID:     id,
+	}, s.undelegate(id, principalID))
+}
+
+func (s keyService) undelegate(id string, principalID string) core.IError {
+	key, ierr := s.findAuthorized(id, consts.KeyOperationManage)
+	if ierr != nil {
+		return s.ctx.NewError(ierr, ierr)
+	}
+
+	result := s.ctx.DB().Where("key_id = ? AND principal_id = ?", key.ID, principalID).Delete(&models.KeyDelegation{})
+	if result.Error != nil {
+		return s.ctx.NewError(result.Error, errmsgs.DBError)
+	}
+	if result.RowsAffected == 0 {
+		return s.ctx.NewError(emsgs.KeyDelegationNotFoundError, emsgs.KeyDelegationNotFoundError)
+	}
+
+	return nil
+}
+
+// audit records the outcome of an operation, a failed operation keeps its own error
+// and a successful one fails when it cannot be recorded
+func (s keyService) audit(payload *AuditEventPayload, ierr core.IError) core.IError {
+	payload.Error = ierr
+	auditErr := s.auditService.Record(payload)
+	if ierr != nil {
+		return s.ctx.NewError(ierr, ierr)
+	}
+	if auditErr != nil {
+		return s.ctx.NewError(auditErr, auditErr)
+	}
+
+	return nil
+}
+
+func (s keyService) auditedKey(operation consts.AuditOperation, id string, key *models.Key, ierr core.IError) (*models.Key, core.IError) {
+	payload := &AuditEventPayload{
+		Operation: operation,
+		KeyID:     id,
+	}
+	if key != nil {
+		payload.KeyID = key.ID
+		payload.KeyVersion = key.Version
+	}
+	ierr = s.audit(payload, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return key, nil
+}
+
+// invalidateCachedKey drops the cached private keys of every version of a key from this replica, the other replicas
+// cannot use theirs either because a rotated key has a new version and a deleted key is not found anymore
+func (s keyService) invalidateCachedKey(keyID string) {
+	if cache, ok := s.ctx.GetData(consts.ContextKeyKeyCache).(*helpers.KeyCache); ok {
+		cache.Invalidate(keyID)
+	}
+}
+
+// authorize allows the owner every operation and delegated principals everything but managing the key
+func (s keyService) authorize(key *models.Key, operation consts.KeyOperation) core.IError {
+	principal := helpers.GetPrincipal(s.ctx)
+	if key.TenantID != principal.TenantID {
+		return s.ctx.NewError(emsgs.KeyAccessDeniedError, emsgs.KeyAccessDeniedError)
+	}
+	if key.OwnerID == principal.ID {
+		return nil
+	}
+	if operation == consts.KeyOperationManage {
+		return s.ctx.NewError(emsgs.KeyAccessDeniedError, emsgs.KeyAccessDeniedError)
+	}
+
+	var count int64
+	err := s.ctx.DB().Model(&models.KeyDelegation{}).
+		Where("key_id = ? AND principal_id = ?", key.ID, principal.ID).
+		Count(&count).Error
+	if err != nil {
+		return s.ctx.NewError(err, errmsgs.DBError)
+	}
+	if count == 0 {
+		return s.ctx.NewError(emsgs.KeyAccessDeniedError, emsgs.KeyAccessDeniedError)
+	}
+
+	return nil
+}
+
+// checkAdminPolicy lets only admins make a key exportable, an exported key is no longer protected by the HSM alone,
+// and set its approvers, which decide who else can export, delete or change the key. Other callers may change the
+// rest of a policy as long as they keep these fields of the current policy
+func (s keyService) checkAdminPolicy(current *models.KeyPolicy, policy *models.KeyPolicy) core.IError {
+	if policy == nil || reflect.DeepEqual(adminPolicyFields(current), adminPolicyFields(policy)) {
+		return nil
+	}
+	if !helpers.GetPrincipal(s.ctx).HasScope(consts.ScopeKeysAdmin) {
+		ierr := emsgs.InsufficientScopeError(consts.ScopeKeysAdmin)
+		return s.ctx.NewError(ierr, ierr)
+	}
+
+	return nil
+}
+
+func adminPolicyFields(policy *models.KeyPolicy) models.KeyPolicy {
+	if policy == nil {
+		return models.KeyPolicy{}
+	}
+
+	fields := models.KeyPolicy{
+		Exportable:        policy.Exportable,
+		ApprovalsRequired: policy.ApprovalsRequired,
+	}
+	// an empty list and no list are the same policy
+	if len(policy.ExportWrappingKeys) > 0 {
+		fields.ExportWrappingKeys = policy.ExportWrappingKeys
+	}
+	if len(policy.Approvers) > 0 {
+		fields.Approvers = policy.Approvers
+	}
+
+	return fields
+}
+
+// checkApproval lets an operation on a key whose policy needs a quorum through only when it runs for an approved
+// request of that key and operation, the operation marks the request executed with markExecuted
+func (s keyService) checkApproval(key *models.Key, operation consts.KeyApprovalOperation, approved *models.KeyOperationRequest) core.IError {
+	if key.Policy == nil || !key.Policy.RequiresApproval() {
+		return nil
+	}
+	if approved == nil || approved.KeyID != key.ID || approved.Operation != string(operation) {
+		return s.ctx.NewError(emsgs.KeyApprovalRequiredError, emsgs.KeyApprovalRequiredError)
+	}
+
+	return nil
+}
+
+// markExecuted moves an approved request to executed in the transaction of its operation, so the request is only
+// executed when the operation succeeds and the row lock makes a concurrent execution find it already executed
+func markExecuted(tx *gorm.DB, approved *models.KeyOperationRequest) error {
+	if approved == nil {
+		return nil
+	}
+
+	result := tx.Model(&models.KeyOperationRequest{}).
+		Where("id = ? AND key_id = ? AND operation = ? AND status = ?",
+			approved.ID, approved.KeyID, approved.Operation, consts.KeyApprovalStatusApproved).
+		Updates(map[string]interface{}{
+			"status":      consts.KeyApprovalStatusExecuted,
+			"executed_at": utils.GetCurrentDateTime(),
+			"updated_at":  utils.GetCurrentDateTime(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return emsgs.KeyOperationRequestNotApprovedError
+	}
+
+	return nil
+}
+
+// checkAlias makes sure an alias is not used by another key of the same tenant
+func (s keyService) checkAlias(tenantID string, alias string, exceptKeyID string) core.IError {
+	if alias == "" {
+		return nil
+	}
+
+	var count int64
+	err := s.ctx.DB().Model(&models.Key{}).
+		Where("tenant_id = ? AND alias = ? AND id <> ? AND deleted_at IS NULL", tenantID, alias, exceptKeyID).
+		Count(&count).Error
+	if err != nil {
+		return s.ctx.NewError(err, errmsgs.DBError)
+	}
+	if count > 0 {
+		return s.ctx.NewError(emsgs.KeyAliasAlreadyExistsError, emsgs.KeyAliasAlreadyExistsError)
+	}
+
+	return nil
+}
+
+// generateKeyPair returns the public key PEM and the private key PEM in a buffer the caller must zero
+func (s keyService) generateKeyPair(keyType consts.KeyType) (string, []byte, core.IError) {
+	var publicKey string
+	var privateKey []byte
+	var err error
+	switch keyType {
+	case consts.KeyTypeECDSA:
+		publicKey, privateKey, err = helpers.GenerateECDSAKeyPair()
+	case consts.KeyTypeRSA:
+		publicKey, privateKey, err = helpers.GenerateRSAKeyPair(consts.RSAKeySize)
+	case consts.KeyTypeBLS12381G2:
+		publicKey, privateKey, err = helpers.GenerateBBSKeyPair()
+	default:
+		return "", nil, s.ctx.NewError(emsgs.UnsupportedSigningAlgorithm, emsgs.UnsupportedSigningAlgorithm)
+	}
+	if err != nil {
+		return "", nil, s.ctx.NewError(err, emsgs.GenerateKeyError)
+	}
+
+	return publicKey, privateKey, nil
+}
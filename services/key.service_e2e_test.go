@@ -4,11 +4,15 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"gitlab.finema.co/finema/etda/key-repository-api/consts"
 	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
 	"gitlab.finema.co/finema/etda/key-repository-api/models"
 	core "ssi-gitlab.teda.th/ssi/core"
 	"ssi-gitlab.teda.th/ssi/core/errmsgs"
@@ -22,7 +26,7 @@ import (
 type MockKeyData struct {
 	ID         string
 	PublicKey  string
-	PrivateKey string
+	PrivateKey []byte
 	Type       string
 	CreatedAt  *time.Time
 	UpdatedAt  *time.Time
@@ -37,7 +41,7 @@ func NewMockKeyData() *MockKeyData {
 	return &MockKeyData{
 		ID:         utils.GetUUID(),
 		PublicKey:  kp.PublicKeyPem,
-		PrivateKey: kp.PrivateKeyPem,
+		PrivateKey: []byte(kp.PrivateKeyPem),
 		Type:       string(consts.KeyTypeECDSA),
 		CreatedAt:  utils.GetCurrentDateTime(),
 		UpdatedAt:  utils.GetCurrentDateTime(),
@@ -58,6 +62,7 @@ type KeyServiceTestSuite struct {
 	rhs  IHSMService
 	mks  *MockKeyService
 	mhs  *MockHSMService
+	mas  *MockAuditService
 }
 
 func TestKeyServiceTestSuite(t *testing.T) {
@@ -76,11 +81,15 @@ func (k *KeyServiceTestSuite) SetupSuite() {
 func (k *KeyServiceTestSuite) SetupTest() {
 	k.mCtx = core.NewMockContext()
 	k.rhs = NewHSMService(k.rCtx)
-	k.rks = NewKeyService(k.rCtx, k.rhs)
+	k.rks = NewKeyService(k.rCtx, k.rhs, NewAuditService(k.rCtx))
 	k.mhs = NewMockHSMService()
 	k.mks = NewMockKeyService()
+	k.mas = NewMockAuditService()
+
+	k.mas.On("Record", mock.Anything).Return(nil)
 
 	k.mCtx.On("DB").Return(k.mCtx.MockDB.Gorm)
+	k.mCtx.On("GetData", consts.ContextKeyRateLimiter).Return(nil)
 }
 
 func (k *KeyServiceTestSuite) TestKeyService_Find_ExpectSuccess() {
@@ -91,6 +100,7 @@ func (k *KeyServiceTestSuite) TestKeyService_Find_ExpectSuccess() {
 		PublicKey:           mockKeyData.PublicKey,
 		PrivateKeyEncrypted: "encrypted_private_key",
 		Type:                string(consts.KeyTypeECDSA),
+		Version:             1,
 		CreatedAt:           utils.GetCurrentDateTime(),
 		UpdatedAt:           utils.GetCurrentDateTime(),
 	}).Error
@@ -111,20 +121,21 @@ func (k *KeyServiceTestSuite) TestKeyService_Find_ExpectError() {
 	mockKeyData := NewMockKeyData()
 
 	k.rhs = NewHSMService(k.mCtx)
-	k.rks = NewKeyService(k.mCtx, k.rhs)
+	k.rks = NewKeyService(k.mCtx, k.rhs, k.mas)
 
-	k.mCtx.MockDB.Mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `keys` WHERE id = ? ORDER BY `keys`.`id` LIMIT 1")).
-		WithArgs(mockKeyData.ID).WillReturnError(gorm.ErrRecordNotFound)
+	k.mCtx.MockDB.Mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `keys` WHERE deleted_at IS NULL AND (id = ? OR (alias = ? AND tenant_id = ?)) ORDER BY `keys`.`id` LIMIT 1")).
+		WithArgs(mockKeyData.ID, mockKeyData.ID, "").WillReturnError(gorm.ErrRecordNotFound)
 	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(emsgs.KeyNotFoundError).Once()
+	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(emsgs.KeyAccessDeniedError).Once()
 
 	key, ierr := k.rks.Find(mockKeyData.ID)
 	k.Error(ierr)
-	k.True(errors.Is(emsgs.KeyNotFoundError, ierr))
+	k.True(errors.Is(emsgs.KeyAccessDeniedError, ierr))
 	k.Nil(key)
 
-	k.mCtx.MockDB.Mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `keys` WHERE id = ? ORDER BY `keys`.`id` LIMIT 1")).
-		WithArgs(mockKeyData.ID).WillReturnError(gorm.ErrUnsupportedDriver)
-	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(errmsgs.DBError).Once()
+	k.mCtx.MockDB.Mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `keys` WHERE deleted_at IS NULL AND (id = ? OR (alias = ? AND tenant_id = ?)) ORDER BY `keys`.`id` LIMIT 1")).
+		WithArgs(mockKeyData.ID, mockKeyData.ID, "").WillReturnError(gorm.ErrUnsupportedDriver)
+	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(errmsgs.DBError).Twice()
 
 	key, ierr = k.rks.Find(mockKeyData.ID)
 	k.Error(ierr)
@@ -148,20 +159,44 @@ func (k *KeyServiceTestSuite) TestKeyService_Store_ExpectSuccess() {
 	k.NotEmpty(expectPrivateKey)
 
 	k.Equal(mockKeyData.PublicKey, key.PublicKey)
-	k.NotEqual(mockKeyData.PrivateKey, key.PrivateKeyEncrypted)
+	k.NotEqual(string(mockKeyData.PrivateKey), key.PrivateKeyEncrypted)
 	k.Equal(expectPrivateKey, mockKeyData.PrivateKey)
 }
 
+func (k *KeyServiceTestSuite) TestKeyService_Store_ExpectKeyPairMismatch() {
+	mockKeyData := NewMockKeyData()
+	otherKeyData := NewMockKeyData()
+
+	key, ierr := k.rks.Store(&KeyStorePayload{
+		PublicKey:  otherKeyData.PublicKey,
+		PrivateKey: mockKeyData.PrivateKey,
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyPairMismatchError.GetCode(), ierr.GetCode())
+	k.Nil(key)
+
+	key, ierr = k.rks.Store(&KeyStorePayload{
+		PrivateKey: mockKeyData.PrivateKey,
+		KeyType:    string(consts.KeyTypeRSA),
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyTypeMismatchError.GetCode(), ierr.GetCode())
+	k.Nil(key)
+}
+
 func (k *KeyServiceTestSuite) TestKeyService_Store_ExpectError() {
 	mockKeyData := NewMockKeyData()
 
-	k.rhs = NewHSMService(k.mCtx)
-	k.rks = NewKeyService(k.mCtx, k.rhs)
+	k.mhs.On("Encrypt", mock.Anything).Return("encrypted_private_key", nil)
+	k.mhs.On("KEKID").Return("kek_id", nil)
+	k.rks = NewKeyService(k.mCtx, k.mhs, k.mas)
 
-	k.mCtx.MockDB.Mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `keys` (`id`,`public_key`,`private_key_encrypted`,`type`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?)")).
-		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+	k.mCtx.MockDB.Mock.ExpectBegin()
+	k.mCtx.MockDB.Mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `keys` (`id`,`public_key`,`private_key_encrypted`,`kek_id`,`type`,`version`,`tenant_id`,`owner_id`,`alias`,`policy`,`sign_rate_limit`,`cache_policy`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)")).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnError(gorm.ErrInvalidData)
-	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(errmsgs.DBError).Once()
+	k.mCtx.MockDB.Mock.ExpectRollback()
+	k.mCtx.On("NewError", mock.Anything, errmsgs.DBError, mock.Anything).Return(errmsgs.DBError).Twice()
 
 	// Expect DBError
 	key, ierr := k.rks.Store(&KeyStorePayload{
@@ -171,15 +206,14 @@ func (k *KeyServiceTestSuite) TestKeyService_Store_ExpectError() {
 	k.Error(ierr)
 	k.Equal(errmsgs.DBError.GetCode(), ierr.GetCode())
 	k.Nil(key)
+	k.NoError(k.mCtx.MockDB.Mock.ExpectationsWereMet())
 
-	// Expect DBError
-	k.mhs.On("Encrypt", mockKeyData.PrivateKey).Return("", errmsgs.InternalServerError)
-	k.rks = NewKeyService(k.mCtx, k.mhs)
+	// Expect InternalServerError, the key never reaches the database
+	k.mhs = NewMockHSMService()
+	k.mhs.On("Encrypt", mock.Anything).Return("", errmsgs.InternalServerError)
+	k.rks = NewKeyService(k.mCtx, k.mhs, k.mas)
 
-	k.mCtx.MockDB.Mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `keys` (`id`,`public_key`,`private_key_encrypted`,`type`,`created_at`,`updated_at`,`deleted_at`) VALUES (?,?,?,?,?,?,?)")).
-		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnError(gorm.ErrInvalidData)
-	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(errmsgs.InternalServerError).Once()
+	k.mCtx.On("NewError", mock.Anything, errmsgs.InternalServerError, mock.Anything).Return(errmsgs.InternalServerError).Twice()
 
 	key, ierr = k.rks.Store(&KeyStorePayload{
 		PublicKey:  mockKeyData.PublicKey,
@@ -188,10 +222,11 @@ func (k *KeyServiceTestSuite) TestKeyService_Store_ExpectError() {
 	k.Error(ierr)
 	k.Equal(errmsgs.InternalServerError.GetCode(), ierr.GetCode())
 	k.Nil(key)
+	k.NoError(k.mCtx.MockDB.Mock.ExpectationsWereMet())
 }
 
 func (k *KeyServiceTestSuite) TestKeyService_Generate_ExpectSuccess() {
-	key, ierr := k.rks.Generate()
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
 	k.NoError(ierr)
 	k.NotNil(key)
 
@@ -202,6 +237,8 @@ func (k *KeyServiceTestSuite) TestKeyService_Generate_ExpectSuccess() {
 	k.NotNil(key.CreatedAt)
 	k.NotNil(key.UpdatedAt)
 	k.Nil(key.DeletedAt)
+	k.Regexp("^did:key:zDn", key.DIDKey)
+	k.Regexp("^did:jwk:", key.DIDJWK)
 }
 
 func (k *KeyServiceTestSuite) TestKeyService_Sign_ExpectSuccess() {
@@ -216,6 +253,7 @@ func (k *KeyServiceTestSuite) TestKeyService_Sign_ExpectSuccess() {
 		PublicKey:           mockKeyData.PublicKey,
 		PrivateKeyEncrypted: encryptedPrivateKey,
 		Type:                string(consts.KeyTypeECDSA),
+		Version:             1,
 		CreatedAt:           utils.GetCurrentDateTime(),
 		UpdatedAt:           utils.GetCurrentDateTime(),
 	}).Error
@@ -223,13 +261,14 @@ func (k *KeyServiceTestSuite) TestKeyService_Sign_ExpectSuccess() {
 
 	signature, ierr := k.rks.Sign(mockKeyData.ID, mockSignData.Message)
 	k.NoError(ierr)
-	k.NotEmpty(signature)
+	k.NotNil(signature)
 
-	valid, err := utils.VerifySignature(mockKeyData.PublicKey, signature, mockSignData.Message)
+	valid, err := utils.VerifySignature(mockKeyData.PublicKey, signature.Signature, mockSignData.Message)
 
 	k.NoError(err)
 	k.True(valid)
-	k.NotEmpty(signature)
+	k.NotEmpty(signature.Signature)
+	k.Equal(mockKeyData.ID, signature.KeyID)
 
 	err = k.rCtx.DB().Delete(models.Key{}, "id = ?", mockKeyData.ID).Error
 	k.NoError(err)
@@ -240,22 +279,23 @@ func (k *KeyServiceTestSuite) TestKeyService_Sign_ExpectError() {
 	mockSignData := NewMockSignData()
 
 	k.rhs = NewHSMService(k.mCtx)
-	k.rks = NewKeyService(k.mCtx, k.rhs)
+	k.rks = NewKeyService(k.mCtx, k.rhs, k.mas)
 
-	k.mCtx.MockDB.Mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `keys` WHERE id = ? ORDER BY `keys`.`id` LIMIT 1")).
-		WithArgs("invalid-ref-id").
+	k.mCtx.MockDB.Mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `keys` WHERE deleted_at IS NULL AND (id = ? OR (alias = ? AND tenant_id = ?)) ORDER BY `keys`.`id` LIMIT 1")).
+		WithArgs("invalid-ref-id", "invalid-ref-id", "").
 		WillReturnError(gorm.ErrRecordNotFound)
-	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(emsgs.KeyNotFoundError).Twice()
+	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(emsgs.KeyNotFoundError).Once()
+	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(emsgs.KeyAccessDeniedError).Twice()
 
-	// Expect KeyNotFoundError at Find function
+	// Expect KeyAccessDeniedError at Find function
 	signature, ierr := k.rks.Sign("invalid-ref-id", mockSignData.Message)
 	k.Error(ierr)
-	k.Equal(emsgs.KeyNotFoundError.GetCode(), ierr.GetCode())
-	k.Empty(signature)
+	k.Equal(emsgs.KeyAccessDeniedError.GetCode(), ierr.GetCode())
+	k.Nil(signature)
 
 	// Expect InternalServerError at Encrypt function
 	k.rhs = NewHSMService(k.rCtx)
-	k.rks = NewKeyService(k.rCtx, k.rhs)
+	k.rks = NewKeyService(k.rCtx, k.rhs, NewAuditService(k.rCtx))
 
 	encryptedPrivateKey, ierr := k.rhs.Encrypt(mockKeyData.PrivateKey)
 	k.NoError(ierr)
@@ -265,20 +305,274 @@ func (k *KeyServiceTestSuite) TestKeyService_Sign_ExpectError() {
 		PublicKey:           mockKeyData.PublicKey,
 		PrivateKeyEncrypted: encryptedPrivateKey,
 		Type:                string(consts.KeyTypeECDSA),
+		Version:             1,
 		CreatedAt:           utils.GetCurrentDateTime(),
 		UpdatedAt:           utils.GetCurrentDateTime(),
 	}).Error
 	k.NoError(err)
 
-	k.mhs.On("Decrypt", encryptedPrivateKey).Return("", errmsgs.InternalServerError)
-	k.rks = NewKeyService(k.mCtx, k.mhs)
+	k.mhs.On("Decrypt", encryptedPrivateKey).Return(nil, errmsgs.InternalServerError)
+	k.rks = NewKeyService(k.mCtx, k.mhs, k.mas)
 
 	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(errmsgs.InternalServerError)
 	signature, ierr = k.rks.Sign(mockKeyData.ID, mockSignData.Message)
 	k.Error(ierr)
 	k.Equal(errmsgs.InternalServerError.GetCode(), ierr.GetCode())
-	k.Empty(signature)
+	k.Nil(signature)
+
+	err = k.rCtx.DB().Delete(models.Key{}, "id = ?", mockKeyData.ID).Error
+	k.NoError(err)
+}
+
+func (k *KeyServiceTestSuite) TestKeyService_Sign_ExpectPolicyDenied() {
+	mockKeyData := NewMockKeyData()
+	mockSignData := NewMockSignData()
+
+	encryptedPrivateKey, ierr := k.rhs.Encrypt(mockKeyData.PrivateKey)
+	k.NoError(ierr)
+
+	err := k.rCtx.DB().Create(models.Key{
+		ID:                  mockKeyData.ID,
+		PublicKey:           mockKeyData.PublicKey,
+		PrivateKeyEncrypted: encryptedPrivateKey,
+		Type:                string(consts.KeyTypeECDSA),
+		Version:             1,
+		Policy: &models.KeyPolicy{
+			Usages:         []consts.KeyUsage{consts.KeyUsageSign},
+			Algorithms:     []consts.SigningAlgorithm{consts.SigningAlgorithmES256},
+			MessageFormats: []consts.MessageFormat{consts.MessageFormatBase64},
+		},
+		CreatedAt: utils.GetCurrentDateTime(),
+		UpdatedAt: utils.GetCurrentDateTime(),
+	}).Error
+	k.NoError(err)
+
+	// The DID operation message is base64 so it is allowed
+	signature, ierr := k.rks.Sign(mockKeyData.ID, mockSignData.Message)
+	k.NoError(ierr)
+	k.NotNil(signature)
+
+	// Arbitrary data is refused before the private key is decrypted
+	k.rks = NewKeyService(k.rCtx, k.mhs, k.mas)
+	signature, ierr = k.rks.Sign(mockKeyData.ID, "arbitrary data")
+	k.Error(ierr)
+	k.Equal(emsgs.KeyPolicyMessageFormatDeniedError.GetCode(), ierr.GetCode())
+	k.Nil(signature)
+	k.mhs.AssertNotCalled(k.T(), "Decrypt", mock.Anything)
+
+	policy := &models.KeyPolicy{Usages: []consts.KeyUsage{consts.KeyUsageVerify}}
+	err = k.rCtx.DB().Model(&models.Key{}).Where("id = ?", mockKeyData.ID).Update("policy", policy).Error
+	k.NoError(err)
+
+	signature, ierr = k.rks.Sign(mockKeyData.ID, mockSignData.Message)
+	k.Error(ierr)
+	k.Equal(emsgs.KeyPolicyUsageDeniedError.GetCode(), ierr.GetCode())
+	k.Nil(signature)
 
 	err = k.rCtx.DB().Delete(models.Key{}, "id = ?", mockKeyData.ID).Error
 	k.NoError(err)
 }
+
+func (k *KeyServiceTestSuite) TestKeyService_SignBatch_ExpectPerItemResults() {
+	mockKeyData := NewMockKeyData()
+	mockSignData := NewMockSignData()
+
+	encryptedPrivateKey, ierr := k.rhs.Encrypt(mockKeyData.PrivateKey)
+	k.NoError(ierr)
+
+	err := k.rCtx.DB().Create(models.Key{
+		ID:                  mockKeyData.ID,
+		PublicKey:           mockKeyData.PublicKey,
+		PrivateKeyEncrypted: encryptedPrivateKey,
+		Type:                string(consts.KeyTypeECDSA),
+		Version:             1,
+		CreatedAt:           utils.GetCurrentDateTime(),
+		UpdatedAt:           utils.GetCurrentDateTime(),
+	}).Error
+	k.NoError(err)
+
+	results, ierr := k.rks.SignBatch([]KeySignBatchItem{
+		{ID: mockKeyData.ID, Message: mockSignData.Message},
+		{ID: "invalid-ref-id", Message: mockSignData.Message},
+		{ID: mockKeyData.ID, Message: "another message"},
+	})
+	k.NoError(ierr)
+	k.Len(results, 3)
+
+	valid, err := utils.VerifySignature(mockKeyData.PublicKey, results[0].Signature, mockSignData.Message)
+	k.NoError(err)
+	k.True(valid)
+	k.Equal(mockKeyData.ID, results[0].KeyID)
+	k.Nil(results[0].Error)
+
+	k.Empty(results[1].Signature)
+	k.NotNil(results[1].Error)
+
+	valid, err = utils.VerifySignature(mockKeyData.PublicKey, results[2].Signature, "another message")
+	k.NoError(err)
+	k.True(valid)
+
+	err = k.rCtx.DB().Delete(models.Key{}, "id = ?", mockKeyData.ID).Error
+	k.NoError(err)
+}
+
+func (k *KeyServiceTestSuite) TestKeyService_SignBatch_ExpectTokenPerItem() {
+	mockSignData := NewMockSignData()
+	ctx := core.NewContext(&core.ContextOptions{
+		DB:  k.rCtx.DB(),
+		ENV: k.rCtx.ENV(),
+		DATA: map[string]interface{}{
+			consts.ContextKeyRateLimiter: helpers.NewMemoryRateLimiter(),
+		},
+	})
+	rks := NewKeyService(ctx, NewHSMService(ctx), NewAuditService(ctx))
+
+	key, ierr := rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+	err := k.rCtx.DB().Model(&models.Key{}).Where("id = ?", key.ID).
+		Update("sign_rate_limit", &models.RateLimit{Rate: 0.001, Burst: 2}).Error
+	k.Require().NoError(err)
+
+	// Expect error on the items of the group past the burst of the key
+	results, ierr := rks.SignBatch([]KeySignBatchItem{
+		{ID: key.ID, Message: mockSignData.Message},
+		{ID: key.ID, Message: mockSignData.Message},
+		{ID: key.ID, Message: mockSignData.Message},
+	})
+	k.Require().NoError(ierr)
+	k.Nil(results[0].Error)
+	k.Nil(results[1].Error)
+	k.NotEmpty(results[1].Signature)
+	k.Empty(results[2].Signature)
+	k.NotNil(results[2].Error)
+	k.Equal(emsgs.KeyRateLimitExceededError.JSON(), results[2].Error)
+}
+
+func (k *KeyServiceTestSuite) TestKeyService_Sign_ExpectNoClientTokenOnRefusal() {
+	mockSignData := NewMockSignData()
+	principal := &models.Principal{
+		ID:        utils.GetUUID(),
+		TenantID:  utils.GetUUID(),
+		RateLimit: &models.RateLimit{Rate: 0.001, Burst: 1},
+	}
+	e := core.NewHTTPServer(&core.HTTPContextOptions{ContextOptions: &core.ContextOptions{
+		DB:  k.rCtx.DB(),
+		ENV: k.rCtx.ENV(),
+		DATA: map[string]interface{}{
+			consts.ContextKeyRateLimiter: helpers.NewMemoryRateLimiter(),
+		},
+	}})
+	e.GET("/", core.WithHTTPContext(func(c core.IHTTPContext) error {
+		c.Set(consts.ContextKeyPrincipal, principal)
+		rks := NewKeyService(c, NewHSMService(c), NewAuditService(c))
+
+		// Expect the refusals to leave the only token of the client
+		_, ierr := rks.Sign(utils.GetUUID(), mockSignData.Message)
+		k.Equal(emsgs.KeyAccessDeniedError.GetCode(), ierr.GetCode())
+		_, ierr = rks.Sign(utils.GetUUID(), mockSignData.Message)
+		k.Equal(emsgs.KeyAccessDeniedError.GetCode(), ierr.GetCode())
+
+		key, ierr := rks.Generate(&KeyGeneratePayload{})
+		k.Require().NoError(ierr)
+		signature, ierr := rks.Sign(key.ID, mockSignData.Message)
+		k.NoError(ierr)
+		k.NotEmpty(signature.Signature)
+
+		// Expect error once the token is spent
+		_, ierr = rks.Sign(key.ID, mockSignData.Message)
+		k.Equal(emsgs.ClientRateLimitExceededError.GetCode(), ierr.GetCode())
+
+		return c.NoContent(http.StatusNoContent)
+	}))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func (k *KeyServiceTestSuite) TestKeyService_Rotate_ExpectSuccess() {
+	mockSignData := NewMockSignData()
+
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.NoError(ierr)
+
+	rotated, ierr := k.rks.Rotate(key.ID)
+	k.NoError(ierr)
+	k.Equal(key.ID, rotated.ID)
+	k.Equal(key.Version+1, rotated.Version)
+	k.NotEqual(key.PublicKey, rotated.PublicKey)
+
+	signature, ierr := k.rks.Sign(key.ID, mockSignData.Message)
+	k.NoError(ierr)
+	k.Equal(rotated.Version, signature.Version)
+
+	valid, err := utils.VerifySignature(rotated.PublicKey, signature.Signature, mockSignData.Message)
+	k.NoError(err)
+	k.True(valid)
+
+	previous, ierr := k.rks.Find(fmt.Sprintf("%s@%d", key.ID, key.Version))
+	k.NoError(ierr)
+	k.Equal(key.PublicKey, previous.PublicKey)
+	k.Equal(key.Version, previous.Version)
+
+	// Expect error on a signature with an older version, which is kept for verification only
+	_, ierr = k.rks.Sign(fmt.Sprintf("%s@%d", key.ID, key.Version), mockSignData.Message)
+	k.Error(ierr)
+	k.Equal(emsgs.KeyVersionNotSignableError.GetCode(), ierr.GetCode())
+
+	signature, ierr = k.rks.Sign(fmt.Sprintf("%s@%d", key.ID, rotated.Version), mockSignData.Message)
+	k.NoError(ierr)
+	k.Equal(rotated.Version, signature.Version)
+
+	versions, ierr := k.rks.Versions(key.ID)
+	k.NoError(ierr)
+	k.Len(versions, 2)
+
+	// Expect the same error on a missing version as on a missing key
+	_, ierr = k.rks.Find(fmt.Sprintf("%s@%d", key.ID, rotated.Version+1))
+	k.Error(ierr)
+	k.Equal(emsgs.KeyAccessDeniedError.GetCode(), ierr.GetCode())
+
+	_, ierr = k.rks.Find(fmt.Sprintf("%s@%d", utils.GetUUID(), rotated.Version+1))
+	k.Error(ierr)
+	k.Equal(emsgs.KeyAccessDeniedError.GetCode(), ierr.GetCode())
+}
+
+func (k *KeyServiceTestSuite) TestKeyService_Update_ExpectSuccess() {
+	alias := "did:example:" + utils.GetUUID() + "#key-1"
+
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{
+		Alias: alias,
+		Tags:  map[string]string{"owner": "issuer"},
+	})
+	k.NoError(ierr)
+	k.Equal(alias, *key.Alias)
+	k.Len(key.Tags, 1)
+
+	found, ierr := k.rks.Find(alias)
+	k.NoError(ierr)
+	k.Equal(key.ID, found.ID)
+
+	_, ierr = k.rks.Generate(&KeyGeneratePayload{Alias: alias})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyAliasAlreadyExistsError.GetCode(), ierr.GetCode())
+
+	emptyAlias := ""
+	updated, ierr := k.rks.Update(key.ID, &KeyUpdatePayload{
+		Alias: &emptyAlias,
+		Tags:  map[string]string{"owner": "holder", "env": "test"},
+	})
+	k.NoError(ierr)
+	k.Nil(updated.Alias)
+	k.Len(updated.Tags, 2)
+}
+
+func (k *KeyServiceTestSuite) TestKeyService_Delete_ExpectSuccess() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.NoError(ierr)
+
+	ierr = k.rks.Delete(key.ID)
+	k.NoError(ierr)
+
+	found, ierr := k.rks.Find(key.ID)
+	k.Error(ierr)
+	k.Equal(emsgs.KeyAccessDeniedError.GetCode(), ierr.GetCode())
+	k.Nil(found)
+}
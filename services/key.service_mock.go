@@ -19,17 +19,127 @@ func (m *MockKeyService) Find(id string) (*models.Key, core.IError) {
 	return args.Get(0).(*models.Key), core.MockIError(args, 1)
 }
 
+func (m *MockKeyService) Versions(id string) ([]models.KeyVersion, core.IError) {
+	args := m.Called(id)
+	return args.Get(0).([]models.KeyVersion), core.MockIError(args, 1)
+}
+
 func (m *MockKeyService) Store(payload *KeyStorePayload) (*models.Key, core.IError) {
 	args := m.Called(payload)
 	return args.Get(0).(*models.Key), core.MockIError(args, 1)
 }
 
-func (m *MockKeyService) Generate() (*models.Key, core.IError) {
-	args := m.Called()
+func (m *MockKeyService) Import(payload *KeyStorePayload) (*models.Key, core.IError) {
+	args := m.Called(payload)
+	return args.Get(0).(*models.Key), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) Export(id string, payload *KeyExportPayload) (*models.KeyExportBundle, core.IError) {
+	args := m.Called(id, payload)
+	return args.Get(0).(*models.KeyExportBundle), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) Pagination(payload *KeyPaginationPayload, pageOptions *core.PageOptions) ([]models.Key, *core.PageResponse, core.IError) {
+	args := m.Called(payload, pageOptions)
+	return args.Get(0).([]models.Key), args.Get(1).(*core.PageResponse), core.MockIError(args, 2)
+}
+
+func (m *MockKeyService) Update(id string, payload *KeyUpdatePayload) (*models.Key, core.IError) {
+	args := m.Called(id, payload)
+	return args.Get(0).(*models.Key), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) Generate(payload *KeyGeneratePayload) (*models.Key, core.IError) {
+	args := m.Called(payload)
 	return args.Get(0).(*models.Key), core.MockIError(args, 1)
 }
 
-func (m *MockKeyService) Sign(payload *KeySignPayload) (string, string, core.IError) {
+func (m *MockKeyService) GenerateRSA(payload *KeyGeneratePayload) (*models.Key, core.IError) {
 	args := m.Called(payload)
-	return args.String(0), args.String(1), core.MockIError(args, 2)
+	return args.Get(0).(*models.Key), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) GenerateBBS(payload *KeyGeneratePayload) (*models.Key, core.IError) {
+	args := m.Called(payload)
+	return args.Get(0).(*models.Key), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) Rotate(id string) (*models.Key, core.IError) {
+	args := m.Called(id)
+	return args.Get(0).(*models.Key), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) Sign(id string, message string) (*KeySignature, core.IError) {
+	args := m.Called(id, message)
+	return args.Get(0).(*KeySignature), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) SignBatch(items []KeySignBatchItem) ([]KeySignBatchResult, core.IError) {
+	args := m.Called(items)
+	return args.Get(0).([]KeySignBatchResult), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) Delete(id string) core.IError {
+	args := m.Called(id)
+	return core.MockIError(args, 0)
+}
+
+func (m *MockKeyService) ExecuteApproved(request *models.KeyOperationRequest) (*KeyOperationResult, core.IError) {
+	args := m.Called(request)
+	return args.Get(0).(*KeyOperationResult), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) Delegations(id string) ([]models.KeyDelegation, core.IError) {
+	args := m.Called(id)
+	return args.Get(0).([]models.KeyDelegation), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) Delegate(id string, principalID string) (*models.KeyDelegation, core.IError) {
+	args := m.Called(id, principalID)
+	return args.Get(0).(*models.KeyDelegation), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) Undelegate(id string, principalID string) core.IError {
+	args := m.Called(id, principalID)
+	return core.MockIError(args, 0)
+}
+
+func (m *MockKeyService) Escrows(id string) ([]models.KeyEscrow, core.IError) {
+	args := m.Called(id)
+	return args.Get(0).([]models.KeyEscrow), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) Recover(id string, payload *KeyRecoverPayload) (*models.Key, core.IError) {
+	args := m.Called(id, payload)
+	return args.Get(0).(*models.Key), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) VerificationMethod(id string, payload *KeyVerificationMethodPayload) (*models.DIDVerificationMethod, core.IError) {
+	args := m.Called(id, payload)
+	return args.Get(0).(*models.DIDVerificationMethod), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) SignDIDOperation(id string, payload *KeyDIDOperationPayload) (*models.DIDOperationEnvelope, core.IError) {
+	args := m.Called(id, payload)
+	return args.Get(0).(*models.DIDOperationEnvelope), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) SignPresentation(id string, payload *KeyPresentationPayload) (*models.KeyPresentation, core.IError) {
+	args := m.Called(id, payload)
+	return args.Get(0).(*models.KeyPresentation), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) IssueSDJWT(id string, payload *KeySDJWTPayload) (*models.SDJWT, core.IError) {
+	args := m.Called(id, payload)
+	return args.Get(0).(*models.SDJWT), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) SignBBS(id string, payload *KeyBBSPayload) (*models.BBSSignature, core.IError) {
+	args := m.Called(id, payload)
+	return args.Get(0).(*models.BBSSignature), core.MockIError(args, 1)
+}
+
+func (m *MockKeyService) SignBBS2023(id string, payload *KeyBBS2023Payload) (*models.BBS2023Proof, core.IError) {
+	args := m.Called(id, payload)
+	return args.Get(0).(*models.BBS2023Proof), core.MockIError(args, 1)
 }
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type KeyOperationRequestCreatePayload struct {
+	KeyID     string
+	Operation consts.KeyApprovalOperation
+	// Policy is the new policy of an update_policy request
+	Policy *models.KeyPolicy
+	// WrappingPublicKey and Format are the arguments of an export request
+	WrappingPublicKey string
+	Format            consts.KeyExportFormat
+	TTLSeconds        int
+}
+
+type KeyOperationRequestPaginationPayload struct {
+	KeyID  string
+	Status string
+}
+
+// KeyOperationResult is the outcome of an executed request, Bundle is only set for an export
+type KeyOperationResult struct {
+	Request *models.KeyOperationRequest `json:"request"`
+	Key     *models.Key                 `json:"key,omitempty"`
+	Bundle  *models.KeyExportBundle     `json:"bundle,omitempty"`
+}
+
+type IKeyApprovalService interface {
+	Create(payload *KeyOperationRequestCreatePayload) (*models.KeyOperationRequest, core.IError)
+	Find(id string) (*models.KeyOperationRequest, core.IError)
+	Pagination(payload *KeyOperationRequestPaginationPayload, pageOptions *core.PageOptions) ([]models.KeyOperationRequest, *core.PageResponse, core.IError)
+	Approve(id string, comment string) (*models.KeyOperationRequest, core.IError)
+	Reject(id string, comment string) (*models.KeyOperationRequest, core.IError)
+	Execute(id string) (*KeyOperationResult, core.IError)
+}
+
+type keyApprovalService struct {
+	ctx          core.IContext
+	keyService   IKeyService
+	auditService IAuditService
+}
+
+func NewKeyApprovalService(ctx core.IContext, keyService IKeyService, auditService IAuditService) IKeyApprovalService {
+	return &keyApprovalService{
+		ctx:          ctx,
+		keyService:   keyService,
+		auditService: auditService,
+	}
+}
+
+// Create records a pending operation on a key whose policy needs approvals, the operation itself is authorized
+// again by the key service when the requester executes it
+func (s keyApprovalService) Create(payload *KeyOperationRequestCreatePayload) (*models.KeyOperationRequest, core.IError) {
+	request, ierr := s.create(payload)
+	ierr = s.audit(consts.AuditOperationRequestApproval, payload.KeyID, request, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return request, nil
+}
+
+func (s keyApprovalService) create(payload *KeyOperationRequestCreatePayload) (*models.KeyOperationRequest, core.IError) {
+	key, ierr := s.keyService.Find(payload.KeyID)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	if key.Policy == nil || !key.Policy.RequiresApproval() {
+		return nil, s.ctx.NewError(emsgs.KeyApprovalNotRequiredError, emsgs.KeyApprovalNotRequiredError)
+	}
+
+	requestPayload := &models.KeyOperationRequestPayload{}
+	switch payload.Operation {
+	case consts.KeyApprovalOperationUpdatePolicy:
+		requestPayload.Policy = payload.Policy
+	case consts.KeyApprovalOperationExport:
+		requestPayload.WrappingPublicKey = payload.WrappingPublicKey
+		requestPayload.Format = string(payload.Format)
+	}
+
+	request := models.NewKeyOperationRequest(key, payload.Operation, requestPayload, helpers.GetPrincipal(s.ctx),
+		time.Duration(payload.TTLSeconds)*time.Second)
+	err := s.ctx.DB().Create(request).Error
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return request, nil
+}
+
+// Find returns requests of the tenant of the caller and requests on keys whose policy lists the caller
+// as an approver, admins see every request
+func (s keyApprovalService) Find(id string) (*models.KeyOperationRequest, core.IError) {
+	request := &models.KeyOperationRequest{}
+	db := s.visible(s.ctx.DB().Preload("Approvals", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at asc")
+	}))
+	err := db.First(request, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, emsgs.KeyOperationRequestNotFoundError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return request, nil
+}
+
+func (s keyApprovalService) Pagination(payload *KeyOperationRequestPaginationPayload, pageOptions *core.PageOptions) ([]models.KeyOperationRequest, *core.PageResponse, core.IError) {
+	requests := make([]models.KeyOperationRequest, 0)
+
+	db := s.visible(s.ctx.DB().Preload("Approvals"))
+	if payload.KeyID != "" {
+		db = db.Where("key_id = ?", payload.KeyID)
+	}
+	if payload.Status != "" {
+		db = db.Where("status = ?", payload.Status)
+	}
+
+	pageResponse, err := core.Paginate(db, &requests, pageOptions)
+	if err != nil {
+		return nil, nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return requests, pageResponse, nil
+}
+
+// visible limits db to the requests the caller may see, the approvers of a policy may be of any tenant
+// so they also see the requests on the keys that list them
+func (s keyApprovalService) visible(db *gorm.DB) *gorm.DB {
+	principal := helpers.GetPrincipal(s.ctx)
+	if principal.HasScope(consts.ScopeKeysAdmin) {
+		return db
+	}
+	if principal.ID == "" {
+		return db.Where("tenant_id = ?", principal.TenantID)
+	}
+
+	approverKeys := s.ctx.DB().Model(&models.Key{}).Select("id").
+		Where("deleted_at IS NULL AND JSON_CONTAINS(policy, JSON_QUOTE(?), '$.approvers')", principal.ID)
+
+	return db.Where("(tenant_id = ? OR key_id IN (?))", principal.TenantID, approverKeys)
+}
+
+// Approve adds the approval of the caller, the request is approved once it has ApprovalsRequired approvals
+func (s keyApprovalService) Approve(id string, comment string) (*models.KeyOperationRequest, core.IError) {
+	keyID := s.requestKeyID(id)
+	request, ierr := s.decide(id, consts.KeyApprovalDecisionApprove, comment)
+	ierr = s.audit(consts.AuditOperationApprove, keyID, request, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return request, nil
+}
+
+// Reject adds the rejection of the caller, the request is rejected once the remaining approvers cannot reach
+// the quorum, or right away when any admin of the tenant may approve
+func (s keyApprovalService) Reject(id string, comment string) (*models.KeyOperationRequest, core.IError) {
+	keyID := s.requestKeyID(id)
+	request, ierr := s.decide(id, consts.KeyApprovalDecisionReject, comment)
+	ierr = s.audit(consts.AuditOperationReject, keyID, request, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return request, nil
+}
+
+// requestKeyID finds the key of a request before the decision so that refused decisions are also recorded
+// in the audit log of the key, it is empty when the request does not exist
+func (s keyApprovalService) requestKeyID(id string) string {
+	request := &models.KeyOperationRequest{}
+	err := s.ctx.DB().Select("key_id").First(request, "id = ?", id).Error
+	if err != nil {
+		return ""
+	}
+
+	return request.KeyID
+}
+
+func (s keyApprovalService) decide(id string, decision consts.KeyApprovalDecision, comment string) (*models.KeyOperationRequest, core.IError) {
+	request, ierr := s.Find(id)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	if request.Status != string(consts.KeyApprovalStatusPending) {
+		return nil, s.ctx.NewError(emsgs.KeyOperationRequestNotPendingError, emsgs.KeyOperationRequestNotPendingError)
+	}
+	if request.IsExpired() {
+		return nil, s.ctx.NewError(emsgs.KeyOperationRequestExpiredError, emsgs.KeyOperationRequestExpiredError)
+	}
+
+	key, ierr := s.findKey(request.KeyID)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	principal := helpers.GetPrincipal(s.ctx)
+	if !s.isApprover(key, request, principal) {
+		return nil, s.ctx.NewError(emsgs.KeyApproverDeniedError, emsgs.KeyApproverDeniedError)
+	}
+
+	approval := models.NewKeyOperationApproval(request.ID, principal, decision, comment)
+	var decideErr core.IError
+	err := s.ctx.DB().Transaction(func(tx *gorm.DB) error {
+		// the lock on the request serializes the decisions, each one counts the decisions committed before it
+		locked := &models.KeyOperationRequest{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("status").First(locked, "id = ?", request.ID).Error
+		if err != nil {
+			return err
+		}
+		if locked.Status != string(consts.KeyApprovalStatusPending) {
+			decideErr = emsgs.KeyOperationRequestNotPendingError
+			return decideErr
+		}
+
+		var decisions int64
+		err = tx.Model(&models.KeyOperationApproval{}).
+			Where("request_id = ? AND approver_id = ?", request.ID, principal.ID).
+			Count(&decisions).Error
+		if err != nil {
+			return err
+		}
+		if decisions > 0 {
+			decideErr = emsgs.KeyApprovalDuplicateError
+			return decideErr
+		}
+
+		if err := tx.Create(approval).Error; err != nil {
+			return err
+		}
+
+		var approvals, rejections int64
+		err = tx.Model(&models.KeyOperationApproval{}).
+			Where("request_id = ? AND decision = ?", request.ID, consts.KeyApprovalDecisionApprove).
+			Count(&approvals).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.KeyOperationApproval{}).
+			Where("request_id = ? AND decision = ?", request.ID, consts.KeyApprovalDecisionReject).
+			Count(&rejections).Error
+		if err != nil {
+			return err
+		}
+
+		status := consts.KeyApprovalStatusPending
+		if int(approvals) >= request.ApprovalsRequired {
+			status = consts.KeyApprovalStatusApproved
+		} else if rejections > 0 && (len(key.Policy.Approvers) == 0 ||
+			len(key.Policy.Approvers)-int(rejections) < request.ApprovalsRequired) {
+			status = consts.KeyApprovalStatusRejected
+		}
+		if status == consts.KeyApprovalStatusPending {
+			return nil
+		}
+
+		return tx.Model(&models.KeyOperationRequest{}).
+			Where("id = ?", request.ID).
+			Updates(map[string]interface{}{
+				"status":     status,
+				"updated_at": utils.GetCurrentDateTime(),
+			}).Error
+	})
+	if decideErr != nil {
+		return nil, s.ctx.NewError(decideErr, decideErr)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return s.Find(request.ID)
+}
+
+// isApprover excludes the requester so a quorum always counts other principals, the approvers of the policy
+// may be of any tenant while the default approvers are the admins of the tenant of the key
+func (s keyApprovalService) isApprover(key *models.Key, request *models.KeyOperationRequest, principal *models.Principal) bool {
+	if principal.ID == "" || principal.ID == request.RequestedBy {
+		return false
+	}
+	if len(key.Policy.Approvers) > 0 {
+		return key.Policy.IsApprover(principal.ID)
+	}
+
+	return principal.TenantID == key.TenantID && principal.HasScope(consts.ScopeKeysAdmin)
+}
+
+// findKey reads the current policy of the key without the access checks of the key service,
+// approvers are usually neither owners nor delegates of the key
+func (s keyApprovalService) findKey(id string) (*models.Key, core.IError) {
+	key := &models.Key{}
+	err := s.ctx.DB().Where("deleted_at IS NULL").First(key, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, emsgs.KeyNotFoundError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+	if key.Policy == nil {
+		key.Policy = &models.KeyPolicy{}
+	}
+
+	return key, nil
+}
+
+// Execute runs an approved request once, as the requester, the key service marks the request executed together
+// with the operation
+func (s keyApprovalService) Execute(id string) (*KeyOperationResult, core.IError) {
+	request, ierr := s.Find(id)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	if request.RequestedBy != helpers.GetPrincipal(s.ctx).ID {
+		return nil, s.ctx.NewError(emsgs.KeyAccessDeniedError, emsgs.KeyAccessDeniedError)
+	}
+	if request.IsExpired() {
+		return nil, s.ctx.NewError(emsgs.KeyOperationRequestExpiredError, emsgs.KeyOperationRequestExpiredError)
+	}
+	if request.Status != string(consts.KeyApprovalStatusApproved) {
+		return nil, s.ctx.NewError(emsgs.KeyOperationRequestNotApprovedError, emsgs.KeyOperationRequestNotApprovedError)
+	}
+
+	result, ierr := s.keyService.ExecuteApproved(request)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	return result, nil
+}
+
+// audit records requests and decisions in the audit log of the key, like the operations they lead to
+func (s keyApprovalService) audit(operation consts.AuditOperation, keyID string, request *models.KeyOperationRequest, ierr core.IError) core.IError {
+	if request != nil {
+		keyID = request.KeyID
+	}
+	auditErr := s.auditService.Record(&AuditEventPayload{
+		Operation: operation,
+		KeyID:     keyID,
+		Error:     ierr,
+	})
+	if ierr != nil {
+		return s.ctx.NewError(ierr, ierr)
+	}
+	if auditErr != nil {
+		return s.ctx.NewError(auditErr, auditErr)
+	}
+
+	return nil
+}
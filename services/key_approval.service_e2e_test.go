@@ -0,0 +1,255 @@
+// +build e2e
+
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type KeyApprovalServiceTestSuite struct {
+	suite.Suite
+	rCtx core.IContext
+	rks  IKeyService
+	ras  IKeyApprovalService
+}
+
+func TestKeyApprovalServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyApprovalServiceTestSuite))
+}
+
+func (k *KeyApprovalServiceTestSuite) SetupSuite() {
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	k.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+}
+
+// as runs fn in a request of the principal, the services only take the principal from the HTTP context
+func (k *KeyApprovalServiceTestSuite) as(principal *models.Principal, fn func(ras IKeyApprovalService, rks IKeyService)) {
+	e := core.NewHTTPServer(&core.HTTPContextOptions{ContextOptions: &core.ContextOptions{
+		DB:  k.rCtx.DB(),
+		ENV: k.rCtx.ENV(),
+	}})
+	e.GET("/", core.WithHTTPContext(func(c core.IHTTPContext) error {
+		c.Set(consts.ContextKeyPrincipal, principal)
+		auditService := NewAuditService(c)
+		rks := NewKeyService(c, NewHSMService(c), auditService)
+		fn(NewKeyApprovalService(c, rks, auditService), rks)
+
+		return c.NoContent(http.StatusNoContent)
+	}))
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func (k *KeyApprovalServiceTestSuite) SetupTest() {
+	auditService := NewAuditService(k.rCtx)
+	k.rks = NewKeyService(k.rCtx, NewHSMService(k.rCtx), auditService)
+	k.ras = NewKeyApprovalService(k.rCtx, k.rks, auditService)
+}
+
+// generateQuorumKey sets the policy directly because only admins may ask for approvals
+func (k *KeyApprovalServiceTestSuite) generateQuorumKey() *models.Key {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+	err := k.rCtx.DB().Model(&models.Key{}).Where("id = ?", key.ID).
+		Update("policy", &models.KeyPolicy{ApprovalsRequired: 2}).Error
+	k.Require().NoError(err)
+
+	return key
+}
+
+func (k *KeyApprovalServiceTestSuite) TestKeyApprovalService_Delete_ExpectApprovalRequired() {
+	key := k.generateQuorumKey()
+
+	ierr := k.rks.Delete(key.ID)
+	k.Error(ierr)
+	k.Equal(emsgs.KeyApprovalRequiredError.GetCode(), ierr.GetCode())
+
+	_, ierr = k.rks.Update(key.ID, &KeyUpdatePayload{Policy: &models.KeyPolicy{}})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyApprovalRequiredError.GetCode(), ierr.GetCode())
+
+	// the key service cannot be tricked by an approved request of another key
+	_, ierr = k.rks.ExecuteApproved(&models.KeyOperationRequest{
+		ID:        "request-1",
+		KeyID:     "other",
+		Operation: string(consts.KeyApprovalOperationDelete),
+		Status:    string(consts.KeyApprovalStatusApproved),
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyApprovalRequiredError.GetCode(), ierr.GetCode())
+
+	// nor by a request of the key that is not approved in the database
+	_, ierr = k.rks.ExecuteApproved(&models.KeyOperationRequest{
+		ID:        "request-1",
+		KeyID:     key.ID,
+		Operation: string(consts.KeyApprovalOperationDelete),
+		Status:    string(consts.KeyApprovalStatusApproved),
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyOperationRequestNotApprovedError.GetCode(), ierr.GetCode())
+
+	_, ierr = k.rks.Find(key.ID)
+	k.NoError(ierr)
+}
+
+func (k *KeyApprovalServiceTestSuite) TestKeyApprovalService_Approve_ExpectRequesterDenied() {
+	key := k.generateQuorumKey()
+
+	request, ierr := k.ras.Create(&KeyOperationRequestCreatePayload{
+		KeyID:      key.ID,
+		Operation:  consts.KeyApprovalOperationDelete,
+		TTLSeconds: 60,
+	})
+	k.NoError(ierr)
+	k.Equal(string(consts.KeyApprovalStatusPending), request.Status)
+	k.Equal(2, request.ApprovalsRequired)
+
+	request, ierr = k.ras.Approve(request.ID, "")
+	k.Error(ierr)
+	k.Equal(emsgs.KeyApproverDeniedError.GetCode(), ierr.GetCode())
+	k.Nil(request)
+
+	// Expect the refused decision in the audit log of the key
+	event := &models.KeyAuditEvent{}
+	err := k.rCtx.DB().Where("key_id = ? AND operation = ?", key.ID, consts.AuditOperationApprove).
+		Order("sequence desc").First(event).Error
+	k.Require().NoError(err)
+	k.Equal(emsgs.KeyApproverDeniedError.GetCode(), event.ErrorCode)
+}
+
+func (k *KeyApprovalServiceTestSuite) TestKeyApprovalService_Execute_ExpectNotApproved() {
+	key := k.generateQuorumKey()
+
+	request, ierr := k.ras.Create(&KeyOperationRequestCreatePayload{
+		KeyID:      key.ID,
+		Operation:  consts.KeyApprovalOperationDelete,
+		TTLSeconds: 60,
+	})
+	k.Require().NoError(ierr)
+
+	result, ierr := k.ras.Execute(request.ID)
+	k.Error(ierr)
+	k.Equal(emsgs.KeyOperationRequestNotApprovedError.GetCode(), ierr.GetCode())
+	k.Nil(result)
+}
+
+func (k *KeyApprovalServiceTestSuite) TestKeyApprovalService_Create_ExpectApprovalNotRequired() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	request, ierr := k.ras.Create(&KeyOperationRequestCreatePayload{
+		KeyID:      key.ID,
+		Operation:  consts.KeyApprovalOperationDelete,
+		TTLSeconds: 60,
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyApprovalNotRequiredError.GetCode(), ierr.GetCode())
+	k.Nil(request)
+}
+
+func (k *KeyApprovalServiceTestSuite) TestKeyApprovalService_Execute_ExpectQuorum() {
+	tenantID := "tenant-" + utils.GetUUID()
+	requester := &models.Principal{ID: "requester-" + utils.GetUUID(), TenantID: tenantID, Scopes: []string{string(consts.ScopeKeysAdmin)}}
+	approvers := []*models.Principal{
+		{ID: "approver-" + utils.GetUUID(), TenantID: tenantID, Scopes: []string{string(consts.ScopeKeysAdmin)}},
+		{ID: "approver-" + utils.GetUUID(), TenantID: tenantID, Scopes: []string{string(consts.ScopeKeysAdmin)}},
+	}
+
+	var key *models.Key
+	var request *models.KeyOperationRequest
+	k.as(requester, func(ras IKeyApprovalService, rks IKeyService) {
+		var ierr core.IError
+		key, ierr = rks.Generate(&KeyGeneratePayload{Policy: &models.KeyPolicy{ApprovalsRequired: 2}})
+		k.Require().NoError(ierr)
+		request, ierr = ras.Create(&KeyOperationRequestCreatePayload{
+			KeyID:      key.ID,
+			Operation:  consts.KeyApprovalOperationDelete,
+			TTLSeconds: 60,
+		})
+		k.Require().NoError(ierr)
+	})
+
+	k.as(approvers[0], func(ras IKeyApprovalService, rks IKeyService) {
+		approved, ierr := ras.Approve(request.ID, "")
+		k.Require().NoError(ierr)
+		k.Equal(string(consts.KeyApprovalStatusPending), approved.Status)
+
+		// Expect error on a second decision of the same approver
+		_, ierr = ras.Approve(request.ID, "")
+		k.Error(ierr)
+		k.Equal(emsgs.KeyApprovalDuplicateError.GetCode(), ierr.GetCode())
+	})
+	k.as(approvers[1], func(ras IKeyApprovalService, rks IKeyService) {
+		approved, ierr := ras.Approve(request.ID, "")
+		k.Require().NoError(ierr)
+		k.Equal(string(consts.KeyApprovalStatusApproved), approved.Status)
+	})
+
+	k.as(requester, func(ras IKeyApprovalService, rks IKeyService) {
+		result, ierr := ras.Execute(request.ID)
+		k.Require().NoError(ierr)
+		k.Equal(string(consts.KeyApprovalStatusExecuted), result.Request.Status)
+
+		_, ierr = rks.Find(key.ID)
+		k.Error(ierr)
+
+		executed, ierr := ras.Find(request.ID)
+		k.Require().NoError(ierr)
+		k.Equal(string(consts.KeyApprovalStatusExecuted), executed.Status)
+		k.NotNil(executed.ExecutedAt)
+
+		// Expect error on a second execution
+		_, ierr = ras.Execute(request.ID)
+		k.Error(ierr)
+		k.Equal(emsgs.KeyOperationRequestNotApprovedError.GetCode(), ierr.GetCode())
+	})
+}
+
+func (k *KeyApprovalServiceTestSuite) TestKeyApprovalService_Approve_ExpectCrossTenantApprover() {
+	requester := &models.Principal{ID: "requester-" + utils.GetUUID(), TenantID: "tenant-" + utils.GetUUID(), Scopes: []string{string(consts.ScopeKeysAdmin)}}
+	otherTenantID := "tenant-" + utils.GetUUID()
+	approver := &models.Principal{ID: "approver-" + utils.GetUUID(), TenantID: otherTenantID}
+	stranger := &models.Principal{ID: "stranger-" + utils.GetUUID(), TenantID: otherTenantID}
+
+	var request *models.KeyOperationRequest
+	k.as(requester, func(ras IKeyApprovalService, rks IKeyService) {
+		key, ierr := rks.Generate(&KeyGeneratePayload{Policy: &models.KeyPolicy{ApprovalsRequired: 1, Approvers: []string{approver.ID}}})
+		k.Require().NoError(ierr)
+		request, ierr = ras.Create(&KeyOperationRequestCreatePayload{
+			KeyID:      key.ID,
+			Operation:  consts.KeyApprovalOperationDelete,
+			TTLSeconds: 60,
+		})
+		k.Require().NoError(ierr)
+	})
+
+	// Expect error for a principal of the other tenant who is not an approver of the key
+	k.as(stranger, func(ras IKeyApprovalService, rks IKeyService) {
+		found, ierr := ras.Find(request.ID)
+		k.Error(ierr)
+		k.Equal(emsgs.KeyOperationRequestNotFoundError.GetCode(), ierr.GetCode())
+		k.Nil(found)
+	})
+
+	k.as(approver, func(ras IKeyApprovalService, rks IKeyService) {
+		found, ierr := ras.Find(request.ID)
+		k.Require().NoError(ierr)
+		k.Equal(request.ID, found.ID)
+
+		approved, ierr := ras.Approve(request.ID, "")
+		k.Require().NoError(ierr)
+		k.Equal(string(consts.KeyApprovalStatusApproved), approved.Status)
+	})
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"github.com/stretchr/testify/mock"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type MockKeyApprovalService struct {
+	mock.Mock
+}
+
+func NewMockKeyApprovalService() *MockKeyApprovalService {
+	return &MockKeyApprovalService{}
+}
+
+func (m *MockKeyApprovalService) Create(payload *KeyOperationRequestCreatePayload) (*models.KeyOperationRequest, core.IError) {
+	args := m.Called(payload)
+	return args.Get(0).(*models.KeyOperationRequest), core.MockIError(args, 1)
+}
+
+func (m *MockKeyApprovalService) Find(id string) (*models.KeyOperationRequest, core.IError) {
+	args := m.Called(id)
+	return args.Get(0).(*models.KeyOperationRequest), core.MockIError(args, 1)
+}
+
+func (m *MockKeyApprovalService) Pagination(payload *KeyOperationRequestPaginationPayload, pageOptions *core.PageOptions) ([]models.KeyOperationRequest, *core.PageResponse, core.IError) {
+	args := m.Called(payload, pageOptions)
+	return args.Get(0).([]models.KeyOperationRequest), args.Get(1).(*core.PageResponse), core.MockIError(args, 2)
+}
+
+func (m *MockKeyApprovalService) Approve(id string, comment string) (*models.KeyOperationRequest, core.IError) {
+	args := m.Called(id, comment)
+	return args.Get(0).(*models.KeyOperationRequest), core.MockIError(args, 1)
+}
+
+func (m *MockKeyApprovalService) Reject(id string, comment string) (*models.KeyOperationRequest, core.IError) {
+	args := m.Called(id, comment)
+	return args.Get(0).(*models.KeyOperationRequest), core.MockIError(args, 1)
+}
+
+func (m *MockKeyApprovalService) Execute(id string) (*KeyOperationResult, core.IError) {
+	args := m.Called(id)
+	return args.Get(0).(*KeyOperationResult), core.MockIError(args, 1)
+}
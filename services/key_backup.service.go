@@ -0,0 +1,440 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gorm.io/gorm"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+const (
+	keyBackupFormatVersion  = 1
+	keyBackupMinHMACKeySize = 32
+	keyRestoreBatchSize     = 500
+
+	keyBackupFileKeys           = "keys.jsonl"
+	keyBackupFileKeyVersions    = "key_versions.jsonl"
+	keyBackupFileKeyTags        = "key_tags.jsonl"
+	keyBackupFileKeyDelegations = "key_delegations.jsonl"
+	keyBackupFileKeyEscrows     = "key_escrows.jsonl"
+	keyBackupFileAuditEvents    = "key_audit_events.jsonl"
+)
+
+type KeyRestorePayload struct {
+	// SampleSize is the number of private keys of each KEK that are decrypted before restoring
+	SampleSize int
+	DryRun     bool
+	// AllowMissingKEK restores keys encrypted with KEKs the HSM does not hold, they stay unusable until it does
+	AllowMissingKEK bool
+}
+
+type KeyRestoreReport struct {
+	Manifest      *models.KeyBackupManifest `json:"manifest"`
+	HSMKEKID      string                    `json:"hsm_kek_id"`
+	MissingKEKIDs []string                  `json:"missing_kek_ids"`
+	DecryptedKeys int                       `json:"decrypted_keys"`
+	Restored      bool                      `json:"restored"`
+}
+
+// keyBackupRecords are the rows of every table a backup holds
+type keyBackupRecords struct {
+	Keys        []models.Key
+	KeyVersions []models.KeyVersionBackupRecord
+	KeyTags     []models.KeyTagBackupRecord
+	Delegations []models.KeyDelegation
+	KeyEscrows  []models.KeyEscrowBackupRecord
+	AuditEvents []models.KeyAuditEvent
+}
+
+type IKeyBackupService interface {
+	Backup(w io.Writer) (*models.KeyBackupManifest, core.IError)
+	Restore(r io.Reader, payload *KeyRestorePayload) (*KeyRestoreReport, core.IError)
+}
+
+type keyBackupService struct {
+	ctx        core.IContext
+	hsmService IHSMService
+}
+
+func NewKeyBackupService(ctx core.IContext, hsmService IHSMService) IKeyBackupService {
+	return &keyBackupService{
+		ctx:        ctx,
+		hsmService: hsmService,
+	}
+}
+
+// Backup writes every key, version, tag, delegation, escrow and audit event read in one snapshot, deleted keys
+// included. The audit log carries the signature counts of the key policies and its hash chain over to the restore.
+// Private keys stay encrypted by the HSM so the manifest lists the KEKs a restore needs
+func (s keyBackupService) Backup(w io.Writer) (*models.KeyBackupManifest, core.IError) {
+	hmacKey, ierr := s.hmacKey()
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	hsmKEKID, ierr := s.hsmService.KEKID()
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	records := &keyBackupRecords{}
+	err := s.ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Order("id").Find(&records.Keys).Error; err != nil {
+			return err
+		}
+		if err := tx.Order("key_id, version").Find(&records.KeyVersions).Error; err != nil {
+			return err
+		}
+		if err := tx.Order("key_id, name").Find(&records.KeyTags).Error; err != nil {
+			return err
+		}
+		if err := tx.Order("key_id, principal_id").Find(&records.Delegations).Error; err != nil {
+			return err
+		}
+		if err := tx.Order("key_id, version").Find(&records.KeyEscrows).Error; err != nil {
+			return err
+		}
+
+		return tx.Order("sequence").Find(&records.AuditEvents).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	files := make([]helpers.BackupFile, 0)
+	for name, rows := range map[string]interface{}{
+		keyBackupFileKeys:           records.Keys,
+		keyBackupFileKeyVersions:    records.KeyVersions,
+		keyBackupFileKeyTags:        records.KeyTags,
+		keyBackupFileKeyDelegations: records.Delegations,
+		keyBackupFileKeyEscrows:     records.KeyEscrows,
+		keyBackupFileAuditEvents:    records.AuditEvents,
+	} {
+		file, err := encodeBackupFile(name, rows)
+		if err != nil {
+			return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+		}
+		files = append(files, *file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	manifest := &models.KeyBackupManifest{
+		FormatVersion: keyBackupFormatVersion,
+		CreatedAt:     utils.GetCurrentDateTime(),
+		HSMKEKID:      hsmKEKID,
+		KEKs:          backupKEKs(records),
+	}
+	err = helpers.WriteBackupArchive(w, hmacKey, manifest, files)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+
+	return manifest, nil
+}
+
+// Restore verifies the archive, makes the HSM decrypt a sample of the private keys of every KEK and only then
+// inserts every row in one transaction, into a database that has no keys and no audit events yet
+func (s keyBackupService) Restore(r io.Reader, payload *KeyRestorePayload) (*KeyRestoreReport, core.IError) {
+	hmacKey, ierr := s.hmacKey()
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	manifest, files, err := helpers.ReadBackupArchive(r, hmacKey)
+	if errors.Is(err, helpers.ErrBackupIntegrity) {
+		return nil, s.ctx.NewError(err, emsgs.BackupIntegrityError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.BackupFormatError)
+	}
+	if manifest.FormatVersion != keyBackupFormatVersion {
+		err = fmt.Errorf("backup: format version %d is not supported", manifest.FormatVersion)
+		return nil, s.ctx.NewError(err, emsgs.BackupFormatError)
+	}
+	records, ierr := s.decodeRecords(manifest, files)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	hsmKEKID, ierr := s.hsmService.KEKID()
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	report := &KeyRestoreReport{
+		Manifest:      manifest,
+		HSMKEKID:      hsmKEKID,
+		MissingKEKIDs: make([]string, 0),
+	}
+	for _, kek := range manifest.KEKs {
+		if kek.KEKID != "" && kek.KEKID != hsmKEKID {
+			report.MissingKEKIDs = append(report.MissingKEKIDs, kek.KEKID)
+		}
+	}
+	if len(report.MissingKEKIDs) > 0 && !payload.AllowMissingKEK {
+		ierr = emsgs.BackupKEKMissingError(report.MissingKEKIDs)
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	report.DecryptedKeys, ierr = s.decryptSample(records, hsmKEKID, payload.SampleSize)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	if payload.DryRun {
+		return report, nil
+	}
+
+	ierr = s.insertRecords(records)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	report.Restored = true
+
+	return report, nil
+}
+
+func (s keyBackupService) hmacKey() ([]byte, core.IError) {
+	hmacKey := []byte(s.ctx.ENV().String(consts.ENVBackupHMACKey))
+	if len(hmacKey) < keyBackupMinHMACKeySize {
+		return nil, s.ctx.NewError(emsgs.BackupHMACKeyError, emsgs.BackupHMACKeyError)
+	}
+
+	return hmacKey, nil
+}
+
+func (s keyBackupService) decodeRecords(manifest *models.KeyBackupManifest, files map[string][]byte) (*keyBackupRecords, core.IError) {
+	records := &keyBackupRecords{}
+	rows := map[string]interface{}{
+		keyBackupFileKeys:           &records.Keys,
+		keyBackupFileKeyVersions:    &records.KeyVersions,
+		keyBackupFileKeyTags:        &records.KeyTags,
+		keyBackupFileKeyDelegations: &records.Delegations,
+		keyBackupFileKeyEscrows:     &records.KeyEscrows,
+		keyBackupFileAuditEvents:    &records.AuditEvents,
+	}
+	if len(manifest.Files) != len(rows) {
+		return nil, s.ctx.NewError(emsgs.BackupFormatError, emsgs.BackupFormatError)
+	}
+	for _, file := range manifest.Files {
+		target, ok := rows[file.Name]
+		if !ok {
+			return nil, s.ctx.NewError(emsgs.BackupFormatError, emsgs.BackupFormatError)
+		}
+		count, err := decodeBackupFile(files[file.Name], target)
+		if err != nil {
+			return nil, s.ctx.NewError(err, emsgs.BackupFormatError)
+		}
+		if count != file.Rows {
+			err = fmt.Errorf("backup: %s has %d rows, the manifest lists %d", file.Name, count, file.Rows)
+			return nil, s.ctx.NewError(err, emsgs.BackupFormatError)
+		}
+	}
+
+	return records, nil
+}
+
+// decryptSample decrypts up to sampleSize random private keys of every KEK the HSM holds, keys stored before
+// the KEK was recorded are sampled as one more KEK, and checks that they match their public key
+func (s keyBackupService) decryptSample(records *keyBackupRecords, hsmKEKID string, sampleSize int) (int, core.IError) {
+	keyTypes := make(map[string]string)
+	for _, key := range records.Keys {
+		keyTypes[key.ID] = key.Type
+	}
+
+	byKEK := make(map[string][]models.KeyVersionBackupRecord)
+	for _, version := range records.KeyVersions {
+		if version.KEKID == "" || version.KEKID == hsmKEKID {
+			byKEK[version.KEKID] = append(byKEK[version.KEKID], version)
+		}
+	}
+
+	random := rand.New(rand.NewSource(time.Now().UnixNano()))
+	decrypted := 0
+	for _, versions := range byKEK {
+		random.Shuffle(len(versions), func(i, j int) {
+			versions[i], versions[j] = versions[j], versions[i]
+		})
+		if len(versions) > sampleSize {
+			versions = versions[:sampleSize]
+		}
+		for _, version := range versions {
+			ierr := s.decryptVersion(&version, keyTypes[version.KeyID])
+			if ierr != nil {
+				return decrypted, s.ctx.NewError(ierr, ierr)
+			}
+			decrypted++
+		}
+	}
+
+	return decrypted, nil
+}
+
+func (s keyBackupService) decryptVersion(version *models.KeyVersionBackupRecord, keyType string) core.IError {
+	decryptError := emsgs.BackupDecryptError(version.KeyID, version.Version)
+
+	decryptedPrivateKey, ierr := s.hsmService.Decrypt(version.PrivateKeyEncrypted)
+	if ierr != nil {
+		return s.ctx.NewError(ierr, decryptError)
+	}
+	defer helpers.Zeroize(decryptedPrivateKey)
+
+	privateKey, ierr := parsePrivateKey(s.ctx, &models.Key{Type: keyType}, decryptedPrivateKey)
+	if ierr != nil {
+		return s.ctx.NewError(ierr, decryptError)
+	}
+	defer helpers.ZeroizePrivateKey(privateKey)
+
+	_, publicKey, err := helpers.PublicKeyFingerprint(version.PublicKey)
+	if err != nil {
+		return s.ctx.NewError(err, decryptError)
+	}
+	signer, ok := privateKey.(interface{ Public() crypto.PublicKey })
+	if !ok {
+		return s.ctx.NewError(decryptError, decryptError)
+	}
+	derived, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !derived.Equal(publicKey) {
+		return s.ctx.NewError(decryptError, decryptError)
+	}
+
+	return nil
+}
+
+func (s keyBackupService) insertRecords(records *keyBackupRecords) core.IError {
+	var keys, auditEvents int64
+	err := s.ctx.DB().Model(&models.Key{}).Count(&keys).Error
+	if err != nil {
+		return s.ctx.NewError(err, errmsgs.DBError)
+	}
+	err = s.ctx.DB().Model(&models.KeyAuditEvent{}).Count(&auditEvents).Error
+	if err != nil {
+		return s.ctx.NewError(err, errmsgs.DBError)
+	}
+	if keys > 0 || auditEvents > 0 {
+		return s.ctx.NewError(emsgs.BackupRestoreTargetNotEmptyError, emsgs.BackupRestoreTargetNotEmptyError)
+	}
+
+	err = s.ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if len(records.Keys) > 0 {
+			if err := tx.Omit("Tags").CreateInBatches(records.Keys, keyRestoreBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(records.KeyVersions) > 0 {
+			if err := tx.CreateInBatches(records.KeyVersions, keyRestoreBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(records.KeyTags) > 0 {
+			if err := tx.CreateInBatches(records.KeyTags, keyRestoreBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(records.Delegations) > 0 {
+			if err := tx.CreateInBatches(records.Delegations, keyRestoreBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(records.KeyEscrows) > 0 {
+			if err := tx.CreateInBatches(records.KeyEscrows, keyRestoreBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(records.AuditEvents) > 0 {
+			return tx.CreateInBatches(records.AuditEvents, keyRestoreBatchSize).Error
+		}
+
+		return nil
+	})
+	if err != nil {
+		return s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return nil
+}
+
+// backupKEKs counts the keys and versions of every KEK, sorted by KEK ID
+func backupKEKs(records *keyBackupRecords) []models.KeyBackupKEK {
+	counts := make(map[string]*models.KeyBackupKEK)
+	kek := func(kekID string) *models.KeyBackupKEK {
+		if counts[kekID] == nil {
+			counts[kekID] = &models.KeyBackupKEK{KEKID: kekID}
+		}
+		return counts[kekID]
+	}
+	for _, key := range records.Keys {
+		kek(key.KEKID).Keys++
+	}
+	for _, version := range records.KeyVersions {
+		kek(version.KEKID).KeyVersions++
+	}
+
+	keks := make([]models.KeyBackupKEK, 0, len(counts))
+	for _, count := range counts {
+		keks = append(keks, *count)
+	}
+	sort.Slice(keks, func(i, j int) bool {
+		return keks[i].KEKID < keks[j].KEKID
+	})
+
+	return keks
+}
+
+// encodeBackupFile writes rows, a slice, as JSON Lines
+func encodeBackupFile(name string, rows interface{}) (*helpers.BackupFile, error) {
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+
+	buffer := &bytes.Buffer{}
+	for _, item := range items {
+		buffer.Write(item)
+		buffer.WriteByte('\n')
+	}
+
+	return &helpers.BackupFile{Name: name, Rows: len(items), Data: buffer.Bytes()}, nil
+}
+
+// decodeBackupFile reads JSON Lines into target, a pointer to a slice, and returns the number of rows
+func decodeBackupFile(data []byte, target interface{}) (int, error) {
+	items := make([]json.RawMessage, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		items = append(items, append(json.RawMessage{}, scanner.Bytes()...))
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	array, err := json.Marshal(items)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(items), json.Unmarshal(array, target)
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"io"
+
+	"github.com/stretchr/testify/mock"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type MockKeyBackupService struct {
+	mock.Mock
+}
+
+func NewMockKeyBackupService() *MockKeyBackupService {
+	return &MockKeyBackupService{}
+}
+
+func (m *MockKeyBackupService) Backup(w io.Writer) (*models.KeyBackupManifest, core.IError) {
+	args := m.Called(w)
+	return args.Get(0).(*models.KeyBackupManifest), core.MockIError(args, 1)
+}
+
+func (m *MockKeyBackupService) Restore(r io.Reader, payload *KeyRestorePayload) (*KeyRestoreReport, core.IError) {
+	args := m.Called(r, payload)
+	return args.Get(0).(*KeyRestoreReport), core.MockIError(args, 1)
+}
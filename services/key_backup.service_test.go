@@ -0,0 +1,142 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+// backupTestContext answers BACKUP_HMAC_KEY on top of the mock context
+type backupTestContext struct {
+	*core.ContextMock
+	hmacKey string
+}
+
+func (c backupTestContext) ENV() core.IENV {
+	return backupTestENV{hmacKey: c.hmacKey}
+}
+
+type backupTestENV struct {
+	core.IENV
+	hmacKey string
+}
+
+func (e backupTestENV) String(key string) string {
+	if key == consts.ENVBackupHMACKey {
+		return e.hmacKey
+	}
+
+	return ""
+}
+
+type KeyBackupTestSuite struct {
+	suite.Suite
+	mCtx       *core.ContextMock
+	mhs        *MockHSMService
+	rbs        IKeyBackupService
+	publicKey  string
+	privateKey []byte
+	createdAt  time.Time
+}
+
+func TestKeyBackupTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyBackupTestSuite))
+}
+
+func (k *KeyBackupTestSuite) SetupTest() {
+	k.mCtx = core.NewMockContext()
+	k.mCtx.On("DB").Return(k.mCtx.MockDB.Gorm)
+	k.mhs = NewMockHSMService()
+	k.mhs.On("KEKID").Return("kek-1", nil)
+	k.rbs = NewKeyBackupService(backupTestContext{ContextMock: k.mCtx, hmacKey: "0123456789abcdef0123456789abcdef"}, k.mhs)
+
+	var err error
+	k.publicKey, k.privateKey, err = helpers.GenerateECDSAKeyPair()
+	k.Require().NoError(err)
+	k.createdAt = time.Now().Truncate(time.Second)
+}
+
+// expectDecrypt answers one decryption with a copy of the private key, the service zeroes what it decrypts
+func (k *KeyBackupTestSuite) expectDecrypt() {
+	k.mhs.On("Decrypt", "encrypted-1").Return(append([]byte{}, k.privateKey...), nil).Once()
+}
+
+// backup writes an archive of one key with one version and one audit event
+func (k *KeyBackupTestSuite) backup() []byte {
+	db := k.mCtx.MockDB.Mock
+	db.ExpectBegin()
+	db.ExpectQuery("SELECT \\* FROM `keys`").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "public_key", "private_key_encrypted", "kek_id", "type", "version", "tenant_id", "owner_id", "created_at"}).
+			AddRow("key-1", k.publicKey, "encrypted-1", "kek-1", string(consts.KeyTypeECDSA), 1, "tenant-1", "client-1", k.createdAt))
+	db.ExpectQuery("SELECT \\* FROM `key_versions`").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "key_id", "version", "public_key", "private_key_encrypted", "kek_id", "created_at"}).
+			AddRow("version-1", "key-1", 1, k.publicKey, "encrypted-1", "kek-1", k.createdAt))
+	db.ExpectQuery("SELECT \\* FROM `key_tags`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	db.ExpectQuery("SELECT \\* FROM `key_delegations`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	db.ExpectQuery("SELECT \\* FROM `key_escrows`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	db.ExpectQuery("SELECT \\* FROM `key_audit_events`").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "sequence", "operation", "key_id", "key_version", "outcome", "previous_hash", "hash", "created_at"}).
+			AddRow("event-1", 1, string(consts.AuditOperationSign), "key-1", 1, string(consts.AuditOutcomeSuccess), consts.AuditGenesisHash, "hash-1", k.createdAt))
+	db.ExpectCommit()
+
+	archive := &bytes.Buffer{}
+	manifest, ierr := k.rbs.Backup(archive)
+	k.Require().NoError(ierr)
+	k.Equal([]models.KeyBackupKEK{{KEKID: "kek-1", Keys: 1, KeyVersions: 1}}, manifest.KEKs)
+	k.Len(manifest.Files, 6)
+	k.Require().NoError(db.ExpectationsWereMet())
+
+	return archive.Bytes()
+}
+
+func (k *KeyBackupTestSuite) TestKeyBackupService_Restore_ExpectDryRun() {
+	archive := k.backup()
+	k.expectDecrypt()
+
+	report, ierr := k.rbs.Restore(bytes.NewReader(archive), &KeyRestorePayload{SampleSize: 10, DryRun: true})
+	k.Require().NoError(ierr)
+	k.Equal(1, report.DecryptedKeys)
+	k.Empty(report.MissingKEKIDs)
+	k.False(report.Restored)
+	k.NoError(k.mCtx.MockDB.Mock.ExpectationsWereMet())
+}
+
+func (k *KeyBackupTestSuite) TestKeyBackupService_Restore_ExpectRestored() {
+	archive := k.backup()
+	k.expectDecrypt()
+
+	db := k.mCtx.MockDB.Mock
+	db.ExpectQuery("SELECT count\\(\\*\\) FROM `keys`").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	db.ExpectQuery("SELECT count\\(\\*\\) FROM `key_audit_events`").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	db.ExpectBegin()
+	db.ExpectExec("INSERT INTO `keys`").WillReturnResult(sqlmock.NewResult(0, 1))
+	db.ExpectExec("INSERT INTO `key_versions`").WillReturnResult(sqlmock.NewResult(0, 1))
+	db.ExpectExec("INSERT INTO `key_audit_events`").WillReturnResult(sqlmock.NewResult(0, 1))
+	db.ExpectCommit()
+
+	report, ierr := k.rbs.Restore(bytes.NewReader(archive), &KeyRestorePayload{SampleSize: 10})
+	k.Require().NoError(ierr)
+	k.True(report.Restored)
+	k.NoError(db.ExpectationsWereMet())
+}
+
+func (k *KeyBackupTestSuite) TestKeyBackupService_Restore_ExpectIntegrityError() {
+	archive := k.backup()
+	k.mCtx.On("NewError", mock.Anything, mock.Anything, mock.Anything).Return(emsgs.BackupIntegrityError)
+
+	// Expect error on an archive read with another HMAC key
+	rbs := NewKeyBackupService(backupTestContext{ContextMock: k.mCtx, hmacKey: "fedcba9876543210fedcba9876543210"}, k.mhs)
+	report, ierr := rbs.Restore(bytes.NewReader(archive), &KeyRestorePayload{SampleSize: 10, DryRun: true})
+	k.Error(ierr)
+	k.Nil(report)
+	k.mCtx.AssertCalled(k.T(), "NewError", mock.Anything, emsgs.BackupIntegrityError, mock.Anything)
+}
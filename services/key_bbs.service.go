@@ -0,0 +1,175 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+)
+
+type KeyBBSPayload struct {
+	Header   []byte
+	Messages [][]byte
+}
+
+type KeyBBS2023Payload struct {
+	// ProofConfig is the canonical N-Quads of the proof options without the proof value
+	ProofConfig string
+	// Statements are the canonical N-Quads of the document, one statement each
+	Statements []string
+	// MandatoryIndexes are the indexes of the statements every derived proof discloses
+	MandatoryIndexes []int
+	// MandatoryPointers are the JSON Pointers the mandatory statements were selected with, they are kept in the proof
+	MandatoryPointers []string
+	// HMACKey shuffles the blank node labels, a random key is used when it is empty
+	HMACKey []byte
+}
+
+// SignBBS makes a BBS signature of the header and the messages, the policy of the key is evaluated on a JSON
+// of the base64 header and messages and the signature is audited like any other
+func (s keyService) SignBBS(id string, payload *KeyBBSPayload) (*models.BBSSignature, core.IError) {
+	key, ierr := s.findSigningKey(id)
+	if ierr != nil {
+		return nil, s.audit(&AuditEventPayload{
+			Operation: consts.AuditOperationSign,
+			KeyID:     id,
+		}, ierr)
+	}
+
+	signature, message, ierr := s.signBBS(key, payload.Header, payload.Messages)
+	ierr = s.auditBBS(key, message, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return &models.BBSSignature{
+		KeyID:     key.ID,
+		Version:   key.Version,
+		Signature: base64.StdEncoding.EncodeToString(signature),
+	}, nil
+}
+
+// SignBBS2023 issues the base proof of the bbs-2023 cryptosuite of a document given as canonical N-Quads,
+// the non-mandatory statements are the BBS messages and the header binds the proof configuration and the mandatory statements
+func (s keyService) SignBBS2023(id string, payload *KeyBBS2023Payload) (*models.BBS2023Proof, core.IError) {
+	key, ierr := s.findSigningKey(id)
+	if ierr != nil {
+		return nil, s.audit(&AuditEventPayload{
+			Operation: consts.AuditOperationSign,
+			KeyID:     id,
+		}, ierr)
+	}
+
+	proof, message, ierr := s.signBBS2023(key, payload)
+	ierr = s.auditBBS(key, message, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return proof, nil
+}
+
+func (s keyService) signBBS2023(key *models.Key, payload *KeyBBS2023Payload) (*models.BBS2023Proof, string, core.IError) {
+	hmacKey := payload.HMACKey
+	if len(hmacKey) == 0 {
+		hmacKey = make([]byte, consts.BBS2023HMACKeySize)
+		if _, err := rand.Read(hmacKey); err != nil {
+			return nil, "", s.ctx.NewError(err, errmsgs.InternalServerError)
+		}
+	}
+
+	header, messages, err := helpers.BBS2023SignatureInput(hmacKey, payload.ProofConfig, payload.Statements, payload.MandatoryIndexes)
+	if err != nil {
+		return nil, "", s.ctx.NewError(err, emsgs.InvalidBBS2023StatementsError)
+	}
+	signature, message, ierr := s.signBBS(key, header, messages)
+	if ierr != nil {
+		return nil, message, s.ctx.NewError(ierr, ierr)
+	}
+
+	_, publicKey, err := helpers.PublicKeyFingerprint(key.PublicKey)
+	if err != nil {
+		return nil, message, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	baseProof := &helpers.BBS2023BaseProof{
+		Signature:         signature,
+		Header:            header,
+		PublicKey:         publicKey.(helpers.BBSPublicKey),
+		HMACKey:           hmacKey,
+		MandatoryPointers: payload.MandatoryPointers,
+	}
+
+	return &models.BBS2023Proof{
+		KeyID:              key.ID,
+		Version:            key.Version,
+		VerificationMethod: key.DIDKey + "#" + strings.TrimPrefix(key.DIDKey, consts.DIDMethodKey),
+		ProofValue:         baseProof.ProofValue(),
+	}, message, nil
+}
+
+// signBBS returns the BBS signature and the message the policy was evaluated on, the private key only leaves
+// the HSM once the policy allows the signature
+func (s keyService) signBBS(key *models.Key, header []byte, messages [][]byte) ([]byte, string, core.IError) {
+	if key.Type != string(consts.KeyTypeBLS12381G2) {
+		return nil, "", s.ctx.NewError(emsgs.BBSKeyRequiredError, emsgs.BBSKeyRequiredError)
+	}
+
+	message, err := bbsPolicyMessage(header, messages)
+	if err != nil {
+		return nil, "", s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	ierr := s.enforceSignPolicy(key, message)
+	if ierr != nil {
+		return nil, message, s.ctx.NewError(ierr, ierr)
+	}
+
+	signer, ierr := newKeySigner(s.ctx, s.hsmService, key, 1)
+	if ierr != nil {
+		return nil, message, s.ctx.NewError(ierr, ierr)
+	}
+	defer signer.Release()
+
+	signature, err := signer.SignBBS(header, messages)
+	if err != nil {
+		return nil, message, signingError(s.ctx, err)
+	}
+
+	return signature, message, nil
+}
+
+func (s keyService) auditBBS(key *models.Key, message string, ierr core.IError) core.IError {
+	payload := &AuditEventPayload{
+		Operation:  consts.AuditOperationSign,
+		KeyID:      key.ID,
+		KeyVersion: key.Version,
+	}
+	if message != "" {
+		payload.MessageDigest = helpers.MessageDigest(message)
+	}
+
+	return s.audit(payload, ierr)
+}
+
+// bbsPolicyMessage is the JSON message a sign policy is evaluated on for the header and the messages of a BBS signature
+func bbsPolicyMessage(header []byte, messages [][]byte) (string, error) {
+	encoded := make([]string, len(messages))
+	for i, message := range messages {
+		encoded[i] = base64.StdEncoding.EncodeToString(message)
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"header":   base64.StdEncoding.EncodeToString(header),
+		"messages": encoded,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
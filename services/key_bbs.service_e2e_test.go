@@ -0,0 +1,140 @@
+// +build e2e
+
+package services
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyBBSServiceTestSuite struct {
+	suite.Suite
+	rCtx core.IContext
+	rks  IKeyService
+	rbs  IBBSService
+}
+
+func TestKeyBBSServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyBBSServiceTestSuite))
+}
+
+func (k *KeyBBSServiceTestSuite) SetupSuite() {
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	k.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+}
+
+func (k *KeyBBSServiceTestSuite) SetupTest() {
+	k.rks = NewKeyService(k.rCtx, NewHSMService(k.rCtx), NewAuditService(k.rCtx))
+	k.rbs = NewBBSService(k.rCtx)
+}
+
+func (k *KeyBBSServiceTestSuite) TestKeyBBSService_SignBBS_ExpectVerified() {
+	key, ierr := k.rks.GenerateBBS(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+	k.Equal(string(consts.KeyTypeBLS12381G2), key.Type)
+	k.True(strings.HasPrefix(key.DIDKey, consts.DIDMethodKey+"zUC7"))
+
+	messages := [][]byte{[]byte("given_name"), []byte("family_name")}
+	signature, ierr := k.rks.SignBBS(key.ID, &KeyBBSPayload{
+		Header:   []byte("header"),
+		Messages: messages,
+	})
+	k.Require().NoError(ierr)
+
+	data, err := base64.StdEncoding.DecodeString(signature.Signature)
+	k.Require().NoError(err)
+	_, publicKey, err := helpers.PublicKeyFingerprint(key.PublicKey)
+	k.Require().NoError(err)
+	k.NoError(helpers.BBSVerify(publicKey.(helpers.BBSPublicKey), data, []byte("header"), messages))
+
+	// the rotated version is a BLS12381G2 key as well
+	rotated, ierr := k.rks.Rotate(key.ID)
+	k.Require().NoError(ierr)
+	k.Equal(string(consts.KeyTypeBLS12381G2), rotated.Type)
+}
+
+func (k *KeyBBSServiceTestSuite) TestKeyBBSService_SignBBS2023_ExpectDerivedProofVerified() {
+	key, ierr := k.rks.GenerateBBS(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	statements := []string{
+		`<did:example:issuer> <https://schema.org/name> "Issuer" .`,
+		`_:c14n0 <https://schema.org/birthDate> "2000-01-01" .`,
+		`_:c14n0 <https://schema.org/givenName> "Alice" .`,
+		`_:c14n1 <https://www.w3.org/2018/credentials#credentialSubject> _:c14n0 .`,
+		`_:c14n1 <https://www.w3.org/2018/credentials#issuer> <did:example:issuer> .`,
+	}
+	proof, ierr := k.rks.SignBBS2023(key.ID, &KeyBBS2023Payload{
+		ProofConfig:       `_:c14n0 <https://w3id.org/security#cryptosuite> "bbs-2023" .`,
+		Statements:        statements,
+		MandatoryIndexes:  []int{0, 4},
+		MandatoryPointers: []string{"/issuer"},
+	})
+	k.Require().NoError(ierr)
+	k.Equal(key.DIDKey+"#"+strings.TrimPrefix(key.DIDKey, consts.DIDMethodKey), proof.VerificationMethod)
+
+	derived, ierr := k.rbs.DeriveBBS2023(&BBS2023DerivePayload{
+		ProofValue:         proof.ProofValue,
+		Statements:         statements,
+		MandatoryIndexes:   []int{0, 4},
+		SelectiveIndexes:   []int{2, 3},
+		PresentationHeader: []byte("nonce"),
+	})
+	k.Require().NoError(ierr)
+	k.Len(derived.Statements, 4)
+
+	ierr = k.rbs.VerifyBBS2023(&BBS2023VerifyPayload{
+		VerificationMethod: proof.VerificationMethod,
+		ProofValue:         derived.ProofValue,
+		ProofConfig:        `_:c14n0 <https://w3id.org/security#cryptosuite> "bbs-2023" .`,
+		Statements:         derived.Statements,
+	})
+	k.NoError(ierr)
+
+	// Expect error on another proof configuration
+	ierr = k.rbs.VerifyBBS2023(&BBS2023VerifyPayload{
+		VerificationMethod: proof.VerificationMethod,
+		ProofValue:         derived.ProofValue,
+		ProofConfig:        `_:c14n0 <https://w3id.org/security#cryptosuite> "ecdsa-sd-2023" .`,
+		Statements:         derived.Statements,
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.BBS2023ProofVerificationError.GetCode(), ierr.GetCode())
+
+	// Expect error on mandatory indexes the base proof was not issued with
+	_, ierr = k.rbs.DeriveBBS2023(&BBS2023DerivePayload{
+		ProofValue:       proof.ProofValue,
+		Statements:       statements,
+		MandatoryIndexes: []int{0},
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.BBS2023BaseProofMismatchError.GetCode(), ierr.GetCode())
+}
+
+func (k *KeyBBSServiceTestSuite) TestKeyBBSService_ExpectError() {
+	bbsKey, ierr := k.rks.GenerateBBS(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+	ecdsaKey, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	// Expect error on a BBS signature of a key of another type
+	_, ierr = k.rks.SignBBS(ecdsaKey.ID, &KeyBBSPayload{Messages: [][]byte{[]byte("message")}})
+	k.Error(ierr)
+	k.Equal(emsgs.BBSKeyRequiredError.GetCode(), ierr.GetCode())
+
+	// Expect error on a signature of one message with a BLS12381G2 key
+	_, ierr = k.rks.Sign(bbsKey.ID, "message")
+	k.Error(ierr)
+	k.Equal(emsgs.BBSKeyMessageSigningError.GetCode(), ierr.GetCode())
+}
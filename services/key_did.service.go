@@ -0,0 +1,178 @@
+package services
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+)
+
+type KeyDIDOperationPayload struct {
+	Operation  consts.DIDOperation
+	DIDAddress string
+	// PublicKey is the key a DID_ADD_KEY adds, a DID_REGISTER registers the signing key
+	PublicKey  string
+	KeyType    consts.DIDVerificationMethodType
+	KeyID      string
+	Controller string
+	Nonce      string
+}
+
+type KeyVerificationMethodPayload struct {
+	Controller string
+	Type       consts.DIDVerificationMethodType
+	// Fragment names the verification method in the DID document of the controller, without the "#"
+	Fragment string
+}
+
+// VerificationMethod renders the public key of a key as a verification method of the controller, ready to be
+// added to its DID document. The fragment defaults to the one of the did:key or did:jwk of the key when it is
+// the controller and to the key ID otherwise
+func (s keyService) VerificationMethod(id string, payload *KeyVerificationMethodPayload) (*models.DIDVerificationMethod, core.IError) {
+	key, ierr := s.Find(id)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	methodType := payload.Type
+	if methodType == "" {
+		methodType = consts.DIDVerificationMethodJsonWebKey2020
+	}
+	fragment := payload.Fragment
+	switch {
+	case fragment != "":
+	case payload.Controller == key.DIDKey:
+		fragment = strings.TrimPrefix(key.DIDKey, consts.DIDMethodKey)
+	case payload.Controller == key.DIDJWK:
+		fragment = strings.TrimPrefix(consts.DIDJWKVerificationID, "#")
+	default:
+		fragment = key.ID
+	}
+
+	_, publicKey, err := helpers.PublicKeyFingerprint(key.PublicKey)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	verificationMethod, err := helpers.NewVerificationMethod(publicKey, methodType, payload.Controller, payload.Controller+"#"+fragment)
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.InvalidVerificationMethodTypeError)
+	}
+	verificationMethod.Context = consts.DIDVerificationMethodContexts[methodType]
+
+	return verificationMethod, nil
+}
+
+// SignDIDOperation builds the canonical payload of a DID registry operation, checks it against the signing key
+// and signs its base64 with Sign
+func (s keyService) SignDIDOperation(id string, payload *KeyDIDOperationPayload) (*models.DIDOperationEnvelope, core.IError) {
+	// refusals before Sign is reached are audited as the sign failures they would have been
+	key, ierr := s.findAuthorized(id, consts.KeyOperationSign)
+	if ierr != nil {
+		return nil, s.audit(&AuditEventPayload{
+			Operation: consts.AuditOperationSign,
+			KeyID:     id,
+		}, ierr)
+	}
+	operation, ierr := s.didOperation(key, payload)
+	if ierr != nil {
+		return nil, s.audit(&AuditEventPayload{
+			Operation:  consts.AuditOperationSign,
+			KeyID:      key.ID,
+			KeyVersion: key.Version,
+		}, ierr)
+	}
+
+	data, err := json.Marshal(operation)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	message := base64.StdEncoding.EncodeToString(data)
+
+	// the version is pinned so the signature is made by the public key the payload was checked against
+	signature, ierr := s.Sign(fmt.Sprintf("%s@%d", key.ID, key.Version), message)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	return &models.DIDOperationEnvelope{
+		Message:   message,
+		Signature: signature.Signature,
+		KeyID:     signature.KeyID,
+		Version:   signature.Version,
+		Payload:   operation,
+	}, nil
+}
+
+// didOperation builds the canonical payload, a DID_REGISTER registers the public key of the signing key and the
+// key type defaults to the 2018 type of the key the operation registers or adds
+func (s keyService) didOperation(key *models.Key, payload *KeyDIDOperationPayload) (*models.DIDOperation, core.IError) {
+	operation := &models.DIDOperation{
+		Operation: string(payload.Operation),
+	}
+	// a DID_REGISTER creates the DID, every other operation changes an existing one
+	if payload.Operation != consts.DIDOperationRegister {
+		operation.DIDAddress = payload.DIDAddress
+		operation.Nonce = payload.Nonce
+	}
+
+	switch payload.Operation {
+	case consts.DIDOperationRegister:
+		if payload.PublicKey != "" {
+			same, err := helpers.SamePublicKey(payload.PublicKey, key.PublicKey)
+			if err != nil {
+				return nil, s.ctx.NewError(err, emsgs.InvalidPublicKeyError)
+			}
+			if !same {
+				return nil, s.ctx.NewError(emsgs.DIDOperationKeyMismatchError, emsgs.DIDOperationKeyMismatchError)
+			}
+		}
+		operation.PublicKey = key.PublicKey
+	case consts.DIDOperationAddKey:
+		operation.PublicKey = payload.PublicKey
+	case consts.DIDOperationRevokeKey:
+		operation.KeyID = payload.KeyID
+	case consts.DIDOperationAddController:
+		operation.Controller = payload.Controller
+	case consts.DIDOperationDeactivate:
+	default:
+		return nil, s.ctx.NewError(emsgs.UnsupportedDIDOperationError, emsgs.UnsupportedDIDOperationError)
+	}
+
+	if operation.PublicKey != "" {
+		_, publicKey, err := helpers.PublicKeyFingerprint(operation.PublicKey)
+		if err != nil {
+			return nil, s.ctx.NewError(err, emsgs.InvalidPublicKeyError)
+		}
+		keyType := payload.KeyType
+		if keyType == "" {
+			keyType = consts.DIDVerificationMethodSecp256r1VerificationKey2018
+			if _, ok := publicKey.(*rsa.PublicKey); ok {
+				keyType = consts.DIDVerificationMethodRsaVerificationKey2018
+			}
+		}
+		// the verification method is rendered only to check that the key type fits the key
+		_, err = helpers.NewVerificationMethod(publicKey, keyType, "", "")
+		if err != nil {
+			return nil, s.ctx.NewError(err, emsgs.InvalidVerificationMethodTypeError)
+		}
+		if payload.Operation == consts.DIDOperationAddKey {
+			der, err := x509.MarshalPKIXPublicKey(publicKey)
+			if err != nil {
+				return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+			}
+			operation.PublicKey = string(helpers.EncodePEM("PUBLIC KEY", der))
+		}
+		operation.KeyType = string(keyType)
+	}
+
+	return operation, nil
+}
@@ -0,0 +1,171 @@
+// +build e2e
+
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type KeyDIDServiceTestSuite struct {
+	suite.Suite
+	rCtx core.IContext
+	rks  IKeyService
+}
+
+func TestKeyDIDServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyDIDServiceTestSuite))
+}
+
+func (k *KeyDIDServiceTestSuite) SetupSuite() {
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	k.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+}
+
+func (k *KeyDIDServiceTestSuite) SetupTest() {
+	k.rks = NewKeyService(k.rCtx, NewHSMService(k.rCtx), NewAuditService(k.rCtx))
+}
+
+func (k *KeyDIDServiceTestSuite) rsaPublicKey() string {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	k.Require().NoError(err)
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	k.Require().NoError(err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func (k *KeyDIDServiceTestSuite) TestKeyDIDService_SignDIDOperation_Register_ExpectSuccess() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	envelope, ierr := k.rks.SignDIDOperation(key.ID, &KeyDIDOperationPayload{
+		Operation: consts.DIDOperationRegister,
+		PublicKey: key.PublicKey,
+		Nonce:     "ignored",
+	})
+	k.Require().NoError(ierr)
+
+	data, err := base64.StdEncoding.DecodeString(envelope.Message)
+	k.Require().NoError(err)
+	expected, err := json.Marshal(map[string]string{
+		"operation":  "DID_REGISTER",
+		"public_key": key.PublicKey,
+		"key_type":   "Secp256r1VerificationKey2018",
+	})
+	k.Require().NoError(err)
+	k.JSONEq(string(expected), string(data))
+	k.Equal(key.ID, envelope.KeyID)
+
+	valid, err := utils.VerifySignature(key.PublicKey, envelope.Signature, envelope.Message)
+	k.NoError(err)
+	k.True(valid)
+}
+
+func (k *KeyDIDServiceTestSuite) TestKeyDIDService_SignDIDOperation_Register_ExpectKeyMismatch() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	_, ierr = k.rks.SignDIDOperation(key.ID, &KeyDIDOperationPayload{
+		Operation: consts.DIDOperationRegister,
+		PublicKey: k.rsaPublicKey(),
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.DIDOperationKeyMismatchError.GetCode(), ierr.GetCode())
+}
+
+func (k *KeyDIDServiceTestSuite) TestKeyDIDService_SignDIDOperation_AddKey() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+	payload := &KeyDIDOperationPayload{
+		Operation:  consts.DIDOperationAddKey,
+		DIDAddress: "did:idin:1234",
+		PublicKey:  k.rsaPublicKey(),
+		Nonce:      "1",
+	}
+
+	envelope, ierr := k.rks.SignDIDOperation(key.ID, payload)
+	k.Require().NoError(ierr)
+	k.Equal(string(consts.DIDVerificationMethodRsaVerificationKey2018), envelope.Payload.KeyType)
+	k.Equal("did:idin:1234", envelope.Payload.DIDAddress)
+	k.Equal(&models.DIDOperation{
+		Operation:  "DID_ADD_KEY",
+		DIDAddress: "did:idin:1234",
+		PublicKey:  payload.PublicKey,
+		KeyType:    "RsaVerificationKey2018",
+		Nonce:      "1",
+	}, envelope.Payload)
+
+	payload.KeyType = consts.DIDVerificationMethodSecp256r1VerificationKey2018
+	_, ierr = k.rks.SignDIDOperation(key.ID, payload)
+	k.Error(ierr)
+	k.Equal(emsgs.InvalidVerificationMethodTypeError.GetCode(), ierr.GetCode())
+}
+
+func (k *KeyDIDServiceTestSuite) TestKeyDIDService_VerificationMethod_ExpectDefaultFragments() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+	k.Require().NotEmpty(key.DIDKey)
+	k.Require().NotEmpty(key.DIDJWK)
+
+	// the did:key of the key names its method by the multibase key
+	method, ierr := k.rks.VerificationMethod(key.ID, &KeyVerificationMethodPayload{Controller: key.DIDKey})
+	k.Require().NoError(ierr)
+	k.Equal(key.DIDKey+"#"+strings.TrimPrefix(key.DIDKey, consts.DIDMethodKey), method.ID)
+	k.Equal(key.DIDKey, method.Controller)
+	k.Equal(string(consts.DIDVerificationMethodJsonWebKey2020), method.Type)
+	k.Equal(consts.DIDVerificationMethodContexts[consts.DIDVerificationMethodJsonWebKey2020], method.Context)
+	k.NotNil(method.PublicKeyJWK)
+
+	method, ierr = k.rks.VerificationMethod(key.ID, &KeyVerificationMethodPayload{Controller: key.DIDJWK})
+	k.Require().NoError(ierr)
+	k.Equal(key.DIDJWK+"#0", method.ID)
+
+	// any other controller names it by the key ID unless a fragment is given
+	method, ierr = k.rks.VerificationMethod(key.ID, &KeyVerificationMethodPayload{Controller: "did:example:issuer"})
+	k.Require().NoError(ierr)
+	k.Equal("did:example:issuer#"+key.ID, method.ID)
+
+	method, ierr = k.rks.VerificationMethod(key.ID, &KeyVerificationMethodPayload{
+		Controller: "did:example:issuer",
+		Type:       consts.DIDVerificationMethodMultikey,
+		Fragment:   "signing",
+	})
+	k.Require().NoError(ierr)
+	k.Equal("did:example:issuer#signing", method.ID)
+	k.Equal(string(consts.DIDVerificationMethodMultikey), method.Type)
+	k.NotEmpty(method.PublicKeyMultibase)
+}
+
+func (k *KeyDIDServiceTestSuite) TestKeyDIDService_VerificationMethod_ExpectInvalidType() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	// Expect error on an unknown type and on a type of another key type
+	for _, methodType := range []consts.DIDVerificationMethodType{"UnknownKey2099", consts.DIDVerificationMethodRsaVerificationKey2018} {
+		method, ierr := k.rks.VerificationMethod(key.ID, &KeyVerificationMethodPayload{
+			Controller: "did:example:issuer",
+			Type:       methodType,
+		})
+		k.Error(ierr)
+		k.Equal(emsgs.InvalidVerificationMethodTypeError.GetCode(), ierr.GetCode())
+		k.Nil(method)
+	}
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gorm.io/gorm"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type KeyRecoverPayload struct {
+	// Shares are the base64 shares the custodians decrypted, buffers owned by the caller who zeroes them
+	Shares [][]byte
+}
+
+// Escrows lists the recovery copies of the versions of a key with the shares encrypted to each custodian
+func (s keyService) Escrows(id string) ([]models.KeyEscrow, core.IError) {
+	key, ierr := s.findAuthorized(id, consts.KeyOperationManage)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	escrows := make([]models.KeyEscrow, 0)
+	err := s.ctx.DB().Where("key_id = ?", key.ID).Order("version").Find(&escrows).Error
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return escrows, nil
+}
+
+// Recover rebuilds the recovery key of the escrow of the version of a key from the shares of enough custodians,
+// unwraps the private key and stores it again under the current KEK of the HSM
+func (s keyService) Recover(id string, payload *KeyRecoverPayload) (*models.Key, core.IError) {
+	key, ierr := s.recover(id, payload)
+	return s.auditedKey(consts.AuditOperationRecover, id, key, ierr)
+}
+
+func (s keyService) recover(id string, payload *KeyRecoverPayload) (*models.Key, core.IError) {
+	key, ierr := s.findAuthorized(id, consts.KeyOperationManage)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	escrow := &models.KeyEscrow{}
+	err := s.ctx.DB().Where("key_id = ? AND version = ?", key.ID, key.Version).First(escrow).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(emsgs.KeyEscrowNotFoundError, emsgs.KeyEscrowNotFoundError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	privateKey, ierr := s.combineEscrow(escrow, payload.Shares)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	defer helpers.Zeroize(privateKey.PrivateKey)
+
+	same, err := helpers.SamePublicKey(key.PublicKey, privateKey.PublicKey)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	if !same {
+		return nil, s.ctx.NewError(emsgs.KeyEscrowRecoveryError, emsgs.KeyEscrowRecoveryError)
+	}
+
+	encryptedPrivateKey, ierr := s.hsmService.Encrypt(privateKey.PrivateKey)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	kekID, ierr := s.hsmService.KEKID()
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	err = s.ctx.DB().Transaction(func(tx *gorm.DB) error {
+		values := map[string]interface{}{
+			"private_key_encrypted": encryptedPrivateKey,
+			"kek_id":                kekID,
+			"updated_at":            utils.GetCurrentDateTime(),
+		}
+		err := tx.Model(&models.KeyVersion{}).Where("key_id = ? AND version = ?", key.ID, key.Version).Updates(values).Error
+		if err != nil {
+			return err
+		}
+		// the key row only holds the private key when the recovered version is still the latest
+		err = tx.Model(&models.Key{}).Where("id = ? AND version = ?", key.ID, key.Version).Updates(values).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(escrow).Updates(map[string]interface{}{
+			"recovered_at": utils.GetCurrentDateTime(),
+			"updated_at":   utils.GetCurrentDateTime(),
+		}).Error
+	})
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+	s.invalidateCachedKey(key.ID)
+
+	return s.Find(id)
+}
+
+// combineEscrow rebuilds the recovery key from the base64 shares and unwraps the private key of the escrow
+func (s keyService) combineEscrow(escrow *models.KeyEscrow, encodedShares [][]byte) (*helpers.ImportedKey, core.IError) {
+	indexes := make(map[int]bool)
+	for _, share := range escrow.Shares {
+		indexes[share.Index] = true
+	}
+
+	shares := make([][]byte, 0, len(encodedShares))
+	defer func() {
+		for _, share := range shares {
+			helpers.Zeroize(share)
+		}
+	}()
+	for _, encodedShare := range encodedShares {
+		share := make([]byte, base64.StdEncoding.DecodedLen(len(encodedShare)))
+		n, err := base64.StdEncoding.Decode(share, encodedShare)
+		shares = append(shares, share)
+		if err != nil || n < 2 || !indexes[int(share[0])] {
+			return nil, s.ctx.NewError(emsgs.KeyEscrowSharesError, emsgs.KeyEscrowSharesError)
+		}
+		shares[len(shares)-1] = share[:n]
+	}
+	if len(shares) < escrow.Threshold {
+		return nil, s.ctx.NewError(emsgs.KeyEscrowSharesError, emsgs.KeyEscrowSharesError)
+	}
+
+	recoveryKey, err := helpers.CombineShares(shares)
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.KeyEscrowSharesError)
+	}
+	defer helpers.Zeroize(recoveryKey)
+
+	wrappedKey, err := base64.StdEncoding.DecodeString(escrow.WrappedKey)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	der, err := helpers.UnwrapKeyAESKWP(recoveryKey, wrappedKey)
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.KeyEscrowRecoveryError)
+	}
+	defer helpers.Zeroize(der)
+
+	privateKeyPEM := helpers.EncodePEM("PRIVATE KEY", der)
+	defer helpers.Zeroize(privateKeyPEM)
+	privateKey, err := helpers.ImportPrivateKey(privateKeyPEM, nil)
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.KeyEscrowRecoveryError)
+	}
+
+	return privateKey, nil
+}
+
+// escrow wraps the private key PEM of a version of the key under a random recovery key and encrypts one
+// Shamir share of the recovery key to each custodian, the recovery key itself is never stored
+func (s keyService) escrow(key *models.Key, privateKeyPEM []byte, policy *models.KeyEscrowPolicy) (*models.KeyEscrow, core.IError) {
+	type custodian struct {
+		publicKey   string
+		fingerprint string
+		wrap        keyWrapFunc
+		format      string
+	}
+	custodians := make([]custodian, 0, len(policy.Custodians))
+	fingerprints := make(map[string]bool)
+	for _, publicKey := range policy.Custodians {
+		fingerprint, custodianKey, err := helpers.PublicKeyFingerprint(publicKey)
+		if err != nil {
+			return nil, s.ctx.NewError(err, emsgs.InvalidEscrowCustodianError)
+		}
+		wrap, format, ierr := s.keyWrapper(custodianKey, consts.KeyExportFormatAESKWP)
+		if ierr != nil {
+			return nil, s.ctx.NewError(ierr, emsgs.InvalidEscrowCustodianError)
+		}
+		if fingerprints[fingerprint] {
+			return nil, s.ctx.NewError(emsgs.InvalidEscrowPolicyError, emsgs.InvalidEscrowPolicyError)
+		}
+		fingerprints[fingerprint] = true
+		custodians = append(custodians, custodian{publicKey: publicKey, fingerprint: fingerprint, wrap: wrap, format: format})
+	}
+
+	// BLS12381G2 private keys have no PKCS #8 form that recover could import
+	if key.Type == string(consts.KeyTypeBLS12381G2) {
+		return nil, s.ctx.NewError(emsgs.BBSKeyNotPortableError, emsgs.BBSKeyNotPortableError)
+	}
+	privateKey, ierr := parsePrivateKey(s.ctx, key, privateKeyPEM)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	defer helpers.ZeroizePrivateKey(privateKey)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	defer helpers.Zeroize(der)
+
+	recoveryKey := make([]byte, consts.KeyEscrowRecoveryKeySize)
+	defer helpers.Zeroize(recoveryKey)
+	if _, err := rand.Read(recoveryKey); err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	wrappedKey, err := helpers.WrapKeyAESKWP(recoveryKey, der)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+
+	shares, err := helpers.SplitSecret(recoveryKey, len(custodians), policy.Threshold)
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.InvalidEscrowPolicyError)
+	}
+	defer func() {
+		for _, share := range shares {
+			helpers.Zeroize(share)
+		}
+	}()
+
+	escrowShares := make(models.KeyEscrowShares, 0, len(custodians))
+	for i, custodian := range custodians {
+		encryptedShare, err := custodian.wrap(shares[i], key.ID, nil)
+		if err != nil {
+			return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+		}
+		escrowShares = append(escrowShares, models.KeyEscrowShare{
+			Index:                int(shares[i][0]),
+			CustodianPublicKey:   custodian.publicKey,
+			CustodianFingerprint: custodian.fingerprint,
+			Format:               custodian.format,
+			EncryptedShare:       encryptedShare,
+		})
+	}
+
+	return models.NewKeyEscrow(key.ID, key.Version, policy.Threshold, base64.StdEncoding.EncodeToString(wrappedKey), escrowShares), nil
+}
@@ -0,0 +1,137 @@
+// +build e2e
+
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyEscrowServiceTestSuite struct {
+	suite.Suite
+	rCtx       core.IContext
+	rks        IKeyService
+	custodians []*ecdsa.PrivateKey
+}
+
+func TestKeyEscrowServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyEscrowServiceTestSuite))
+}
+
+func (k *KeyEscrowServiceTestSuite) SetupSuite() {
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	k.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+	for i := 0; i < 3; i++ {
+		custodian, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		k.Require().NoError(err)
+		k.custodians = append(k.custodians, custodian)
+	}
+}
+
+func (k *KeyEscrowServiceTestSuite) SetupTest() {
+	k.rks = NewKeyService(k.rCtx, NewHSMService(k.rCtx), NewAuditService(k.rCtx))
+}
+
+func (k *KeyEscrowServiceTestSuite) escrowPolicy() *models.KeyEscrowPolicy {
+	policy := &models.KeyEscrowPolicy{Threshold: 2}
+	for _, custodian := range k.custodians {
+		der, err := x509.MarshalPKIXPublicKey(&custodian.PublicKey)
+		k.Require().NoError(err)
+		policy.Custodians = append(policy.Custodians, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	}
+
+	return policy
+}
+
+// shares unwraps the shares of the escrow the way the custodians would
+func (k *KeyEscrowServiceTestSuite) shares(escrow models.KeyEscrow, custodians ...int) [][]byte {
+	shares := make([][]byte, 0)
+	for _, i := range custodians {
+		wrapped, err := base64.StdEncoding.DecodeString(escrow.Shares[i].EncryptedShare)
+		k.Require().NoError(err)
+		share, err := helpers.UnwrapKeyECDHAESKWP(k.custodians[i], wrapped, []byte(consts.KeyImportMethodECDHAESKWP))
+		k.Require().NoError(err)
+		shares = append(shares, []byte(base64.StdEncoding.EncodeToString(share)))
+	}
+
+	return shares
+}
+
+func (k *KeyEscrowServiceTestSuite) TestKeyEscrowService_Recover_ExpectSuccess() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{Escrow: k.escrowPolicy()})
+	k.Require().NoError(ierr)
+
+	escrows, ierr := k.rks.Escrows(key.ID)
+	k.Require().NoError(ierr)
+	k.Require().Len(escrows, 1)
+	k.Equal(2, escrows[0].Threshold)
+	k.Len(escrows[0].Shares, 3)
+
+	recovered, ierr := k.rks.Recover(key.ID, &KeyRecoverPayload{Shares: k.shares(escrows[0], 2, 0)})
+	k.Require().NoError(ierr)
+	k.Equal(key.PublicKey, recovered.PublicKey)
+
+	_, ierr = k.rks.Sign(key.ID, "message")
+	k.NoError(ierr)
+}
+
+func (k *KeyEscrowServiceTestSuite) TestKeyEscrowService_Rotate_ExpectEscrowedVersion() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{Escrow: k.escrowPolicy()})
+	k.Require().NoError(ierr)
+	_, ierr = k.rks.Rotate(key.ID)
+	k.Require().NoError(ierr)
+
+	escrows, ierr := k.rks.Escrows(key.ID)
+	k.Require().NoError(ierr)
+	k.Require().Len(escrows, 2)
+	k.Equal(2, escrows[1].Version)
+
+	_, ierr = k.rks.Recover(fmt.Sprintf("%s@1", key.ID), &KeyRecoverPayload{Shares: k.shares(escrows[0], 0, 1)})
+	k.NoError(ierr)
+}
+
+func (k *KeyEscrowServiceTestSuite) TestKeyEscrowService_Recover_ExpectSharesError() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{Escrow: k.escrowPolicy()})
+	k.Require().NoError(ierr)
+	escrows, ierr := k.rks.Escrows(key.ID)
+	k.Require().NoError(ierr)
+
+	_, ierr = k.rks.Recover(key.ID, &KeyRecoverPayload{Shares: k.shares(escrows[0], 1)})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyEscrowSharesError.GetCode(), ierr.GetCode())
+
+	// shares of another escrow rebuild a wrong recovery key
+	other, ierr := k.rks.Generate(&KeyGeneratePayload{Escrow: k.escrowPolicy()})
+	k.Require().NoError(ierr)
+	otherEscrows, ierr := k.rks.Escrows(other.ID)
+	k.Require().NoError(ierr)
+	_, ierr = k.rks.Recover(key.ID, &KeyRecoverPayload{Shares: k.shares(otherEscrows[0], 0, 1)})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyEscrowRecoveryError.GetCode(), ierr.GetCode())
+}
+
+func (k *KeyEscrowServiceTestSuite) TestKeyEscrowService_Recover_ExpectNotFound() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	_, ierr = k.rks.Recover(key.ID, &KeyRecoverPayload{})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyEscrowNotFoundError.GetCode(), ierr.GetCode())
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type KeyExportPayload struct {
+	// WrappingPublicKey is the PKIX PEM of the public key of an import job of the receiving deployment
+	WrappingPublicKey string
+	Format            consts.KeyExportFormat
+}
+
+// Export wraps the private key under the wrapping public key, the key never leaves the service unwrapped.
+// The policy of the key must make it exportable and may limit the wrapping keys
+func (s keyService) Export(id string, payload *KeyExportPayload) (*models.KeyExportBundle, core.IError) {
+	return s.auditedExport(id, payload, nil)
+}
+
+func (s keyService) auditedExport(id string, payload *KeyExportPayload, approved *models.KeyOperationRequest) (*models.KeyExportBundle, core.IError) {
+	bundle, ierr := s.export(id, payload, approved)
+	var key *models.Key
+	if bundle != nil {
+		key = &models.Key{ID: bundle.Key.ID, Version: bundle.Key.Version}
+	}
+	_, ierr = s.auditedKey(consts.AuditOperationExport, id, key, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return bundle, nil
+}
+
+func (s keyService) export(id string, payload *KeyExportPayload, approved *models.KeyOperationRequest) (*models.KeyExportBundle, core.IError) {
+	key, ierr := s.findAuthorized(id, consts.KeyOperationManage)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	if key.Type == string(consts.KeyTypeBLS12381G2) {
+		return nil, s.ctx.NewError(emsgs.BBSKeyNotPortableError, emsgs.BBSKeyNotPortableError)
+	}
+
+	fingerprint, wrappingKey, err := helpers.PublicKeyFingerprint(payload.WrappingPublicKey)
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.InvalidWrappingKeyError)
+	}
+	if key.Policy == nil || !key.Policy.AllowsExportTo(fingerprint) {
+		return nil, s.ctx.NewError(emsgs.KeyNotExportableError, emsgs.KeyNotExportableError)
+	}
+	ierr = s.checkApproval(key, consts.KeyApprovalOperationExport, approved)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	wrap, format, ierr := s.keyWrapper(wrappingKey, payload.Format)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	decryptedPrivateKey, ierr := s.hsmService.Decrypt(key.PrivateKeyEncrypted)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	defer helpers.Zeroize(decryptedPrivateKey)
+
+	privateKey, ierr := parsePrivateKey(s.ctx, key, decryptedPrivateKey)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	defer helpers.ZeroizePrivateKey(privateKey)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	defer helpers.Zeroize(der)
+
+	bundleKey := models.NewKeyExportBundleKey(key)
+	digest, err := bundleKey.Digest()
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	wrappedKey, err := wrap(der, key.ID, digest)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+
+	// the bundle is only handed out once its request is marked executed
+	err = markExecuted(s.ctx.DB(), approved)
+	if errors.Is(err, emsgs.KeyOperationRequestNotApprovedError) {
+		return nil, s.ctx.NewError(err, emsgs.KeyOperationRequestNotApprovedError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return &models.KeyExportBundle{
+		Format:                 format,
+		WrappedKey:             wrappedKey,
+		WrappingKeyFingerprint: fingerprint,
+		Key:                    bundleKey,
+		ExportedAt:             utils.GetCurrentDateTime(),
+	}, nil
+}
+
+// keyWrapFunc wraps der for the key kid, a non-nil digest is bound to the wrapping so the key only unwraps
+// along with the metadata it was exported with
+type keyWrapFunc func(der []byte, kid string, digest []byte) (string, error)
+
+// keyWrapper picks the wrapping that an import job holding the private part of wrappingKey can undo
+func (s keyService) keyWrapper(wrappingKey interface{}, format consts.KeyExportFormat) (keyWrapFunc, string, core.IError) {
+	switch wrappingKey := wrappingKey.(type) {
+	case *rsa.PublicKey:
+		if wrappingKey.N.BitLen() < 2048 {
+			break
+		}
+		if format == consts.KeyExportFormatJWE {
+			return func(der []byte, kid string, digest []byte) (string, error) {
+				header := map[string]string{
+					"cty": "application/pkcs8",
+					"kid": kid,
+				}
+				if digest != nil {
+					header[consts.KeyExportJWEHeaderDigest] = base64.RawURLEncoding.EncodeToString(digest)
+				}
+				return helpers.EncryptJWERSAOAEP(wrappingKey, der, header)
+			}, string(consts.KeyExportFormatJWE), nil
+		}
+		return func(der []byte, kid string, digest []byte) (string, error) {
+			wrapped, err := helpers.WrapKeyRSAOAEPAESKWP(wrappingKey, der, digest)
+			return base64.StdEncoding.EncodeToString(wrapped), err
+		}, string(consts.KeyImportMethodRSAOAEPAESKWP), nil
+	case *ecdsa.PublicKey:
+		if wrappingKey.Curve != elliptic.P256() || format == consts.KeyExportFormatJWE {
+			break
+		}
+		return func(der []byte, kid string, digest []byte) (string, error) {
+			wrapped, err := helpers.WrapKeyECDHAESKWP(wrappingKey, der, keyWrapInfo(consts.KeyImportMethodECDHAESKWP, digest))
+			return base64.StdEncoding.EncodeToString(wrapped), err
+		}, string(consts.KeyImportMethodECDHAESKWP), nil
+	}
+
+	return nil, "", s.ctx.NewError(emsgs.InvalidWrappingKeyError, emsgs.InvalidWrappingKeyError)
+}
+
+// keyWrapInfo is the HKDF info of an ECDH wrapping, the method followed by the digest of the bundle metadata if any
+func keyWrapInfo(method consts.KeyImportMethod, digest []byte) []byte {
+	return append([]byte(method), digest...)
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	"gorm.io/gorm"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type KeyImportJobCreatePayload struct {
+	Method consts.KeyImportMethod
+	// TTLSeconds of 0 creates a long-lived job
+	TTLSeconds int
+}
+
+type KeyImportPayload struct {
+	ImportJobID string
+	// WrappedKey is a PKCS #8 private key wrapped with the method of the import job
+	WrappedKey []byte
+	PublicKey  string
+	KeyType    string
+	Alias      string
+	Tags       map[string]string
+	Policy     *models.KeyPolicy
+}
+
+// KeyImportBundlePayload imports a bundle made by the export of another deployment, the fields left empty
+// are taken from the bundle
+type KeyImportBundlePayload struct {
+	ImportJobID string
+	Bundle      *models.KeyExportBundle
+	Alias       string
+	Tags        map[string]string
+	Policy      *models.KeyPolicy
+}
+
+type IKeyImportService interface {
+	CreateJob(payload *KeyImportJobCreatePayload) (*models.KeyImportJob, core.IError)
+	FindJob(id string) (*models.KeyImportJob, core.IError)
+	Import(payload *KeyImportPayload) (*models.Key, core.IError)
+	ImportBundle(payload *KeyImportBundlePayload) (*models.Key, core.IError)
+}
+
+type keyImportService struct {
+	ctx        core.IContext
+	hsmService IHSMService
+	keyService IKeyService
+}
+
+func NewKeyImportService(ctx core.IContext, hsmService IHSMService, keyService IKeyService) IKeyImportService {
+	return &keyImportService{
+		ctx:        ctx,
+		hsmService: hsmService,
+		keyService: keyService,
+	}
+}
+
+// CreateJob generates a transport key pair for the method, its private key never leaves the service unencrypted
+func (s keyImportService) CreateJob(payload *KeyImportJobCreatePayload) (*models.KeyImportJob, core.IError) {
+	principal := helpers.GetPrincipal(s.ctx)
+	if payload.TTLSeconds == 0 && !principal.HasScope(consts.ScopeKeysAdmin) {
+		ierr := emsgs.InsufficientScopeError(consts.ScopeKeysAdmin)
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	var publicKey string
+	var privateKey []byte
+	var err error
+	switch payload.Method {
+	case consts.KeyImportMethodRSAOAEPAESKWP:
+		publicKey, privateKey, err = helpers.GenerateRSAKeyPair(consts.KeyImportTransportRSAKeySize)
+	case consts.KeyImportMethodECDHAESKWP:
+		publicKey, privateKey, err = helpers.GenerateECDSAKeyPair()
+	default:
+		return nil, s.ctx.NewError(emsgs.UnsupportedSigningAlgorithm, emsgs.UnsupportedSigningAlgorithm)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.GenerateKeyError)
+	}
+	defer helpers.Zeroize(privateKey)
+
+	encryptedPrivateKey, ierr := s.hsmService.Encrypt(privateKey)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	job := models.NewKeyImportJob(string(payload.Method), publicKey, encryptedPrivateKey, principal,
+		time.Duration(payload.TTLSeconds)*time.Second)
+	err = s.ctx.DB().Create(job).Error
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return job, nil
+}
+
+// FindJob returns import jobs of the tenant of the caller, admins see every job
+func (s keyImportService) FindJob(id string) (*models.KeyImportJob, core.IError) {
+	job := &models.KeyImportJob{}
+	db := s.ctx.DB()
+	principal := helpers.GetPrincipal(s.ctx)
+	if !principal.HasScope(consts.ScopeKeysAdmin) {
+		db = db.Where("tenant_id = ?", principal.TenantID)
+	}
+	err := db.First(job, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, s.ctx.NewError(err, emsgs.KeyImportJobNotFoundError)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.DBError)
+	}
+
+	return job, nil
+}
+
+// Import unwraps the private key with the transport key of the job and stores it encrypted by the HSM,
+// an ephemeral job is spent before unwrapping so a failed attempt needs a new job
+func (s keyImportService) Import(payload *KeyImportPayload) (*models.Key, core.IError) {
+	job, ierr := s.FindJob(payload.ImportJobID)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	if job.ExpiresAt != nil && job.ExpiresAt.Before(*utils.GetCurrentDateTime()) {
+		return nil, s.ctx.NewError(emsgs.KeyImportJobExpiredError, emsgs.KeyImportJobExpiredError)
+	}
+	ierr = s.spend(job)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	privateKey, ierr := s.unwrap(job, payload.WrappedKey, nil)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	defer helpers.Zeroize(privateKey)
+
+	return s.keyService.Import(&KeyStorePayload{
+		PublicKey:  payload.PublicKey,
+		PrivateKey: privateKey,
+		KeyType:    payload.KeyType,
+		Alias:      payload.Alias,
+		Tags:       payload.Tags,
+		Policy:     payload.Policy,
+	})
+}
+
+// ImportBundle checks that the bundle was wrapped for the transport key of the job before spending it,
+// the key is then imported like Import with the public key and type of the bundle as expected values.
+// The metadata of the bundle is bound to the wrapping, a bundle whose metadata was changed cannot be unwrapped,
+// a policy given with the bundle must be stricter than the policy of the bundle unless the caller is an admin
+func (s keyImportService) ImportBundle(payload *KeyImportBundlePayload) (*models.Key, core.IError) {
+	job, ierr := s.FindJob(payload.ImportJobID)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	if job.ExpiresAt != nil && job.ExpiresAt.Before(*utils.GetCurrentDateTime()) {
+		return nil, s.ctx.NewError(emsgs.KeyImportJobExpiredError, emsgs.KeyImportJobExpiredError)
+	}
+
+	bundle := payload.Bundle
+	fingerprint, _, err := helpers.PublicKeyFingerprint(job.PublicKey)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	isJWE := bundle.Format == string(consts.KeyExportFormatJWE) && job.Method == string(consts.KeyImportMethodRSAOAEPAESKWP)
+	if bundle.WrappingKeyFingerprint != fingerprint || (bundle.Format != job.Method && !isJWE) {
+		return nil, s.ctx.NewError(emsgs.KeyBundleMismatchError, emsgs.KeyBundleMismatchError)
+	}
+
+	policy := bundle.Key.Policy
+	if payload.Policy != nil {
+		if !payload.Policy.IsStricterThan(bundle.Key.Policy) && !helpers.GetPrincipal(s.ctx).HasScope(consts.ScopeKeysAdmin) {
+			ierr := emsgs.InsufficientScopeError(consts.ScopeKeysAdmin)
+			return nil, s.ctx.NewError(ierr, ierr)
+		}
+		policy = payload.Policy
+	}
+	digest, err := bundle.Key.Digest()
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+
+	ierr = s.spend(job)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	var privateKey []byte
+	if isJWE {
+		privateKey, ierr = s.decryptJWE(job, bundle.WrappedKey, digest)
+	} else {
+		wrappedKey, decodeErr := helpers.DecodeBase64(bundle.WrappedKey)
+		if decodeErr != nil {
+			return nil, s.ctx.NewError(decodeErr, emsgs.InvalidWrappedKeyError)
+		}
+		privateKey, ierr = s.unwrap(job, wrappedKey, digest)
+	}
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	defer helpers.Zeroize(privateKey)
+
+	alias := payload.Alias
+	if alias == "" {
+		alias = utils.GetString(bundle.Key.Alias)
+	}
+	tags := payload.Tags
+	if tags == nil {
+		tags = bundle.Key.Tags
+	}
+
+	return s.keyService.Import(&KeyStorePayload{
+		PublicKey:  bundle.Key.PublicKey,
+		PrivateKey: privateKey,
+		KeyType:    bundle.Key.Type,
+		Alias:      alias,
+		Tags:       tags,
+		Policy:     policy,
+	})
+}
+
+func (s keyImportService) spend(job *models.KeyImportJob) core.IError {
+	if job.LongLived {
+		return nil
+	}
+
+	result := s.ctx.DB().Model(&models.KeyImportJob{}).
+		Where("id = ? AND used_at IS NULL", job.ID).
+		Updates(map[string]interface{}{
+			"used_at":    utils.GetCurrentDateTime(),
+			"updated_at": utils.GetCurrentDateTime(),
+		})
+	if result.Error != nil {
+		return s.ctx.NewError(result.Error, errmsgs.DBError)
+	}
+	if result.RowsAffected == 0 {
+		return s.ctx.NewError(emsgs.KeyImportJobUsedError, emsgs.KeyImportJobUsedError)
+	}
+
+	return nil
+}
+
+// unwrap returns the private key as a PKCS #8 PEM that the caller must zero, digest is the digest of the metadata
+// of a bundle that the wrapping is bound to and nil for keys wrapped by other tools.
+// The transport private key is decrypted into process memory for the unwrap: the HSM only holds the KEK,
+// a C_UnwrapKey would create an HSM object that has to be extracted again to be stored like every other key
+func (s keyImportService) unwrap(job *models.KeyImportJob, wrappedKey []byte, digest []byte) ([]byte, core.IError) {
+	transportKey, ierr := s.hsmService.Decrypt(job.PrivateKeyEncrypted)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	defer helpers.Zeroize(transportKey)
+
+	var der []byte
+	var err error
+	switch consts.KeyImportMethod(job.Method) {
+	case consts.KeyImportMethodRSAOAEPAESKWP:
+		privateKey, parseErr := helpers.ParseRSAPrivateKeyPEM(transportKey)
+		if parseErr != nil {
+			return nil, s.ctx.NewError(parseErr, errmsgs.InternalServerError)
+		}
+		defer helpers.ZeroizePrivateKey(privateKey)
+		der, err = helpers.UnwrapKeyRSAOAEPAESKWP(privateKey, wrappedKey, digest)
+	case consts.KeyImportMethodECDHAESKWP:
+		privateKey, parseErr := helpers.ParseECDSAPrivateKeyPEM(transportKey)
+		if parseErr != nil {
+			return nil, s.ctx.NewError(parseErr, errmsgs.InternalServerError)
+		}
+		defer helpers.ZeroizePrivateKey(privateKey)
+		der, err = helpers.UnwrapKeyECDHAESKWP(privateKey, wrappedKey, keyWrapInfo(consts.KeyImportMethod(job.Method), digest))
+	default:
+		return nil, s.ctx.NewError(emsgs.UnsupportedSigningAlgorithm, emsgs.UnsupportedSigningAlgorithm)
+	}
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.InvalidWrappedKeyError)
+	}
+	defer helpers.Zeroize(der)
+
+	return helpers.EncodePEM("PRIVATE KEY", der), nil
+}
+
+// decryptJWE returns the private key of a JWE bundle as a PKCS #8 PEM that the caller must zero,
+// the protected header must carry digest
+func (s keyImportService) decryptJWE(job *models.KeyImportJob, compact string, digest []byte) ([]byte, core.IError) {
+	transportKey, ierr := s.hsmService.Decrypt(job.PrivateKeyEncrypted)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	defer helpers.Zeroize(transportKey)
+
+	privateKey, err := helpers.ParseRSAPrivateKeyPEM(transportKey)
+	if err != nil {
+		return nil, s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	defer helpers.ZeroizePrivateKey(privateKey)
+
+	header, der, err := helpers.DecryptJWERSAOAEP(privateKey, compact)
+	if err != nil {
+		return nil, s.ctx.NewError(err, emsgs.InvalidWrappedKeyError)
+	}
+	defer helpers.Zeroize(der)
+	if header[consts.KeyExportJWEHeaderDigest] != base64.RawURLEncoding.EncodeToString(digest) {
+		return nil, s.ctx.NewError(emsgs.InvalidWrappedKeyError, emsgs.InvalidWrappedKeyError)
+	}
+
+	return helpers.EncodePEM("PRIVATE KEY", der), nil
+}
@@ -0,0 +1,197 @@
+// +build e2e
+
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyImportServiceTestSuite struct {
+	suite.Suite
+	rCtx core.IContext
+	ris  IKeyImportService
+}
+
+func TestKeyImportServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyImportServiceTestSuite))
+}
+
+func (k *KeyImportServiceTestSuite) SetupSuite() {
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	k.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+}
+
+func (k *KeyImportServiceTestSuite) SetupTest() {
+	hsmService := NewHSMService(k.rCtx)
+	k.ris = NewKeyImportService(k.rCtx, hsmService, NewKeyService(k.rCtx, hsmService, NewAuditService(k.rCtx)))
+}
+
+func (k *KeyImportServiceTestSuite) publicKey(publicKeyPEM string) interface{} {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	k.Require().NotNil(block)
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	k.Require().NoError(err)
+	return publicKey
+}
+
+func (k *KeyImportServiceTestSuite) TestKeyImportService_Import_RSAOAEPAESKWP_ExpectSuccess() {
+	job, ierr := k.ris.CreateJob(&KeyImportJobCreatePayload{
+		Method:     consts.KeyImportMethodRSAOAEPAESKWP,
+		TTLSeconds: 60,
+	})
+	k.NoError(ierr)
+	k.NotEmpty(job.PublicKey)
+	k.False(job.LongLived)
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	k.Require().NoError(err)
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	k.Require().NoError(err)
+	wrapped, err := helpers.WrapKeyRSAOAEPAESKWP(k.publicKey(job.PublicKey).(*rsa.PublicKey), der, nil)
+	k.Require().NoError(err)
+
+	key, ierr := k.ris.Import(&KeyImportPayload{ImportJobID: job.ID, WrappedKey: wrapped})
+	k.NoError(ierr)
+	k.Equal(string(consts.KeyTypeECDSA), key.Type)
+	k.True(privateKey.PublicKey.Equal(k.publicKey(key.PublicKey)))
+
+	// an ephemeral job can only be used once
+	key, ierr = k.ris.Import(&KeyImportPayload{ImportJobID: job.ID, WrappedKey: wrapped})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyImportJobUsedError.GetCode(), ierr.GetCode())
+	k.Nil(key)
+}
+
+func (k *KeyImportServiceTestSuite) TestKeyImportService_Import_ECDHAESKWP_ExpectError() {
+	job, ierr := k.ris.CreateJob(&KeyImportJobCreatePayload{
+		Method:     consts.KeyImportMethodECDHAESKWP,
+		TTLSeconds: 60,
+	})
+	k.NoError(ierr)
+
+	wrapped, err := helpers.WrapKeyECDHAESKWP(k.publicKey(job.PublicKey).(*ecdsa.PublicKey), []byte("not a key"), []byte("other info"))
+	k.Require().NoError(err)
+
+	key, ierr := k.ris.Import(&KeyImportPayload{ImportJobID: job.ID, WrappedKey: wrapped})
+	k.Error(ierr)
+	k.Equal(emsgs.InvalidWrappedKeyError.GetCode(), ierr.GetCode())
+	k.Nil(key)
+}
+
+func (k *KeyImportServiceTestSuite) TestKeyImportService_CreateJob_LongLived_ExpectError() {
+	job, ierr := k.ris.CreateJob(&KeyImportJobCreatePayload{Method: consts.KeyImportMethodRSAOAEPAESKWP})
+	k.Error(ierr)
+	k.Nil(job)
+}
+
+func (k *KeyImportServiceTestSuite) TestKeyImportService_ImportBundle_ExpectKeyBundleMismatch() {
+	job, ierr := k.ris.CreateJob(&KeyImportJobCreatePayload{
+		Method:     consts.KeyImportMethodECDHAESKWP,
+		TTLSeconds: 60,
+	})
+	k.NoError(ierr)
+
+	key, ierr := k.ris.ImportBundle(&KeyImportBundlePayload{
+		ImportJobID: job.ID,
+		Bundle: &models.KeyExportBundle{
+			Format:                 string(consts.KeyExportFormatJWE),
+			WrappedKey:             "a.b.c.d.e",
+			WrappingKeyFingerprint: "0000000000000000000000000000000000000000000000000000000000000000",
+			Key:                    &models.KeyExportBundleKey{Type: string(consts.KeyTypeECDSA)},
+		},
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyBundleMismatchError.GetCode(), ierr.GetCode())
+	k.Nil(key)
+
+	// a mismatched bundle does not spend the job
+	job, ierr = k.ris.FindJob(job.ID)
+	k.NoError(ierr)
+	k.Nil(job.UsedAt)
+}
+
+func (k *KeyImportServiceTestSuite) TestKeyImportService_Export_ExpectKeyNotExportable() {
+	hsmService := NewHSMService(k.rCtx)
+	keyService := NewKeyService(k.rCtx, hsmService, NewAuditService(k.rCtx))
+	key, ierr := keyService.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+	job, ierr := k.ris.CreateJob(&KeyImportJobCreatePayload{
+		Method:     consts.KeyImportMethodRSAOAEPAESKWP,
+		TTLSeconds: 60,
+	})
+	k.Require().NoError(ierr)
+
+	bundle, ierr := keyService.Export(key.ID, &KeyExportPayload{WrappingPublicKey: job.PublicKey})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyNotExportableError.GetCode(), ierr.GetCode())
+	k.Nil(bundle)
+}
+
+func (k *KeyImportServiceTestSuite) TestKeyImportService_ImportBundle_ExpectAuthenticatedPolicy() {
+	hsmService := NewHSMService(k.rCtx)
+	keyService := NewKeyService(k.rCtx, hsmService, NewAuditService(k.rCtx))
+	key, ierr := keyService.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+	err := k.rCtx.DB().Model(&models.Key{}).Where("id = ?", key.ID).
+		Update("policy", &models.KeyPolicy{Exportable: true, Usages: []consts.KeyUsage{consts.KeyUsageSign, consts.KeyUsageVerify}}).Error
+	k.Require().NoError(err)
+
+	export := func(format consts.KeyExportFormat) (*models.KeyImportJob, *models.KeyExportBundle) {
+		job, ierr := k.ris.CreateJob(&KeyImportJobCreatePayload{
+			Method:     consts.KeyImportMethodRSAOAEPAESKWP,
+			TTLSeconds: 60,
+		})
+		k.Require().NoError(ierr)
+		bundle, ierr := keyService.Export(key.ID, &KeyExportPayload{WrappingPublicKey: job.PublicKey, Format: format})
+		k.Require().NoError(ierr)
+		return job, bundle
+	}
+
+	// Expect error when the policy of the bundle was loosened on the way
+	for _, format := range []consts.KeyExportFormat{consts.KeyExportFormatAESKWP, consts.KeyExportFormatJWE} {
+		job, bundle := export(format)
+		bundle.Key.Policy = &models.KeyPolicy{Exportable: true}
+		imported, ierr := k.ris.ImportBundle(&KeyImportBundlePayload{ImportJobID: job.ID, Bundle: bundle})
+		k.Error(ierr)
+		k.Equal(emsgs.InvalidWrappedKeyError.GetCode(), ierr.GetCode())
+		k.Nil(imported)
+	}
+
+	// Expect error when a caller who is not an admin loosens the policy of the bundle
+	job, bundle := export(consts.KeyExportFormatAESKWP)
+	imported, ierr := k.ris.ImportBundle(&KeyImportBundlePayload{
+		ImportJobID: job.ID,
+		Bundle:      bundle,
+		Policy:      &models.KeyPolicy{Usages: []consts.KeyUsage{consts.KeyUsageSign, consts.KeyUsageDerive}},
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.InsufficientScopeError(consts.ScopeKeysAdmin).GetCode(), ierr.GetCode())
+	k.Nil(imported)
+
+	// Expect success with a stricter policy, the refusal above did not spend the job
+	imported, ierr = k.ris.ImportBundle(&KeyImportBundlePayload{
+		ImportJobID: job.ID,
+		Bundle:      bundle,
+		Policy:      &models.KeyPolicy{Usages: []consts.KeyUsage{consts.KeyUsageSign}},
+	})
+	k.NoError(ierr)
+	k.Equal(key.PublicKey, imported.PublicKey)
+	k.Equal([]consts.KeyUsage{consts.KeyUsageSign}, imported.Policy.Usages)
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"github.com/stretchr/testify/mock"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type MockKeyImportService struct {
+	mock.Mock
+}
+
+func NewMockKeyImportService() *MockKeyImportService {
+	return &MockKeyImportService{}
+}
+
+func (m *MockKeyImportService) CreateJob(payload *KeyImportJobCreatePayload) (*models.KeyImportJob, core.IError) {
+	args := m.Called(payload)
+	return args.Get(0).(*models.KeyImportJob), core.MockIError(args, 1)
+}
+
+func (m *MockKeyImportService) FindJob(id string) (*models.KeyImportJob, core.IError) {
+	args := m.Called(id)
+	return args.Get(0).(*models.KeyImportJob), core.MockIError(args, 1)
+}
+
+func (m *MockKeyImportService) Import(payload *KeyImportPayload) (*models.Key, core.IError) {
+	args := m.Called(payload)
+	return args.Get(0).(*models.Key), core.MockIError(args, 1)
+}
+
+func (m *MockKeyImportService) ImportBundle(payload *KeyImportBundlePayload) (*models.Key, core.IError) {
+	args := m.Called(payload)
+	return args.Get(0).(*models.Key), core.MockIError(args, 1)
+}
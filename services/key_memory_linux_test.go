@@ -0,0 +1,201 @@
+// +build linux
+
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+const memoryScanChunkSize = 1 << 20
+
+// maskedNeedle is kept XOR-ed so the scanner itself does not leave the key material it looks for in memory
+type maskedNeedle struct {
+	name  string
+	bytes []byte
+}
+
+func newMaskedNeedle(name string, plaintext []byte) maskedNeedle {
+	masked := make([]byte, len(plaintext))
+	for i, b := range plaintext {
+		masked[i] = b ^ 0xFF
+	}
+
+	return maskedNeedle{name: name, bytes: masked}
+}
+
+func (n maskedNeedle) matchAt(data []byte, offset int) bool {
+	for i, b := range n.bytes {
+		if data[offset+i]^0xFF != b {
+			return false
+		}
+	}
+
+	return true
+}
+
+type memoryRegion struct {
+	start uint64
+	end   uint64
+}
+
+func readableMemoryRegions() ([]memoryRegion, error) {
+	maps, err := os.Open("/proc/self/maps")
+	if err != nil {
+		return nil, err
+	}
+	defer maps.Close()
+
+	regions := make([]memoryRegion, 0)
+	scanner := bufio.NewScanner(maps)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.HasPrefix(fields[1], "r") {
+			continue
+		}
+		if len(fields) >= 6 && (fields[5] == "[vvar]" || fields[5] == "[vsyscall]") {
+			continue
+		}
+
+		bounds := strings.SplitN(fields[0], "-", 2)
+		start, err := strconv.ParseUint(bounds[0], 16, 64)
+		if err != nil {
+			continue
+		}
+		end, err := strconv.ParseUint(bounds[1], 16, 64)
+		if err != nil {
+			continue
+		}
+		regions = append(regions, memoryRegion{start: start, end: end})
+	}
+
+	return regions, scanner.Err()
+}
+
+// scanMemory returns the names of the needles found anywhere in the readable memory of the process
+func scanMemory(needles []maskedNeedle) ([]string, error) {
+	regions, err := readableMemoryRegions()
+	if err != nil {
+		return nil, err
+	}
+	mem, err := os.Open("/proc/self/mem")
+	if err != nil {
+		return nil, err
+	}
+	defer mem.Close()
+
+	overlap := 0
+	for _, needle := range needles {
+		if len(needle.bytes) > overlap {
+			overlap = len(needle.bytes)
+		}
+	}
+
+	found := make(map[string]bool)
+	chunk := make([]byte, memoryScanChunkSize+overlap)
+	for _, region := range regions {
+		for offset := region.start; offset < region.end; offset += memoryScanChunkSize {
+			length := region.end - offset
+			if length > uint64(len(chunk)) {
+				length = uint64(len(chunk))
+			}
+			n, err := mem.ReadAt(chunk[:length], int64(offset))
+			if err != nil && n == 0 {
+				break
+			}
+
+			for _, needle := range needles {
+				for i := 0; i+len(needle.bytes) <= n; i++ {
+					if needle.matchAt(chunk, i) {
+						found[needle.name] = true
+						break
+					}
+				}
+			}
+		}
+	}
+	for i := range chunk {
+		chunk[i] = 0
+	}
+
+	names := make([]string, 0)
+	for _, needle := range needles {
+		if found[needle.name] {
+			names = append(names, needle.name)
+		}
+	}
+
+	return names, nil
+}
+
+// KeyMemoryTestSuite checks that signing leaves no copy of the decrypted private key behind. It looks for the PEM
+// text and the DER of the key, not for the bare private scalar, the standard library makes copies of the scalar
+// while parsing and signing that cannot be reached to zero them
+type KeyMemoryTestSuite struct {
+	suite.Suite
+	ctx *core.ContextMock
+	hs  *MockHSMService
+}
+
+func TestKeyMemoryTestSuite(t *testing.T) {
+	if _, err := os.Stat("/proc/self/mem"); err != nil {
+		t.Skip("/proc/self/mem is not available")
+	}
+	suite.Run(t, new(KeyMemoryTestSuite))
+}
+
+func (k *KeyMemoryTestSuite) SetupTest() {
+	k.ctx = core.NewMockContext()
+	k.hs = NewMockHSMService()
+
+	k.ctx.On("GetData", consts.ContextKeyKeyCache).Return(nil)
+}
+
+func (k *KeyMemoryTestSuite) TestKeyMemory_SignWithKey_ExpectNoPrivateKeyLeft() {
+	publicKey, privateKey, err := helpers.GenerateECDSAKeyPair()
+	k.NoError(err)
+
+	_, der, err := helpers.DecodePEM(privateKey)
+	k.NoError(err)
+	body := privateKey[bytes.IndexByte(privateKey, '\n')+1:]
+	needles := []maskedNeedle{
+		newMaskedNeedle("der", der),
+		newMaskedNeedle("pem", body[:64]),
+	}
+	helpers.Zeroize(der)
+
+	found, err := scanMemory(needles)
+	if err != nil {
+		k.T().Skipf("cannot scan memory: %v", err)
+	}
+	k.ElementsMatch([]string{"pem"}, found, "the scanner must find the key it was given")
+
+	key := &models.Key{
+		ID:                  "key-id",
+		PublicKey:           publicKey,
+		PrivateKeyEncrypted: "encrypted_private_key",
+		Type:                string(consts.KeyTypeECDSA),
+	}
+	k.hs.On("Decrypt", key.PrivateKeyEncrypted).Return(privateKey, nil)
+
+	signature, ierr := signWithKey(k.ctx, k.hs, key, "message")
+	k.Nil(ierr)
+	k.NotEmpty(signature)
+	privateKey = nil
+	runtime.GC()
+
+	found, err = scanMemory(needles)
+	k.NoError(err)
+	k.Empty(found, "decrypted private key material is still in memory")
+}
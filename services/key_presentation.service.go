@@ -0,0 +1,213 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type KeyPresentationPayload struct {
+	// Presentation is the unsigned presentation, its holder is set to the controller of the verification method when absent
+	Presentation map[string]interface{}
+	Challenge    string
+	Domain       string
+	Format       consts.PresentationFormat
+	// VerificationMethod is the DID URL of the key in the DID document of the holder, it defaults to the did:key of the key
+	VerificationMethod string
+}
+
+// SignPresentation secures a presentation with an authentication proof bound to the challenge and the domain of
+// the verifier
+func (s keyService) SignPresentation(id string, payload *KeyPresentationPayload) (*models.KeyPresentation, core.IError) {
+	key, ierr := s.findSigningKey(id)
+	if ierr != nil {
+		return nil, s.audit(&AuditEventPayload{
+			Operation: consts.AuditOperationSign,
+			KeyID:     id,
+		}, ierr)
+	}
+
+	presentation, message, ierr := s.signPresentation(key, payload)
+	auditPayload := &AuditEventPayload{
+		Operation:  consts.AuditOperationSign,
+		KeyID:      key.ID,
+		KeyVersion: key.Version,
+	}
+	if message != "" {
+		auditPayload.MessageDigest = helpers.MessageDigest(message)
+	}
+	ierr = s.audit(auditPayload, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return presentation, nil
+}
+
+// signPresentation returns the signed presentation and the message the policy was evaluated on: the signing input
+// of the JWT or the canonical JSON of the presentation with its proof configuration
+func (s keyService) signPresentation(key *models.Key, payload *KeyPresentationPayload) (*models.KeyPresentation, string, core.IError) {
+	presentation := make(map[string]interface{}, len(payload.Presentation)+1)
+	for name, value := range payload.Presentation {
+		presentation[name] = value
+	}
+
+	verificationMethod, ierr := s.presentationVerificationMethod(key, presentation, payload.VerificationMethod)
+	if ierr != nil {
+		return nil, "", s.ctx.NewError(ierr, ierr)
+	}
+
+	format := payload.Format
+	if format == "" {
+		format = consts.PresentationFormatDataIntegrity
+	}
+	result := &models.KeyPresentation{
+		KeyID:              key.ID,
+		Version:            key.Version,
+		Format:             string(format),
+		VerificationMethod: verificationMethod,
+	}
+
+	if format == consts.PresentationFormatJWT {
+		claims := map[string]interface{}{
+			"iss":   presentation["holder"],
+			"nonce": payload.Challenge,
+			"iat":   utils.GetCurrentDateTime().Unix(),
+			"vp":    presentation,
+		}
+		if payload.Domain != "" {
+			claims["aud"] = payload.Domain
+		}
+		if presentationID, ok := presentation["id"].(string); ok {
+			claims["jti"] = presentationID
+		}
+		signingInput, err := helpers.JWTSigningInput(&helpers.JWTHeader{
+			Alg: string(signingAlgorithm(key)),
+			Kid: verificationMethod,
+			Typ: "JWT",
+		}, claims)
+		if err != nil {
+			return nil, "", s.ctx.NewError(err, errmsgs.InternalServerError)
+		}
+
+		signature, ierr := s.signJWS(key, signingInput, []byte(signingInput))
+		if ierr != nil {
+			return nil, signingInput, s.ctx.NewError(ierr, ierr)
+		}
+		result.JWT = signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+		return result, signingInput, nil
+	}
+
+	// ecdsa-jcs-2019 is defined for P-256 and P-384 keys only
+	if key.Type != string(consts.KeyTypeECDSA) {
+		return nil, "", s.ctx.NewError(emsgs.PresentationProofUnsupportedError, emsgs.PresentationProofUnsupportedError)
+	}
+	proof := map[string]interface{}{
+		"type":               consts.DataIntegrityProofType,
+		"cryptosuite":        consts.DataIntegrityCryptosuiteECDSAJCS2019,
+		"created":            utils.GetCurrentDateTime().UTC().Format(time.RFC3339),
+		"verificationMethod": verificationMethod,
+		"proofPurpose":       consts.ProofPurposeAuthentication,
+		"challenge":          payload.Challenge,
+	}
+	if payload.Domain != "" {
+		proof["domain"] = payload.Domain
+	}
+	// the proof configuration carries the context of the document it secures
+	proofConfig := make(map[string]interface{}, len(proof)+1)
+	for name, value := range proof {
+		proofConfig[name] = value
+	}
+	if context, ok := presentation["@context"]; ok {
+		proofConfig["@context"] = context
+	}
+
+	hashData, err := helpers.DataIntegrityHashData(proofConfig, presentation)
+	if err != nil {
+		return nil, "", s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	securedDocument := make(map[string]interface{}, len(presentation)+1)
+	for name, value := range presentation {
+		securedDocument[name] = value
+	}
+	securedDocument["proof"] = proof
+	message, err := canonicalJSON(securedDocument)
+	if err != nil {
+		return nil, "", s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+
+	signature, ierr := s.signJWS(key, message, hashData)
+	if ierr != nil {
+		return nil, message, s.ctx.NewError(ierr, ierr)
+	}
+	proof["proofValue"] = consts.MultibaseBase58BTC + helpers.EncodeBase58BTC(signature)
+	result.Presentation = securedDocument
+
+	return result, message, nil
+}
+
+// presentationVerificationMethod defaults the verification method to the one of the did:key of the key when the
+// presentation has no holder or is held by that did:key, and sets the holder to the controller of the verification method
+func (s keyService) presentationVerificationMethod(key *models.Key, presentation map[string]interface{}, verificationMethod string) (string, core.IError) {
+	holder, _ := presentation["holder"].(string)
+	if verificationMethod == "" {
+		if key.DIDKey == "" || (holder != "" && holder != key.DIDKey) {
+			return "", s.ctx.NewError(emsgs.PresentationVerificationMethodRequiredError, emsgs.PresentationVerificationMethodRequiredError)
+		}
+		verificationMethod = key.DIDKey + "#" + strings.TrimPrefix(key.DIDKey, consts.DIDMethodKey)
+	}
+
+	controller := strings.SplitN(verificationMethod, "#", 2)[0]
+	if holder == "" {
+		presentation["holder"] = controller
+	} else if holder != controller {
+		return "", s.ctx.NewError(emsgs.PresentationHolderMismatchError, emsgs.PresentationHolderMismatchError)
+	}
+
+	return verificationMethod, nil
+}
+
+// signJWS evaluates the policy of the key on message before signing the input with the JWS algorithm of the key
+func (s keyService) signJWS(key *models.Key, message string, signingInput []byte) ([]byte, core.IError) {
+	// the policy is evaluated before newKeySigner has the HSM decrypt the private key into the memory of the service
+	ierr := s.enforceSignPolicy(key, message)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+
+	signer, ierr := newKeySigner(s.ctx, s.hsmService, key, 1)
+	if ierr != nil {
+		return nil, s.ctx.NewError(ierr, ierr)
+	}
+	defer signer.Release()
+
+	signature, err := signer.SignJWS(signingInput)
+	if err != nil {
+		return nil, signingError(s.ctx, err)
+	}
+
+	return signature, nil
+}
+
+func canonicalJSON(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	canonical, err := helpers.CanonicalizeJSON(data)
+	if err != nil {
+		return "", err
+	}
+
+	return string(canonical), nil
+}
@@ -0,0 +1,161 @@
+// +build e2e
+
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeyPresentationServiceTestSuite struct {
+	suite.Suite
+	rCtx core.IContext
+	rks  IKeyService
+}
+
+func TestKeyPresentationServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(KeyPresentationServiceTestSuite))
+}
+
+func (k *KeyPresentationServiceTestSuite) SetupSuite() {
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	k.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+}
+
+func (k *KeyPresentationServiceTestSuite) SetupTest() {
+	k.rks = NewKeyService(k.rCtx, NewHSMService(k.rCtx), NewAuditService(k.rCtx))
+}
+
+func (k *KeyPresentationServiceTestSuite) presentation() map[string]interface{} {
+	return map[string]interface{}{
+		"@context":             []interface{}{"https://www.w3.org/ns/credentials/v2"},
+		"type":                 []interface{}{"VerifiablePresentation"},
+		"verifiableCredential": []interface{}{},
+	}
+}
+
+func (k *KeyPresentationServiceTestSuite) TestKeyPresentationService_SignPresentation_DataIntegrity_ExpectVerified() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	result, ierr := k.rks.SignPresentation(key.ID, &KeyPresentationPayload{
+		Presentation: k.presentation(),
+		Challenge:    "challenge-1",
+		Domain:       "https://verifier.example",
+	})
+	k.Require().NoError(ierr)
+	k.Equal(string(consts.PresentationFormatDataIntegrity), result.Format)
+	k.Equal(key.DIDKey, result.Presentation["holder"])
+	k.Equal(key.DIDKey+"#"+strings.TrimPrefix(key.DIDKey, consts.DIDMethodKey), result.VerificationMethod)
+
+	proof := result.Presentation["proof"].(map[string]interface{})
+	k.Equal(consts.ProofPurposeAuthentication, proof["proofPurpose"])
+	k.Equal("challenge-1", proof["challenge"])
+	k.Equal("https://verifier.example", proof["domain"])
+
+	// verify as a verifier would, with the proof configuration and the document without the proof
+	proofValue := proof["proofValue"].(string)
+	proofConfig := map[string]interface{}{"@context": result.Presentation["@context"]}
+	for name, value := range proof {
+		if name != "proofValue" {
+			proofConfig[name] = value
+		}
+	}
+	document := map[string]interface{}{}
+	for name, value := range result.Presentation {
+		if name != "proof" {
+			document[name] = value
+		}
+	}
+	hashData, err := helpers.DataIntegrityHashData(proofConfig, document)
+	k.Require().NoError(err)
+	signature, err := helpers.DecodeBase58BTC(strings.TrimPrefix(proofValue, consts.MultibaseBase58BTC))
+	k.Require().NoError(err)
+	_, publicKey, err := helpers.PublicKeyFingerprint(key.PublicKey)
+	k.Require().NoError(err)
+	k.NoError(helpers.VerifyJWSSignature("ES256", publicKey, hashData, signature))
+}
+
+func (k *KeyPresentationServiceTestSuite) TestKeyPresentationService_SignPresentation_JWT_ExpectVerified() {
+	key, ierr := k.rks.GenerateRSA(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+	presentation := k.presentation()
+	presentation["holder"] = "did:web:holder.example"
+
+	result, ierr := k.rks.SignPresentation(key.ID, &KeyPresentationPayload{
+		Presentation:       presentation,
+		Challenge:          "challenge-1",
+		Domain:             "https://verifier.example",
+		Format:             consts.PresentationFormatJWT,
+		VerificationMethod: "did:web:holder.example#key-1",
+	})
+	k.Require().NoError(ierr)
+
+	parts := strings.Split(result.JWT, ".")
+	k.Require().Len(parts, 3)
+	header := &helpers.JWTHeader{}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	k.Require().NoError(err)
+	k.Require().NoError(json.Unmarshal(data, header))
+	k.Equal(&helpers.JWTHeader{Alg: "RS256", Kid: "did:web:holder.example#key-1", Typ: "JWT"}, header)
+
+	claims := map[string]interface{}{}
+	data, err = base64.RawURLEncoding.DecodeString(parts[1])
+	k.Require().NoError(err)
+	k.Require().NoError(json.Unmarshal(data, &claims))
+	k.Equal("did:web:holder.example", claims["iss"])
+	k.Equal("https://verifier.example", claims["aud"])
+	k.Equal("challenge-1", claims["nonce"])
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	k.Require().NoError(err)
+	_, publicKey, err := helpers.PublicKeyFingerprint(key.PublicKey)
+	k.Require().NoError(err)
+	k.NoError(helpers.VerifyJWSSignature("RS256", publicKey, []byte(parts[0]+"."+parts[1]), signature))
+}
+
+func (k *KeyPresentationServiceTestSuite) TestKeyPresentationService_SignPresentation_ExpectError() {
+	key, ierr := k.rks.GenerateRSA(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	// Expect error on a data_integrity proof of an RSA key
+	_, ierr = k.rks.SignPresentation(key.ID, &KeyPresentationPayload{
+		Presentation: k.presentation(),
+		Challenge:    "challenge-1",
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.PresentationProofUnsupportedError.GetCode(), ierr.GetCode())
+
+	// Expect error on a holder that does not control the verification method
+	presentation := k.presentation()
+	presentation["holder"] = "did:web:holder.example"
+	_, ierr = k.rks.SignPresentation(key.ID, &KeyPresentationPayload{
+		Presentation:       presentation,
+		Challenge:          "challenge-1",
+		Format:             consts.PresentationFormatJWT,
+		VerificationMethod: "did:web:other.example#key-1",
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.PresentationHolderMismatchError.GetCode(), ierr.GetCode())
+
+	// Expect error on a holder that is not the did:key without a verification method
+	_, ierr = k.rks.SignPresentation(key.ID, &KeyPresentationPayload{
+		Presentation: presentation,
+		Challenge:    "challenge-1",
+		Format:       consts.PresentationFormatJWT,
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.PresentationVerificationMethodRequiredError.GetCode(), ierr.GetCode())
+}
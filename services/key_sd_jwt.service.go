@@ -0,0 +1,130 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	"gitlab.finema.co/finema/etda/key-repository-api/models"
+	core "ssi-gitlab.teda.th/ssi/core"
+	"ssi-gitlab.teda.th/ssi/core/errmsgs"
+	"ssi-gitlab.teda.th/ssi/core/utils"
+)
+
+type KeySDJWTPayload struct {
+	// Claims are the claims of the credential, "iss" defaults to the did:key of the key and "iat" to now
+	Claims map[string]interface{}
+	// Disclosable are the JSON Pointers of the selectively disclosable claims
+	Disclosable []string
+	// HolderKeyID binds the SD-JWT to the public key of another key through the "cnf" claim
+	HolderKeyID string
+	// Kid defaults to the verification method of the did:key of the key when it is the issuer
+	Kid string
+}
+
+// IssueSDJWT issues an SD-JWT of the claims, the disclosable claims are replaced by the digests of their salted
+// disclosures
+func (s keyService) IssueSDJWT(id string, payload *KeySDJWTPayload) (*models.SDJWT, core.IError) {
+	key, ierr := s.findSigningKey(id)
+	if ierr != nil {
+		return nil, s.audit(&AuditEventPayload{
+			Operation: consts.AuditOperationSign,
+			KeyID:     id,
+		}, ierr)
+	}
+
+	sdJWT, signingInput, ierr := s.issueSDJWT(key, payload)
+	auditPayload := &AuditEventPayload{
+		Operation:  consts.AuditOperationSign,
+		KeyID:      key.ID,
+		KeyVersion: key.Version,
+	}
+	if signingInput != "" {
+		auditPayload.MessageDigest = helpers.MessageDigest(signingInput)
+	}
+	ierr = s.audit(auditPayload, ierr)
+	if ierr != nil {
+		return nil, ierr
+	}
+
+	return sdJWT, nil
+}
+
+// issueSDJWT returns the SD-JWT and the signing input of its issuer JWT, which the policy is evaluated on
+func (s keyService) issueSDJWT(key *models.Key, payload *KeySDJWTPayload) (*models.SDJWT, string, core.IError) {
+	claims := make(map[string]interface{}, len(payload.Claims)+3)
+	for name, value := range payload.Claims {
+		claims[name] = value
+	}
+	if _, ok := claims["iss"]; !ok && key.DIDKey != "" {
+		claims["iss"] = key.DIDKey
+	}
+	if _, ok := claims["iat"]; !ok {
+		claims["iat"] = utils.GetCurrentDateTime().Unix()
+	}
+
+	if payload.HolderKeyID != "" {
+		holderKey, ierr := s.findAuthorized(payload.HolderKeyID, consts.KeyOperationRead)
+		if ierr != nil {
+			return nil, "", s.ctx.NewError(ierr, ierr)
+		}
+		_, publicKey, err := helpers.PublicKeyFingerprint(holderKey.PublicKey)
+		if err != nil {
+			return nil, "", s.ctx.NewError(err, errmsgs.InternalServerError)
+		}
+		jwk, err := helpers.NewPublicJWK(publicKey)
+		if err != nil {
+			return nil, "", s.ctx.NewError(err, errmsgs.InternalServerError)
+		}
+		claims["cnf"] = map[string]interface{}{"jwk": jwk}
+	}
+
+	paths := make([][]string, len(payload.Disclosable))
+	for i, pointer := range payload.Disclosable {
+		path, err := helpers.ParseSDJWTPath(pointer)
+		if err != nil {
+			return nil, "", s.ctx.NewError(err, emsgs.InvalidSDJWTDisclosablePathError)
+		}
+		paths[i] = path
+	}
+	disclosed, disclosures, err := helpers.SelectivelyDisclose(claims, paths)
+	if errors.Is(err, helpers.ErrSDJWTPath) {
+		return nil, "", s.ctx.NewError(err, emsgs.InvalidSDJWTDisclosablePathError)
+	}
+	if err != nil {
+		return nil, "", s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+	if len(disclosures) > 0 {
+		disclosed["_sd_alg"] = helpers.SDJWTAlg
+	}
+
+	kid := payload.Kid
+	if kid == "" && key.DIDKey != "" && claims["iss"] == key.DIDKey {
+		kid = key.DIDKey + "#" + strings.TrimPrefix(key.DIDKey, consts.DIDMethodKey)
+	}
+	signingInput, err := helpers.JWTSigningInput(&helpers.JWTHeader{
+		Alg: string(signingAlgorithm(key)),
+		Kid: kid,
+		Typ: consts.SDJWTTypeVC,
+	}, disclosed)
+	if err != nil {
+		return nil, "", s.ctx.NewError(err, errmsgs.InternalServerError)
+	}
+
+	signature, ierr := s.signJWS(key, signingInput, []byte(signingInput))
+	if ierr != nil {
+		return nil, signingInput, s.ctx.NewError(ierr, ierr)
+	}
+	jwt := signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+
+	return &models.SDJWT{
+		KeyID:       key.ID,
+		Version:     key.Version,
+		SDJWT:       jwt + helpers.SDJWTSeparator + strings.Join(append(disclosures, ""), helpers.SDJWTSeparator),
+		JWT:         jwt,
+		Disclosures: disclosures,
+	}, signingInput, nil
+}
@@ -0,0 +1,109 @@
+// +build e2e
+
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"gitlab.finema.co/finema/etda/key-repository-api/consts"
+	"gitlab.finema.co/finema/etda/key-repository-api/emsgs"
+	"gitlab.finema.co/finema/etda/key-repository-api/helpers"
+	core "ssi-gitlab.teda.th/ssi/core"
+)
+
+type KeySDJWTServiceTestSuite struct {
+	suite.Suite
+	rCtx core.IContext
+	rks  IKeyService
+}
+
+func TestKeySDJWTServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(KeySDJWTServiceTestSuite))
+}
+
+func (k *KeySDJWTServiceTestSuite) SetupSuite() {
+	env := core.NewENVPath("./..")
+	mysql, _ := core.NewDatabase(env.Config()).Connect()
+	k.rCtx = core.NewContext(&core.ContextOptions{
+		DB:  mysql,
+		ENV: env,
+	})
+}
+
+func (k *KeySDJWTServiceTestSuite) SetupTest() {
+	k.rks = NewKeyService(k.rCtx, NewHSMService(k.rCtx), NewAuditService(k.rCtx))
+}
+
+func (k *KeySDJWTServiceTestSuite) TestKeySDJWTService_IssueSDJWT_ExpectVerified() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+	holderKey, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	sdJWT, ierr := k.rks.IssueSDJWT(key.ID, &KeySDJWTPayload{
+		Claims: map[string]interface{}{
+			"vct":         "https://credentials.example/identity",
+			"given_name":  "Erika",
+			"family_name": "Mustermann",
+		},
+		Disclosable: []string{"/given_name"},
+		HolderKeyID: holderKey.ID,
+	})
+	k.Require().NoError(ierr)
+	k.Equal(sdJWT.JWT+"~"+sdJWT.Disclosures[0]+"~", sdJWT.SDJWT)
+
+	parts := strings.Split(sdJWT.JWT, ".")
+	k.Require().Len(parts, 3)
+	header := &helpers.JWTHeader{}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	k.Require().NoError(err)
+	k.Require().NoError(json.Unmarshal(data, header))
+	k.Equal(consts.SDJWTTypeVC, header.Typ)
+	k.Equal(key.DIDKey+"#"+strings.TrimPrefix(key.DIDKey, consts.DIDMethodKey), header.Kid)
+
+	claims := map[string]interface{}{}
+	data, err = base64.RawURLEncoding.DecodeString(parts[1])
+	k.Require().NoError(err)
+	k.Require().NoError(json.Unmarshal(data, &claims))
+	k.Equal(key.DIDKey, claims["iss"])
+	k.Equal("Mustermann", claims["family_name"])
+	k.NotContains(claims, "given_name")
+	k.Equal("sha-256", claims["_sd_alg"])
+	k.Equal([]interface{}{helpers.SDJWTDigest(sdJWT.Disclosures[0])}, claims["_sd"])
+	_, holderPublicKey, err := helpers.PublicKeyFingerprint(holderKey.PublicKey)
+	k.Require().NoError(err)
+	jwk, err := helpers.NewPublicJWK(holderPublicKey)
+	k.Require().NoError(err)
+	k.Equal(jwk.X, claims["cnf"].(map[string]interface{})["jwk"].(map[string]interface{})["x"])
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	k.Require().NoError(err)
+	_, publicKey, err := helpers.PublicKeyFingerprint(key.PublicKey)
+	k.Require().NoError(err)
+	k.NoError(helpers.VerifyJWSSignature("ES256", publicKey, []byte(parts[0]+"."+parts[1]), signature))
+}
+
+func (k *KeySDJWTServiceTestSuite) TestKeySDJWTService_IssueSDJWT_ExpectError() {
+	key, ierr := k.rks.Generate(&KeyGeneratePayload{})
+	k.Require().NoError(ierr)
+
+	// Expect error on a path to a claim that does not exist
+	_, ierr = k.rks.IssueSDJWT(key.ID, &KeySDJWTPayload{
+		Claims:      map[string]interface{}{"given_name": "Erika"},
+		Disclosable: []string{"/address/street_address"},
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.InvalidSDJWTDisclosablePathError.GetCode(), ierr.GetCode())
+
+	// Expect error on a holder key that is not readable
+	_, ierr = k.rks.IssueSDJWT(key.ID, &KeySDJWTPayload{
+		Claims:      map[string]interface{}{"given_name": "Erika"},
+		HolderKeyID: "missing",
+	})
+	k.Error(ierr)
+	k.Equal(emsgs.KeyAccessDeniedError.GetCode(), ierr.GetCode())
+}